@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/config"
+	"github.com/podsite/backend/internal/handlers"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/tlsutil"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newCertReloader loads the configured certificate and watches it for
+// changes. It returns nil when TLS is not configured.
+func newCertReloader(cfg *config.Config, appLogger *logger.Logger) (*tlsutil.CertReloader, error) {
+	if !cfg.TLS.Enabled() {
+		return nil, nil
+	}
+
+	certs, err := tlsutil.NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	certs.OnReload(func(err error) {
+		if err != nil {
+			appLogger.LogError(err, map[string]interface{}{"event": "tls_reload", "cert_file": cfg.TLS.CertFile})
+			return
+		}
+
+		fields := map[string]interface{}{"cert_file": cfg.TLS.CertFile}
+		if cert, _ := certs.GetCertificate(nil); cert != nil && cert.Leaf != nil {
+			fields["subject"] = cert.Leaf.Subject.String()
+			fields["not_after"] = cert.Leaf.NotAfter
+		}
+		appLogger.LogInfo("TLS certificate reloaded", fields)
+	})
+	go certs.Watch(cfg.TLS.ReloadInterval)
+
+	return certs, nil
+}
+
+// newServerTLSConfig returns the TLS configuration for the public listener
+func newServerTLSConfig(cfg *config.Config, certs *tlsutil.CertReloader) (*tls.Config, error) {
+	minVersion, err := tlsutil.ParseVersion(cfg.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	return certs.ServerConfig(minVersion), nil
+}
+
+// newHTTP3Server returns an HTTP/3 server sharing the public listener's
+// certificate. The handler is set once routes are registered.
+func newHTTP3Server(cfg *config.Config, tlsConfig *tls.Config) *http3.Server {
+	port := cfg.TLS.HTTP3Port
+	if port == "" {
+		// QUIC uses UDP, so it can share the port number of the TCP listener
+		port = cfg.Server.Port
+	}
+
+	return &http3.Server{
+		Addr:        ":" + port,
+		TLSConfig:   http3.ConfigureTLSConfig(tlsConfig),
+		IdleTimeout: cfg.Server.IdleTimeout,
+	}
+}
+
+// newAdminServer returns the admin diagnostics server, or nil when it is not
+// configured. Clients authenticate with the bearer token, a client
+// certificate, or both when both are configured.
+func newAdminServer(cfg *config.Config, certs *tlsutil.CertReloader, appLogger *logger.Logger) (*http.Server, error) {
+	if cfg.Admin.Port == "" {
+		return nil, nil
+	}
+	if cfg.Admin.Token == "" && cfg.Admin.ClientCAFile == "" {
+		appLogger.Warn("ADMIN_PORT is set but neither ADMIN_TOKEN nor ADMIN_CLIENT_CA_FILE is configured; admin listener disabled")
+		return nil, nil
+	}
+
+	adminRouter := gin.New()
+	adminRouter.Use(middleware.Recovery())
+	if cfg.Admin.Token != "" {
+		adminRouter.Use(middleware.AdminAuth(cfg.Admin.Token))
+	}
+	handlers.RegisterDebugRoutes(adminRouter.Group("/debug"))
+
+	adminServer := &http.Server{
+		Addr:        ":" + cfg.Admin.Port,
+		Handler:     adminRouter,
+		ReadTimeout: cfg.Server.ReadTimeout,
+		// Profiles and traces stream for the requested duration
+		WriteTimeout: 0,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	if certs != nil {
+		tlsConfig, err := newServerTLSConfig(cfg, certs)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Admin.ClientCAFile != "" {
+			clientCAs, err := tlsutil.LoadClientCAs(cfg.Admin.ClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+
+		adminServer.TLSConfig = tlsConfig
+	}
+
+	return adminServer, nil
+}
+
+// listenAndServe starts server with TLS when it has a TLS configuration
+func listenAndServe(server *http.Server) error {
+	if server.TLSConfig != nil {
+		// Certificates come from TLSConfig.GetCertificate
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	// Embedded zone database so publishing.timezone works in minimal images
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/auth"
 	"github.com/podsite/backend/internal/config"
 	"github.com/podsite/backend/internal/handlers"
 	"github.com/podsite/backend/internal/logger"
 	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+	"github.com/quic-go/quic-go/http3"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -32,86 +38,425 @@ import (
 
 // @host localhost:3001
 // @BasePath /api
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API key or JWT as "Bearer <token>"
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	opts, err := config.ParseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if opts.PrintConfig {
+		if err := cfg.WriteYAML(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
 
 	// Initialize logger
-	logger.InitLogger(cfg.LogLevel)
+	logger.InitLogger(cfg.Log.Level, cfg.LogFormat())
 	appLogger := logger.GetLogger()
 
 	// Set Gin mode based on environment
-	if cfg.Environment == "production" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Load content from the configured directory; as on reload, a broken
+	// directory is an error rather than a reason to serve sample content
+	episodes, err := models.LoadEpisodeService(cfg.Content.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load episodes from %s: %v", cfg.Content.Dir, err)
+	}
+	applyMedia(episodes, cfg.Content, appLogger)
+	handlers.SetEpisodeService(episodes)
+	content, err := models.LoadContentService(cfg.Content.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load content from %s: %v", cfg.Content.Dir, err)
+	}
+	handlers.SetContentService(content)
+	people, err := models.LoadPeopleService(cfg.Content.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load people from %s: %v", cfg.Content.Dir, err)
+	}
+	handlers.SetPeopleService(people)
+	tags, err := models.LoadTagService(cfg.Content.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load tags from %s: %v", cfg.Content.Dir, err)
+	}
+	handlers.SetTagService(tags)
+	handlers.SetTranscriptService(models.NewTranscriptServiceFromDir(cfg.Content.Dir))
+	handlers.SetChapterService(models.NewChapterServiceFromDir(cfg.Content.Dir))
+	handlers.SetArtworkService(newArtworkService(cfg.Content))
+	handlers.SetWaveformService(newWaveformService(cfg.Content))
+	handlers.SetMediaProber(models.NewMediaProber(cfg.Content.MediaRoot()))
+
+	// Append-only record of administrative changes
+	auditLog := models.NewAuditService()
+	if cfg.Audit.File != "" {
+		if auditLog, err = models.OpenAuditService(cfg.Audit.File); err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+	} else {
+		appLogger.Warn("AUDIT_FILE is not set; audit entries are kept in memory and lost on restart")
+	}
+	handlers.SetAuditService(auditLog)
+
+	// Counted episode downloads
+	analytics := models.NewAnalyticsService()
+	if cfg.Analytics.File != "" {
+		if analytics, err = models.OpenAnalyticsService(cfg.Analytics.File); err != nil {
+			log.Fatalf("Failed to open analytics file: %v", err)
+		}
+	} else {
+		appLogger.Warn("ANALYTICS_FILE is not set; downloads are counted in memory and lost on restart")
+	}
+	handlers.SetAnalyticsService(analytics)
+	rules, err := loadUserAgentRules(cfg.Analytics)
+	if err != nil {
+		log.Fatalf("Failed to load user agent rules: %v", err)
+	}
+	handlers.SetUserAgentRules(rules)
+	clientStats := models.NewClientStatsService()
+	if cfg.Analytics.ClientsFile != "" {
+		if clientStats, err = models.OpenClientStatsService(cfg.Analytics.ClientsFile); err != nil {
+			log.Fatalf("Failed to open client stats: %v", err)
+		}
+	}
+	handlers.SetClientStatsService(clientStats)
+
+	// Listener accounts and playback progress
+	listeners := models.NewListenerService(cfg.Listeners.LoginTTL, cfg.Listeners.SessionTTL)
+	if cfg.Listeners.File != "" {
+		if listeners, err = models.OpenListenerService(cfg.Listeners.File, cfg.Listeners.LoginTTL, cfg.Listeners.SessionTTL); err != nil {
+			log.Fatalf("Failed to open listeners file: %v", err)
+		}
+	} else {
+		appLogger.Warn("LISTENERS_FILE is not set; listener accounts are kept in memory and lost on restart")
+	}
+	handlers.SetListenerService(listeners)
+	handlers.SetListenerSignIn(newMailer(cfg.Mail), cfg.Listeners.LoginURL)
+	playlists := models.NewPlaylistService()
+	if cfg.Listeners.PlaylistsFile != "" {
+		if playlists, err = models.OpenPlaylistService(cfg.Listeners.PlaylistsFile); err != nil {
+			log.Fatalf("Failed to open playlists file: %v", err)
+		}
+	} else {
+		appLogger.Warn("LISTENERS_PLAYLISTS_FILE is not set; playlists are kept in memory and lost on restart")
+	}
+	handlers.SetPlaylistService(playlists)
+	handlers.SetPublicURL(cfg.Listeners.PublicURL)
+
+	// Episode comments, reactions and ratings
+	commentPolicy := models.CommentPolicy{
+		AutoApprove:   cfg.Comments.AutoApprove,
+		MaxLinks:      cfg.Comments.MaxLinks,
+		BannedWords:   cfg.Comments.BannedWords,
+		ListenerLimit: cfg.Comments.ListenerLimit,
+		IPLimit:       cfg.Comments.IPLimit,
+		Window:        cfg.Comments.LimitWindow,
+	}
+	comments := models.NewCommentService(commentPolicy)
+	if cfg.Comments.File != "" {
+		if comments, err = models.OpenCommentService(cfg.Comments.File, commentPolicy); err != nil {
+			log.Fatalf("Failed to open comments file: %v", err)
+		}
+	} else {
+		appLogger.Warn("COMMENTS_FILE is not set; comments are kept in memory and lost on restart")
+	}
+	handlers.SetCommentService(comments)
+	reactions := models.NewReactionService()
+	if cfg.Comments.ReactionsFile != "" {
+		if reactions, err = models.OpenReactionService(cfg.Comments.ReactionsFile); err != nil {
+			log.Fatalf("Failed to open reactions file: %v", err)
+		}
+	} else {
+		appLogger.Warn("COMMENTS_REACTIONS_FILE is not set; reactions and ratings are kept in memory and lost on restart")
+	}
+	handlers.SetReactionService(reactions)
+
+	// Scheduled publishing and preview links
+	location, err := time.LoadLocation(cfg.Publishing.Timezone)
+	if err != nil {
+		log.Fatalf("Failed to load publishing timezone: %v", err)
+	}
+	var previewSigner *auth.PreviewSigner
+	if cfg.Publishing.PreviewSecret != "" {
+		if previewSigner, err = auth.NewPreviewSigner([]byte(cfg.Publishing.PreviewSecret)); err != nil {
+			log.Fatalf("Failed to configure preview tokens: %v", err)
+		}
+	} else {
+		appLogger.Warn("PUBLISHING_PREVIEW_SECRET is not set; preview links stop working on restart")
+	}
+	handlers.SetPublishing(location, previewSigner, cfg.Publishing.PreviewTTL)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go handlers.RunPublishScheduler(schedulerCtx, cfg.Publishing.SchedulerInterval)
+	go handlers.RunWaveformWorker(schedulerCtx, cfg.Content.WaveformInterval)
+	go handlers.RunClientStatsWriter(schedulerCtx, time.Minute)
+	go handlers.RunListenerProgressWriter(schedulerCtx, time.Minute)
+
+	// Live configuration, replaced on SIGHUP
+	runtimeCfg, err := newRuntimeConfig(opts, cfg, appLogger)
+	if err != nil {
+		log.Fatalf("Failed to apply configuration: %v", err)
+	}
+
+	// TLS certificate, reloaded when the files change
+	certs, err := newCertReloader(cfg, appLogger)
+	if err != nil {
+		log.Fatalf("Failed to load TLS certificate: %v", err)
+	}
+	var tlsConfig *tls.Config
+	if certs != nil {
+		if tlsConfig, err = newServerTLSConfig(cfg, certs); err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+	}
+
 	// Create Gin router
 	router := gin.New()
 
 	// Add middleware
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
-	router.Use(middleware.CORS(cfg.CORSOrigins))
-	router.Use(middleware.Security())
-	router.Use(middleware.RateLimit())
+	router.Use(middleware.CORSWithPolicies(runtimeCfg.corsPolicies))
+	router.Use(middleware.SecurityWithPolicies(runtimeCfg.securityPolicies))
+	router.Use(middleware.RateLimitWithLimiter(runtimeCfg.rateLimiter))
 	router.Use(middleware.Compression())
-	router.Use(appLogger.LogRequest())
+	router.Use(appLogger.LogRequest(handlers.ObserveClient))
+
+	// HTTP/3 listener, advertised to TLS clients through Alt-Svc
+	var h3Server *http3.Server
+	if cfg.TLS.HTTP3 {
+		h3Server = newHTTP3Server(cfg, tlsConfig)
+		router.Use(middleware.AltSvc(h3Server.SetQUICHeaders))
+	}
 
 	// Health check endpoints
 	router.GET("/health", handlers.HealthCheck)
 	router.GET("/ready", handlers.ReadinessCheck)
 
+	// Resized episode artwork
+	router.GET("/media/artwork/:id", handlers.GetArtwork)
+	router.GET("/media/audio/:id", handlers.GetEpisodeAudio)
+
+	// Private playlist feeds for podcast apps
+	router.GET("/feeds/playlists/:token", handlers.GetPlaylistFeed)
+
+	// Content-Security-Policy violation reports
+	router.POST("/csp-report", handlers.ReportCSPViolation)
+
 	// API routes
 	api := router.Group("/api")
 	{
 		episodes := api.Group("/episodes")
 		{
-			episodes.GET("", middleware.Cache(5*time.Minute), handlers.GetEpisodes)
-			episodes.GET("/featured", middleware.Cache(5*time.Minute), handlers.GetFeaturedEpisode)
-			episodes.GET("/:id", middleware.Cache(5*time.Minute), handlers.GetEpisodeByID)
+			episodes.GET("", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetEpisodes)
+			episodes.GET("/featured", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetFeaturedEpisode)
+			episodes.GET("/:id", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetEpisodeByID)
+			episodes.GET("/:id/related", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetRelatedEpisodes)
+			episodes.GET("/:id/transcript", middleware.CacheDynamic(runtimeCfg.episodesTTL, "Accept"), handlers.GetEpisodeTranscript)
+			episodes.GET("/:id/chapters", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetEpisodeChapters)
+			episodes.GET("/:id/waveform", handlers.GetEpisodeWaveform)
+			episodes.GET("/:id/comments", handlers.GetEpisodeComments)
+			episodes.POST("/:id/comments", handlers.RequireListener(), handlers.PostEpisodeComment)
+			episodes.GET("/:id/reactions", handlers.GetEpisodeReactions)
+			episodes.PUT("/:id/reactions/:kind", handlers.RequireListener(), handlers.PutEpisodeReaction)
+			episodes.DELETE("/:id/reactions/:kind", handlers.RequireListener(), handlers.DeleteEpisodeReaction)
+			episodes.PUT("/:id/rating", handlers.RequireListener(), handlers.PutEpisodeRating)
+			episodes.DELETE("/:id/rating", handlers.RequireListener(), handlers.DeleteEpisodeRating)
+		}
+
+		seasons := api.Group("/seasons")
+		{
+			seasons.GET("", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetSeasons)
+			seasons.GET("/:n/episodes", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetSeasonEpisodes)
+		}
+
+		people := api.Group("/people")
+		{
+			people.GET("", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetPeople)
+			people.GET("/:id", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetPersonByID)
+		}
+
+		tags := api.Group("/tags")
+		{
+			tags.GET("", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetTags)
+			tags.GET("/:tag/episodes", middleware.CacheDynamic(runtimeCfg.episodesTTL), handlers.GetTagEpisodes)
+		}
+
+		// Listener sign-in and playback progress
+		api.POST("/auth/magic-link", handlers.RequestMagicLink)
+		api.POST("/auth/token", handlers.ExchangeLoginToken)
+		api.POST("/auth/logout", handlers.RequireListener(), handlers.Logout)
+		me := api.Group("/me", handlers.RequireListener())
+		{
+			me.GET("", handlers.GetMe)
+			me.GET("/progress", handlers.GetMyProgress)
+			me.PUT("/progress/:episodeId", handlers.PutMyProgress)
+			me.GET("/playlists", handlers.GetMyPlaylists)
+			me.POST("/playlists", handlers.CreateMyPlaylist)
+			me.GET("/playlists/:id", handlers.GetMyPlaylist)
+			me.PUT("/playlists/:id", handlers.UpdateMyPlaylist)
+			me.DELETE("/playlists/:id", handlers.DeleteMyPlaylist)
+			me.POST("/playlists/:id/episodes", handlers.AddMyPlaylistEpisode)
+			me.PUT("/playlists/:id/episodes", handlers.SetMyPlaylistEpisodes)
+			me.DELETE("/playlists/:id/episodes/:episodeId", handlers.RemoveMyPlaylistEpisode)
+			me.POST("/playlists/:id/share", handlers.ShareMyPlaylist)
+			me.DELETE("/playlists/:id/share", handlers.UnshareMyPlaylist)
+			me.POST("/playlists/:id/feed", handlers.EnableMyPlaylistFeed)
+			me.DELETE("/playlists/:id/feed", handlers.DisableMyPlaylistFeed)
+			me.GET("/reactions/:episodeId", handlers.GetMyReactions)
 		}
+		api.GET("/playlists/:shareId", handlers.GetSharedPlaylist)
 
 		// Content routes with longer cache times (static content)
-		api.GET("/about", middleware.Cache(30*time.Minute), handlers.GetAbout)
-		api.GET("/faq", middleware.Cache(30*time.Minute), handlers.GetFAQ)
+		api.GET("/about", middleware.CacheDynamic(runtimeCfg.contentTTL), handlers.GetAbout)
+		api.GET("/faq", middleware.CacheDynamic(runtimeCfg.contentTTL), handlers.GetFAQ)
+
+		// Authenticated administrative endpoints; every mutation is audited
+		admin := api.Group("/admin", auth.Authenticate(runtimeCfg.authenticator), handlers.AuditAdminChanges())
+		{
+			admin.GET("/audit", auth.Require(auth.RoleAdmin, auth.ScopeAdminRead), handlers.GetAuditLog)
+			admin.GET("/featured", auth.Require(auth.RoleEditor), handlers.GetFeaturedSettings)
+			admin.PUT("/featured", auth.Require(auth.RoleEditor, auth.ScopeFeaturedWrite), handlers.UpdateFeaturedSettings)
+			admin.GET("/analytics/episodes/:id", auth.Require(auth.RoleEditor, auth.ScopeAdminRead), handlers.GetEpisodeAnalytics)
+			admin.GET("/analytics/clients", auth.Require(auth.RoleEditor, auth.ScopeAdminRead), handlers.GetClientAnalytics)
+			admin.GET("/comments", auth.Require(auth.RoleEditor, auth.ScopeAdminRead), handlers.GetAdminComments)
+			admin.PUT("/comments/:id", auth.Require(auth.RoleEditor, auth.ScopeCommentsWrite), handlers.ModerateComment)
+			admin.DELETE("/comments/:id", auth.Require(auth.RoleAdmin, auth.ScopeCommentsWrite), handlers.DeleteComment)
+
+			adminEpisodes := admin.Group("/episodes")
+			{
+				adminEpisodes.GET("", auth.Require(auth.RoleEditor), handlers.GetAdminEpisodes)
+				adminEpisodes.POST("", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.CreateEpisode)
+				adminEpisodes.PUT("/:id", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.UpdateEpisode)
+				adminEpisodes.DELETE("/:id", auth.Require(auth.RoleAdmin, auth.ScopeEpisodesWrite), handlers.DeleteEpisode)
+				adminEpisodes.POST("/:id/preview", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.CreateEpisodePreview)
+				adminEpisodes.PUT("/:id/transcript", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.PutEpisodeTranscript)
+				adminEpisodes.DELETE("/:id/transcript", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.DeleteEpisodeTranscript)
+				adminEpisodes.PUT("/:id/chapters", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.PutEpisodeChapters)
+				adminEpisodes.DELETE("/:id/chapters", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), handlers.DeleteEpisodeChapters)
+			}
+		}
 	}
 
 	// Swagger documentation (only in development)
-	if cfg.Environment != "production" {
+	if !cfg.IsProduction() {
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
+	// Admin listener for profiling and runtime diagnostics
+	adminServer, err := newAdminServer(cfg, certs, appLogger)
+	if err != nil {
+		log.Fatalf("Failed to configure admin server: %v", err)
+	}
+
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         ":" + cfg.Port,
+		Addr:         ":" + cfg.Server.Port,
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		TLSConfig:    tlsConfig,
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on port %s", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Starting server on port %s (tls=%t)", cfg.Server.Port, tlsConfig != nil)
+		if err := listenAndServe(server); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 
+	if h3Server != nil {
+		h3Server.Handler = router
+		go func() {
+			log.Printf("Starting HTTP/3 server on %s/udp", h3Server.Addr)
+			if err := h3Server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start HTTP/3 server: %v", err)
+			}
+		}()
+	}
+
+	if adminServer != nil {
+		go func() {
+			log.Printf("Starting admin server on port %s (tls=%t)", cfg.Admin.Port, adminServer.TLSConfig != nil)
+			if err := listenAndServe(adminServer); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start admin server: %v", err)
+			}
+		}()
+	}
+
+	// Reload configuration on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			appLogger.Info("Received SIGHUP, reloading configuration")
+			if err := runtimeCfg.Reload(); err != nil {
+				appLogger.LogError(err, map[string]interface{}{"event": "config_reload"})
+			}
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	signal.Stop(reload)
 
 	log.Println("Shutting down server...")
+	stopScheduler()
 
-	// Give outstanding requests 30 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Give outstanding requests time to complete
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if h3Server != nil {
+		if err := h3Server.Shutdown(ctx); err != nil {
+			log.Printf("HTTP/3 server forced to shutdown: %v", err)
+		}
+	}
+
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			log.Printf("Admin server forced to shutdown: %v", err)
+		}
+	}
+
+	if certs != nil {
+		certs.Stop()
+	}
+
+	if err := auditLog.Close(); err != nil {
+		log.Printf("Failed to close audit log: %v", err)
+	}
+	if err := analytics.Close(); err != nil {
+		log.Printf("Failed to close analytics file: %v", err)
+	}
+	if err := clientStats.Save(time.Now()); err != nil {
+		log.Printf("Failed to save client stats: %v", err)
+	}
+	if err := listeners.Save(); err != nil {
+		log.Printf("Failed to save listener progress: %v", err)
+	}
+
 	log.Println("Server exited")
 }
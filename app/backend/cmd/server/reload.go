@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/podsite/backend/internal/auth"
+	"github.com/podsite/backend/internal/config"
+	"github.com/podsite/backend/internal/handlers"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/mailer"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// runtimeConfig holds the live configuration and applies reloads
+type runtimeConfig struct {
+	opts        config.Options
+	current     atomic.Pointer[config.Config]
+	cors        atomic.Pointer[middleware.CORSPolicySet]
+	security    atomic.Pointer[middleware.SecurityPolicySet]
+	auth        atomic.Pointer[auth.Authenticator]
+	rateLimiter *middleware.RateLimiter
+	log         *logger.Logger
+	mutex       sync.Mutex
+}
+
+// newRuntimeConfig creates a runtime configuration from the initial config
+func newRuntimeConfig(opts config.Options, cfg *config.Config, log *logger.Logger) (*runtimeConfig, error) {
+	cors, err := buildCORSPolicies(cfg.CORS)
+	if err != nil {
+		return nil, err
+	}
+	authenticator, err := buildAuthenticator(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	rc := &runtimeConfig{
+		opts:        opts,
+		rateLimiter: middleware.NewRateLimiter(cfg.RateLimit.Requests, cfg.RateLimit.Window),
+		log:         log,
+	}
+	rc.current.Store(cfg)
+	rc.cors.Store(cors)
+	rc.security.Store(buildSecurityPolicies(cfg.Security))
+	rc.auth.Store(authenticator)
+	return rc, nil
+}
+
+// buildCORSPolicies converts the CORS configuration into middleware policies
+func buildCORSPolicies(cfg config.CORSConfig) (*middleware.CORSPolicySet, error) {
+	defaultPolicy := middleware.CORSPolicy{
+		AllowedOrigins:   cfg.Origins,
+		AllowedMethods:   cfg.Methods,
+		AllowedHeaders:   cfg.Headers,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	routes := make(map[string]middleware.CORSPolicy, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		policy := defaultPolicy
+		if route.Origins != nil {
+			policy.AllowedOrigins = route.Origins
+		}
+		if route.Methods != nil {
+			policy.AllowedMethods = route.Methods
+		}
+		if route.Headers != nil {
+			policy.AllowedHeaders = route.Headers
+		}
+		if route.ExposedHeaders != nil {
+			policy.ExposedHeaders = route.ExposedHeaders
+		}
+		if route.AllowCredentials != nil {
+			policy.AllowCredentials = *route.AllowCredentials
+		}
+		if route.MaxAge != 0 {
+			policy.MaxAge = route.MaxAge
+		}
+		routes[route.PathPrefix] = policy
+	}
+
+	return middleware.NewCORSPolicySet(defaultPolicy, routes)
+}
+
+// buildSecurityPolicies converts the security configuration into middleware policies
+func buildSecurityPolicies(cfg config.SecurityConfig) *middleware.SecurityPolicySet {
+	defaultPolicy := middleware.NewSecurityPolicy(cfg)
+
+	override := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+
+	routes := make(map[string]middleware.SecurityPolicy, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		policy := defaultPolicy
+		override(&policy.ContentSecurityPolicy, route.ContentSecurityPolicy)
+		override(&policy.FrameOptions, route.FrameOptions)
+		override(&policy.ReferrerPolicy, route.ReferrerPolicy)
+		override(&policy.PermissionsPolicy, route.PermissionsPolicy)
+		override(&policy.CrossOriginOpenerPolicy, route.CrossOriginOpenerPolicy)
+		override(&policy.CrossOriginResourcePolicy, route.CrossOriginResourcePolicy)
+		if route.CSPReportOnly != nil {
+			policy.CSPReportOnly = *route.CSPReportOnly
+		}
+		routes[route.PathPrefix] = policy
+	}
+
+	return middleware.NewSecurityPolicySet(defaultPolicy, routes)
+}
+
+// buildAuthenticator loads API keys from the configuration and key file and
+// sets up JWT verification when configured
+func buildAuthenticator(cfg config.AuthConfig) (*auth.Authenticator, error) {
+	keys := make([]auth.APIKey, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		keys = append(keys, auth.APIKey{
+			ID:     key.ID,
+			Name:   key.Name,
+			Hash:   key.Hash,
+			Role:   auth.Role(key.Role),
+			Scopes: key.Scopes,
+		})
+	}
+	if cfg.APIKeysFile != "" {
+		fileKeys, err := auth.LoadKeyFile(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	store, err := auth.NewStaticKeyStore(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.JWT.Enabled() {
+		return auth.NewAuthenticator(store, nil), nil
+	}
+
+	var jwks *auth.JWKS
+	switch {
+	case cfg.JWT.JWKSFile != "":
+		jwks = auth.NewJWKS(cfg.JWT.JWKSFile, cfg.JWT.JWKSRefresh)
+		// A local key set is loaded now so mistakes are reported at startup
+		if err := jwks.Load(context.Background()); err != nil {
+			return nil, err
+		}
+	case cfg.JWT.JWKSURL != "":
+		// Remote key sets are fetched on first use so an identity provider
+		// outage does not prevent startup
+		jwks = auth.NewJWKS(cfg.JWT.JWKSURL, cfg.JWT.JWKSRefresh)
+	}
+
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		HMACSecret: []byte(cfg.JWT.HMACSecret),
+		JWKS:       jwks,
+		Issuer:     cfg.JWT.Issuer,
+		Audience:   cfg.JWT.Audience,
+		Leeway:     cfg.JWT.Leeway,
+		RoleClaim:  cfg.JWT.RoleClaim,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewAuthenticator(store, verifier), nil
+}
+
+// applyMedia measures the episodes' audio files and logs those that could
+// not be read or disagree with episodes.json
+func applyMedia(episodes *models.EpisodeService, cfg config.ContentConfig, log *logger.Logger) {
+	for _, issue := range episodes.ApplyMedia(models.NewMediaProber(cfg.MediaRoot())) {
+		fields := map[string]interface{}{
+			"episode_id": issue.EpisodeID,
+			"field":      issue.Field,
+		}
+		if issue.Error != "" {
+			fields["error"] = issue.Error
+			log.LogWarn("Episode media needs attention", fields)
+			continue
+		}
+		fields["stored"] = issue.Stored
+		fields["probed"] = issue.Probed
+		log.LogWarn("Episode metadata does not match its audio file", fields)
+	}
+}
+
+// newArtworkService resizes artwork from the media directory
+func newArtworkService(cfg config.ContentConfig) *models.ArtworkService {
+	return models.NewArtworkService(models.NewMediaProber(cfg.MediaRoot()), cfg.ArtworkCache())
+}
+
+// newWaveformService stores waveforms in the content directory for audio
+// from the media directory
+func newWaveformService(cfg config.ContentConfig) *models.WaveformService {
+	return models.NewWaveformServiceFromDir(cfg.Dir, models.NewMediaProber(cfg.MediaRoot()))
+}
+
+// loadUserAgentRules reads the configured user agent rules file, or returns
+// the built-in rules
+func loadUserAgentRules(cfg config.AnalyticsConfig) (*models.UserAgentRules, error) {
+	if cfg.UserAgentRules == "" {
+		return models.DefaultUserAgentRules(), nil
+	}
+	return models.LoadUserAgentRules(cfg.UserAgentRules)
+}
+
+// newMailer returns the configured mailer for sign-in emails
+func newMailer(cfg config.MailConfig) mailer.Mailer {
+	if cfg.Mailer == "smtp" {
+		return mailer.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From)
+	}
+	return mailer.NewFileMailer(cfg.Dir, cfg.From)
+}
+
+// Get returns the current configuration
+func (rc *runtimeConfig) Get() *config.Config {
+	return rc.current.Load()
+}
+
+// corsPolicies returns the current CORS policy set
+func (rc *runtimeConfig) corsPolicies() *middleware.CORSPolicySet {
+	return rc.cors.Load()
+}
+
+// securityPolicies returns the current security header policy set
+func (rc *runtimeConfig) securityPolicies() *middleware.SecurityPolicySet {
+	return rc.security.Load()
+}
+
+// authenticator returns the current API authenticator
+func (rc *runtimeConfig) authenticator() *auth.Authenticator {
+	return rc.auth.Load()
+}
+
+// episodesTTL returns the current cache TTL for episode routes
+func (rc *runtimeConfig) episodesTTL() time.Duration {
+	return rc.Get().Cache.EpisodesTTL
+}
+
+// contentTTL returns the current cache TTL for static content routes
+func (rc *runtimeConfig) contentTTL() time.Duration {
+	return rc.Get().Cache.ContentTTL
+}
+
+// Reload re-reads the configuration and applies every field that can change
+// at runtime. An invalid configuration is rejected and nothing is changed.
+func (rc *runtimeConfig) Reload() error {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	next, err := config.Load(rc.opts)
+	if err != nil {
+		return err
+	}
+
+	old := rc.Get()
+	merged := old.WithReloadable(next)
+
+	cors, err := buildCORSPolicies(merged.CORS)
+	if err != nil {
+		return fmt.Errorf("cors: %w", err)
+	}
+
+	// API keys, local key sets and user agent rules are re-read on every
+	// reload, even when the configuration itself is unchanged
+	authenticator, err := buildAuthenticator(merged.Auth)
+	if err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	rules, err := loadUserAgentRules(merged.Analytics)
+	if err != nil {
+		return fmt.Errorf("analytics.userAgentRules: %w", err)
+	}
+
+	// Load new content before touching anything so a bad directory is rejected
+	var episodes *models.EpisodeService
+	var content *models.ContentService
+	var people *models.PeopleService
+	var tags *models.TagService
+	if merged.Content != old.Content {
+		if episodes, err = models.LoadEpisodeService(merged.Content.Dir); err != nil {
+			return fmt.Errorf("content.dir: %w", err)
+		}
+		applyMedia(episodes, merged.Content, rc.log)
+		if content, err = models.LoadContentService(merged.Content.Dir); err != nil {
+			return fmt.Errorf("content.dir: %w", err)
+		}
+		if people, err = models.LoadPeopleService(merged.Content.Dir); err != nil {
+			return fmt.Errorf("content.dir: %w", err)
+		}
+		if tags, err = models.LoadTagService(merged.Content.Dir); err != nil {
+			return fmt.Errorf("content.dir: %w", err)
+		}
+	}
+
+	rc.auth.Store(authenticator)
+	handlers.SetUserAgentRules(rules)
+
+	changes := config.Diff(old, next)
+	if len(changes) == 0 {
+		rc.log.Info("Configuration reloaded with no changes")
+		return nil
+	}
+
+	for _, change := range changes {
+		fields := map[string]interface{}{
+			"field": change.Field,
+			"old":   change.Old,
+			"new":   change.New,
+		}
+		if config.IsReloadable(change.Field) {
+			rc.log.LogInfo("Configuration change applied", fields)
+		} else {
+			rc.log.LogWarn("Configuration change requires a restart and was ignored", fields)
+		}
+	}
+
+	// Apply side effects, then publish the new configuration in one swap
+	rc.log.SetLevelName(merged.Log.Level)
+	rc.rateLimiter.SetPolicy(merged.RateLimit.Requests, merged.RateLimit.Window)
+	rc.cors.Store(cors)
+	rc.security.Store(buildSecurityPolicies(merged.Security))
+	if episodes != nil {
+		handlers.SetEpisodeService(episodes)
+		handlers.SetContentService(content)
+		handlers.SetPeopleService(people)
+		handlers.SetTagService(tags)
+		handlers.SetTranscriptService(models.NewTranscriptServiceFromDir(merged.Content.Dir))
+		handlers.SetChapterService(models.NewChapterServiceFromDir(merged.Content.Dir))
+		handlers.SetArtworkService(newArtworkService(merged.Content))
+		handlers.SetWaveformService(newWaveformService(merged.Content))
+		handlers.SetMediaProber(models.NewMediaProber(merged.Content.MediaRoot()))
+	}
+	rc.current.Store(merged)
+
+	if episodes != nil || merged.Cache != old.Cache {
+		middleware.PurgeCache()
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// apiKeyHashPrefix marks the hash algorithm of a stored key
+const apiKeyHashPrefix = "sha256:"
+
+// APIKey is a stored API key. Only the hash of the secret is kept.
+type APIKey struct {
+	ID     string   `json:"id" yaml:"id"`
+	Name   string   `json:"name" yaml:"name"`
+	Hash   string   `json:"hash" yaml:"hash"`
+	Role   Role     `json:"role" yaml:"role"`
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes"`
+}
+
+// HashAPIKey returns the stored form of an API key secret. API keys are long
+// random values, so a fast hash is sufficient to keep them safe at rest.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return apiKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// Validate checks that a stored key is usable
+func (k APIKey) Validate() error {
+	if k.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if !strings.HasPrefix(k.Hash, apiKeyHashPrefix) {
+		return fmt.Errorf("hash must start with %q", apiKeyHashPrefix)
+	}
+	if decoded, err := hex.DecodeString(strings.TrimPrefix(k.Hash, apiKeyHashPrefix)); err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("hash must be a hex-encoded SHA-256 digest")
+	}
+	if _, err := ParseRole(string(k.Role)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// KeyStore looks up API keys by the hash of their secret
+type KeyStore interface {
+	Lookup(hash string) (*APIKey, bool)
+}
+
+// StaticKeyStore is an in-memory KeyStore
+type StaticKeyStore struct {
+	keys map[string]APIKey
+}
+
+// NewStaticKeyStore validates keys and indexes them by hash
+func NewStaticKeyStore(keys []APIKey) (*StaticKeyStore, error) {
+	store := &StaticKeyStore{keys: make(map[string]APIKey, len(keys))}
+	ids := make(map[string]bool, len(keys))
+
+	for i, key := range keys {
+		key.Hash = strings.ToLower(key.Hash)
+		key.Role = Role(strings.ToLower(string(key.Role)))
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("api key %d (%s): %w", i, key.ID, err)
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("api key %d: duplicate id %q", i, key.ID)
+		}
+		if _, exists := store.keys[key.Hash]; exists {
+			return nil, fmt.Errorf("api key %d (%s): duplicate hash", i, key.ID)
+		}
+		ids[key.ID] = true
+		store.keys[key.Hash] = key
+	}
+
+	return store, nil
+}
+
+// Lookup returns the key with the given hash
+func (s *StaticKeyStore) Lookup(hash string) (*APIKey, bool) {
+	key, ok := s.keys[hash]
+	if !ok {
+		return nil, false
+	}
+	return &key, true
+}
+
+// Len returns the number of stored keys
+func (s *StaticKeyStore) Len() int {
+	return len(s.keys)
+}
+
+// LoadKeyFile reads a JSON array of API keys, such as api_keys.json in the data directory
+func LoadKeyFile(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API key file %s: %w", path, err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,86 @@
+// Package auth authenticates API clients with hashed API keys or JWT bearer
+// tokens and guards routes by role and scope.
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role is the level of access granted to a principal
+type Role string
+
+// Roles in increasing order of privilege
+const (
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// roleRank orders roles so higher roles include the permissions of lower ones
+var roleRank = map[Role]int{
+	RoleEditor: 1,
+	RoleAdmin:  2,
+}
+
+// Scopes restrict what a credential may do within its role
+const (
+	ScopeAll           = "*"
+	ScopeEpisodesWrite = "episodes:write"
+	ScopeFeaturedWrite = "featured:write"
+	ScopeAdminRead     = "admin:read"
+	ScopeCommentsWrite = "comments:write"
+)
+
+// ParseRole validates a role name
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q, expected editor or admin", value)
+	}
+	return role, nil
+}
+
+// Includes reports whether r grants at least the permissions of required
+func (r Role) Includes(required Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[required]
+}
+
+// Principal is the authenticated identity behind a request
+type Principal struct {
+	// Subject identifies the caller: the API key ID or the JWT subject
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+	// Scopes limits the credential; empty means every scope of the role
+	Scopes []string `json:"scopes,omitempty"`
+	// Method is "api_key" or "jwt"
+	Method string `json:"method"`
+}
+
+// HasScope reports whether the principal may use scope
+func (p *Principal) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalKey is the Gin context key holding the authenticated *Principal
+const PrincipalKey = "authPrincipal"
+
+// FromContext returns the principal authenticated for the request, if any
+func FromContext(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(PrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
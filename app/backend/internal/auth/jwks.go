@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJWKSSize bounds the size of a fetched key set
+const maxJWKSSize = 1 << 20
+
+// jwksMinRefetch limits how often the key set is refetched, whether for an
+// unknown key ID or after a failed refresh
+const jwksMinRefetch = time.Minute
+
+// errUnknownKey is returned when no key matches a token's key ID
+var errUnknownKey = errors.New("no matching signing key")
+
+// jsonWebKey is a single RSA key of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// ParseJWKS extracts the RSA signing keys of a JWKS document, keyed by key ID
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range doc.Keys {
+		// Keys for other algorithms or for encryption are not used here
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") || (key.Alg != "" && key.Alg != "RS256") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus: %w", key.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: invalid exponent", key.Kid)
+		}
+
+		publicKey := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if publicKey.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("key %q: RSA keys must be at least 2048 bits", key.Kid)
+		}
+		keys[key.Kid] = publicKey
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no RS256 signing keys")
+	}
+	return keys, nil
+}
+
+// JWKS is a set of RSA verification keys loaded from a local file or a URL
+// and refreshed periodically
+type JWKS struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	mutex       sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+	loadErr     error
+}
+
+// NewJWKS returns a key set read from source, an http(s) URL or a file
+// path. Keys are reloaded when older than refresh.
+func NewJWKS(source string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Load fetches the key set now
+func (j *JWKS) Load(ctx context.Context) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.loadLocked(ctx)
+}
+
+// Key returns the verification key for kid. An empty kid matches the only
+// key of a single-key set. Unknown key IDs trigger a rate-limited refetch so
+// rotated keys are picked up before the refresh interval. While the source
+// is unavailable, stale keys stay in use and fetches are retried at the same
+// rate rather than on every request.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.keys == nil || time.Since(j.fetched) > j.refresh {
+		if j.canRefetch() {
+			j.loadLocked(ctx)
+		}
+		if j.keys == nil {
+			return nil, j.loadErr
+		}
+	}
+
+	if key, ok := j.find(kid); ok {
+		return key, nil
+	}
+
+	if j.canRefetch() {
+		if err := j.loadLocked(ctx); err == nil {
+			if key, ok := j.find(kid); ok {
+				return key, nil
+			}
+		}
+	}
+
+	return nil, errUnknownKey
+}
+
+// canRefetch reports whether the last fetch attempt is old enough to try again
+func (j *JWKS) canRefetch() bool {
+	return j.lastAttempt.IsZero() || time.Since(j.lastAttempt) >= jwksMinRefetch
+}
+
+// find looks up kid in the current keys
+func (j *JWKS) find(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// loadLocked reads and parses the source; the previous keys stay in use on failure
+func (j *JWKS) loadLocked(ctx context.Context) error {
+	j.lastAttempt = time.Now()
+
+	data, err := j.read(ctx)
+	if err == nil {
+		var keys map[string]*rsa.PublicKey
+		if keys, err = ParseJWKS(data); err == nil {
+			j.keys = keys
+			j.fetched = time.Now()
+		}
+	}
+
+	j.loadErr = err
+	return err
+}
+
+// read returns the raw JWKS document
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		data, err := os.ReadFile(j.source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWKS URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+	return data, nil
+}
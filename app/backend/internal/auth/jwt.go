@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned when a token cannot be verified
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidClaims    = errors.New("invalid claims")
+)
+
+// JWTConfig configures token verification. HS256 is accepted when HMACSecret
+// is set and RS256 when JWKS is set.
+type JWTConfig struct {
+	HMACSecret []byte
+	JWKS       *JWKS
+	Issuer     string
+	Audience   string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+	// RoleClaim names the claim holding the role; defaults to "role"
+	RoleClaim string
+}
+
+// JWTVerifier validates JWT bearer tokens
+type JWTVerifier struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+// NewJWTVerifier returns a verifier for the configuration
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	if len(config.HMACSecret) == 0 && config.JWKS == nil {
+		return nil, fmt.Errorf("an HMAC secret or a JWKS source is required")
+	}
+	if len(config.HMACSecret) > 0 && len(config.HMACSecret) < 32 {
+		return nil, fmt.Errorf("the HMAC secret must be at least 32 bytes")
+	}
+	if config.RoleClaim == "" {
+		config.RoleClaim = "role"
+	}
+
+	return &JWTVerifier{config: config, now: time.Now}, nil
+}
+
+// jwtHeader is the JOSE header of a token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks the signature and registered claims of a compact JWT and
+// returns the principal it describes
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := v.verifySignature(ctx, header, signed, signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	return v.principalFromClaims(claims)
+}
+
+// verifySignature checks the signature with the key allowed for the token's algorithm
+func (v *JWTVerifier) verifySignature(ctx context.Context, header jwtHeader, signed, signature []byte) error {
+	switch header.Alg {
+	case "HS256":
+		if len(v.config.HMACSecret) == 0 {
+			return ErrUnsupportedAlg
+		}
+		mac := hmac.New(sha256.New, v.config.HMACSecret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case "RS256":
+		if v.config.JWKS == nil {
+			return ErrUnsupportedAlg
+		}
+		key, err := v.config.JWKS.Key(ctx, header.Kid)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		// Includes "none" and algorithms that could be confused with the configured keys
+		return ErrUnsupportedAlg
+	}
+}
+
+// principalFromClaims validates registered claims and maps the rest onto a principal
+func (v *JWTVerifier) principalFromClaims(claims map[string]interface{}) (*Principal, error) {
+	now := v.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return nil, fmt.Errorf("%w: exp is required", ErrInvalidClaims)
+	}
+	if now.After(time.Unix(exp, 0).Add(v.config.Leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.config.Leeway).Before(time.Unix(nbf, 0)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	if v.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+			return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidClaims)
+		}
+	}
+	if v.config.Audience != "" && !audienceContains(claims["aud"], v.config.Audience) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidClaims)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidClaims)
+	}
+
+	roleValue, _ := claims[v.config.RoleClaim].(string)
+	role, err := ParseRole(roleValue)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClaims, err)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Role:    role,
+		Scopes:  scopeClaim(claims),
+		Method:  "jwt",
+	}, nil
+}
+
+// decodeSegment decodes a base64url JSON segment
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericClaim reads a NumericDate claim
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+// audienceContains reports whether the aud claim, a string or array, contains audience
+func audienceContains(aud interface{}, audience string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopeClaim reads scopes from a space-separated "scope" claim or a "scopes" array
+func scopeClaim(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var scopes []string
+	if values, ok := claims["scopes"].([]interface{}); ok {
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHMACSecret = "0123456789abcdef0123456789abcdef"
+
+// signToken builds a compact JWT; key is an HMAC secret or an *rsa.PrivateKey
+func signToken(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writeJWKS writes a JWKS file holding the public half of key
+func writeJWKS(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	doc := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "alice",
+		"role":  "editor",
+		"scope": "episodes:write",
+		"iss":   "https://id.podsite.com",
+		"aud":   []string{"podsite-api"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTVerifierHS256(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{
+		HMACSecret: []byte(testHMACSecret),
+		Issuer:     "https://id.podsite.com",
+		Audience:   "podsite-api",
+	})
+	require.NoError(t, err)
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signToken(t, hs256, validClaims(), []byte(testHMACSecret)), nil},
+		{"wrong secret", signToken(t, hs256, validClaims(), []byte("another-secret-of-at-least-32-bytes")), ErrInvalidSignature},
+		{"alg none", signToken(t, map[string]interface{}{"alg": "none"}, validClaims(), []byte(testHMACSecret)), ErrUnsupportedAlg},
+		{"RS256 without JWKS", signToken(t, map[string]interface{}{"alg": "RS256"}, validClaims(), []byte(testHMACSecret)), ErrUnsupportedAlg},
+		{"expired", signToken(t, hs256, withClaim("exp", time.Now().Add(-time.Hour).Unix()), []byte(testHMACSecret)), ErrTokenExpired},
+		{"missing exp", signToken(t, hs256, withClaim("exp", nil), []byte(testHMACSecret)), ErrInvalidClaims},
+		{"not yet valid", signToken(t, hs256, withClaim("nbf", time.Now().Add(time.Hour).Unix()), []byte(testHMACSecret)), ErrTokenNotYetValid},
+		{"wrong issuer", signToken(t, hs256, withClaim("iss", "https://evil.example"), []byte(testHMACSecret)), ErrInvalidClaims},
+		{"wrong audience", signToken(t, hs256, withClaim("aud", "other"), []byte(testHMACSecret)), ErrInvalidClaims},
+		{"unknown role", signToken(t, hs256, withClaim("role", "owner"), []byte(testHMACSecret)), ErrInvalidClaims},
+		{"malformed", "not.a-token", ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", principal.Subject)
+			assert.Equal(t, RoleEditor, principal.Role)
+			assert.Equal(t, []string{"episodes:write"}, principal.Scopes)
+			assert.Equal(t, "jwt", principal.Method)
+		})
+	}
+}
+
+func TestJWTVerifierRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := NewJWKS(writeJWKS(t, "key-1", key), time.Hour)
+	require.NoError(t, jwks.Load(context.Background()))
+
+	verifier, err := NewJWTVerifier(JWTConfig{JWKS: jwks})
+	require.NoError(t, err)
+
+	token := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, validClaims(), key)
+	principal, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", principal.Subject)
+
+	forged := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-1"}, validClaims(), otherKey)
+	_, err = verifier.Verify(context.Background(), forged)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// HS256 must not be accepted when only RSA keys are configured
+	hs := signToken(t, map[string]interface{}{"alg": "HS256"}, validClaims(), []byte(testHMACSecret))
+	_, err = verifier.Verify(context.Background(), hs)
+	assert.ErrorIs(t, err, ErrUnsupportedAlg)
+}
+
+func TestJWKSRefetchBackoff(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	doc, err := os.ReadFile(writeJWKS(t, "key-1", key))
+	require.NoError(t, err)
+
+	var requests atomic.Int32
+	var available atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(doc)
+	}))
+	defer server.Close()
+
+	// Without keys, a failed fetch is not retried on every request
+	jwks := NewJWKS(server.URL, time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := jwks.Key(context.Background(), "key-1")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Stale keys keep being served while the source is down
+	available.Store(true)
+	require.NoError(t, jwks.Load(context.Background()))
+	available.Store(false)
+	jwks.fetched = time.Now().Add(-2 * time.Hour)
+	jwks.lastAttempt = time.Now().Add(-2 * jwksMinRefetch)
+	requests.Store(0)
+	for i := 0; i < 3; i++ {
+		got, err := jwks.Key(context.Background(), "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, &key.PublicKey, got)
+	}
+	_, err = jwks.Key(context.Background(), "key-2")
+	assert.ErrorIs(t, err, errUnknownKey)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestNewJWTVerifierRequiresKeys(t *testing.T) {
+	_, err := NewJWTVerifier(JWTConfig{})
+	assert.Error(t, err)
+
+	_, err = NewJWTVerifier(JWTConfig{HMACSecret: []byte("short")})
+	assert.Error(t, err)
+}
+
+func TestPreviewSigner(t *testing.T) {
+	signer, err := NewPreviewSigner([]byte(testHMACSecret))
+	require.NoError(t, err)
+	other, err := NewPreviewSigner([]byte("another-secret-of-at-least-32-bytes"))
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	token := signer.Sign("ep010", now.Add(time.Hour))
+
+	tests := []struct {
+		name      string
+		signer    *PreviewSigner
+		token     string
+		episodeID string
+		now       time.Time
+		wantErr   error
+	}{
+		{"valid", signer, token, "ep010", now, nil},
+		{"other episode", signer, token, "ep011", now, ErrInvalidPreviewToken},
+		{"expired", signer, token, "ep010", now.Add(time.Hour), ErrPreviewTokenExpired},
+		{"other secret", other, token, "ep010", now, ErrInvalidPreviewToken},
+		{"tampered", signer, "x" + token, "ep010", now, ErrInvalidPreviewToken},
+		{"garbage", signer, "nope", "ep010", now, ErrInvalidPreviewToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.token, tt.episodeID, tt.now)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	_, err = NewPreviewSigner([]byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidCredentials is returned for missing or unknown credentials
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeyHeader is an alternative to the Authorization header for API keys
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator resolves request credentials to a principal
+type Authenticator struct {
+	keys KeyStore
+	jwt  *JWTVerifier
+}
+
+// NewAuthenticator returns an authenticator for API keys and, when jwt is
+// not nil, JWT bearer tokens
+func NewAuthenticator(keys KeyStore, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{keys: keys, jwt: jwt}
+}
+
+// authenticate returns the principal for the request credentials. The
+// boolean is false when the request carries no credentials at all.
+func (a *Authenticator) authenticate(c *gin.Context) (*Principal, bool, error) {
+	credential := c.GetHeader(APIKeyHeader)
+	if credential == "" {
+		authorization := c.GetHeader("Authorization")
+		scheme, value, found := strings.Cut(authorization, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return nil, authorization != "", errInvalidCredentials
+		}
+		credential = strings.TrimSpace(value)
+	}
+	if credential == "" {
+		return nil, false, errInvalidCredentials
+	}
+
+	if a.jwt != nil && strings.Count(credential, ".") == 2 {
+		principal, err := a.jwt.Verify(c.Request.Context(), credential)
+		return principal, true, err
+	}
+
+	if a.keys != nil {
+		if key, ok := a.keys.Lookup(HashAPIKey(credential)); ok {
+			return &Principal{
+				Subject: key.ID,
+				Role:    key.Role,
+				Scopes:  key.Scopes,
+				Method:  "api_key",
+			}, true, nil
+		}
+	}
+
+	return nil, true, errInvalidCredentials
+}
+
+// Authenticate returns a Gin middleware that requires valid credentials and
+// stores the principal in the context. The authenticator is read on every
+// request so credentials can be replaced at runtime.
+func Authenticate(authenticator func() *Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, provided, err := authenticator().authenticate(c)
+		if err != nil {
+			message := "Authentication is required"
+			if provided {
+				message = "Invalid or expired credentials"
+			}
+			abortUnauthorized(c, message)
+			return
+		}
+
+		c.Set(PrincipalKey, principal)
+		c.Next()
+	}
+}
+
+// Require returns a Gin middleware that allows only principals with at
+// least role and every listed scope. It must run after Authenticate.
+func Require(role Role, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := FromContext(c)
+		if !ok {
+			abortUnauthorized(c, "Authentication is required")
+			return
+		}
+
+		if !principal.Role.Includes(role) {
+			abortForbidden(c, "The "+string(role)+" role is required")
+			return
+		}
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				abortForbidden(c, "The "+scope+" scope is required")
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// abortUnauthorized responds with 401 and a bearer challenge
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":   "unauthorized",
+		"message": message,
+		"code":    http.StatusUnauthorized,
+	})
+	c.Abort()
+}
+
+// abortForbidden responds with 403 for authenticated callers lacking permission
+func abortForbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "forbidden",
+		"message": message,
+		"code":    http.StatusForbidden,
+	})
+	c.Abort()
+}
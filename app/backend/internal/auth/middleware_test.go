@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := NewStaticKeyStore([]APIKey{
+		{ID: "editor-key", Hash: HashAPIKey("editor-secret"), Role: RoleEditor, Scopes: []string{ScopeEpisodesWrite}},
+		{ID: "readonly-key", Hash: HashAPIKey("readonly-secret"), Role: RoleEditor, Scopes: []string{ScopeAdminRead}},
+		{ID: "admin-key", Hash: HashAPIKey("admin-secret"), Role: RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	verifier, err := NewJWTVerifier(JWTConfig{HMACSecret: []byte(testHMACSecret)})
+	require.NoError(t, err)
+
+	authenticator := NewAuthenticator(keys, verifier)
+
+	router := gin.New()
+	admin := router.Group("/api/admin", Authenticate(func() *Authenticator { return authenticator }))
+	admin.POST("/episodes", Require(RoleEditor, ScopeEpisodesWrite), func(c *gin.Context) {
+		principal, _ := FromContext(c)
+		c.String(http.StatusOK, principal.Subject)
+	})
+	admin.DELETE("/episodes/:id", Require(RoleAdmin, ScopeEpisodesWrite), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func TestAuthenticateAndRequire(t *testing.T) {
+	router := setupAuthTestRouter(t)
+
+	editorJWT := signToken(t, map[string]interface{}{"alg": "HS256"}, map[string]interface{}{
+		"sub":  "alice",
+		"role": "editor",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}, []byte(testHMACSecret))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		header         string
+		value          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"no credentials", "POST", "/api/admin/episodes", "", "", http.StatusUnauthorized, "unauthorized"},
+		{"unknown key", "POST", "/api/admin/episodes", "Authorization", "Bearer nope", http.StatusUnauthorized, "unauthorized"},
+		{"basic scheme", "POST", "/api/admin/episodes", "Authorization", "Basic ZWRpdG9y", http.StatusUnauthorized, "unauthorized"},
+		{"editor key", "POST", "/api/admin/episodes", "Authorization", "Bearer editor-secret", http.StatusOK, ""},
+		{"editor key header", "POST", "/api/admin/episodes", APIKeyHeader, "editor-secret", http.StatusOK, ""},
+		{"missing scope", "POST", "/api/admin/episodes", "Authorization", "Bearer readonly-secret", http.StatusForbidden, "forbidden"},
+		{"editor cannot delete", "DELETE", "/api/admin/episodes/ep001", "Authorization", "Bearer editor-secret", http.StatusForbidden, "forbidden"},
+		{"admin can delete", "DELETE", "/api/admin/episodes/ep001", "Authorization", "Bearer admin-secret", http.StatusNoContent, ""},
+		{"editor JWT", "POST", "/api/admin/episodes", "Authorization", "Bearer " + editorJWT, http.StatusOK, ""},
+		{"tampered JWT", "POST", "/api/admin/episodes", "Authorization", "Bearer " + editorJWT + "x", http.StatusUnauthorized, "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedError != "" {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedError, response["error"])
+				assert.Equal(t, float64(tt.expectedStatus), response["code"])
+				assert.NotEmpty(t, response["message"])
+			}
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestNewStaticKeyStoreRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []APIKey
+	}{
+		{"plaintext secret", []APIKey{{ID: "a", Hash: "secret", Role: RoleEditor}}},
+		{"unknown role", []APIKey{{ID: "a", Hash: HashAPIKey("a"), Role: "owner"}}},
+		{"duplicate id", []APIKey{{ID: "a", Hash: HashAPIKey("a"), Role: RoleEditor}, {ID: "a", Hash: HashAPIKey("b"), Role: RoleEditor}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStaticKeyStore(tt.keys)
+			assert.Error(t, err)
+		})
+	}
+}
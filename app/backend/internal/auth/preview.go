@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned when verifying preview tokens
+var (
+	ErrInvalidPreviewToken = errors.New("invalid preview token")
+	ErrPreviewTokenExpired = errors.New("preview token has expired")
+)
+
+// PreviewSigner issues and verifies tokens that let anyone holding them view
+// one unpublished episode until the token expires. Tokens are
+// base64url(episodeID "." expiry) "." base64url(HMAC-SHA256).
+type PreviewSigner struct {
+	secret []byte
+}
+
+// NewPreviewSigner returns a signer keyed with secret, which must be at least 32 bytes
+func NewPreviewSigner(secret []byte) (*PreviewSigner, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("the preview secret must be at least 32 bytes")
+	}
+	return &PreviewSigner{secret: append([]byte(nil), secret...)}, nil
+}
+
+// Sign returns a token for episodeID that expires at expires
+func (s *PreviewSigner) Sign(episodeID string, expires time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(episodeID + "." + strconv.FormatInt(expires.Unix(), 10)))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// Verify checks that token was issued for episodeID and has not expired at now
+func (s *PreviewSigner) Verify(token, episodeID string, now time.Time) error {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidPreviewToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return ErrInvalidPreviewToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrInvalidPreviewToken
+	}
+	dot := strings.LastIndexByte(string(decoded), '.')
+	if dot < 0 || string(decoded[:dot]) != episodeID {
+		return ErrInvalidPreviewToken
+	}
+	expiry, err := strconv.ParseInt(string(decoded[dot+1:]), 10, 64)
+	if err != nil {
+		return ErrInvalidPreviewToken
+	}
+	if now.Unix() >= expiry {
+		return ErrPreviewTokenExpired
+	}
+	return nil
+}
+
+// mac signs payload with a purpose prefix so preview tokens can never be
+// confused with JWTs signed by the same secret
+func (s *PreviewSigner) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte("preview:" + payload))
+	return h.Sum(nil)
+}
@@ -1,40 +1,391 @@
 package config
 
 import (
+	"io"
 	"os"
-	"strings"
+	"path/filepath"
+	"time"
+
+	"github.com/podsite/backend/internal/models"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Port        string
-	Environment string
-	CORSOrigins []string
-	LogLevel    string
+	Environment string           `yaml:"environment"`
+	Server      ServerConfig     `yaml:"server"`
+	TLS         TLSConfig        `yaml:"tls"`
+	Admin       AdminConfig      `yaml:"admin"`
+	Auth        AuthConfig       `yaml:"auth"`
+	Audit       AuditConfig      `yaml:"audit"`
+	Analytics   AnalyticsConfig  `yaml:"analytics"`
+	Listeners   ListenersConfig  `yaml:"listeners"`
+	Mail        MailConfig       `yaml:"mail"`
+	Comments    CommentsConfig   `yaml:"comments"`
+	Publishing  PublishingConfig `yaml:"publishing"`
+	Log         LogConfig        `yaml:"log"`
+	CORS        CORSConfig       `yaml:"cors"`
+	Security    SecurityConfig   `yaml:"security"`
+	Cache       CacheConfig      `yaml:"cache"`
+	RateLimit   RateLimitConfig  `yaml:"rateLimit"`
+	Content     ContentConfig    `yaml:"content"`
 }
 
-// Load loads configuration from environment variables with sensible defaults
-func Load() *Config {
-	return &Config{
-		Port:        getEnv("PORT", "3001"),
-		Environment: getEnv("GO_ENV", "development"),
-		CORSOrigins: getCORSOrigins(),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+// ServerConfig holds settings for the public HTTP listener
+type ServerConfig struct {
+	Port            string        `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+// TLSConfig holds settings for terminating TLS on the public listener.
+// TLS is enabled when both CertFile and KeyFile are set.
+type TLSConfig struct {
+	CertFile       string        `yaml:"certFile"`
+	KeyFile        string        `yaml:"keyFile"`
+	MinVersion     string        `yaml:"minVersion"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+	HTTP3          bool          `yaml:"http3"`
+	HTTP3Port      string        `yaml:"http3Port"`
+}
+
+// Enabled reports whether TLS is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// AdminConfig holds settings for the admin diagnostics listener
+type AdminConfig struct {
+	Port  string `yaml:"port"`
+	Token string `yaml:"token"`
+	// ClientCAFile enables mutual TLS: admin clients must present a
+	// certificate signed by one of these authorities
+	ClientCAFile string `yaml:"clientCaFile"`
+}
+
+// AuthConfig holds credentials accepted on the authenticated /api/admin routes
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `yaml:"apiKeys"`
+	// APIKeysFile is a JSON array of keys in the same shape as APIKeys,
+	// typically api_keys.json next to the content files
+	APIKeysFile string    `yaml:"apiKeysFile"`
+	JWT         JWTConfig `yaml:"jwt"`
+}
+
+// APIKeyConfig describes an API key by the SHA-256 hash of its secret
+type APIKeyConfig struct {
+	ID     string   `yaml:"id"`
+	Name   string   `yaml:"name"`
+	Hash   string   `yaml:"hash"`
+	Role   string   `yaml:"role"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// JWTConfig holds JWT bearer token verification settings. HS256 tokens are
+// accepted when HMACSecret is set and RS256 tokens when a JWKS source is set.
+type JWTConfig struct {
+	HMACSecret  string        `yaml:"hmacSecret"`
+	JWKSFile    string        `yaml:"jwksFile"`
+	JWKSURL     string        `yaml:"jwksUrl"`
+	JWKSRefresh time.Duration `yaml:"jwksRefresh"`
+	Issuer      string        `yaml:"issuer"`
+	Audience    string        `yaml:"audience"`
+	Leeway      time.Duration `yaml:"leeway"`
+	RoleClaim   string        `yaml:"roleClaim"`
+}
+
+// Enabled reports whether JWT verification is configured
+func (c JWTConfig) Enabled() bool {
+	return c.HMACSecret != "" || c.JWKSFile != "" || c.JWKSURL != ""
+}
+
+// AuditConfig holds settings for the administrative audit log
+type AuditConfig struct {
+	// File is an append-only JSON Lines file; empty keeps entries in memory only
+	File string `yaml:"file"`
+}
+
+// AnalyticsConfig holds settings for download counting
+type AnalyticsConfig struct {
+	// File is an append-only JSON Lines file of counted downloads; empty
+	// keeps them in memory only
+	File string `yaml:"file"`
+	// ClientsFile keeps the daily request counts by app, OS and device;
+	// empty keeps them in memory only
+	ClientsFile string `yaml:"clientsFile"`
+	// UserAgentRules is a YAML file replacing the built-in user agent
+	// rules; it is re-read on every reload
+	UserAgentRules string `yaml:"userAgentRules"`
+}
+
+// ListenersConfig holds settings for listener accounts and sign-in
+type ListenersConfig struct {
+	// File keeps accounts, sessions and playback progress; empty keeps
+	// them in memory only
+	File string `yaml:"file"`
+	// PlaylistsFile keeps playlists and queues; empty keeps them in memory
+	// only
+	PlaylistsFile string `yaml:"playlistsFile"`
+	// PublicURL is the scheme and host the site is reached at, which links
+	// for podcast apps, such as playlist feeds, are built from
+	PublicURL string `yaml:"publicUrl"`
+	// LoginURL is the site page that completes sign-in with the token query
+	// parameter; empty sends only the token
+	LoginURL   string        `yaml:"loginUrl"`
+	LoginTTL   time.Duration `yaml:"loginTtl"`
+	SessionTTL time.Duration `yaml:"sessionTtl"`
+}
+
+// MailConfig holds settings for outgoing email
+type MailConfig struct {
+	// Mailer is file, which writes messages to Dir, or smtp
+	Mailer string     `yaml:"mailer"`
+	From   string     `yaml:"from"`
+	Dir    string     `yaml:"dir"`
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig holds the SMTP server outgoing email is sent through
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// CommentsConfig holds settings for episode comments, reactions and
+// ratings and the spam checks applied to comments
+type CommentsConfig struct {
+	// File keeps comments; empty keeps them in memory only
+	File string `yaml:"file"`
+	// ReactionsFile keeps reactions and ratings; empty keeps them in memory
+	// only
+	ReactionsFile string `yaml:"reactionsFile"`
+	// AutoApprove publishes comments that pass the spam checks without
+	// waiting for a moderator
+	AutoApprove bool `yaml:"autoApprove"`
+	// MaxLinks is the most links a comment may contain before it is
+	// rejected
+	MaxLinks int `yaml:"maxLinks"`
+	// BannedWords reject comments containing any of them as a whole word,
+	// ignoring case
+	BannedWords []string `yaml:"bannedWords"`
+	// ListenerLimit and IPLimit are how many comments one account or
+	// address may post per LimitWindow
+	ListenerLimit int           `yaml:"listenerLimit"`
+	IPLimit       int           `yaml:"ipLimit"`
+	LimitWindow   time.Duration `yaml:"limitWindow"`
+}
+
+// PublishingConfig holds settings for scheduled publishing and previews
+type PublishingConfig struct {
+	// Timezone is the IANA zone in which date-only publish dates start
+	Timezone string `yaml:"timezone"`
+	// SchedulerInterval is how often scheduled episodes are checked
+	SchedulerInterval time.Duration `yaml:"schedulerInterval"`
+	// PreviewSecret signs preview tokens; when empty a random secret is
+	// used and preview links stop working on restart
+	PreviewSecret string        `yaml:"previewSecret"`
+	PreviewTTL    time.Duration `yaml:"previewTtl"`
+}
+
+// LogConfig holds logging settings
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// CORSConfig holds the default cross-origin policy and per-route overrides
+type CORSConfig struct {
+	Origins          []string          `yaml:"origins"`
+	Methods          []string          `yaml:"methods"`
+	Headers          []string          `yaml:"headers"`
+	ExposedHeaders   []string          `yaml:"exposedHeaders"`
+	AllowCredentials bool              `yaml:"allowCredentials"`
+	MaxAge           time.Duration     `yaml:"maxAge"`
+	Routes           []CORSRouteConfig `yaml:"routes"`
+}
+
+// CORSRouteConfig overrides the default CORS policy for requests to
+// PathPrefix and the paths below it. Empty fields inherit the default policy.
+type CORSRouteConfig struct {
+	PathPrefix       string        `yaml:"pathPrefix"`
+	Origins          []string      `yaml:"origins"`
+	Methods          []string      `yaml:"methods"`
+	Headers          []string      `yaml:"headers"`
+	ExposedHeaders   []string      `yaml:"exposedHeaders"`
+	AllowCredentials *bool         `yaml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+}
+
+// SecurityConfig holds the default security header policy and per-route overrides.
+// An empty header value omits that header.
+type SecurityConfig struct {
+	ContentSecurityPolicy     string                `yaml:"contentSecurityPolicy"`
+	CSPReportOnly             bool                  `yaml:"cspReportOnly"`
+	CSPReportURI              string                `yaml:"cspReportUri"`
+	FrameOptions              string                `yaml:"frameOptions"`
+	ReferrerPolicy            string                `yaml:"referrerPolicy"`
+	PermissionsPolicy         string                `yaml:"permissionsPolicy"`
+	CrossOriginOpenerPolicy   string                `yaml:"crossOriginOpenerPolicy"`
+	CrossOriginResourcePolicy string                `yaml:"crossOriginResourcePolicy"`
+	HSTS                      HSTSConfig            `yaml:"hsts"`
+	Routes                    []SecurityRouteConfig `yaml:"routes"`
+}
+
+// HSTSConfig holds Strict-Transport-Security settings, sent only over TLS
+type HSTSConfig struct {
+	MaxAge            time.Duration `yaml:"maxAge"`
+	IncludeSubdomains bool          `yaml:"includeSubdomains"`
+	Preload           bool          `yaml:"preload"`
+}
+
+// SecurityRouteConfig overrides the default security policy for requests to
+// PathPrefix and the paths below it. Unset fields inherit the default policy;
+// an explicit empty string omits the header.
+type SecurityRouteConfig struct {
+	PathPrefix                string  `yaml:"pathPrefix"`
+	ContentSecurityPolicy     *string `yaml:"contentSecurityPolicy"`
+	CSPReportOnly             *bool   `yaml:"cspReportOnly"`
+	FrameOptions              *string `yaml:"frameOptions"`
+	ReferrerPolicy            *string `yaml:"referrerPolicy"`
+	PermissionsPolicy         *string `yaml:"permissionsPolicy"`
+	CrossOriginOpenerPolicy   *string `yaml:"crossOriginOpenerPolicy"`
+	CrossOriginResourcePolicy *string `yaml:"crossOriginResourcePolicy"`
+}
+
+// CacheConfig holds response cache TTLs per route group
+type CacheConfig struct {
+	EpisodesTTL time.Duration `yaml:"episodesTtl"`
+	ContentTTL  time.Duration `yaml:"contentTtl"`
+}
+
+// RateLimitConfig holds the per-IP rate limit policy
+type RateLimitConfig struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// ContentConfig holds the location of the content files
+type ContentConfig struct {
+	Dir string `yaml:"dir"`
+	// MediaDir is the directory site-relative audio URLs such as
+	// /assets/audio/ep1.mp3 are served from; empty means the public
+	// directory next to Dir
+	MediaDir string `yaml:"mediaDir"`
+	// ArtworkCacheDir keeps resized artwork; empty means a directory under
+	// the system temporary directory
+	ArtworkCacheDir string `yaml:"artworkCacheDir"`
+	// WaveformInterval is how often episodes are checked for missing or
+	// outdated waveforms
+	WaveformInterval time.Duration `yaml:"waveformInterval"`
+}
+
+// MediaRoot returns the directory audio files are probed in
+func (c ContentConfig) MediaRoot() string {
+	if c.MediaDir != "" {
+		return c.MediaDir
 	}
+	return filepath.Join(c.Dir, "..", "public")
 }
 
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// ArtworkCache returns the directory resized artwork is kept in
+func (c ContentConfig) ArtworkCache() string {
+	if c.ArtworkCacheDir != "" {
+		return c.ArtworkCacheDir
 	}
-	return defaultValue
+	return filepath.Join(os.TempDir(), "podsite-artwork")
 }
 
-// getCORSOrigins parses CORS origins from environment variable
-func getCORSOrigins() []string {
-	origins := getEnv("CORS_ORIGINS", "http://localhost:3000")
-	return strings.Split(origins, ",")
+// secretMask replaces secret values in printed configuration
+const secretMask = "********"
+
+// Default returns the configuration used when nothing else is specified
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Port:            "3001",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		TLS: TLSConfig{
+			MinVersion:     "1.2",
+			ReloadInterval: time.Minute,
+		},
+		Auth: AuthConfig{
+			JWT: JWTConfig{
+				JWKSRefresh: time.Hour,
+				Leeway:      time.Minute,
+				RoleClaim:   "role",
+			},
+		},
+		Listeners: ListenersConfig{
+			PublicURL:  "http://localhost:3001",
+			LoginTTL:   15 * time.Minute,
+			SessionTTL: 90 * 24 * time.Hour,
+		},
+		Mail: MailConfig{
+			Mailer: "file",
+			From:   "Podsite <noreply@localhost>",
+			Dir:    filepath.Join(os.TempDir(), "podsite-mail"),
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+		Comments: CommentsConfig{
+			MaxLinks:      2,
+			ListenerLimit: 5,
+			IPLimit:       20,
+			LimitWindow:   time.Hour,
+		},
+		Publishing: PublishingConfig{
+			Timezone:          "UTC",
+			SchedulerInterval: 30 * time.Second,
+			PreviewTTL:        24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		CORS: CORSConfig{
+			Origins: []string{"http://localhost:3000"},
+			MaxAge:  24 * time.Hour,
+		},
+		Security: SecurityConfig{
+			ContentSecurityPolicy:     "default-src 'self'; frame-ancestors 'none'; base-uri 'self'; object-src 'none'",
+			FrameOptions:              "DENY",
+			ReferrerPolicy:            "strict-origin-when-cross-origin",
+			PermissionsPolicy:         "camera=(), microphone=(), geolocation=(), payment=()",
+			CrossOriginOpenerPolicy:   "same-origin",
+			CrossOriginResourcePolicy: "same-site",
+			HSTS: HSTSConfig{
+				MaxAge: 180 * 24 * time.Hour,
+			},
+			Routes: []SecurityRouteConfig{
+				{
+					// Swagger UI relies on inline scripts and styles
+					PathPrefix:            "/swagger",
+					ContentSecurityPolicy: stringPtr("default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"),
+				},
+			},
+		},
+		Cache: CacheConfig{
+			EpisodesTTL: 5 * time.Minute,
+			ContentTTL:  30 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Requests: 100,
+			Window:   time.Minute,
+		},
+		Content: ContentConfig{
+			Dir:              models.DefaultContentDir(),
+			WaveformInterval: 5 * time.Minute,
+		},
+	}
 }
 
 // IsDevelopment returns true if running in development mode
@@ -46,3 +397,92 @@ func (c *Config) IsDevelopment() bool {
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
 }
+
+// LogFormat returns the configured log format, defaulting to JSON in production
+func (c *Config) LogFormat() string {
+	if c.Log.Format != "" {
+		return c.Log.Format
+	}
+	if c.IsProduction() {
+		return "json"
+	}
+	return "text"
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.CORS = c.CORS.clone()
+	redacted.Security.Routes = append([]SecurityRouteConfig(nil), c.Security.Routes...)
+	redacted.Auth = c.Auth.clone()
+
+	if redacted.Admin.Token != "" {
+		redacted.Admin.Token = secretMask
+	}
+	if redacted.Auth.JWT.HMACSecret != "" {
+		redacted.Auth.JWT.HMACSecret = secretMask
+	}
+	if redacted.Publishing.PreviewSecret != "" {
+		redacted.Publishing.PreviewSecret = secretMask
+	}
+	if redacted.Mail.SMTP.Password != "" {
+		redacted.Mail.SMTP.Password = secretMask
+	}
+
+	return &redacted
+}
+
+// WriteYAML writes the configuration with secrets masked
+func (c *Config) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+
+	return encoder.Encode(c.Redacted())
+}
+
+// clone returns a deep copy of the CORS configuration
+func (c CORSConfig) clone() CORSConfig {
+	cloned := c
+	cloned.Origins = cloneStrings(c.Origins)
+	cloned.Methods = cloneStrings(c.Methods)
+	cloned.Headers = cloneStrings(c.Headers)
+	cloned.ExposedHeaders = cloneStrings(c.ExposedHeaders)
+	if c.Routes != nil {
+		cloned.Routes = make([]CORSRouteConfig, len(c.Routes))
+		for i, route := range c.Routes {
+			route.Origins = cloneStrings(route.Origins)
+			route.Methods = cloneStrings(route.Methods)
+			route.Headers = cloneStrings(route.Headers)
+			route.ExposedHeaders = cloneStrings(route.ExposedHeaders)
+			cloned.Routes[i] = route
+		}
+	}
+	return cloned
+}
+
+// clone returns a deep copy of the auth configuration
+func (c AuthConfig) clone() AuthConfig {
+	cloned := c
+	if c.APIKeys != nil {
+		cloned.APIKeys = make([]APIKeyConfig, len(c.APIKeys))
+		for i, key := range c.APIKeys {
+			key.Scopes = cloneStrings(key.Scopes)
+			cloned.APIKeys[i] = key
+		}
+	}
+	return cloned
+}
+
+// cloneStrings copies a slice, keeping the distinction between nil and empty
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+// stringPtr returns a pointer to s
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "3001", cfg.Server.Port)
+	assert.Equal(t, 15*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 5*time.Minute, cfg.Cache.EpisodesTTL)
+	assert.Equal(t, 30*time.Minute, cfg.Cache.ContentTTL)
+	assert.Equal(t, 100, cfg.RateLimit.Requests)
+	assert.Equal(t, []string{"http://localhost:3000"}, cfg.CORS.Origins)
+}
+
+func TestLoadLayering(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: "4000"
+  readTimeout: 20s
+log:
+  level: debug
+rateLimit:
+  requests: 50
+`)
+	t.Setenv("PORT", "5000")
+	t.Setenv("RATE_LIMIT_WINDOW", "30s")
+
+	opts, err := ParseFlags([]string{"--config", path, "--port", "6000"})
+	require.NoError(t, err)
+
+	cfg, err := Load(opts)
+	require.NoError(t, err)
+
+	// Flag beats env beats file beats default
+	assert.Equal(t, "6000", cfg.Server.Port)
+	assert.Equal(t, 20*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, 50, cfg.RateLimit.Requests)
+	assert.Equal(t, 30*time.Second, cfg.RateLimit.Window)
+	assert.Equal(t, 15*time.Second, cfg.Server.WriteTimeout)
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  prot: \"4000\"\n")
+
+	_, err := Load(Options{ConfigFile: path})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "prot")
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = "99999"
+	cfg.Log.Level = "verbose"
+	cfg.RateLimit.Requests = 0
+	cfg.CORS.Origins = []string{"localhost:3000"}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"server.port", "log.level", "rateLimit.requests", "cors.origins[0]"} {
+		assert.Contains(t, err.Error(), field)
+	}
+}
+
+func TestInvalidEnvValue(t *testing.T) {
+	t.Setenv("CACHE_EPISODES_TTL", "five minutes")
+
+	_, err := Load(Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CACHE_EPISODES_TTL")
+}
+
+func TestWriteYAMLMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Admin.Token = "super-secret"
+	cfg.Auth.JWT.HMACSecret = "another-super-secret-value-of-32-bytes"
+	cfg.Mail.SMTP.Password = "smtp-super-secret"
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.WriteYAML(&buf))
+
+	assert.NotContains(t, buf.String(), "super-secret")
+	assert.True(t, strings.Contains(buf.String(), secretMask))
+	assert.Equal(t, "super-secret", cfg.Admin.Token, "original config must not be modified")
+}
+
+func TestDiff(t *testing.T) {
+	old := Default()
+	next := Default()
+	next.Log.Level = "debug"
+	next.Server.Port = "4000"
+	next.Admin.Token = "new-secret"
+	next.CORS.Origins = []string{"https://podsite.com"}
+
+	changes := Diff(old, next)
+
+	fields := make(map[string]Change)
+	for _, change := range changes {
+		fields[change.Field] = change
+	}
+	assert.Len(t, changes, 4)
+	assert.Equal(t, "info", fields["log.level"].Old)
+	assert.Equal(t, "debug", fields["log.level"].New)
+	assert.Equal(t, secretMask, fields["admin.token"].New)
+	assert.Contains(t, fields, "cors.origins")
+	assert.Contains(t, fields, "server.port")
+
+	// Rotating a secret is reported without revealing either value
+	old.Admin.Token = "old-secret"
+	changes = Diff(old, next)
+	require.NotEmpty(t, changes)
+	for _, change := range changes {
+		if change.Field == "admin.token" {
+			assert.Equal(t, secretMask, change.Old)
+			assert.Equal(t, secretMask, change.New)
+		}
+	}
+}
+
+func TestWithReloadableMatchesIsReloadable(t *testing.T) {
+	old := Default()
+	next := Default()
+	next.Environment = "production"
+	next.Server.Port = "4000"
+	next.Admin.Port = "6060"
+	next.Log.Level = "debug"
+	next.Log.Format = "json"
+	next.CORS.Origins = []string{"https://podsite.com"}
+	next.Security.FrameOptions = "SAMEORIGIN"
+	next.Cache.EpisodesTTL = time.Minute
+	next.RateLimit.Requests = 10
+	next.Content.Dir = "/srv/content"
+	next.Auth.JWT.Issuer = "https://id.podsite.com"
+
+	merged := old.WithReloadable(next)
+
+	// Everything still different after merging must be a restart-only field
+	for _, change := range Diff(merged, next) {
+		assert.False(t, IsReloadable(change.Field), "%s is reloadable but was not applied", change.Field)
+	}
+	// Everything applied must be reloadable
+	for _, change := range Diff(old, merged) {
+		assert.True(t, IsReloadable(change.Field), "%s was applied but requires a restart", change.Field)
+	}
+}
+
+func TestValidateCORS(t *testing.T) {
+	allow := true
+	cfg := Default()
+	cfg.CORS.Origins = []string{"https://*.podsite.com", "https://*.*.podsite.com"}
+	cfg.CORS.Routes = []CORSRouteConfig{
+		{PathPrefix: "api/admin", Origins: []string{"*"}, AllowCredentials: &allow},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "cors.origins[0]")
+	assert.Contains(t, err.Error(), "cors.origins[1]")
+	assert.Contains(t, err.Error(), "cors.routes[0].pathPrefix")
+	assert.Contains(t, err.Error(), "cors.routes[0].origins[0]")
+}
+
+func TestValidateTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte("cert"), 0o600))
+
+	cfg := Default()
+	cfg.TLS.CertFile = certFile
+	cfg.TLS.MinVersion = "1.1"
+	cfg.TLS.HTTP3 = true
+	cfg.Admin.ClientCAFile = filepath.Join(dir, "missing.pem")
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"tls.keyFile", "tls.minVersion", "tls.http3", "admin.clientCaFile"} {
+		assert.Contains(t, err.Error(), field)
+	}
+
+	cfg = Default()
+	cfg.TLS.CertFile = certFile
+	cfg.TLS.KeyFile = certFile
+	cfg.TLS.HTTP3 = true
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateAuth(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.APIKeys = []APIKeyConfig{
+		{ID: "ci", Hash: "sha256:" + strings.Repeat("ab", 32), Role: "editor"},
+		{ID: "ci", Hash: "md5:abc", Role: "owner"},
+	}
+	cfg.Auth.JWT.HMACSecret = "short"
+	cfg.Auth.JWT.JWKSFile = "jwks.json"
+	cfg.Auth.JWT.JWKSURL = "ftp://id.podsite.com/jwks"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "auth.apiKeys[0]")
+	for _, field := range []string{"auth.apiKeys[1].id", "auth.apiKeys[1].hash", "auth.apiKeys[1].role", "auth.jwt.hmacSecret", "auth.jwt.jwksUrl"} {
+		assert.Contains(t, err.Error(), field)
+	}
+}
+
+func TestValidatePublishing(t *testing.T) {
+	cfg := Default()
+	cfg.Publishing.Timezone = "Mars/Olympus_Mons"
+	cfg.Publishing.SchedulerInterval = 0
+	cfg.Publishing.PreviewSecret = "short"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"publishing.timezone", "publishing.schedulerInterval", "publishing.previewSecret"} {
+		assert.Contains(t, err.Error(), field)
+	}
+
+	cfg.Publishing.Timezone = "Europe/Berlin"
+	cfg.Publishing.SchedulerInterval = time.Minute
+	cfg.Publishing.PreviewSecret = strings.Repeat("s", 32)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestContentMediaRoot(t *testing.T) {
+	content := ContentConfig{Dir: filepath.Join("site", "content")}
+	assert.Equal(t, filepath.Join("site", "public"), content.MediaRoot())
+
+	content.MediaDir = "/srv/media"
+	assert.Equal(t, "/srv/media", content.MediaRoot())
+
+	cfg := Default()
+	cfg.Content.MediaDir = writeConfigFile(t, "")
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "content.mediaDir")
+}
+
+func TestValidateWaveformInterval(t *testing.T) {
+	cfg := Default()
+	cfg.Content.WaveformInterval = 0
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "content.waveformInterval")
+}
+
+func TestValidateAnalytics(t *testing.T) {
+	cfg := Default()
+	cfg.Analytics.UserAgentRules = filepath.Join(t.TempDir(), "missing.yaml")
+	cfg.Analytics.ClientsFile = t.TempDir()
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "analytics.userAgentRules")
+	assert.Contains(t, err.Error(), "analytics.clientsFile")
+}
+
+func TestValidateListeners(t *testing.T) {
+	cfg := Default()
+	cfg.Listeners.PublicURL = "https://example.com/podcast"
+	cfg.Listeners.LoginURL = "/sign-in"
+	cfg.Listeners.SessionTTL = 0
+	cfg.Mail.Mailer = "smtp"
+	cfg.Mail.From = "not an address"
+	cfg.Mail.SMTP.Password = "secret"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"listeners.publicUrl", "listeners.loginUrl", "listeners.sessionTtl", "mail.from", "mail.smtp.host", "mail.smtp.username"} {
+		assert.Contains(t, err.Error(), field)
+	}
+
+	cfg.Listeners.PublicURL = "https://example.com/"
+	cfg.Listeners.LoginURL = "https://example.com/sign-in"
+	cfg.Listeners.SessionTTL = 30 * 24 * time.Hour
+	cfg.Mail.From = "Podsite <noreply@example.com>"
+	cfg.Mail.SMTP = SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "podsite", Password: "secret"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Mail.Mailer = "sendmail"
+	assert.ErrorContains(t, cfg.Validate(), "mail.mailer")
+}
+
+func TestValidateComments(t *testing.T) {
+	cfg := Default()
+	cfg.Comments.MaxLinks = -1
+	cfg.Comments.BannedWords = []string{"casino", " "}
+	cfg.Comments.IPLimit = 0
+	cfg.Comments.LimitWindow = 0
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, field := range []string{"comments.maxLinks", "comments.bannedWords[1]", "comments.ipLimit", "comments.limitWindow"} {
+		assert.Contains(t, err.Error(), field)
+	}
+
+	t.Setenv("COMMENTS_BANNED_WORDS", "casino, free money")
+	t.Setenv("COMMENTS_MAX_LINKS", "0")
+	t.Setenv("COMMENTS_AUTO_APPROVE", "true")
+	cfg, err = Load(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"casino", "free money"}, cfg.Comments.BannedWords)
+	assert.Equal(t, 0, cfg.Comments.MaxLinks)
+	assert.True(t, cfg.Comments.AutoApprove)
+
+	t.Setenv("COMMENTS_IP_LIMIT", "many")
+	_, err = Load(Options{})
+	assert.ErrorContains(t, err, "COMMENTS_IP_LIMIT")
+}
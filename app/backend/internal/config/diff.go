@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a configuration field whose value differs between two configs
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// reloadableFields lists the fields, or field prefixes ending in ".", that can
+// be applied to a running server. Everything else requires a restart.
+var reloadableFields = []string{
+	"log.level",
+	"cors.",
+	"security.",
+	"cache.",
+	"rateLimit.",
+	"content.",
+	"auth.",
+}
+
+// secretFields are masked when a change is reported
+var secretFields = map[string]bool{
+	"admin.token":              true,
+	"auth.jwt.hmacSecret":      true,
+	"publishing.previewSecret": true,
+	"mail.smtp.password":       true,
+}
+
+// IsReloadable reports whether a field can be changed without a restart
+func IsReloadable(field string) bool {
+	for _, reloadable := range reloadableFields {
+		if field == reloadable || (strings.HasSuffix(reloadable, ".") && strings.HasPrefix(field, reloadable)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the fields that differ between old and new, with secrets masked
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffValues("", reflect.ValueOf(*old), reflect.ValueOf(*new), &changes)
+	return changes
+}
+
+// diffValues walks two structs of the same type and records differing leaves
+func diffValues(prefix string, old, new reflect.Value, changes *[]Change) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			field := old.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			diffValues(name, old.Field(i), new.Field(i), changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+		change := Change{
+			Field: prefix,
+			Old:   formatValue(old),
+			New:   formatValue(new),
+		}
+		if secretFields[prefix] {
+			change.Old = maskSecret(change.Old)
+			change.New = maskSecret(change.New)
+		}
+		*changes = append(*changes, change)
+	}
+}
+
+// maskSecret hides a non-empty secret value
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// formatValue renders a leaf value for logging; slices of structs are JSON encoded
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct {
+		data, err := json.Marshal(v.Interface())
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// WithReloadable returns a copy of c that takes every reloadable field from next
+// and keeps the rest, so settings that need a restart are left untouched
+func (c *Config) WithReloadable(next *Config) *Config {
+	merged := *c
+	merged.Log.Level = next.Log.Level
+	merged.CORS = next.CORS.clone()
+	merged.Security = next.Security
+	merged.Security.Routes = append([]SecurityRouteConfig(nil), next.Security.Routes...)
+	merged.Cache = next.Cache
+	merged.RateLimit = next.RateLimit
+	merged.Content = next.Content
+	merged.Auth = next.Auth.clone()
+	return &merged
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options holds command-line options. Only flags that were explicitly
+// passed override values from the config file and environment.
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
+
+	overrides map[string]string
+}
+
+// ParseFlags parses command-line arguments into Options
+func ParseFlags(args []string) (Options, error) {
+	fs := flag.NewFlagSet("podsite-backend", flag.ContinueOnError)
+
+	configFile := fs.String("config", getEnv("CONFIG_FILE", ""), "path to a YAML configuration file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets masked and exit")
+	fs.String("env", "", "environment (development, staging, production)")
+	fs.String("port", "", "public HTTP port")
+	fs.String("admin-port", "", "admin listener port")
+	fs.String("tls-cert", "", "TLS certificate file; enables HTTPS together with --tls-key")
+	fs.String("tls-key", "", "TLS private key file")
+	fs.String("log-level", "", "log level (debug, info, warn, error)")
+	fs.String("log-format", "", "log format (text, json)")
+	fs.String("content-dir", "", "directory containing episodes.json, faq.json and about.md")
+
+	if err := fs.Parse(args); err != nil {
+		return Options{}, err
+	}
+
+	opts := Options{
+		ConfigFile:  *configFile,
+		PrintConfig: *printConfig,
+		overrides:   make(map[string]string),
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" && f.Name != "print-config" {
+			opts.overrides[f.Name] = f.Value.String()
+		}
+	})
+
+	return opts, nil
+}
+
+// Load builds the configuration from defaults, the config file, environment
+// variables and command-line flags, in increasing order of precedence, and
+// validates the result.
+func Load(opts Options) (*Config, error) {
+	cfg := Default()
+
+	if opts.ConfigFile != "" {
+		if err := cfg.loadFile(opts.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	cfg.applyFlags(opts.overrides)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile merges a YAML file into the configuration
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// envBinding maps an environment variable onto a configuration field
+type envBinding struct {
+	key   string
+	apply func(c *Config, value string) error
+}
+
+var envBindings = []envBinding{
+	{"GO_ENV", func(c *Config, v string) error { c.Environment = v; return nil }},
+	{"PORT", func(c *Config, v string) error { c.Server.Port = v; return nil }},
+	{"SERVER_READ_TIMEOUT", durationSetter(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"SERVER_WRITE_TIMEOUT", durationSetter(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"SERVER_IDLE_TIMEOUT", durationSetter(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"SHUTDOWN_TIMEOUT", durationSetter(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"ADMIN_PORT", func(c *Config, v string) error { c.Admin.Port = v; return nil }},
+	{"ADMIN_TOKEN", func(c *Config, v string) error { c.Admin.Token = v; return nil }},
+	{"ADMIN_CLIENT_CA_FILE", func(c *Config, v string) error { c.Admin.ClientCAFile = v; return nil }},
+	{"AUTH_API_KEYS_FILE", func(c *Config, v string) error { c.Auth.APIKeysFile = v; return nil }},
+	{"AUTH_JWT_HMAC_SECRET", func(c *Config, v string) error { c.Auth.JWT.HMACSecret = v; return nil }},
+	{"AUTH_JWT_JWKS_FILE", func(c *Config, v string) error { c.Auth.JWT.JWKSFile = v; return nil }},
+	{"AUTH_JWT_JWKS_URL", func(c *Config, v string) error { c.Auth.JWT.JWKSURL = v; return nil }},
+	{"AUTH_JWT_ISSUER", func(c *Config, v string) error { c.Auth.JWT.Issuer = v; return nil }},
+	{"AUTH_JWT_AUDIENCE", func(c *Config, v string) error { c.Auth.JWT.Audience = v; return nil }},
+	{"AUDIT_FILE", func(c *Config, v string) error { c.Audit.File = v; return nil }},
+	{"ANALYTICS_FILE", func(c *Config, v string) error { c.Analytics.File = v; return nil }},
+	{"ANALYTICS_CLIENTS_FILE", func(c *Config, v string) error { c.Analytics.ClientsFile = v; return nil }},
+	{"ANALYTICS_USER_AGENT_RULES", func(c *Config, v string) error { c.Analytics.UserAgentRules = v; return nil }},
+	{"LISTENERS_FILE", func(c *Config, v string) error { c.Listeners.File = v; return nil }},
+	{"LISTENERS_PLAYLISTS_FILE", func(c *Config, v string) error { c.Listeners.PlaylistsFile = v; return nil }},
+	{"LISTENERS_PUBLIC_URL", func(c *Config, v string) error { c.Listeners.PublicURL = v; return nil }},
+	{"LISTENERS_LOGIN_URL", func(c *Config, v string) error { c.Listeners.LoginURL = v; return nil }},
+	{"LISTENERS_LOGIN_TTL", durationSetter(func(c *Config) *time.Duration { return &c.Listeners.LoginTTL })},
+	{"LISTENERS_SESSION_TTL", durationSetter(func(c *Config) *time.Duration { return &c.Listeners.SessionTTL })},
+	{"MAIL_MAILER", func(c *Config, v string) error { c.Mail.Mailer = v; return nil }},
+	{"MAIL_FROM", func(c *Config, v string) error { c.Mail.From = v; return nil }},
+	{"MAIL_DIR", func(c *Config, v string) error { c.Mail.Dir = v; return nil }},
+	{"MAIL_SMTP_HOST", func(c *Config, v string) error { c.Mail.SMTP.Host = v; return nil }},
+	{"MAIL_SMTP_PORT", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		c.Mail.SMTP.Port = n
+		return nil
+	}},
+	{"MAIL_SMTP_USERNAME", func(c *Config, v string) error { c.Mail.SMTP.Username = v; return nil }},
+	{"MAIL_SMTP_PASSWORD", func(c *Config, v string) error { c.Mail.SMTP.Password = v; return nil }},
+	{"COMMENTS_FILE", func(c *Config, v string) error { c.Comments.File = v; return nil }},
+	{"COMMENTS_REACTIONS_FILE", func(c *Config, v string) error { c.Comments.ReactionsFile = v; return nil }},
+	{"COMMENTS_AUTO_APPROVE", boolSetter(func(c *Config) *bool { return &c.Comments.AutoApprove })},
+	{"COMMENTS_MAX_LINKS", intSetter(func(c *Config) *int { return &c.Comments.MaxLinks })},
+	{"COMMENTS_BANNED_WORDS", func(c *Config, v string) error { c.Comments.BannedWords = splitList(v); return nil }},
+	{"COMMENTS_LISTENER_LIMIT", intSetter(func(c *Config) *int { return &c.Comments.ListenerLimit })},
+	{"COMMENTS_IP_LIMIT", intSetter(func(c *Config) *int { return &c.Comments.IPLimit })},
+	{"COMMENTS_LIMIT_WINDOW", durationSetter(func(c *Config) *time.Duration { return &c.Comments.LimitWindow })},
+	{"PUBLISHING_TIMEZONE", func(c *Config, v string) error { c.Publishing.Timezone = v; return nil }},
+	{"PUBLISHING_SCHEDULER_INTERVAL", durationSetter(func(c *Config) *time.Duration { return &c.Publishing.SchedulerInterval })},
+	{"PUBLISHING_PREVIEW_SECRET", func(c *Config, v string) error { c.Publishing.PreviewSecret = v; return nil }},
+	{"PUBLISHING_PREVIEW_TTL", durationSetter(func(c *Config) *time.Duration { return &c.Publishing.PreviewTTL })},
+	{"TLS_CERT_FILE", func(c *Config, v string) error { c.TLS.CertFile = v; return nil }},
+	{"TLS_KEY_FILE", func(c *Config, v string) error { c.TLS.KeyFile = v; return nil }},
+	{"TLS_MIN_VERSION", func(c *Config, v string) error { c.TLS.MinVersion = v; return nil }},
+	{"TLS_RELOAD_INTERVAL", durationSetter(func(c *Config) *time.Duration { return &c.TLS.ReloadInterval })},
+	{"TLS_HTTP3", boolSetter(func(c *Config) *bool { return &c.TLS.HTTP3 })},
+	{"TLS_HTTP3_PORT", func(c *Config, v string) error { c.TLS.HTTP3Port = v; return nil }},
+	{"LOG_LEVEL", func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{"LOG_FORMAT", func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{"CORS_ORIGINS", func(c *Config, v string) error { c.CORS.Origins = splitList(v); return nil }},
+	{"CORS_ALLOW_CREDENTIALS", boolSetter(func(c *Config) *bool { return &c.CORS.AllowCredentials })},
+	{"SECURITY_CSP", func(c *Config, v string) error { c.Security.ContentSecurityPolicy = v; return nil }},
+	{"SECURITY_CSP_REPORT_ONLY", boolSetter(func(c *Config) *bool { return &c.Security.CSPReportOnly })},
+	{"SECURITY_CSP_REPORT_URI", func(c *Config, v string) error { c.Security.CSPReportURI = v; return nil }},
+	{"SECURITY_HSTS_MAX_AGE", durationSetter(func(c *Config) *time.Duration { return &c.Security.HSTS.MaxAge })},
+	{"CACHE_EPISODES_TTL", durationSetter(func(c *Config) *time.Duration { return &c.Cache.EpisodesTTL })},
+	{"CACHE_CONTENT_TTL", durationSetter(func(c *Config) *time.Duration { return &c.Cache.ContentTTL })},
+	{"RATE_LIMIT_REQUESTS", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		c.RateLimit.Requests = n
+		return nil
+	}},
+	{"RATE_LIMIT_WINDOW", durationSetter(func(c *Config) *time.Duration { return &c.RateLimit.Window })},
+	{"CONTENT_DIR", func(c *Config, v string) error { c.Content.Dir = v; return nil }},
+	{"CONTENT_MEDIA_DIR", func(c *Config, v string) error { c.Content.MediaDir = v; return nil }},
+	{"CONTENT_ARTWORK_CACHE_DIR", func(c *Config, v string) error { c.Content.ArtworkCacheDir = v; return nil }},
+	{"CONTENT_WAVEFORM_INTERVAL", durationSetter(func(c *Config) *time.Duration { return &c.Content.WaveformInterval })},
+}
+
+// durationSetter returns an env binding that parses a Go duration string
+func durationSetter(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// boolSetter returns an env binding that parses a boolean
+func boolSetter(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// intSetter returns an env binding that parses an integer
+func intSetter(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// applyEnv overrides configuration with environment variables that are set
+func (c *Config) applyEnv() error {
+	var errs []error
+
+	for _, binding := range envBindings {
+		value := os.Getenv(binding.key)
+		if value == "" {
+			continue
+		}
+		if err := binding.apply(c, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", binding.key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyFlags overrides configuration with explicitly passed flags
+func (c *Config) applyFlags(overrides map[string]string) {
+	for name, value := range overrides {
+		switch name {
+		case "env":
+			c.Environment = value
+		case "port":
+			c.Server.Port = value
+		case "admin-port":
+			c.Admin.Port = value
+		case "tls-cert":
+			c.TLS.CertFile = value
+		case "tls-key":
+			c.TLS.KeyFile = value
+		case "log-level":
+			c.Log.Level = value
+		case "log-format":
+			c.Log.Format = value
+		case "content-dir":
+			c.Content.Dir = value
+		}
+	}
+}
+
+// getEnv gets an environment variable with a fallback default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// splitList splits a comma-separated list, trimming blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,417 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError describes a single invalid configuration field
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Validate checks the configuration and returns every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch c.Environment {
+	case "development", "staging", "production", "test":
+	default:
+		invalid("environment", "must be one of development, staging, production, test (got %q)", c.Environment)
+	}
+
+	if !isValidPort(c.Server.Port) {
+		invalid("server.port", "must be a port number between 1 and 65535 (got %q)", c.Server.Port)
+	}
+	checkPositive := func(field string, d time.Duration) {
+		if d <= 0 {
+			invalid(field, "must be a positive duration (got %s)", d)
+		}
+	}
+	checkPositive("server.readTimeout", c.Server.ReadTimeout)
+	checkPositive("server.writeTimeout", c.Server.WriteTimeout)
+	checkPositive("server.idleTimeout", c.Server.IdleTimeout)
+	checkPositive("server.shutdownTimeout", c.Server.ShutdownTimeout)
+
+	if c.Admin.Port != "" {
+		if !isValidPort(c.Admin.Port) {
+			invalid("admin.port", "must be a port number between 1 and 65535 (got %q)", c.Admin.Port)
+		} else if c.Admin.Port == c.Server.Port {
+			invalid("admin.port", "must differ from server.port")
+		}
+	}
+
+	c.validateTLS(invalid)
+	c.validateAuth(invalid)
+
+	for _, file := range []struct{ field, path string }{
+		{"audit.file", c.Audit.File},
+		{"analytics.file", c.Analytics.File},
+		{"analytics.clientsFile", c.Analytics.ClientsFile},
+		{"listeners.file", c.Listeners.File},
+		{"listeners.playlistsFile", c.Listeners.PlaylistsFile},
+		{"comments.file", c.Comments.File},
+		{"comments.reactionsFile", c.Comments.ReactionsFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Dir(file.path)); err != nil || !info.IsDir() {
+			invalid(file.field, "directory of %q does not exist", file.path)
+		} else if info, err := os.Stat(file.path); err == nil && info.IsDir() {
+			invalid(file.field, "%q is a directory", file.path)
+		}
+	}
+
+	if info, err := os.Stat(c.Analytics.UserAgentRules); c.Analytics.UserAgentRules != "" && (err != nil || info.IsDir()) {
+		invalid("analytics.userAgentRules", "%q is not a file", c.Analytics.UserAgentRules)
+	}
+
+	c.validateListeners(invalid)
+
+	if c.Comments.MaxLinks < 0 {
+		invalid("comments.maxLinks", "must not be negative (got %d)", c.Comments.MaxLinks)
+	}
+	for i, word := range c.Comments.BannedWords {
+		if strings.TrimSpace(word) == "" {
+			invalid(fmt.Sprintf("comments.bannedWords[%d]", i), "must not be empty")
+		}
+	}
+	if c.Comments.ListenerLimit < 1 {
+		invalid("comments.listenerLimit", "must be at least 1 (got %d)", c.Comments.ListenerLimit)
+	}
+	if c.Comments.IPLimit < 1 {
+		invalid("comments.ipLimit", "must be at least 1 (got %d)", c.Comments.IPLimit)
+	}
+	checkPositive("comments.limitWindow", c.Comments.LimitWindow)
+
+	if _, err := time.LoadLocation(c.Publishing.Timezone); err != nil || c.Publishing.Timezone == "" {
+		invalid("publishing.timezone", "must be an IANA time zone such as Europe/Berlin (got %q)", c.Publishing.Timezone)
+	}
+	checkPositive("publishing.schedulerInterval", c.Publishing.SchedulerInterval)
+	checkPositive("publishing.previewTtl", c.Publishing.PreviewTTL)
+	if c.Publishing.PreviewSecret != "" && len(c.Publishing.PreviewSecret) < 32 {
+		invalid("publishing.previewSecret", "must be at least 32 bytes")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level", "must be one of debug, info, warn, error (got %q)", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "", "text", "json":
+	default:
+		invalid("log.format", "must be text or json (got %q)", c.Log.Format)
+	}
+
+	if len(c.CORS.Origins) == 0 {
+		invalid("cors.origins", "must list at least one origin")
+	}
+	validateOrigins := func(field string, origins []string, allowCredentials bool) {
+		for i, origin := range origins {
+			if origin == "*" {
+				if allowCredentials {
+					invalid(fmt.Sprintf("%s[%d]", field, i), "the wildcard origin cannot be combined with allowCredentials")
+				}
+				continue
+			}
+			if !isValidOrigin(origin) {
+				invalid(fmt.Sprintf("%s[%d]", field, i), "must be an origin such as https://example.com or https://*.example.com (got %q)", origin)
+			}
+		}
+	}
+	validateOrigins("cors.origins", c.CORS.Origins, c.CORS.AllowCredentials)
+	if c.CORS.MaxAge < 0 {
+		invalid("cors.maxAge", "must not be negative (got %s)", c.CORS.MaxAge)
+	}
+	for i, route := range c.CORS.Routes {
+		field := fmt.Sprintf("cors.routes[%d]", i)
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			invalid(field+".pathPrefix", "must start with / (got %q)", route.PathPrefix)
+		}
+		origins := route.Origins
+		if origins == nil {
+			origins = c.CORS.Origins
+		}
+		allowCredentials := c.CORS.AllowCredentials
+		if route.AllowCredentials != nil {
+			allowCredentials = *route.AllowCredentials
+		}
+		validateOrigins(field+".origins", origins, allowCredentials)
+	}
+
+	c.validateSecurity(invalid)
+
+	checkPositive("cache.episodesTtl", c.Cache.EpisodesTTL)
+	checkPositive("cache.contentTtl", c.Cache.ContentTTL)
+
+	if c.RateLimit.Requests <= 0 {
+		invalid("rateLimit.requests", "must be greater than zero (got %d)", c.RateLimit.Requests)
+	}
+	checkPositive("rateLimit.window", c.RateLimit.Window)
+
+	if c.Content.Dir == "" {
+		invalid("content.dir", "must not be empty")
+	} else if info, err := os.Stat(c.Content.Dir); err == nil && !info.IsDir() {
+		invalid("content.dir", "%q is not a directory", c.Content.Dir)
+	}
+	if info, err := os.Stat(c.Content.MediaDir); c.Content.MediaDir != "" && err == nil && !info.IsDir() {
+		invalid("content.mediaDir", "%q is not a directory", c.Content.MediaDir)
+	}
+	checkPositive("content.waveformInterval", c.Content.WaveformInterval)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+}
+
+// isValidPort reports whether value is a TCP port number
+func isValidPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port > 0 && port <= 65535
+}
+
+// isValidOrigin reports whether value is a scheme://host[:port] origin,
+// optionally with a single leading subdomain wildcard
+func isValidOrigin(value string) bool {
+	if strings.Count(value, "*") > 1 {
+		return false
+	}
+	if strings.Contains(value, "*") && !strings.Contains(value, "://*.") {
+		return false
+	}
+
+	u, err := url.Parse(strings.Replace(value, "*", "wildcard", 1))
+	return err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// validateTLS checks the TLS, HTTP/3 and admin mutual TLS settings
+func (c *Config) validateTLS(invalid func(field, format string, args ...interface{})) {
+	checkFile := func(field, path string) {
+		if info, err := os.Stat(path); err != nil {
+			invalid(field, "cannot read %q: %v", path, err)
+		} else if info.IsDir() {
+			invalid(field, "%q is a directory", path)
+		}
+	}
+
+	tls := c.TLS
+	switch {
+	case tls.CertFile != "" && tls.KeyFile == "":
+		invalid("tls.keyFile", "must be set when tls.certFile is set")
+	case tls.CertFile == "" && tls.KeyFile != "":
+		invalid("tls.certFile", "must be set when tls.keyFile is set")
+	case tls.Enabled():
+		checkFile("tls.certFile", tls.CertFile)
+		checkFile("tls.keyFile", tls.KeyFile)
+	}
+
+	switch tls.MinVersion {
+	case "1.2", "1.3":
+	default:
+		invalid("tls.minVersion", "must be 1.2 or 1.3 (got %q)", tls.MinVersion)
+	}
+	if tls.ReloadInterval <= 0 {
+		invalid("tls.reloadInterval", "must be a positive duration (got %s)", tls.ReloadInterval)
+	}
+
+	if tls.HTTP3 && !tls.Enabled() {
+		invalid("tls.http3", "requires tls.certFile and tls.keyFile")
+	}
+	if tls.HTTP3Port != "" && !isValidPort(tls.HTTP3Port) {
+		invalid("tls.http3Port", "must be a port number between 1 and 65535 (got %q)", tls.HTTP3Port)
+	}
+
+	if c.Admin.ClientCAFile != "" {
+		if !tls.Enabled() {
+			invalid("admin.clientCaFile", "requires tls.certFile and tls.keyFile")
+		}
+		checkFile("admin.clientCaFile", c.Admin.ClientCAFile)
+	}
+}
+
+// validateAuth checks API key and JWT settings
+func (c *Config) validateAuth(invalid func(field, format string, args ...interface{})) {
+	ids := make(map[string]bool, len(c.Auth.APIKeys))
+	for i, key := range c.Auth.APIKeys {
+		field := fmt.Sprintf("auth.apiKeys[%d]", i)
+		if key.ID == "" {
+			invalid(field+".id", "must not be empty")
+		} else if ids[key.ID] {
+			invalid(field+".id", "duplicate id %q", key.ID)
+		}
+		ids[key.ID] = true
+
+		if !isValidKeyHash(key.Hash) {
+			invalid(field+".hash", "must be sha256: followed by 64 hex characters")
+		}
+		switch strings.ToLower(key.Role) {
+		case "editor", "admin":
+		default:
+			invalid(field+".role", "must be editor or admin (got %q)", key.Role)
+		}
+	}
+
+	if c.Auth.APIKeysFile != "" {
+		if info, err := os.Stat(c.Auth.APIKeysFile); err != nil {
+			invalid("auth.apiKeysFile", "cannot read %q: %v", c.Auth.APIKeysFile, err)
+		} else if info.IsDir() {
+			invalid("auth.apiKeysFile", "%q is a directory", c.Auth.APIKeysFile)
+		}
+	}
+
+	jwt := c.Auth.JWT
+	if jwt.HMACSecret != "" && len(jwt.HMACSecret) < 32 {
+		invalid("auth.jwt.hmacSecret", "must be at least 32 bytes")
+	}
+	if jwt.JWKSFile != "" && jwt.JWKSURL != "" {
+		invalid("auth.jwt.jwksUrl", "cannot be combined with auth.jwt.jwksFile")
+	}
+	if jwt.JWKSURL != "" {
+		if u, err := url.Parse(jwt.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			invalid("auth.jwt.jwksUrl", "must be an http or https URL (got %q)", jwt.JWKSURL)
+		}
+	}
+	if jwt.JWKSRefresh <= 0 {
+		invalid("auth.jwt.jwksRefresh", "must be a positive duration (got %s)", jwt.JWKSRefresh)
+	}
+	if jwt.Leeway < 0 {
+		invalid("auth.jwt.leeway", "must not be negative (got %s)", jwt.Leeway)
+	}
+	if jwt.RoleClaim == "" {
+		invalid("auth.jwt.roleClaim", "must not be empty")
+	}
+}
+
+// validateListeners checks listener sign-in and outgoing mail settings
+func (c *Config) validateListeners(invalid func(field, format string, args ...interface{})) {
+	if u, err := url.Parse(c.Listeners.PublicURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		invalid("listeners.publicUrl", "must be an http or https URL without a path, such as https://podsite.com (got %q)", c.Listeners.PublicURL)
+	}
+	if c.Listeners.LoginURL != "" {
+		if u, err := url.Parse(c.Listeners.LoginURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Fragment != "" {
+			invalid("listeners.loginUrl", "must be an http or https URL without a fragment (got %q)", c.Listeners.LoginURL)
+		}
+	}
+	if c.Listeners.LoginTTL <= 0 {
+		invalid("listeners.loginTtl", "must be a positive duration (got %s)", c.Listeners.LoginTTL)
+	}
+	if c.Listeners.SessionTTL <= 0 {
+		invalid("listeners.sessionTtl", "must be a positive duration (got %s)", c.Listeners.SessionTTL)
+	}
+
+	mail := c.Mail
+	if address, err := netmail.ParseAddress(mail.From); err != nil || address.Address == "" {
+		invalid("mail.from", "must be an email address such as Podsite <noreply@example.com> (got %q)", mail.From)
+	}
+	switch mail.Mailer {
+	case "file":
+		if mail.Dir == "" {
+			invalid("mail.dir", "must be set for the file mailer")
+		} else if info, err := os.Stat(mail.Dir); err == nil && !info.IsDir() {
+			invalid("mail.dir", "%q is not a directory", mail.Dir)
+		}
+	case "smtp":
+		if mail.SMTP.Host == "" {
+			invalid("mail.smtp.host", "must be set for the smtp mailer")
+		}
+		if mail.SMTP.Port <= 0 || mail.SMTP.Port > 65535 {
+			invalid("mail.smtp.port", "must be a port number between 1 and 65535 (got %d)", mail.SMTP.Port)
+		}
+		if mail.SMTP.Password != "" && mail.SMTP.Username == "" {
+			invalid("mail.smtp.username", "must be set when mail.smtp.password is set")
+		}
+	default:
+		invalid("mail.mailer", "must be file or smtp (got %q)", mail.Mailer)
+	}
+}
+
+// isValidKeyHash reports whether value is a sha256:-prefixed hex digest
+func isValidKeyHash(value string) bool {
+	digest, ok := strings.CutPrefix(strings.ToLower(value), "sha256:")
+	if !ok || len(digest) != 64 {
+		return false
+	}
+	for _, r := range digest {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSecurity checks the security header policies
+func (c *Config) validateSecurity(invalid func(field, format string, args ...interface{})) {
+	checkOneOf := func(field, value string, allowed ...string) {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				return
+			}
+		}
+		invalid(field, "must be one of %s (got %q)", strings.Join(allowed[1:], ", "), value)
+	}
+	checkHeaderValue := func(field, value string) {
+		if strings.ContainsAny(value, "\r\n") {
+			invalid(field, "must not contain line breaks")
+		}
+	}
+
+	sec := c.Security
+	checkHeaderValue("security.contentSecurityPolicy", sec.ContentSecurityPolicy)
+	checkHeaderValue("security.permissionsPolicy", sec.PermissionsPolicy)
+	checkOneOf("security.frameOptions", sec.FrameOptions, "", "DENY", "SAMEORIGIN")
+	checkOneOf("security.crossOriginOpenerPolicy", sec.CrossOriginOpenerPolicy, "", "same-origin", "same-origin-allow-popups", "unsafe-none")
+	checkOneOf("security.crossOriginResourcePolicy", sec.CrossOriginResourcePolicy, "", "same-site", "same-origin", "cross-origin")
+
+	if sec.CSPReportURI != "" && !strings.HasPrefix(sec.CSPReportURI, "/") {
+		if u, err := url.Parse(sec.CSPReportURI); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid("security.cspReportUri", "must be an absolute path or URL (got %q)", sec.CSPReportURI)
+		}
+	}
+
+	if sec.HSTS.MaxAge < 0 {
+		invalid("security.hsts.maxAge", "must not be negative (got %s)", sec.HSTS.MaxAge)
+	}
+	if sec.HSTS.Preload && (!sec.HSTS.IncludeSubdomains || sec.HSTS.MaxAge < 365*24*time.Hour) {
+		invalid("security.hsts.preload", "requires includeSubdomains and a maxAge of at least one year")
+	}
+
+	for i, route := range sec.Routes {
+		field := fmt.Sprintf("security.routes[%d]", i)
+		if !strings.HasPrefix(route.PathPrefix, "/") {
+			invalid(field+".pathPrefix", "must start with / (got %q)", route.PathPrefix)
+		}
+		if route.ContentSecurityPolicy != nil {
+			checkHeaderValue(field+".contentSecurityPolicy", *route.ContentSecurityPolicy)
+		}
+		if route.PermissionsPolicy != nil {
+			checkHeaderValue(field+".permissionsPolicy", *route.PermissionsPolicy)
+		}
+		if route.FrameOptions != nil {
+			checkOneOf(field+".frameOptions", *route.FrameOptions, "", "DENY", "SAMEORIGIN")
+		}
+		if route.CrossOriginOpenerPolicy != nil {
+			checkOneOf(field+".crossOriginOpenerPolicy", *route.CrossOriginOpenerPolicy, "", "same-origin", "same-origin-allow-popups", "unsafe-none")
+		}
+		if route.CrossOriginResourcePolicy != nil {
+			checkOneOf(field+".crossOriginResourcePolicy", *route.CrossOriginResourcePolicy, "", "same-site", "same-origin", "cross-origin")
+		}
+	}
+}
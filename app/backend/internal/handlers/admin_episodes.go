@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// CreateEpisode handles POST /api/admin/episodes
+// @Summary Create an episode
+// @Description Adds a new episode. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param episode body models.Episode true "Episode"
+// @Success 201 {object} models.Episode
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes [post]
+func CreateEpisode(c *gin.Context) {
+	var episode models.Episode
+	if err := c.ShouldBindJSON(&episode); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid episode JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := peopleService.Load().CheckCredits(episode.People); err != nil {
+		respondEpisodeMutationError(c, err)
+		return
+	}
+	episode.Tags = tagService.Load().Normalize(episode.Tags)
+
+	created, err := episodeService.Load().Create(episode)
+	if err != nil {
+		respondEpisodeMutationError(c, err)
+		return
+	}
+
+	setAuditDetails(c, "episode.create", "episode", created.ID, nil, created)
+	middleware.PurgeCache()
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateEpisode handles PUT /api/admin/episodes/:id
+// @Summary Replace an episode
+// @Description Replaces every field of an existing episode except its ID. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Episode ID"
+// @Param episode body models.Episode true "Episode"
+// @Success 200 {object} models.Episode
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id} [put]
+func UpdateEpisode(c *gin.Context) {
+	var episode models.Episode
+	if err := c.ShouldBindJSON(&episode); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid episode JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if episode.ID != "" && episode.ID != c.Param("id") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Episode ID in the body does not match the URL",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := peopleService.Load().CheckCredits(episode.People); err != nil {
+		respondEpisodeMutationError(c, err)
+		return
+	}
+	episode.Tags = tagService.Load().Normalize(episode.Tags)
+
+	updated, previous, err := episodeService.Load().Update(c.Param("id"), episode)
+	if err != nil {
+		respondEpisodeMutationError(c, err)
+		return
+	}
+
+	setAuditDetails(c, "episode.update", "episode", updated.ID, previous, updated)
+	middleware.PurgeCache()
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteEpisode handles DELETE /api/admin/episodes/:id
+// @Summary Delete an episode
+// @Description Removes an episode. Requires the admin role and the episodes:write scope.
+// @Tags admin
+// @Param id path string true "Episode ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id} [delete]
+func DeleteEpisode(c *gin.Context) {
+	deleted, err := episodeService.Load().Delete(c.Param("id"))
+	if err != nil {
+		respondEpisodeMutationError(c, err)
+		return
+	}
+
+	// A later episode reusing the ID must not inherit the transcript,
+	// chapters, waveform, comments or reactions
+	if err := transcriptService.Load().Delete(deleted.ID); err != nil && !errors.Is(err, models.ErrTranscriptNotFound) {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "transcript_delete", "episode_id": deleted.ID})
+	}
+	if err := chapterService.Load().Delete(deleted.ID); err != nil && !errors.Is(err, models.ErrChaptersNotFound) {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "chapters_delete", "episode_id": deleted.ID})
+	}
+	if err := waveformService.Load().Delete(deleted.ID); err != nil && !errors.Is(err, models.ErrWaveformNotFound) {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "waveform_delete", "episode_id": deleted.ID})
+	}
+	if err := commentService.Load().DeleteEpisode(deleted.ID); err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "comments_delete", "episode_id": deleted.ID})
+	}
+	if err := reactionService.Load().DeleteEpisode(deleted.ID); err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "reactions_delete", "episode_id": deleted.ID})
+	}
+
+	setAuditDetails(c, "episode.delete", "episode", deleted.ID, deleted, nil)
+	middleware.PurgeCache()
+	c.Status(http.StatusNoContent)
+}
+
+// respondEpisodeMutationError maps episode service errors to responses
+func respondEpisodeMutationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrEpisodeNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, models.ErrEpisodeConflict):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: err.Error(),
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, models.ErrEpisodeInvalid):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not save episodes",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAdminTestRouter serves the admin episode handlers over a temporary
+// copy of the episode data
+func setupAdminTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "First", AudioURL: "/assets/audio/ep001.mp3"},
+	})
+
+	router := gin.New()
+	admin := router.Group("/api/admin/episodes")
+	admin.POST("", CreateEpisode)
+	admin.PUT("/:id", UpdateEpisode)
+	admin.DELETE("/:id", DeleteEpisode)
+	return router
+}
+
+func TestAdminEpisodeEndpoints(t *testing.T) {
+	router := setupAdminTestRouter(t)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"create", "POST", "/api/admin/episodes", `{"id":"ep002","number":2,"title":"Second","audioUrl":"/a.mp3"}`, http.StatusCreated},
+		{"create duplicate", "POST", "/api/admin/episodes", `{"id":"ep002","number":3,"title":"Again","audioUrl":"/a.mp3"}`, http.StatusConflict},
+		{"create invalid", "POST", "/api/admin/episodes", `{"id":"ep004","number":4}`, http.StatusBadRequest},
+		{"create malformed", "POST", "/api/admin/episodes", `{`, http.StatusBadRequest},
+		{"update", "PUT", "/api/admin/episodes/ep002", `{"number":2,"title":"Second, revised","audioUrl":"/a.mp3"}`, http.StatusOK},
+		{"update id mismatch", "PUT", "/api/admin/episodes/ep002", `{"id":"ep009","number":2,"title":"X","audioUrl":"/a.mp3"}`, http.StatusBadRequest},
+		{"update missing", "PUT", "/api/admin/episodes/ep404", `{"number":9,"title":"X","audioUrl":"/a.mp3"}`, http.StatusNotFound},
+		{"delete", "DELETE", "/api/admin/episodes/ep001", "", http.StatusNoContent},
+		{"delete missing", "DELETE", "/api/admin/episodes/ep001", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if w.Code >= 400 {
+				var response ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedStatus, response.Code)
+			}
+		})
+	}
+
+	episode, err := episodeService.Load().GetByID("ep002")
+	require.NoError(t, err)
+	assert.Equal(t, "Second, revised", episode.Title)
+}
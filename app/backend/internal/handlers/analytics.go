@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+var (
+	analyticsService atomic.Pointer[models.AnalyticsService]
+	// mediaProber opens local audio files under the media root
+	mediaProber atomic.Pointer[models.MediaProber]
+)
+
+func init() {
+	analyticsService.Store(models.NewAnalyticsService())
+	mediaProber.Store(models.NewMediaProber("public"))
+}
+
+// SetAnalyticsService replaces the service downloads are counted in
+func SetAnalyticsService(service *models.AnalyticsService) {
+	analyticsService.Store(service)
+}
+
+// SetMediaProber replaces the prober audio files are served through
+func SetMediaProber(prober *models.MediaProber) {
+	mediaProber.Store(prober)
+}
+
+// GetEpisodeAudio handles GET /media/audio/:id
+// @Summary Download episode audio
+// @Description Serves the episode's audio file, with range requests, and counts the download. Remote audio redirects to audioUrl.
+// @Tags media
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param preview query string false "Preview token"
+// @Produce audio/mpeg,audio/mp4,audio/ogg
+// @Success 200
+// @Success 206
+// @Success 302
+// @Failure 404 {object} ErrorResponse
+// @Router /media/audio/{id} [get]
+func GetEpisodeAudio(c *gin.Context) {
+	episode, _, err := episodeService.Load().Resolve(c.Param("id"))
+	preview := false
+	if err == nil && !episode.IsReachable(publishingNow()) {
+		if preview = hasValidPreview(c, episode.ID); !preview {
+			err = models.ErrEpisodeNotFound
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	file, stat, err := mediaProber.Load().Open(episode.AudioURL)
+	if errors.Is(err, models.ErrMediaNotLocal) {
+		// The bytes a redirect leads to are unknown, so the request stands
+		// in for them
+		if !preview && c.Request.Method == http.MethodGet {
+			size := int64(0)
+			if episode.Audio != nil {
+				size = episode.Audio.Length
+			}
+			countDownload(c, episode, requestedBytes(c.GetHeader("Range"), size))
+		}
+		c.Redirect(http.StatusFound, episode.AudioURL)
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.GetLogger().LogError(err, map[string]interface{}{
+				"event":      "audio_open",
+				"episode_id": episode.ID,
+			})
+		}
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Audio not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	defer file.Close()
+
+	if preview {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		// Shared caches would hide downloads from the count
+		c.Header("Cache-Control", "private, max-age=3600")
+	}
+	c.Header("Content-Type", episode.EnclosureType())
+	// Downloads of long episodes outlast the server's write timeout, which
+	// would cut them off and still count them. Writers without deadlines,
+	// such as test recorders, return http.ErrNotSupported.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	http.ServeContent(c.Writer, c.Request, "", stat.ModTime(), file)
+
+	if !preview && c.Request.Method == http.MethodGet {
+		countDownload(c, episode, int64(max(c.Writer.Size(), 0)))
+	}
+}
+
+// countDownload records bytes of audio served for episode
+func countDownload(c *gin.Context, episode *models.Episode, bytes int64) {
+	_, err := analyticsService.Load().Record(models.DownloadRequest{
+		EpisodeID:    episode.ID,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Client:       requestClient(c),
+		Bytes:        bytes,
+		MinimumBytes: episode.MinimumDownloadBytes(),
+	})
+	if err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":      "download_record",
+			"episode_id": episode.ID,
+		})
+	}
+}
+
+// requestedBytes returns the number of bytes a Range header asks for from
+// a file of size bytes, which may be unknown (0). Without a Range header,
+// or with ranges that cannot be measured, the whole file is requested.
+func requestedBytes(header string, size int64) int64 {
+	whole := size
+	if whole <= 0 {
+		whole = math.MaxInt64
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return whole
+	}
+
+	total := int64(0)
+	for _, part := range strings.Split(spec, ",") {
+		first, last, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return whole
+		}
+		switch {
+		case first == "":
+			// A suffix range of the last bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil {
+				return whole
+			}
+			total += n
+		case last == "":
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || size <= 0 {
+				return whole
+			}
+			total += max(size-start, 0)
+		default:
+			start, err1 := strconv.ParseInt(first, 10, 64)
+			end, err2 := strconv.ParseInt(last, 10, 64)
+			if err1 != nil || err2 != nil || end < start {
+				return whole
+			}
+			total += end - start + 1
+		}
+	}
+	return total
+}
+
+// GetEpisodeAnalytics handles GET /api/admin/analytics/episodes/:id
+// @Summary Get episode downloads
+// @Description Returns the episode's downloads by app and by day in the publishing timezone. Downloads follow IAB-style rules: bots (per the user agent rules) are excluded, one listener (IP address and user agent) counts once per 24 hours, and at least a minute of audio must be served.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param from query string false "Earliest timestamp (RFC 3339, inclusive)"
+// @Param to query string false "Latest timestamp (RFC 3339, exclusive)"
+// @Success 200 {object} models.DownloadStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/analytics/episodes/{id} [get]
+func GetEpisodeAnalytics(c *gin.Context) {
+	badRequest := func(message string) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	var from, to time.Time
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			badRequest("from must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			badRequest("to must be an RFC 3339 timestamp")
+			return
+		}
+	}
+
+	// Deleted episodes keep their downloads
+	id := c.Param("id")
+	if episode, _, err := episodeService.Load().Resolve(id); err == nil {
+		id = episode.ID
+	}
+
+	stats := analyticsService.Load().EpisodeDownloads(id, from, to, publishingNow().Location())
+	if stats.Total == 0 {
+		if _, _, err := episodeService.Load().Resolve(id); err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Episode not found",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, stats)
+}
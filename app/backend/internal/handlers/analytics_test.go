@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPodcastAgent = "Overcast/3.0 (+http://overcast.fm/; iOS podcast app)"
+
+func setupAnalyticsTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	media := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(media, "ep.mp3"), make([]byte, 200_000), 0o644))
+
+	useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/ep.mp3", PublishDate: "2024-01-01", Audio: &models.MediaInfo{Length: 200_000, Bitrate: 8}},
+		{ID: "ep002", Number: 2, Title: "Draft", AudioURL: "/ep.mp3", Status: models.StatusDraft},
+		{ID: "ep003", Number: 3, Title: "Remote", AudioURL: "https://cdn.example.com/ep.mp3", PublishDate: "2024-01-03"},
+		{ID: "ep004", Number: 4, Title: "Missing", AudioURL: "/missing.mp3", PublishDate: "2024-01-04"},
+	})
+
+	previousProber := mediaProber.Load()
+	SetMediaProber(models.NewMediaProber(media))
+	t.Cleanup(func() { SetMediaProber(previousProber) })
+
+	previousAnalytics := analyticsService.Load()
+	SetAnalyticsService(models.NewAnalyticsService())
+	t.Cleanup(func() { SetAnalyticsService(previousAnalytics) })
+
+	router := gin.New()
+	router.GET("/media/audio/:id", GetEpisodeAudio)
+	router.HEAD("/media/audio/:id", GetEpisodeAudio)
+	router.GET("/api/admin/analytics/episodes/:id", GetEpisodeAnalytics)
+	return router
+}
+
+func requestTestAudio(router *gin.Engine, method, url, ip, rangeHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	req.RemoteAddr = ip + ":1234"
+	req.Header.Set("User-Agent", testPodcastAgent)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func getTestAnalytics(t *testing.T, router *gin.Engine, id string) models.DownloadStats {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/analytics/episodes/"+id, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stats models.DownloadStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	return stats
+}
+
+func TestGetEpisodeAudio(t *testing.T) {
+	router := setupAnalyticsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		ip             string
+		rangeHeader    string
+		expectedStatus int
+	}{
+		{"full download", "GET", "/media/audio/ep001", "192.0.2.1", "", http.StatusOK},
+		{"repeat", "GET", "/media/audio/1", "192.0.2.1", "", http.StatusOK},
+		{"probe", "GET", "/media/audio/ep001", "192.0.2.2", "bytes=0-1", http.StatusPartialContent},
+		{"head", "HEAD", "/media/audio/ep001", "192.0.2.3", "", http.StatusOK},
+		{"chunk", "GET", "/media/audio/ep001", "192.0.2.4", "bytes=0-39999", http.StatusPartialContent},
+		{"next chunk", "GET", "/media/audio/ep001", "192.0.2.4", "bytes=40000-", http.StatusPartialContent},
+		{"remote", "GET", "/media/audio/ep003", "192.0.2.1", "", http.StatusFound},
+		{"draft", "GET", "/media/audio/ep002", "192.0.2.1", "", http.StatusNotFound},
+		{"missing file", "GET", "/media/audio/ep004", "192.0.2.1", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := requestTestAudio(router, tt.method, tt.url, tt.ip, tt.rangeHeader)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	stats := getTestAnalytics(t, router, "ep001")
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, map[string]int{"Overcast": 2}, stats.Apps)
+	require.Len(t, stats.Days, 1)
+	assert.Equal(t, 2, stats.Days[0].Total)
+
+	assert.Equal(t, 1, getTestAnalytics(t, router, "3").Total)
+}
+
+func TestGetEpisodeAudioServesFile(t *testing.T) {
+	router := setupAnalyticsTestRouter(t)
+
+	w := requestTestAudio(router, "GET", "/media/audio/ep001", "192.0.2.1", "bytes=100-199")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "audio/mpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, "bytes 100-199/200000", w.Header().Get("Content-Range"))
+	assert.Len(t, w.Body.Bytes(), 100)
+
+	w = requestTestAudio(router, "GET", "/media/audio/ep003", "192.0.2.1", "")
+	assert.Equal(t, "https://cdn.example.com/ep.mp3", w.Header().Get("Location"))
+}
+
+func TestGetEpisodeAudioOutlastsWriteTimeout(t *testing.T) {
+	router := setupAnalyticsTestRouter(t)
+	media := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(media, "ep.mp3"), make([]byte, 32<<20), 0o644))
+	SetMediaProber(models.NewMediaProber(media))
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/media/audio/ep001")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// A slow listener is still downloading when the write timeout passes
+	time.Sleep(300 * time.Millisecond)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, body, 32<<20)
+}
+
+func TestGetEpisodeAnalyticsErrors(t *testing.T) {
+	router := setupAnalyticsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{"bad from", "/api/admin/analytics/episodes/ep001?from=yesterday", http.StatusBadRequest},
+		{"bad to", "/api/admin/analytics/episodes/ep001?to=2024-01-01", http.StatusBadRequest},
+		{"unknown episode", "/api/admin/analytics/episodes/ep999", http.StatusNotFound},
+		{"draft", "/api/admin/analytics/episodes/ep002", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequestedBytes(t *testing.T) {
+	tests := []struct {
+		header   string
+		size     int64
+		expected int64
+	}{
+		{"", 1000, 1000},
+		{"", 0, math.MaxInt64},
+		{"bytes=0-1", 1000, 2},
+		{"bytes=100-", 1000, 900},
+		{"bytes=100-", 0, math.MaxInt64},
+		{"bytes=-300", 0, 300},
+		{"bytes=0-9, 20-29", 1000, 20},
+		{"bytes=9-0", 1000, 1000},
+		{"items=0-1", 1000, 1000},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, requestedBytes(tt.header, tt.size), tt.header)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+// artworkService renders and caches resized episode artwork
+var artworkService atomic.Pointer[models.ArtworkService]
+
+func init() {
+	artworkService.Store(models.NewArtworkService(models.NewMediaProber("public"), os.TempDir()))
+}
+
+// SetArtworkService replaces the artwork service used by the handlers
+func SetArtworkService(service *models.ArtworkService) {
+	artworkService.Store(service)
+}
+
+// GetArtwork handles GET /media/artwork/:id
+// @Summary Get resized episode artwork
+// @Description Returns the episode's JPEG or PNG artwork resized to w and/or h (64, 128, 256, 300, 512, 600, 1024, 1400, 2048 or 3000 pixels; both crop to fill) and converted to fmt. Artwork is never enlarged. Vector and remote artwork redirects to the original.
+// @Tags media
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param w query int false "Width in pixels"
+// @Param h query int false "Height in pixels"
+// @Param fmt query string false "jpeg, jpg or png; defaults to the source format"
+// @Param preview query string false "Preview token"
+// @Produce jpeg,png
+// @Success 200
+// @Success 302
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /media/artwork/{id} [get]
+func GetArtwork(c *gin.Context) {
+	badRequest := func(message string) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	var opts models.ArtworkOptions
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{{"w", &opts.Width}, {"h", &opts.Height}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil || !slices.Contains(models.ArtworkSizes, size) {
+			badRequest(param.name + " must be one of " + models.ArtworkSizeList() + " pixels")
+			return
+		}
+		*param.target = size
+	}
+	if value := c.Query("fmt"); value != "" {
+		format, ok := models.ParseArtworkFormat(value)
+		if !ok {
+			badRequest("fmt must be jpeg, jpg or png")
+			return
+		}
+		opts.Format = format
+	}
+
+	episode, _, err := episodeService.Load().Resolve(c.Param("id"))
+	if err == nil && !episode.IsReachable(publishingNow()) && !hasValidPreview(c, episode.ID) {
+		err = models.ErrEpisodeNotFound
+	}
+	if err != nil || episode.ArtworkURL == "" {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Artwork not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	// Vector artwork scales by itself
+	if !models.IsRasterArtwork(episode.ArtworkURL) {
+		c.Redirect(http.StatusFound, episode.ArtworkURL)
+		return
+	}
+	path, format, err := artworkService.Load().Render(episode.ArtworkURL, opts)
+	// Remote and undecodable artwork is left to the client as it is
+	if errors.Is(err, models.ErrMediaNotLocal) || errors.Is(err, models.ErrArtworkUnsupported) {
+		c.Redirect(http.StatusFound, episode.ArtworkURL)
+		return
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Artwork not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":      "artwork_render",
+			"episode_id": episode.ID,
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not render artwork",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not read artwork",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	if c.Query("preview") != "" {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=86400")
+	}
+	// Derivatives are named after a hash of their source and options; their
+	// modification time only records when they were last used
+	c.Header("ETag", strconv.Quote(stat.Name()))
+	c.Header("Content-Type", format.ContentType())
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, file)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupArtworkTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Raster", AudioURL: "/a.mp3", ArtworkURL: "/images/ep001.png", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Vector", AudioURL: "/a.mp3", ArtworkURL: "/images/ep002.svg", PublishDate: "2024-01-01"},
+		{ID: "ep003", Number: 3, Title: "Missing", AudioURL: "/a.mp3", ArtworkURL: "/images/ep003.jpg", PublishDate: "2024-01-01"},
+		{ID: "ep004", Number: 4, Title: "Draft", AudioURL: "/a.mp3", ArtworkURL: "/images/ep001.png", Status: models.StatusDraft},
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+3] = 180, 255
+	}
+	img.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "ep001.png"), buf.Bytes(), 0o644))
+
+	previousArtwork := artworkService.Load()
+	SetArtworkService(models.NewArtworkService(models.NewMediaProber(dir), t.TempDir()))
+	t.Cleanup(func() { SetArtworkService(previousArtwork) })
+
+	router := gin.New()
+	router.GET("/media/artwork/:id", GetArtwork)
+	return router
+}
+
+func TestGetArtwork(t *testing.T) {
+	router := setupArtworkTestRouter(t)
+
+	tests := []struct {
+		name                string
+		path                string
+		expectedStatus      int
+		expectedContentType string
+		expectedWidth       int
+		expectedHeight      int
+	}{
+		{"original size", "/media/artwork/ep001", http.StatusOK, "image/png", 200, 100},
+		{"width", "/media/artwork/ep001?w=128", http.StatusOK, "image/png", 128, 64},
+		{"crop and convert", "/media/artwork/1?w=64&h=64&fmt=jpg", http.StatusOK, "image/jpeg", 64, 64},
+		{"vector redirects", "/media/artwork/ep002?w=64", http.StatusFound, "", 0, 0},
+		{"too small", "/media/artwork/ep001?w=8", http.StatusBadRequest, "", 0, 0},
+		{"not a listed size", "/media/artwork/ep001?w=65", http.StatusBadRequest, "", 0, 0},
+		{"not a number", "/media/artwork/ep001?h=big", http.StatusBadRequest, "", 0, 0},
+		{"unknown format", "/media/artwork/ep001?fmt=gif", http.StatusBadRequest, "", 0, 0},
+		{"missing file", "/media/artwork/ep003", http.StatusNotFound, "", 0, 0},
+		{"draft", "/media/artwork/ep004", http.StatusNotFound, "", 0, 0},
+		{"unknown episode", "/media/artwork/ep999", http.StatusNotFound, "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			switch tt.expectedStatus {
+			case http.StatusOK:
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+				config, _, err := image.DecodeConfig(w.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedWidth, config.Width)
+				assert.Equal(t, tt.expectedHeight, config.Height)
+			case http.StatusFound:
+				assert.Equal(t, "/images/ep002.svg", w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestGetArtworkNotModified(t *testing.T) {
+	router := setupArtworkTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/media/artwork/ep001?w=64", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest("GET", "/media/artwork/ep001?w=64", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/auth"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// Limits for GET /api/admin/audit
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditDetailsKey is the Gin context key for the audit details of a request
+const auditDetailsKey = "auditDetails"
+
+// auditService records administrative changes
+var auditService atomic.Pointer[models.AuditService]
+
+func init() {
+	auditService.Store(models.NewAuditService())
+}
+
+// SetAuditService replaces the audit store used by the handlers
+func SetAuditService(service *models.AuditService) {
+	auditService.Store(service)
+}
+
+// auditDetails describes what a mutating handler changed
+type auditDetails struct {
+	action     string
+	targetType string
+	targetID   string
+	before     interface{}
+	after      interface{}
+}
+
+// setAuditDetails records what a handler changed, for AuditAdminChanges to log.
+// before is nil for creations and after is nil for deletions.
+func setAuditDetails(c *gin.Context, action, targetType, targetID string, before, after interface{}) {
+	c.Set(auditDetailsKey, &auditDetails{
+		action:     action,
+		targetType: targetType,
+		targetID:   targetID,
+		before:     before,
+		after:      after,
+	})
+}
+
+// AuditAdminChanges returns a Gin middleware that appends an audit entry for
+// every mutating request, including ones rejected by role or scope guards.
+// It must run after auth.Authenticate so the actor is known.
+func AuditAdminChanges() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		entry := models.AuditEntry{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			RequestID: middleware.GetRequestID(c),
+			// Requests that never reached a handler are identified by their route
+			Action:   c.Request.Method + " " + c.FullPath(),
+			TargetID: c.Param("id"),
+		}
+		if principal, ok := auth.FromContext(c); ok {
+			entry.Actor = principal.Subject
+			entry.ActorRole = string(principal.Role)
+			entry.AuthMethod = principal.Method
+		}
+		if value, ok := c.Get(auditDetailsKey); ok {
+			details := value.(*auditDetails)
+			entry.Action = details.action
+			entry.TargetType = details.targetType
+			entry.TargetID = details.targetID
+			entry.Changes = models.DiffFields(details.before, details.after)
+		}
+
+		if _, err := auditService.Load().Record(entry); err != nil {
+			// The change has already been applied, so the failure can only be reported
+			logger.GetLogger().LogError(err, map[string]interface{}{
+				"event":      "audit_record",
+				"actor":      entry.Actor,
+				"action":     entry.Action,
+				"target_id":  entry.TargetID,
+				"request_id": entry.RequestID,
+			})
+		}
+	}
+}
+
+// AuditLogResponse is the response of GET /api/admin/audit
+type AuditLogResponse struct {
+	Entries []models.AuditEntry `json:"entries"`
+	Count   int                 `json:"count"`
+}
+
+// GetAuditLog handles GET /api/admin/audit
+// @Summary List administrative changes
+// @Description Returns audit entries, newest first. Requires the admin role and the admin:read scope.
+// @Tags admin
+// @Produce json
+// @Param actor query string false "Actor (API key ID or JWT subject)"
+// @Param action query string false "Action, e.g. episode.update"
+// @Param targetType query string false "Target type, e.g. episode"
+// @Param target query string false "Target ID"
+// @Param from query string false "Earliest timestamp (RFC 3339, inclusive)"
+// @Param to query string false "Latest timestamp (RFC 3339, exclusive)"
+// @Param limit query int false "Maximum entries (default 100, max 1000)"
+// @Success 200 {object} AuditLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func GetAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("target"),
+		Limit:      defaultAuditLimit,
+	}
+
+	badRequest := func(message string) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	var err error
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			badRequest("from must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			badRequest("to must be an RFC 3339 timestamp")
+			return
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			badRequest("limit must be between 1 and " + strconv.Itoa(maxAuditLimit))
+			return
+		}
+	}
+
+	entries := auditService.Load().Query(filter)
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	c.JSON(http.StatusOK, AuditLogResponse{Entries: entries, Count: len(entries)})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/auth"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAuditTestRouter serves admin routes as the given principal, with a
+// fresh in-memory audit store
+func setupAuditTestRouter(t *testing.T, principal *auth.Principal) *gin.Engine {
+	setupAdminTestRouter(t)
+
+	previous := auditService.Load()
+	SetAuditService(models.NewAuditService())
+	t.Cleanup(func() { SetAuditService(previous) })
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	admin := router.Group("/api/admin", func(c *gin.Context) {
+		c.Set(auth.PrincipalKey, principal)
+	}, AuditAdminChanges())
+	admin.GET("/audit", GetAuditLog)
+	admin.PUT("/episodes/:id", auth.Require(auth.RoleEditor, auth.ScopeEpisodesWrite), UpdateEpisode)
+	admin.DELETE("/episodes/:id", auth.Require(auth.RoleAdmin), DeleteEpisode)
+	return router
+}
+
+func TestAuditRecordsChanges(t *testing.T) {
+	router := setupAuditTestRouter(t, &auth.Principal{Subject: "alice", Role: auth.RoleEditor, Method: "api_key"})
+
+	body := `{"number":1,"title":"First, revised","audioUrl":"/assets/audio/ep001.mp3"}`
+	req, _ := http.NewRequest("PUT", "/api/admin/episodes/ep001", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Denied requests are audited too
+	req, _ = http.NewRequest("DELETE", "/api/admin/episodes/ep001", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/admin/audit?actor=alice&target=ep001", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response AuditLogResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, 2, response.Count)
+
+	denied, updated := response.Entries[0], response.Entries[1]
+	assert.Equal(t, "DELETE /api/admin/episodes/:id", denied.Action)
+	assert.Equal(t, http.StatusForbidden, denied.Status)
+
+	assert.Equal(t, "episode.update", updated.Action)
+	assert.Equal(t, "episode", updated.TargetType)
+	assert.Equal(t, "alice", updated.Actor)
+	assert.Equal(t, "editor", updated.ActorRole)
+	assert.Equal(t, "req-123", updated.RequestID)
+	// Renaming also moves the slug; changes are sorted by field
+	require.Len(t, updated.Changes, 3)
+	assert.Equal(t, "slug", updated.Changes[0].Field)
+	assert.Equal(t, "first-revised", updated.Changes[0].After)
+	assert.Equal(t, "slugHistory", updated.Changes[1].Field)
+	assert.Equal(t, "title", updated.Changes[2].Field)
+	assert.Equal(t, "First", updated.Changes[2].Before)
+	assert.Equal(t, "First, revised", updated.Changes[2].After)
+}
+
+func TestGetAuditLogValidation(t *testing.T) {
+	router := setupAuditTestRouter(t, &auth.Principal{Subject: "root", Role: auth.RoleAdmin})
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+	}{
+		{"", http.StatusOK},
+		{"?from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", http.StatusOK},
+		{"?from=yesterday", http.StatusBadRequest},
+		{"?limit=0", http.StatusBadRequest},
+		{"?limit=5000", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/admin/audit"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// maxChaptersSize caps uploaded chapters files; MP3 uploads are only read
+// up to the end of their ID3 tag
+const maxChaptersSize = 1 << 20
+
+// chapterService stores episode chapters
+var chapterService atomic.Pointer[models.ChapterService]
+
+func init() {
+	chapterService.Store(models.NewChapterService())
+}
+
+// SetChapterService replaces the chapter service used by the handlers
+func SetChapterService(service *models.ChapterService) {
+	chapterService.Store(service)
+}
+
+// GetEpisodeChapters handles GET /api/episodes/:id/chapters
+// @Summary Get episode chapters
+// @Description Returns the chapter markers of an episode in the Podcasting 2.0 JSON chapters format
+// @Tags episodes
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param preview query string false "Preview token"
+// @Produce json
+// @Success 200 {object} models.Chapters
+// @Failure 404 {object} ErrorResponse
+// @Router /episodes/{id}/chapters [get]
+func GetEpisodeChapters(c *gin.Context) {
+	episode, _, err := episodeService.Load().Resolve(c.Param("id"))
+	if err == nil && !episode.IsReachable(publishingNow()) && !hasValidPreview(c, episode.ID) {
+		err = models.ErrEpisodeNotFound
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if c.Query("preview") != "" {
+		c.Header("Cache-Control", "private, no-store")
+	}
+
+	chapters, err := chapterService.Load().Get(episode.ID)
+	if errors.Is(err, models.ErrChaptersNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Chapters not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		respondChaptersError(c, "Could not read chapters")
+		return
+	}
+
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		respondChaptersError(c, "Could not encode chapters")
+		return
+	}
+	c.Data(http.StatusOK, models.ChaptersContentType, data)
+}
+
+// PutEpisodeChapters handles PUT /api/admin/episodes/:id/chapters
+// @Summary Upload episode chapters
+// @Description Stores chapters sent as a Podcasting 2.0 JSON chapters file, or read from the ID3v2 CHAP and CTOC frames of the episode MP3 sent as audio/mpeg, replacing any previous ones. Without a body, the chapters are read from the episode's audio file in the media directory. Only the ID3 tag of an MP3 is read. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Accept json,mpeg
+// @Produce json
+// @Param id path string true "Episode ID"
+// @Success 200 {object} models.ChaptersSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id}/chapters [put]
+func PutEpisodeChapters(c *gin.Context) {
+	episode, err := episodeService.Load().GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	// MP3 files are recognised by their ID3 tag rather than trusting the
+	// Content-Type, and are only read up to the end of it
+	body := bufio.NewReader(c.Request.Body)
+	var chapters *models.Chapters
+	if _, empty := body.Peek(1); empty == io.EOF {
+		if chapters, err = readAudioChapters(episode); err != nil && !errors.Is(err, models.ErrChaptersInvalid) {
+			respondChaptersError(c, "Could not read the episode audio")
+			return
+		}
+	} else if magic, _ := body.Peek(3); models.IsID3Tagged(magic) {
+		chapters, err = models.ReadID3Chapters(body)
+	} else {
+		var data []byte
+		data, err = io.ReadAll(http.MaxBytesReader(c.Writer, io.NopCloser(body), maxChaptersSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "payload_too_large",
+				Message: "Chapters files may be at most 1 MiB",
+				Code:    http.StatusRequestEntityTooLarge,
+			})
+			return
+		}
+		if err == nil {
+			chapters, err = models.ParseChapters(data)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	service := chapterService.Load()
+	var before interface{}
+	if previous, err := service.Get(episode.ID); err == nil {
+		before = previous.Summary()
+	}
+	if err := service.Save(episode.ID, chapters); err != nil {
+		respondChaptersError(c, "Could not save chapters")
+		return
+	}
+
+	summary := chapters.Summary()
+	setAuditDetails(c, "chapters.update", "episode", episode.ID, before, summary)
+	middleware.PurgeCache()
+	c.JSON(http.StatusOK, summary)
+}
+
+// readAudioChapters reads the ID3 chapters of the episode's audio file.
+// Remote and missing files are reported as invalid chapters, since the
+// editor has to upload them instead.
+func readAudioChapters(episode *models.Episode) (*models.Chapters, error) {
+	file, _, err := mediaProber.Load().Open(episode.AudioURL)
+	if errors.Is(err, models.ErrMediaNotLocal) {
+		return nil, fmt.Errorf("%w: the episode audio is not in the media directory; upload chapters instead", models.ErrChaptersInvalid)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: the episode audio file was not found", models.ErrChaptersInvalid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return models.ReadID3Chapters(file)
+}
+
+// DeleteEpisodeChapters handles DELETE /api/admin/episodes/:id/chapters
+// @Summary Delete episode chapters
+// @Description Removes the chapters of an episode. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Param id path string true "Episode ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id}/chapters [delete]
+func DeleteEpisodeChapters(c *gin.Context) {
+	service := chapterService.Load()
+	previous, err := service.Get(c.Param("id"))
+	if err == nil {
+		err = service.Delete(c.Param("id"))
+	}
+	if errors.Is(err, models.ErrChaptersNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Chapters not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		respondChaptersError(c, "Could not delete chapters")
+		return
+	}
+
+	setAuditDetails(c, "chapters.delete", "episode", c.Param("id"), previous.Summary(), nil)
+	middleware.PurgeCache()
+	c.Status(http.StatusNoContent)
+}
+
+// respondChaptersError answers a chapter storage failure
+func respondChaptersError(c *gin.Context, message string) {
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "internal_error",
+		Message: message,
+		Code:    http.StatusInternalServerError,
+	})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChaptersJSON = `{"version":"1.2.0","chapters":[{"startTime":62,"title":"Interview"},{"startTime":0,"title":"Intro"}]}`
+
+// testChaptersMP3 is the start of an MP3 whose ID3v2.3 tag has one CHAP frame
+func testChaptersMP3() []byte {
+	frame := func(id string, body []byte) []byte {
+		header := []byte(id)
+		header = append(header, byte(len(body)>>24), byte(len(body)>>16), byte(len(body)>>8), byte(len(body)), 0, 0)
+		return append(header, body...)
+	}
+	chap := append([]byte("chp1\x00"), 0, 0, 0, 0, 0, 0, 0x75, 0x30, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	chap = append(chap, frame("TIT2", []byte("\x03Cold open"))...)
+	frames := frame("CHAP", chap)
+
+	tag := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, byte(len(frames) >> 7), byte(len(frames) & 0x7f)}
+	tag = append(tag, frames...)
+	return append(tag, bytes.Repeat([]byte{0xff, 0xfb, 0x90, 0x00}, 64)...)
+}
+
+func setupChaptersTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/a.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Draft", AudioURL: "/a.mp3", Status: models.StatusDraft},
+		{ID: "ep003", Number: 3, Title: "Remote", AudioURL: "https://cdn.example.com/a.mp3", PublishDate: "2024-01-03"},
+		{ID: "ep004", Number: 4, Title: "Missing", AudioURL: "/missing.mp3", PublishDate: "2024-01-04"},
+	})
+
+	media := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(media, "a.mp3"), testChaptersMP3(), 0o644))
+	previousProber := mediaProber.Load()
+	SetMediaProber(models.NewMediaProber(media))
+	t.Cleanup(func() { SetMediaProber(previousProber) })
+
+	previousChapters := chapterService.Load()
+	SetChapterService(models.NewChapterServiceFromDir(dir))
+	t.Cleanup(func() { SetChapterService(previousChapters) })
+
+	previousAudit := auditService.Load()
+	SetAuditService(models.NewAuditService())
+	t.Cleanup(func() { SetAuditService(previousAudit) })
+
+	router := gin.New()
+	router.GET("/api/episodes/:id/chapters", GetEpisodeChapters)
+	router.PUT("/api/admin/episodes/:id/chapters", PutEpisodeChapters)
+	router.DELETE("/api/admin/episodes/:id/chapters", DeleteEpisodeChapters)
+	return router
+}
+
+func uploadTestChapters(t *testing.T, router *gin.Engine, id string) {
+	req := httptest.NewRequest("PUT", "/api/admin/episodes/"+id+"/chapters", strings.NewReader(testChaptersJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestPutEpisodeChapters(t *testing.T) {
+	router := setupChaptersTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           []byte
+		expectedStatus int
+		expectedTitles []string
+	}{
+		{"json", "/api/admin/episodes/ep001/chapters", "application/json", []byte(testChaptersJSON), http.StatusOK, []string{"Intro", "Interview"}},
+		{"mp3", "/api/admin/episodes/ep001/chapters", "audio/mpeg", testChaptersMP3(), http.StatusOK, []string{"Cold open"}},
+		{"mp3 without content type", "/api/admin/episodes/ep001/chapters", "", testChaptersMP3(), http.StatusOK, []string{"Cold open"}},
+		{"no chapters", "/api/admin/episodes/ep001/chapters", "application/json", []byte(`{"chapters":[]}`), http.StatusBadRequest, nil},
+		{"not chapters", "/api/admin/episodes/ep001/chapters", "audio/mpeg", []byte("RIFF"), http.StatusBadRequest, nil},
+		{"too large", "/api/admin/episodes/ep001/chapters", "application/json", bytes.Repeat([]byte(" "), maxChaptersSize+1), http.StatusRequestEntityTooLarge, nil},
+		{"from the audio file", "/api/admin/episodes/ep001/chapters", "", nil, http.StatusOK, []string{"Cold open"}},
+		{"remote audio file", "/api/admin/episodes/ep003/chapters", "", nil, http.StatusBadRequest, nil},
+		{"missing audio file", "/api/admin/episodes/ep004/chapters", "", nil, http.StatusBadRequest, nil},
+		{"unknown episode", "/api/admin/episodes/ep999/chapters", "application/json", []byte(testChaptersJSON), http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.path, bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			if tt.expectedStatus == http.StatusOK {
+				var summary models.ChaptersSummary
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+				assert.Equal(t, tt.expectedTitles, summary.Titles)
+			}
+		})
+	}
+}
+
+func TestGetEpisodeChapters(t *testing.T) {
+	router := setupChaptersTestRouter(t)
+	uploadTestChapters(t, router, "ep001")
+	uploadTestChapters(t, router, "ep002")
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"by id", "/api/episodes/ep001/chapters", http.StatusOK},
+		{"by number", "/api/episodes/1/chapters", http.StatusOK},
+		{"draft", "/api/episodes/ep002/chapters", http.StatusNotFound},
+		{"unknown episode", "/api/episodes/ep999/chapters", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, models.ChaptersContentType, w.Header().Get("Content-Type"))
+				var chapters models.Chapters
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &chapters))
+				assert.Equal(t, "1.2.0", chapters.Version)
+				require.Len(t, chapters.Chapters, 2)
+				assert.Equal(t, "Intro", chapters.Chapters[0].Title)
+			}
+		})
+	}
+}
+
+func TestDeleteEpisodeChapters(t *testing.T) {
+	router := setupChaptersTestRouter(t)
+	uploadTestChapters(t, router, "ep001")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/admin/episodes/ep001/chapters", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep001/chapters", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/admin/episodes/ep001/chapters", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
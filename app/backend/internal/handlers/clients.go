@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+// clientKey caches the classification of a request's user agent
+const clientKey = "client"
+
+var (
+	// userAgentRules sorts requests into apps and bots
+	userAgentRules atomic.Pointer[models.UserAgentRules]
+	clientStats    atomic.Pointer[models.ClientStatsService]
+)
+
+func init() {
+	userAgentRules.Store(models.DefaultUserAgentRules())
+	clientStats.Store(models.NewClientStatsService())
+}
+
+// SetUserAgentRules replaces the rules user agents are classified by
+func SetUserAgentRules(rules *models.UserAgentRules) {
+	userAgentRules.Store(rules)
+}
+
+// SetClientStatsService replaces the service requests are counted in
+func SetClientStatsService(service *models.ClientStatsService) {
+	clientStats.Store(service)
+}
+
+// requestClient classifies the user agent of the request
+func requestClient(c *gin.Context) models.Client {
+	if client, ok := c.Get(clientKey); ok {
+		return client.(models.Client)
+	}
+	client := userAgentRules.Load().Classify(c.Request.UserAgent())
+	c.Set(clientKey, client)
+	return client
+}
+
+// ObserveClient counts successful public API and media requests by client
+// and adds the client to the request log. It is a logger.RequestObserver.
+func ObserveClient(c *gin.Context) map[string]interface{} {
+	client := requestClient(c)
+
+	path := c.Request.URL.Path
+	kind := ""
+	switch {
+	case strings.HasPrefix(path, "/media/"):
+		kind = models.RequestKindMedia
+	case strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/admin"):
+		kind = models.RequestKindAPI
+	}
+	if kind != "" && c.Writer.Status() < http.StatusBadRequest {
+		clientStats.Load().Record(kind, client, publishingNow().Format("2006-01-02"))
+	}
+
+	return map[string]interface{}{
+		"client_app":    client.App,
+		"client_os":     client.OS,
+		"client_device": client.Device,
+	}
+}
+
+// GetClientAnalytics handles GET /api/admin/analytics/clients
+// @Summary Get listener apps and platforms
+// @Description Returns successful public API and media requests by app, operating system and device class, and by day in the publishing timezone. Clients are classified by the user agent rules.
+// @Tags admin
+// @Produce json
+// @Param kind query string false "api or media; both by default"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD)"
+// @Success 200 {object} models.ClientStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/analytics/clients [get]
+func GetClientAnalytics(c *gin.Context) {
+	badRequest := func(message string) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	kind := c.Query("kind")
+	if kind != "" && kind != models.RequestKindAPI && kind != models.RequestKindMedia {
+		badRequest("kind must be api or media")
+		return
+	}
+	for _, name := range []string{"from", "to"} {
+		if value := c.Query(name); value != "" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				badRequest(name + " must be a date (YYYY-MM-DD)")
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, clientStats.Load().Query(kind, c.Query("from"), c.Query("to")))
+}
+
+// RunClientStatsWriter saves the request counts every interval until ctx
+// is done
+func RunClientStatsWriter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := clientStats.Load().Save(time.Now()); err != nil {
+				logger.GetLogger().LogError(err, map[string]interface{}{"event": "client_stats_save"})
+			}
+		}
+	}
+}
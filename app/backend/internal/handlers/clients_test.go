@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupClientsTestRouter(t *testing.T) (*gin.Engine, *[]map[string]interface{}) {
+	gin.SetMode(gin.TestMode)
+
+	previousRules := userAgentRules.Load()
+	SetUserAgentRules(models.DefaultUserAgentRules())
+	t.Cleanup(func() { SetUserAgentRules(previousRules) })
+
+	previousStats := clientStats.Load()
+	SetClientStatsService(models.NewClientStatsService())
+	t.Cleanup(func() { SetClientStatsService(previousStats) })
+
+	var logged []map[string]interface{}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		logged = append(logged, ObserveClient(c))
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/episodes", ok)
+	router.GET("/media/audio/:id", ok)
+	router.GET("/api/admin/episodes", ok)
+	router.GET("/health", ok)
+	router.GET("/api/admin/analytics/clients", GetClientAnalytics)
+	return router, &logged
+}
+
+func TestObserveClient(t *testing.T) {
+	router, logged := setupClientsTestRouter(t)
+
+	requests := []struct {
+		path      string
+		userAgent string
+	}{
+		{"/api/episodes", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) Chrome/123.0 Safari/537.36"},
+		{"/media/audio/ep001", "Overcast/3.0 (+http://overcast.fm/; iOS podcast app) iPhone"},
+		{"/media/audio/ep001", "Overcast/3.0 (+http://overcast.fm/; iOS podcast app) iPhone"},
+		{"/media/audio/ep999", "Googlebot/2.1"},
+		{"/api/admin/episodes", "Mozilla/5.0 (Macintosh)"},
+		{"/health", "kube-probe/1.29"},
+		{"/api/unknown", "Mozilla/5.0 (Macintosh)"},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest("GET", r.path, nil)
+		req.Header.Set("User-Agent", r.userAgent)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, *logged, len(requests))
+	assert.Equal(t, "Overcast", (*logged)[1]["client_app"])
+	assert.Equal(t, "iOS", (*logged)[1]["client_os"])
+	assert.Equal(t, "Phone", (*logged)[1]["client_device"])
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/analytics/clients", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var stats models.ClientStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	// Admin, health and failed requests are not counted
+	assert.Equal(t, 4, stats.Requests)
+	assert.Equal(t, map[string]int{"Chrome": 1, "Overcast": 2, "Bot": 1}, stats.Apps)
+	assert.Equal(t, 3, stats.Devices["Desktop"]+stats.Devices["Phone"])
+	require.Len(t, stats.Days, 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/analytics/clients?kind=media", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 3, stats.Requests)
+}
+
+func TestGetClientAnalyticsErrors(t *testing.T) {
+	router, _ := setupClientsTestRouter(t)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"bad kind", "/api/admin/analytics/clients?kind=feeds"},
+		{"bad from", "/api/admin/analytics/clients?from=2024-03"},
+		{"bad to", "/api/admin/analytics/clients?to=yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+// commentService keeps episode comments and their moderation state
+var commentService atomic.Pointer[models.CommentService]
+
+func init() {
+	commentService.Store(models.NewCommentService(models.DefaultCommentPolicy()))
+}
+
+// SetCommentService replaces the service comments are kept in
+func SetCommentService(service *models.CommentService) {
+	commentService.Store(service)
+}
+
+// CommentThread is an approved comment with its approved replies, oldest
+// first
+type CommentThread struct {
+	ID         string          `json:"id"`
+	AuthorName string          `json:"authorName"`
+	Body       string          `json:"body"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Replies    []CommentThread `json:"replies"`
+}
+
+// CommentsResponse lists an episode's comment threads; Total counts the
+// comments in every thread
+type CommentsResponse struct {
+	Comments []CommentThread `json:"comments"`
+	Total    int             `json:"total"`
+}
+
+// CommentRequest is a new comment, or a reply when ParentID is set
+type CommentRequest struct {
+	AuthorName string `json:"authorName" example:"Jane"`
+	Body       string `json:"body" example:"Loved the interview!"`
+	ParentID   string `json:"parentId,omitempty"`
+}
+
+// ModerationRequest sets a comment's status
+type ModerationRequest struct {
+	Status models.CommentStatus `json:"status" example:"approved"`
+}
+
+// commentThreads nests approved comments under their parents. Replies to
+// comments that are no longer approved are left out with them.
+func commentThreads(comments []models.Comment) CommentsResponse {
+	children := make(map[string][]models.Comment)
+	for _, comment := range comments {
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+
+	total := 0
+	var build func(parentID string) []CommentThread
+	build = func(parentID string) []CommentThread {
+		threads := []CommentThread{}
+		for _, comment := range children[parentID] {
+			total++
+			threads = append(threads, CommentThread{
+				ID:         comment.ID,
+				AuthorName: comment.AuthorName,
+				Body:       comment.Body,
+				CreatedAt:  comment.CreatedAt,
+				Replies:    build(comment.ID),
+			})
+		}
+		return threads
+	}
+	threads := build("")
+	return CommentsResponse{Comments: threads, Total: total}
+}
+
+// respondCommentError writes the response for an error from CommentService
+func respondCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Comment not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, models.ErrCommentInvalid):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	case errors.Is(err, models.ErrCommentThrottled):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Error:   "rate_limit_exceeded",
+			Message: "Too many comments. Please try again later.",
+			Code:    http.StatusTooManyRequests,
+		})
+	default:
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":      "comment_save",
+			"comment_id": c.Param("id"),
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not save comments",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
+
+// GetEpisodeComments handles GET /api/episodes/:id/comments
+// @Summary Get episode comments
+// @Description Returns an episode's approved comments as threads, oldest first, with replies nested under the comment they answer
+// @Tags comments
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Success 200 {object} CommentsResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /episodes/{id}/comments [get]
+func GetEpisodeComments(c *gin.Context) {
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, commentThreads(commentService.Load().Approved(episodeID)))
+}
+
+// PostEpisodeComment handles POST /api/episodes/:id/comments
+// @Summary Comment on an episode
+// @Description Adds a comment, or a reply to an approved comment, for moderation. Comments with banned words or too many links are rejected and accounts and addresses may only post a few comments an hour. The status says whether the comment is pending, approved or rejected.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param comment body CommentRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /episodes/{id}/comments [post]
+func PostEpisodeComment(c *gin.Context) {
+	var request CommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid comment JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	comment, err := commentService.Load().Post(models.NewComment{
+		EpisodeID:  episodeID,
+		ParentID:   request.ParentID,
+		ListenerID: currentListener(c).ID,
+		AuthorName: request.AuthorName,
+		Body:       request.Body,
+		IP:         c.ClientIP(),
+	}, time.Now())
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	// Spammers are not told which check they failed
+	comment.Reason = ""
+	c.JSON(http.StatusCreated, comment)
+}
+
+// GetAdminComments handles GET /api/admin/comments
+// @Summary List comments for moderation
+// @Description Returns comments with the given status, oldest first, with the reason the spam checks rejected them. Requires the editor role and the admin:read scope.
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending (default), approved, rejected or all"
+// @Param episodeId query string false "Only comments on this episode"
+// @Success 200 {array} models.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/comments [get]
+func GetAdminComments(c *gin.Context) {
+	filter := models.CommentFilter{Status: models.CommentPending, EpisodeID: c.Query("episodeId")}
+	if value := c.Query("status"); value == "all" {
+		filter.Status = ""
+	} else if value != "" {
+		status, err := models.ParseCommentStatus(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "bad_request",
+				Message: "status must be pending, approved, rejected or all",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		filter.Status = status
+	}
+	c.JSON(http.StatusOK, commentService.Load().List(filter))
+}
+
+// ModerateComment handles PUT /api/admin/comments/:id
+// @Summary Moderate a comment
+// @Description Approves or rejects a comment, or returns it to the queue. Requires the editor role and the comments:write scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param status body ModerationRequest true "New status"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/comments/{id} [put]
+func ModerateComment(c *gin.Context) {
+	var request ModerationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid moderation JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	previous, err := commentService.Load().Get(c.Param("id"))
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	updated, err := commentService.Load().Moderate(c.Param("id"), request.Status, time.Now())
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	setAuditDetails(c, "comment.moderate", "comment", updated.ID, previous, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteComment handles DELETE /api/admin/comments/:id
+// @Summary Delete a comment
+// @Description Removes a comment and every reply to it. Requires the admin role and the comments:write scope.
+// @Tags admin
+// @Param id path string true "Comment ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/comments/{id} [delete]
+func DeleteComment(c *gin.Context) {
+	deleted, err := commentService.Load().Delete(c.Param("id"))
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	setAuditDetails(c, "comment.delete", "comment", c.Param("id"), deleted, nil)
+	c.Status(http.StatusNoContent)
+}
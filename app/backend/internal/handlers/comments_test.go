@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCommentsTestRouter(t *testing.T, policy models.CommentPolicy) (*gin.Engine, *recordingMailer) {
+	router, mail := setupListenersTestRouter(t)
+
+	previousComments := commentService.Load()
+	SetCommentService(models.NewCommentService(policy))
+	t.Cleanup(func() { SetCommentService(previousComments) })
+
+	previousReactions := reactionService.Load()
+	SetReactionService(models.NewReactionService())
+	t.Cleanup(func() { SetReactionService(previousReactions) })
+
+	router.GET("/api/episodes/:id/comments", GetEpisodeComments)
+	router.POST("/api/episodes/:id/comments", RequireListener(), PostEpisodeComment)
+	router.GET("/api/episodes/:id/reactions", GetEpisodeReactions)
+	router.PUT("/api/episodes/:id/reactions/:kind", RequireListener(), PutEpisodeReaction)
+	router.DELETE("/api/episodes/:id/reactions/:kind", RequireListener(), DeleteEpisodeReaction)
+	router.PUT("/api/episodes/:id/rating", RequireListener(), PutEpisodeRating)
+	router.DELETE("/api/episodes/:id/rating", RequireListener(), DeleteEpisodeRating)
+	router.GET("/api/me/reactions/:episodeId", RequireListener(), GetMyReactions)
+	router.GET("/api/admin/comments", GetAdminComments)
+	router.PUT("/api/admin/comments/:id", ModerateComment)
+	router.DELETE("/api/admin/comments/:id", DeleteComment)
+	return router, mail
+}
+
+// listenerRequest returns a function that sends requests as the listener
+// signed in with token
+func listenerRequest(router *gin.Engine, token string) func(method, path, body string) *httptest.ResponseRecorder {
+	return func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestEpisodeComments(t *testing.T) {
+	router, mail := setupCommentsTestRouter(t, models.DefaultCommentPolicy())
+	jane := listenerRequest(router, signInListener(t, router, mail, "jane@example.com"))
+	sam := listenerRequest(router, signInListener(t, router, mail, "sam@example.com"))
+	anonymous := listenerRequest(router, "")
+
+	assert.Equal(t, http.StatusUnauthorized, anonymous("POST", "/api/episodes/ep001/comments", `{"authorName": "Jane", "body": "Hi"}`).Code)
+
+	w := jane("POST", "/api/episodes/1/comments", `{"authorName": "Jane", "body": "Loved the interview!"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var comment models.Comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+	assert.Equal(t, "ep001", comment.EpisodeID)
+	assert.Equal(t, models.CommentPending, comment.Status)
+
+	// Pending comments are not shown and cannot be answered
+	w = anonymous("GET", "/api/episodes/ep001/comments", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var threads CommentsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	assert.Empty(t, threads.Comments)
+	assert.Equal(t, http.StatusNotFound, sam("POST", "/api/episodes/ep001/comments", `{"authorName": "Sam", "body": "Agreed", "parentId": "`+comment.ID+`"}`).Code)
+
+	w = anonymous("GET", "/api/admin/comments", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var queue []models.Comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queue))
+	require.Len(t, queue, 1)
+	assert.Equal(t, comment.ID, queue[0].ID)
+
+	w = anonymous("PUT", "/api/admin/comments/"+comment.ID, `{"status": "approved"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = sam("POST", "/api/episodes/ep001/comments", `{"authorName": "Sam", "body": "Agreed", "parentId": "`+comment.ID+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var reply models.Comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	require.Equal(t, http.StatusOK, anonymous("PUT", "/api/admin/comments/"+reply.ID, `{"status": "approved"}`).Code)
+
+	w = anonymous("GET", "/api/episodes/ep001/comments", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	assert.Equal(t, 2, threads.Total)
+	require.Len(t, threads.Comments, 1)
+	require.Len(t, threads.Comments[0].Replies, 1)
+	assert.Equal(t, "Agreed", threads.Comments[0].Replies[0].Body)
+	assert.NotContains(t, w.Body.String(), "listenerId")
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"empty body", "POST", "/api/episodes/ep001/comments", `{"authorName": "Jane", "body": " "}`, http.StatusBadRequest},
+		{"draft episode", "POST", "/api/episodes/ep003/comments", `{"authorName": "Jane", "body": "Hi"}`, http.StatusNotFound},
+		{"comments on a draft", "GET", "/api/episodes/ep003/comments", "", http.StatusNotFound},
+		{"unknown status filter", "GET", "/api/admin/comments?status=hidden", "", http.StatusBadRequest},
+		{"unknown status", "PUT", "/api/admin/comments/" + comment.ID, `{"status": "hidden"}`, http.StatusBadRequest},
+		{"unknown comment", "PUT", "/api/admin/comments/nope", `{"status": "approved"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, jane(tt.method, tt.path, tt.body).Code)
+		})
+	}
+
+	// Deleting a comment takes its replies with it
+	assert.Equal(t, http.StatusNoContent, anonymous("DELETE", "/api/admin/comments/"+comment.ID, "").Code)
+	w = anonymous("GET", "/api/admin/comments?status=all", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queue))
+	assert.Empty(t, queue)
+}
+
+func TestEpisodeCommentSpamChecks(t *testing.T) {
+	policy := models.DefaultCommentPolicy()
+	policy.AutoApprove = true
+	policy.BannedWords = []string{"casino"}
+	policy.ListenerLimit = 3
+	router, mail := setupCommentsTestRouter(t, policy)
+	jane := listenerRequest(router, signInListener(t, router, mail, "jane@example.com"))
+
+	post := func(body string) models.Comment {
+		w := jane("POST", "/api/episodes/ep001/comments", `{"authorName": "Jane", "body": "`+body+`"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var comment models.Comment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+		return comment
+	}
+	assert.Equal(t, models.CommentApproved, post("Great show").Status)
+	rejected := post("Visit my casino")
+	assert.Equal(t, models.CommentRejected, rejected.Status)
+	assert.Empty(t, rejected.Reason)
+	assert.Equal(t, models.CommentRejected, post("http://a.example http://b.example http://c.example").Status)
+
+	w := jane("POST", "/api/episodes/ep001/comments", `{"authorName": "Jane", "body": "One more"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Moderators see why comments were rejected
+	w = listenerRequest(router, "")("GET", "/api/admin/comments?status=rejected&episodeId=ep001", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var rejectedComments []models.Comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rejectedComments))
+	require.Len(t, rejectedComments, 2)
+	assert.Equal(t, "banned word", rejectedComments[0].Reason)
+	assert.Equal(t, "3 links", rejectedComments[1].Reason)
+}
@@ -2,12 +2,23 @@ package handlers
 
 import (
 	"net/http"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/podsite/backend/internal/models"
 )
 
-var contentService = models.NewContentService()
+// contentService serves the about and FAQ pages
+var contentService atomic.Pointer[models.ContentService]
+
+func init() {
+	contentService.Store(models.NewContentService())
+}
+
+// SetContentService replaces the content service used by the handlers
+func SetContentService(service *models.ContentService) {
+	contentService.Store(service)
+}
 
 // GetAbout handles GET /api/about
 // @Summary Get about page content
@@ -18,7 +29,7 @@ var contentService = models.NewContentService()
 // @Failure 500 {object} ErrorResponse
 // @Router /about [get]
 func GetAbout(c *gin.Context) {
-	content := contentService.GetAbout()
+	content := contentService.Load().GetAbout()
 	c.JSON(http.StatusOK, content)
 }
 
@@ -31,6 +42,6 @@ func GetAbout(c *gin.Context) {
 // @Failure 500 {object} ErrorResponse
 // @Router /faq [get]
 func GetFAQ(c *gin.Context) {
-	content := contentService.GetFAQ()
+	content := contentService.Load().GetFAQ()
 	c.JSON(http.StatusOK, content)
 }
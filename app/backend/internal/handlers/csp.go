@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+)
+
+// maxCSPReportSize bounds the size of an accepted violation report body
+const maxCSPReportSize = 64 * 1024
+
+// CSPViolation holds the fields of a CSP violation report that are logged
+type CSPViolation struct {
+	DocumentURI        string `json:"documentURI"`
+	BlockedURI         string `json:"blockedURI"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	LineNumber         int    `json:"lineNumber"`
+	ColumnNumber       int    `json:"columnNumber"`
+	Sample             string `json:"sample"`
+}
+
+// legacyCSPReport is the application/csp-report body sent for report-uri
+type legacyCSPReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is one entry of an application/reports+json body sent for report-to
+type reportingAPIReport struct {
+	Type string       `json:"type"`
+	Body CSPViolation `json:"body"`
+}
+
+// ReportCSPViolation handles POST /csp-report
+// @Summary Collect CSP violation reports
+// @Description Accepts Content-Security-Policy violation reports from browsers (report-uri and report-to formats) and logs them
+// @Tags security
+// @Accept json
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Router /csp-report [post]
+func ReportCSPViolation(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCSPReportSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Could not read report body",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if len(body) > maxCSPReportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error:   "payload_too_large",
+			Message: "Report body is too large",
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+		return
+	}
+
+	violations, err := parseCSPReports(c.ContentType(), body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid CSP report",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	appLogger := logger.GetLogger()
+	for _, violation := range violations {
+		appLogger.LogWarn("CSP violation", map[string]interface{}{
+			"document_uri":        violation.DocumentURI,
+			"blocked_uri":         violation.BlockedURI,
+			"effective_directive": violation.EffectiveDirective,
+			"disposition":         violation.Disposition,
+			"source_file":         violation.SourceFile,
+			"line_number":         violation.LineNumber,
+			"column_number":       violation.ColumnNumber,
+			"sample":              violation.Sample,
+			"user_agent":          c.Request.UserAgent(),
+		})
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseCSPReports decodes either the legacy report-uri format or the Reporting API format
+func parseCSPReports(contentType string, body []byte) ([]CSPViolation, error) {
+	if contentType == "application/reports+json" || strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reports []reportingAPIReport
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, err
+		}
+
+		violations := make([]CSPViolation, 0, len(reports))
+		for _, report := range reports {
+			if report.Type == "csp-violation" {
+				violations = append(violations, report.Body)
+			}
+		}
+		return violations, nil
+	}
+
+	var legacy legacyCSPReport
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+
+	report := legacy.Report
+	directive := report.EffectiveDirective
+	if directive == "" {
+		directive = report.ViolatedDirective
+	}
+
+	return []CSPViolation{{
+		DocumentURI:        report.DocumentURI,
+		BlockedURI:         report.BlockedURI,
+		EffectiveDirective: directive,
+		OriginalPolicy:     report.OriginalPolicy,
+		Disposition:        report.Disposition,
+		SourceFile:         report.SourceFile,
+		LineNumber:         report.LineNumber,
+		ColumnNumber:       report.ColumnNumber,
+		Sample:             report.ScriptSample,
+	}}, nil
+}
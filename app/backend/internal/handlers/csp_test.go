@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCSPTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/csp-report", ReportCSPViolation)
+	return router
+}
+
+func TestReportCSPViolation(t *testing.T) {
+	router := setupCSPTestRouter()
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "Legacy report-uri format",
+			contentType:    "application/csp-report",
+			body:           `{"csp-report":{"document-uri":"https://podsite.com/","blocked-uri":"inline","violated-directive":"script-src"}}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Reporting API format",
+			contentType:    "application/reports+json",
+			body:           `[{"type":"csp-violation","body":{"documentURI":"https://podsite.com/","blockedURI":"eval","effectiveDirective":"script-src"}}]`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Invalid JSON",
+			contentType:    "application/csp-report",
+			body:           `{not json`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Too large",
+			contentType:    "application/csp-report",
+			body:           `{"csp-report":{"sample":"` + strings.Repeat("a", maxCSPReportSize) + `"}}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/csp-report", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestParseCSPReportsDirectiveFallback(t *testing.T) {
+	violations, err := parseCSPReports("application/csp-report",
+		[]byte(`{"csp-report":{"blocked-uri":"inline","violated-directive":"style-src"}}`))
+
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "style-src", violations[0].EffectiveDirective)
+}
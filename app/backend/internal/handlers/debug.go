@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/buildinfo"
+)
+
+var publishExpvarsOnce sync.Once
+
+// publishExpvars registers application variables with the expvar package.
+// memstats and cmdline are published by expvar itself.
+func publishExpvars() {
+	publishExpvarsOnce.Do(func() {
+		expvar.Publish("build", expvar.Func(func() interface{} {
+			return buildinfo.Get()
+		}))
+		expvar.Publish("uptime_seconds", expvar.Func(func() interface{} {
+			return time.Since(startTime).Seconds()
+		}))
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return runtime.NumGoroutine()
+		}))
+	})
+}
+
+// RegisterDebugRoutes mounts pprof and expvar handlers on the given group.
+// These endpoints expose internals and must only be served behind admin auth.
+func RegisterDebugRoutes(group *gin.RouterGroup) {
+	publishExpvars()
+
+	group.GET("/vars", gin.WrapH(expvar.Handler()))
+
+	profiles := group.Group("/pprof")
+	{
+		profiles.GET("/", gin.WrapF(pprof.Index))
+		profiles.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		profiles.GET("/profile", gin.WrapF(pprof.Profile))
+		profiles.POST("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.GET("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.GET("/trace", gin.WrapF(pprof.Trace))
+		profiles.GET("/:profile", func(c *gin.Context) {
+			pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+		})
+	}
+}
@@ -2,13 +2,25 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/podsite/backend/internal/models"
 )
 
-var episodeService = models.NewEpisodeService()
+// The services handlers read live in atomic pointers, like episodeService,
+// with a default stored in init. Their SetXService functions swap them
+// atomically when the content directory or configuration is reloaded.
+var episodeService atomic.Pointer[models.EpisodeService]
+
+func init() {
+	episodeService.Store(models.NewEpisodeService())
+}
+
+// SetEpisodeService replaces the episode service used by the handlers
+func SetEpisodeService(service *models.EpisodeService) {
+	episodeService.Store(service)
+}
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
@@ -19,28 +31,45 @@ type ErrorResponse struct {
 
 // GetEpisodes handles GET /api/episodes
 // @Summary Get all episodes
-// @Description Returns a list of all podcast episodes
+// @Description Returns the published podcast episodes whose publish time has passed
 // @Tags episodes
+// @Param sort query string false "newest (default), longest or shortest"
 // @Produce json
 // @Success 200 {array} models.Episode
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /episodes [get]
 func GetEpisodes(c *gin.Context) {
-	episodes := episodeService.GetAll()
+	episodes := episodeService.Load().GetPublished(publishingNow())
+	if !models.SortEpisodes(episodes, models.EpisodeOrder(c.Query("sort"))) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "sort must be newest, longest or shortest",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	peopleService.Load().Attach(episodes)
 	c.JSON(http.StatusOK, episodes)
 }
 
+// FeaturedEpisodeResponse is the featured episode with the reason it was chosen
+type FeaturedEpisodeResponse struct {
+	models.Episode
+	FeaturedReason models.FeaturedReason `json:"featuredReason"`
+}
+
 // GetFeaturedEpisode handles GET /api/episodes/featured
 // @Summary Get featured episode
-// @Description Returns the most recent episode as the featured episode
+// @Description Returns the pinned, rotating or most recent published episode, with featuredReason set to pinned, rotation, latest, window_inactive or unavailable
 // @Tags episodes
 // @Produce json
-// @Success 200 {object} models.Episode
+// @Success 200 {object} FeaturedEpisodeResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /episodes/featured [get]
 func GetFeaturedEpisode(c *gin.Context) {
-	episode, err := episodeService.GetFeatured()
+	episode, reason, err := episodeService.Load().SelectFeatured(publishingNow())
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
@@ -50,16 +79,27 @@ func GetFeaturedEpisode(c *gin.Context) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, episode)
+	peopleService.Load().AttachTo(episode)
+	c.JSON(http.StatusOK, FeaturedEpisodeResponse{Episode: *episode, FeaturedReason: reason})
+}
+
+// MovedEpisodeResponse is the episode requested by an outdated slug, with the
+// canonical path to use instead
+type MovedEpisodeResponse struct {
+	models.Episode
+	RedirectTo string `json:"redirectTo"`
 }
 
 // GetEpisodeByID handles GET /api/episodes/:id
 // @Summary Get episode by ID
-// @Description Returns a specific episode by its ID
+// @Description Returns a specific episode by its ID, slug, overall number (12) or season and episode (s2e5). An outdated slug answers 301 to the current slug, or 200 with redirectTo when redirect=false. Drafts and scheduled episodes are only returned with a valid preview token.
 // @Tags episodes
-// @Param id path string true "Episode ID"
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param preview query string false "Preview token"
+// @Param redirect query bool false "Set to false to receive redirectTo instead of a 301"
 // @Produce json
 // @Success 200 {object} models.Episode
+// @Success 301 {object} MovedEpisodeResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /episodes/{id} [get]
@@ -74,12 +114,12 @@ func GetEpisodeByID(c *gin.Context) {
 		return
 	}
 	
-	// Handle numeric IDs (episode numbers)
-	if num, err := strconv.Atoi(id); err == nil {
-		id = "ep" + padNumber(num)
+	episode, moved, err := episodeService.Load().Resolve(id)
+	if err == nil && !episode.IsReachable(publishingNow()) {
+		if !hasValidPreview(c, episode.ID) {
+			err = models.ErrEpisodeNotFound
+		}
 	}
-	
-	episode, err := episodeService.GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "not_found",
@@ -88,16 +128,27 @@ func GetEpisodeByID(c *gin.Context) {
 		})
 		return
 	}
+	peopleService.Load().AttachTo(episode)
+	if c.Query("preview") != "" {
+		// Keep previews out of shared caches and the response cache
+		c.Header("Cache-Control", "private, no-store")
+	}
+	
+	if moved {
+		// An outdated slug: point links at the current one
+		response := MovedEpisodeResponse{Episode: *episode, RedirectTo: "/api/episodes/" + episode.Slug}
+		if c.Query("redirect") == "false" {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		location := response.RedirectTo
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Header("Location", location)
+		c.JSON(http.StatusMovedPermanently, response)
+		return
+	}
 	
 	c.JSON(http.StatusOK, episode)
 }
-
-// padNumber pads a number with leading zeros to 3 digits
-func padNumber(num int) string {
-	if num < 10 {
-		return "00" + strconv.Itoa(num)
-	} else if num < 100 {
-		return "0" + strconv.Itoa(num)
-	}
-	return strconv.Itoa(num)
-}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/podsite/backend/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRouter() *gin.Engine {
@@ -29,6 +32,25 @@ func setupTestRouter() *gin.Engine {
 	return router
 }
 
+// useTestEpisodes writes episodes to the episodes.json of a new content
+// directory and serves them until the test ends. It returns the directory.
+func useTestEpisodes(t *testing.T, episodes []models.Episode) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	data, err := json.Marshal(episodes)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "episodes.json"), data, 0o644))
+
+	service, err := models.LoadEpisodeService(dir)
+	require.NoError(t, err)
+
+	previous := episodeService.Load()
+	SetEpisodeService(service)
+	t.Cleanup(func() { SetEpisodeService(previous) })
+	return dir
+}
+
 func TestGetEpisodes(t *testing.T) {
 	router := setupTestRouter()
 
@@ -126,6 +148,41 @@ func TestGetEpisodeByID(t *testing.T) {
 	}
 }
 
+func TestGetEpisodesSort(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{"longest", "?sort=longest", http.StatusOK},
+		{"shortest", "?sort=shortest", http.StatusOK},
+		{"unknown", "?sort=random", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes"+tt.query, nil))
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var episodes []models.Episode
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &episodes))
+			for i := 1; i < len(episodes); i++ {
+				if tt.name == "longest" {
+					assert.GreaterOrEqual(t, episodes[i-1].DurationSeconds, episodes[i].DurationSeconds)
+				} else {
+					assert.LessOrEqual(t, episodes[i-1].DurationSeconds, episodes[i].DurationSeconds)
+				}
+			}
+		})
+	}
+}
+
 func TestGetEpisodesResponseFormat(t *testing.T) {
 	router := setupTestRouter()
 
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// GetFeaturedSettings handles GET /api/admin/featured
+// @Summary Get the featured episode settings
+// @Description Returns how the featured episode is chosen. Requires the editor role.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.FeaturedSettings
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/featured [get]
+func GetFeaturedSettings(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, episodeService.Load().GetFeaturedSettings())
+}
+
+// UpdateFeaturedSettings handles PUT /api/admin/featured
+// @Summary Choose the featured episode
+// @Description Pins an episode, rotates among a curated set or features the latest episode, optionally between startsAt and endsAt. Outside the window, or when no curated episode is published, the latest episode is featured. Requires the editor role and the featured:write scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param settings body models.FeaturedSettings true "Featured settings"
+// @Success 200 {object} models.FeaturedSettings
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/featured [put]
+func UpdateFeaturedSettings(c *gin.Context) {
+	var settings models.FeaturedSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid featured settings JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	updated, previous, err := episodeService.Load().SetFeaturedSettings(settings, time.Now())
+	if err != nil {
+		if errors.Is(err, models.ErrFeaturedInvalid) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "validation_failed",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not save featured settings",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	setAuditDetails(c, "featured.update", "featured", "", previous, updated)
+	middleware.PurgeCache()
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeaturedSettingsEndpoints(t *testing.T) {
+	router := setupPublishingTestRouter(t)
+	router.GET("/api/episodes/featured", GetFeaturedEpisode)
+	router.GET("/api/admin/featured", GetFeaturedSettings)
+	router.PUT("/api/admin/featured", UpdateFeaturedSettings)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedID     string
+		expectedReason string
+	}{
+		{"latest", `{"mode":"latest"}`, http.StatusOK, "ep003", "latest"},
+		{"pin", `{"mode":"pinned","episodeId":"ep001"}`, http.StatusOK, "ep001", "pinned"},
+		{"pin draft", `{"mode":"pinned","episodeId":"ep002"}`, http.StatusOK, "ep003", "unavailable"},
+		{"pin expired", `{"mode":"pinned","episodeId":"ep001","endsAt":"2020-01-01T00:00:00Z"}`, http.StatusOK, "ep003", "window_inactive"},
+		{"rotation", `{"mode":"rotation","rotation":["ep001"]}`, http.StatusOK, "ep001", "rotation"},
+		{"unknown episode", `{"mode":"pinned","episodeId":"ep999"}`, http.StatusBadRequest, "ep001", "rotation"},
+		{"unknown mode", `{"mode":"random"}`, http.StatusBadRequest, "ep001", "rotation"},
+		{"malformed", `{`, http.StatusBadRequest, "ep001", "rotation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/admin/featured", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/featured", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var featured FeaturedEpisodeResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &featured))
+			assert.Equal(t, tt.expectedID, featured.ID)
+			assert.Equal(t, tt.expectedReason, string(featured.FeaturedReason))
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/featured", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"mode":"rotation"`)
+}
@@ -3,9 +3,11 @@ package handlers
 import (
 	"net/http"
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/buildinfo"
 )
 
 // HealthResponse represents the health check response
@@ -14,11 +16,31 @@ type HealthResponse struct {
 	Timestamp string            `json:"timestamp"`
 	Version   string            `json:"version"`
 	Uptime    string            `json:"uptime"`
+	Build     buildinfo.Info    `json:"build"`
 	System    map[string]string `json:"system"`
+	Runtime   RuntimeStats      `json:"runtime"`
+}
+
+// RuntimeStats represents memory and garbage collector statistics
+type RuntimeStats struct {
+	Goroutines     int       `json:"goroutines"`
+	NumCPU         int       `json:"numCpu"`
+	HeapAllocBytes uint64    `json:"heapAllocBytes"`
+	HeapSysBytes   uint64    `json:"heapSysBytes"`
+	HeapObjects    uint64    `json:"heapObjects"`
+	TotalAlloc     uint64    `json:"totalAllocBytes"`
+	SysBytes       uint64    `json:"sysBytes"`
+	NumGC          uint32    `json:"numGc"`
+	LastGC         string    `json:"lastGc,omitempty"`
+	PauseTotalMs   float64   `json:"gcPauseTotalMs"`
+	RecentPausesMs []float64 `json:"gcRecentPausesMs"`
 }
 
 var startTime = time.Now()
 
+// maxRecentPauses is the number of most recent GC pauses reported
+const maxRecentPauses = 10
+
 // HealthCheck handles GET /health
 // @Summary Health check endpoint
 // @Description Returns the health status of the API
@@ -28,22 +50,61 @@ var startTime = time.Now()
 // @Router /health [get]
 func HealthCheck(c *gin.Context) {
 	uptime := time.Since(startTime)
-	
+	build := buildinfo.Get()
+	stats := collectRuntimeStats()
+
 	response := HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Version:   "2.0.0",
+		Version:   build.Version,
 		Uptime:    uptime.String(),
+		Build:     build,
 		System: map[string]string{
-			"go_version":      runtime.Version(),
-			"num_goroutines":  string(rune(runtime.NumGoroutine())),
-			"num_cpu":         string(rune(runtime.NumCPU())),
+			"go_version":     runtime.Version(),
+			"num_goroutines": strconv.Itoa(stats.Goroutines),
+			"num_cpu":        strconv.Itoa(stats.NumCPU),
 		},
+		Runtime: stats,
 	}
-	
+
 	c.JSON(http.StatusOK, response)
 }
 
+// collectRuntimeStats reads the current memory and GC statistics
+func collectRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		NumCPU:         runtime.NumCPU(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapSysBytes:   mem.HeapSys,
+		HeapObjects:    mem.HeapObjects,
+		TotalAlloc:     mem.TotalAlloc,
+		SysBytes:       mem.Sys,
+		NumGC:          mem.NumGC,
+		PauseTotalMs:   float64(mem.PauseTotalNs) / 1e6,
+		RecentPausesMs: make([]float64, 0, maxRecentPauses),
+	}
+
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+
+	// PauseNs is a circular buffer; the most recent pause is at (NumGC+255)%256
+	recent := int(mem.NumGC)
+	if recent > maxRecentPauses {
+		recent = maxRecentPauses
+	}
+	for i := 0; i < recent; i++ {
+		idx := (int(mem.NumGC) - 1 - i + len(mem.PauseNs)) % len(mem.PauseNs)
+		stats.RecentPausesMs = append(stats.RecentPausesMs, float64(mem.PauseNs[idx])/1e6)
+	}
+
+	return stats
+}
+
 // ReadinessResponse represents the readiness check response
 type ReadinessResponse struct {
 	Status      string `json:"status"`
@@ -63,25 +124,25 @@ type ReadinessResponse struct {
 func ReadinessCheck(c *gin.Context) {
 	// Check database connectivity (currently using in-memory data)
 	dbStatus := "ok"
-	
+
 	// Check external API dependencies (none currently)
 	apiStatus := "ok"
-	
+
 	// Determine overall status
 	status := "ready"
 	httpStatus := http.StatusOK
-	
+
 	if dbStatus != "ok" || apiStatus != "ok" {
 		status = "not_ready"
 		httpStatus = http.StatusServiceUnavailable
 	}
-	
+
 	response := ReadinessResponse{
 		Status:      status,
 		Timestamp:   time.Now().UTC().Format(time.RFC3339),
 		Database:    dbStatus,
 		ExternalAPI: apiStatus,
 	}
-	
+
 	c.JSON(httpStatus, response)
 }
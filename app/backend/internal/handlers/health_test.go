@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/buildinfo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,7 +42,7 @@ func TestHealthCheck(t *testing.T) {
 	// Check required fields
 	assert.Equal(t, "healthy", health.Status)
 	assert.NotEmpty(t, health.Timestamp)
-	assert.Equal(t, "2.0.0", health.Version)
+	assert.Equal(t, buildinfo.Get().Version, health.Version)
 	assert.NotEmpty(t, health.Uptime)
 	assert.NotNil(t, health.System)
 
@@ -48,6 +50,20 @@ func TestHealthCheck(t *testing.T) {
 	assert.Contains(t, health.System, "go_version")
 	assert.Contains(t, health.System, "num_goroutines")
 	assert.Contains(t, health.System, "num_cpu")
+
+	// Numeric system values must be rendered as decimal strings
+	goroutines, err := strconv.Atoi(health.System["num_goroutines"])
+	assert.NoError(t, err)
+	assert.Greater(t, goroutines, 0)
+	numCPU, err := strconv.Atoi(health.System["num_cpu"])
+	assert.NoError(t, err)
+	assert.Equal(t, numCPU, health.Runtime.NumCPU)
+
+	// Build and runtime details
+	assert.NotEmpty(t, health.Build.Commit)
+	assert.NotEmpty(t, health.Build.GoVersion)
+	assert.Greater(t, health.Runtime.HeapAllocBytes, uint64(0))
+	assert.NotNil(t, health.Runtime.RecentPausesMs)
 }
 
 func TestReadinessCheck(t *testing.T) {
@@ -143,7 +159,7 @@ func TestHealthCheckConsistency(t *testing.T) {
 		err := json.Unmarshal(w.Body.Bytes(), &health)
 		assert.NoError(t, err)
 		assert.Equal(t, "healthy", health.Status)
-		assert.Equal(t, "2.0.0", health.Version)
+		assert.Equal(t, buildinfo.Get().Version, health.Version)
 	}
 }
 
@@ -199,3 +215,28 @@ func TestReadinessCheckPerformance(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Less(t, duration, 100*time.Millisecond, "Readiness check should respond quickly")
 }
+
+func TestDebugRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterDebugRoutes(router.Group("/debug"))
+
+	req, _ := http.NewRequest("GET", "/debug/vars", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var vars map[string]json.RawMessage
+	err := json.Unmarshal(w.Body.Bytes(), &vars)
+	assert.NoError(t, err)
+	assert.Contains(t, vars, "build")
+	assert.Contains(t, vars, "memstats")
+
+	req, _ = http.NewRequest("GET", "/debug/pprof/", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+}
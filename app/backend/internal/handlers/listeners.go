@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/mailer"
+	"github.com/podsite/backend/internal/models"
+)
+
+// Defaults for listener sign-in
+const (
+	defaultLoginTTL   = 15 * time.Minute
+	defaultSessionTTL = 90 * 24 * time.Hour
+	// completedRemaining marks an episode completed when less than this is
+	// left to play, so skipped outros do not keep it in continue listening
+	completedRemaining = 30.0
+)
+
+// listenerKey is the context key RequireListener stores the listener under
+const listenerKey = "listener"
+
+// signIn holds how sign-in emails are delivered
+type signIn struct {
+	mailer mailer.Mailer
+	// loginURL is the site page that completes sign-in, given the token in
+	// its token query parameter; without it the email only contains the token
+	loginURL string
+}
+
+var (
+	listenerService atomic.Pointer[models.ListenerService]
+	signInSettings  atomic.Pointer[signIn]
+)
+
+func init() {
+	listenerService.Store(models.NewListenerService(defaultLoginTTL, defaultSessionTTL))
+	signInSettings.Store(&signIn{
+		mailer: mailer.NewFileMailer(filepath.Join(os.TempDir(), "podsite-mail"), "Podsite <noreply@localhost>"),
+	})
+}
+
+// SetListenerService replaces the service listener accounts are kept in
+func SetListenerService(service *models.ListenerService) {
+	listenerService.Store(service)
+}
+
+// SetListenerSignIn configures the mailer sign-in emails are sent through
+// and the page their links point to
+func SetListenerSignIn(m mailer.Mailer, loginURL string) {
+	signInSettings.Store(&signIn{mailer: m, loginURL: loginURL})
+}
+
+// RunListenerProgressWriter saves playback progress every interval until
+// ctx is done
+func RunListenerProgressWriter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := listenerService.Load().Save(); err != nil {
+				logger.GetLogger().LogError(err, map[string]interface{}{"event": "listener_progress_save"})
+			}
+		}
+	}
+}
+
+// MagicLinkRequest is the body of POST /api/auth/magic-link
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// RequestMagicLink handles POST /api/auth/magic-link
+// @Summary Email a sign-in link
+// @Description Sends a single-use sign-in link, which also contains the token for POST /api/auth/token, to the address. The account is created on first sign-in. At most one email is sent per address each minute; further requests are accepted but send nothing.
+// @Tags listeners
+// @Accept json
+// @Param request body MagicLinkRequest true "Email address"
+// @Success 202
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/magic-link [post]
+func RequestMagicLink(c *gin.Context) {
+	var request MagicLinkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid sign-in request JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	token, err := listenerService.Load().RequestLogin(request.Email, time.Now())
+	switch {
+	case errors.Is(err, models.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "email must be an email address",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	case errors.Is(err, models.ErrLoginThrottled):
+		// Answer as if sent so the response does not reveal recent sign-ins
+		c.Status(http.StatusAccepted)
+		return
+	case err != nil:
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "listener_login"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not start sign-in",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	settings := signInSettings.Load()
+	email, _ := models.NormalizeEmail(request.Email)
+	if err := settings.mailer.Send(c.Request.Context(), signInMessage(email, token, settings.loginURL)); err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "listener_login_mail"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not send the sign-in email",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// signInMessage returns the sign-in email for token
+func signInMessage(email, token, loginURL string) mailer.Message {
+	var body strings.Builder
+	if loginURL != "" {
+		link := loginURL + "?"
+		if strings.Contains(loginURL, "?") {
+			link = loginURL + "&"
+		}
+		fmt.Fprintf(&body, "Follow this link to sign in:\n\n%stoken=%s\n\n", link, url.QueryEscape(token))
+		body.WriteString("Or enter this code where you asked to sign in:\n\n")
+	} else {
+		body.WriteString("Enter this code where you asked to sign in:\n\n")
+	}
+	fmt.Fprintf(&body, "%s\n\nThe code can be used once and expires soon. If you did not ask to sign in, you can ignore this email.\n", token)
+	return mailer.Message{To: email, Subject: "Sign in to continue listening", Body: body.String()}
+}
+
+// LoginTokenRequest is the body of POST /api/auth/token
+type LoginTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// SessionResponse is a new listener session
+type SessionResponse struct {
+	models.Session
+	Listener models.Listener `json:"listener"`
+}
+
+// ExchangeLoginToken handles POST /api/auth/token
+// @Summary Complete sign-in
+// @Description Exchanges the token from a sign-in email for a session token, sent as "Authorization: Bearer <token>" to the /api/me endpoints
+// @Tags listeners
+// @Accept json
+// @Produce json
+// @Param request body LoginTokenRequest true "Sign-in token"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/token [post]
+func ExchangeLoginToken(c *gin.Context) {
+	var request LoginTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Token == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "token is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	session, listener, err := listenerService.Load().CompleteLogin(strings.TrimSpace(request.Token), time.Now())
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidLoginToken) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "The sign-in token is invalid or has expired",
+				Code:    http.StatusUnauthorized,
+			})
+			return
+		}
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "listener_session"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not complete sign-in",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, SessionResponse{Session: *session, Listener: *listener})
+}
+
+// RequireListener returns a Gin middleware that requires a listener session
+// token and stores the listener in the context. Responses are never cached.
+func RequireListener() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "private, no-store")
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		listener, err := listenerService.Load().Authenticate(strings.TrimSpace(token), time.Now())
+		if !strings.EqualFold(scheme, "Bearer") || err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="listener"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "Sign in to continue",
+				Code:    http.StatusUnauthorized,
+			})
+			return
+		}
+
+		c.Set(listenerKey, listener)
+		c.Next()
+	}
+}
+
+// currentListener returns the listener stored by RequireListener
+func currentListener(c *gin.Context) *models.Listener {
+	return c.MustGet(listenerKey).(*models.Listener)
+}
+
+// Logout handles POST /api/auth/logout
+// @Summary Sign out
+// @Description Ends the session whose token authenticates the request
+// @Tags listeners
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	_, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	if err := listenerService.Load().Logout(strings.TrimSpace(token)); err != nil && !errors.Is(err, models.ErrInvalidSession) {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "listener_logout"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not sign out",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetMe handles GET /api/me
+// @Summary Get the signed-in listener
+// @Tags listeners
+// @Produce json
+// @Success 200 {object} models.Listener
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me [get]
+func GetMe(c *gin.Context) {
+	c.JSON(http.StatusOK, currentListener(c))
+}
+
+// ProgressResponse is the response of GET /api/me/progress
+type ProgressResponse struct {
+	Progress []models.Progress `json:"progress"`
+	// ContinueListening lists the started, unfinished episodes that can
+	// still be played, most recently played first
+	ContinueListening []models.ListeningEpisode `json:"continueListening"`
+}
+
+// GetMyProgress handles GET /api/me/progress
+// @Summary Get playback progress
+// @Description Returns the listener's position in every episode they played and the episodes to continue listening to
+// @Tags listeners
+// @Produce json
+// @Success 200 {object} ProgressResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/progress [get]
+func GetMyProgress(c *gin.Context) {
+	progress := listenerService.Load().GetProgress(currentListener(c).ID)
+	continueListening := episodeService.Load().ContinueListening(progress, publishingNow())
+	for i := range continueListening {
+		peopleService.Load().AttachTo(&continueListening[i].Episode)
+	}
+	c.JSON(http.StatusOK, ProgressResponse{Progress: progress, ContinueListening: continueListening})
+}
+
+// ProgressRequest is the body of PUT /api/me/progress/:episodeId
+type ProgressRequest struct {
+	// Position is the playback position in seconds
+	Position  *float64 `json:"position"`
+	Completed bool     `json:"completed"`
+}
+
+// PutMyProgress handles PUT /api/me/progress/:episodeId
+// @Summary Save playback progress
+// @Description Stores the listener's position in an episode. Positions past the end are clamped to the duration, and an episode with less than 30 seconds left is marked completed and removed from the "up next" queue.
+// @Tags listeners
+// @Accept json
+// @Produce json
+// @Param episodeId path string true "Episode ID, slug, number or sNeN"
+// @Param progress body ProgressRequest true "Playback position"
+// @Success 200 {object} models.Progress
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/progress/{episodeId} [put]
+func PutMyProgress(c *gin.Context) {
+	var request ProgressRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Position == nil ||
+		*request.Position < 0 || math.IsNaN(*request.Position) || math.IsInf(*request.Position, 0) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "position must be a number of seconds, at least 0",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	episode, _, err := episodeService.Load().Resolve(c.Param("episodeId"))
+	if err != nil || !episode.IsReachable(publishingNow()) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	position := *request.Position
+	completed := request.Completed
+	if duration := float64(episode.DurationSeconds); duration > 0 {
+		position = math.Min(position, duration)
+		completed = completed || duration-position < completedRemaining
+	}
+
+	progress, err := listenerService.Load().SetProgress(currentListener(c).ID, models.Progress{
+		EpisodeID: episode.ID,
+		Position:  position,
+		Completed: completed,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":      "listener_progress",
+			"episode_id": episode.ID,
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not save progress",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	// A finished episode is no longer up next
+	if completed {
+		_, err := playlistService.Load().RemoveEpisode(currentListener(c).ID, models.QueueID, episode.ID, time.Now())
+		if err != nil && !errors.Is(err, models.ErrEpisodeNotFound) {
+			logger.GetLogger().LogError(err, map[string]interface{}{
+				"event":      "listener_queue",
+				"episode_id": episode.ID,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, progress)
+}
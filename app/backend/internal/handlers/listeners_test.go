@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/mailer"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer keeps the messages it is asked to send
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func setupListenersTestRouter(t *testing.T) (*gin.Engine, *recordingMailer) {
+	gin.SetMode(gin.TestMode)
+
+	useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "One", Duration: models.Duration(20 * time.Minute), AudioURL: "/1.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Two", Duration: models.Duration(30 * time.Minute), AudioURL: "/2.mp3", PublishDate: "2024-01-02"},
+		{ID: "ep003", Number: 3, Title: "Draft", AudioURL: "/3.mp3", Status: models.StatusDraft},
+	})
+
+	previousListeners := listenerService.Load()
+	SetListenerService(models.NewListenerService(time.Minute, time.Hour))
+	t.Cleanup(func() { SetListenerService(previousListeners) })
+
+	previousPlaylists := playlistService.Load()
+	SetPlaylistService(models.NewPlaylistService())
+	t.Cleanup(func() { SetPlaylistService(previousPlaylists) })
+
+	previousURL := publicURL.Load()
+	SetPublicURL("https://podsite.example/")
+	t.Cleanup(func() { publicURL.Store(previousURL) })
+
+	mail := &recordingMailer{}
+	previousSignIn := signInSettings.Load()
+	SetListenerSignIn(mail, "https://example.com/sign-in")
+	t.Cleanup(func() { signInSettings.Store(previousSignIn) })
+
+	router := gin.New()
+	router.POST("/api/auth/magic-link", RequestMagicLink)
+	router.POST("/api/auth/token", ExchangeLoginToken)
+	router.POST("/api/auth/logout", RequireListener(), Logout)
+	me := router.Group("/api/me", RequireListener())
+	me.GET("", GetMe)
+	me.GET("/progress", GetMyProgress)
+	me.PUT("/progress/:episodeId", PutMyProgress)
+	me.GET("/playlists", GetMyPlaylists)
+	me.POST("/playlists", CreateMyPlaylist)
+	me.GET("/playlists/:id", GetMyPlaylist)
+	me.PUT("/playlists/:id", UpdateMyPlaylist)
+	me.DELETE("/playlists/:id", DeleteMyPlaylist)
+	me.POST("/playlists/:id/episodes", AddMyPlaylistEpisode)
+	me.PUT("/playlists/:id/episodes", SetMyPlaylistEpisodes)
+	me.DELETE("/playlists/:id/episodes/:episodeId", RemoveMyPlaylistEpisode)
+	me.POST("/playlists/:id/share", ShareMyPlaylist)
+	me.DELETE("/playlists/:id/share", UnshareMyPlaylist)
+	me.POST("/playlists/:id/feed", EnableMyPlaylistFeed)
+	me.DELETE("/playlists/:id/feed", DisableMyPlaylistFeed)
+	router.GET("/api/playlists/:shareId", GetSharedPlaylist)
+	router.GET("/feeds/playlists/:token", GetPlaylistFeed)
+	return router, mail
+}
+
+// signInListener completes sign-in for email and returns the session token
+func signInListener(t *testing.T, router *gin.Engine, mail *recordingMailer, email string) string {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/auth/magic-link", bytes.NewBufferString(`{"email":"`+email+`"}`)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotEmpty(t, mail.sent)
+
+	message := mail.sent[len(mail.sent)-1]
+	assert.Equal(t, email, message.To)
+	match := regexp.MustCompile(`https://example\.com/sign-in\?token=([0-9a-f]+)`).FindStringSubmatch(message.Body)
+	require.Len(t, match, 2, message.Body)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/auth/token", bytes.NewBufferString(`{"token":"`+match[1]+`"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var session SessionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+	assert.Equal(t, email, session.Listener.Email)
+	require.NotEmpty(t, session.Token)
+	return session.Token
+}
+
+func TestListenerProgressEndpoints(t *testing.T) {
+	router, mail := setupListenersTestRouter(t)
+	token := signInListener(t, router, mail, "jane@example.com")
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("GET", "/api/me", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+
+	w = request("PUT", "/api/me/progress/ep001", `{"position": 95.5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var progress models.Progress
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+	assert.Equal(t, 95.5, progress.Position)
+	assert.False(t, progress.Completed)
+
+	// Close to the end counts as finished, and positions are clamped
+	w = request("PUT", "/api/me/progress/2", `{"position": 5000}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+	assert.Equal(t, "ep002", progress.EpisodeID)
+	assert.Equal(t, 1800.0, progress.Position)
+	assert.True(t, progress.Completed)
+
+	w = request("GET", "/api/me/progress", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var response ProgressResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Progress, 2)
+	require.Len(t, response.ContinueListening, 1)
+	assert.Equal(t, "ep001", response.ContinueListening[0].ID)
+	assert.Equal(t, 95.5, response.ContinueListening[0].Progress.Position)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected int
+	}{
+		{"missing position", "/api/me/progress/ep001", `{"completed": true}`, http.StatusBadRequest},
+		{"negative position", "/api/me/progress/ep001", `{"position": -3}`, http.StatusBadRequest},
+		{"draft episode", "/api/me/progress/ep003", `{"position": 10}`, http.StatusNotFound},
+		{"unknown episode", "/api/me/progress/ep999", `{"position": 10}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, request("PUT", tt.path, tt.body).Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("POST", "/api/auth/logout", "").Code)
+	w = request("GET", "/api/me/progress", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="listener"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestListenerSignInErrors(t *testing.T) {
+	router, mail := setupListenersTestRouter(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected int
+	}{
+		{"invalid email", "/api/auth/magic-link", `{"email":"nobody"}`, http.StatusBadRequest},
+		{"malformed JSON", "/api/auth/magic-link", `{`, http.StatusBadRequest},
+		{"missing token", "/api/auth/token", `{}`, http.StatusBadRequest},
+		{"unknown token", "/api/auth/token", `{"token":"abc"}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+
+	// Repeated requests are accepted without sending another email
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/auth/magic-link", bytes.NewBufferString(`{"email":"sam@example.com"}`)))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	}
+	assert.Len(t, mail.sent, 1)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/me", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+)
+
+// peopleService holds the hosts and guests credited on episodes
+var peopleService atomic.Pointer[models.PeopleService]
+
+func init() {
+	peopleService.Store(models.NewPeopleService())
+}
+
+// SetPeopleService replaces the people service used by the handlers
+func SetPeopleService(service *models.PeopleService) {
+	peopleService.Store(service)
+}
+
+// Appearance is a published episode a person is credited on
+type Appearance struct {
+	EpisodeID     string            `json:"episodeId"`
+	Slug          string            `json:"slug,omitempty"`
+	Number        int               `json:"number"`
+	Season        int               `json:"season,omitempty"`
+	SeasonEpisode int               `json:"seasonEpisode,omitempty"`
+	Title         string            `json:"title"`
+	PublishDate   models.Date       `json:"publishDate"`
+	Role          models.PersonRole `json:"role,omitempty"`
+}
+
+// PersonResponse is a person with their episode appearances, newest first
+type PersonResponse struct {
+	models.Person
+	Appearances []Appearance `json:"appearances"`
+}
+
+// GetPeople handles GET /api/people
+// @Summary Get hosts and guests
+// @Description Returns every person sorted by name, optionally only those with a usual role
+// @Tags people
+// @Produce json
+// @Param role query string false "host, co-host, guest or producer"
+// @Success 200 {array} models.Person
+// @Router /people [get]
+func GetPeople(c *gin.Context) {
+	c.JSON(http.StatusOK, peopleService.Load().GetAll(models.PersonRole(c.Query("role"))))
+}
+
+// GetPersonByID handles GET /api/people/:id
+// @Summary Get a person
+// @Description Returns a person with the published episodes they appear on, newest first
+// @Tags people
+// @Param id path string true "Person ID"
+// @Produce json
+// @Success 200 {object} PersonResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /people/{id} [get]
+func GetPersonByID(c *gin.Context) {
+	people := peopleService.Load()
+	person, err := people.GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Person not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	appearances := []Appearance{}
+	episodes := episodeService.Load().GetPublished(publishingNow())
+	people.Attach(episodes)
+	for _, episode := range episodes {
+		for _, credit := range episode.People {
+			if credit.PersonID != person.ID {
+				continue
+			}
+			appearances = append(appearances, Appearance{
+				EpisodeID:     episode.ID,
+				Slug:          episode.Slug,
+				Number:        episode.Number,
+				Season:        episode.Season,
+				SeasonEpisode: episode.SeasonEpisode,
+				Title:         episode.Title,
+				PublishDate:   episode.PublishDate,
+				Role:          credit.Role,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, PersonResponse{Person: *person, Appearances: appearances})
+}
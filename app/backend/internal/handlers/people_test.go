@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPeopleTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "pilot", Number: 1, Title: "Pilot", AudioURL: "/a.mp3", PublishDate: "2024-01-01",
+			People: []models.EpisodeCredit{{PersonID: "ada"}}},
+		{ID: "interview", Number: 2, Title: "Interview", AudioURL: "/a.mp3", PublishDate: "2024-02-01",
+			People: []models.EpisodeCredit{{PersonID: "ada"}, {PersonID: "grace", Role: models.PersonGuest}}},
+		{ID: "unaired", Number: 3, Title: "Unaired", AudioURL: "/a.mp3", Status: models.StatusDraft,
+			People: []models.EpisodeCredit{{PersonID: "grace"}}},
+	})
+	people, err := json.Marshal([]models.Person{
+		{ID: "ada", Name: "Ada", AvatarURL: "/ada.jpg", Role: models.PersonHost},
+		{ID: "grace", Name: "Grace"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "people.json"), people, 0o644))
+
+	peopleSvc, err := models.LoadPeopleService(dir)
+	require.NoError(t, err)
+
+	previousPeople := peopleService.Load()
+	SetPeopleService(peopleSvc)
+	t.Cleanup(func() { SetPeopleService(previousPeople) })
+
+	previousAudit := auditService.Load()
+	SetAuditService(models.NewAuditService())
+	t.Cleanup(func() { SetAuditService(previousAudit) })
+
+	router := gin.New()
+	router.GET("/api/episodes/:id", GetEpisodeByID)
+	router.GET("/api/people", GetPeople)
+	router.GET("/api/people/:id", GetPersonByID)
+	router.POST("/api/admin/episodes", CreateEpisode)
+	return router
+}
+
+func TestGetPeople(t *testing.T) {
+	router := setupPeopleTestRouter(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		expectedIDs []string
+	}{
+		{"all", "/api/people", []string{"ada", "grace"}},
+		{"hosts", "/api/people?role=host", []string{"ada"}},
+		{"producers", "/api/people?role=producer", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var people []models.Person
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &people))
+			ids := []string{}
+			for _, person := range people {
+				ids = append(ids, person.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestGetPersonByID(t *testing.T) {
+	router := setupPeopleTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/people/grace", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var person PersonResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &person))
+	assert.Equal(t, "Grace", person.Name)
+	require.Len(t, person.Appearances, 1, "drafts are not appearances")
+	assert.Equal(t, "interview", person.Appearances[0].EpisodeID)
+	assert.Equal(t, models.PersonGuest, person.Appearances[0].Role)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/people/nobody", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestEpisodeResponsesIncludePeople(t *testing.T) {
+	router := setupPeopleTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/interview", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var episode models.Episode
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &episode))
+	require.Len(t, episode.People, 2)
+	assert.Equal(t, models.EpisodeCredit{PersonID: "ada", Role: models.PersonHost, Name: "Ada", AvatarURL: "/ada.jpg"}, episode.People[0])
+	assert.Equal(t, "Grace", episode.People[1].Name)
+}
+
+func TestCreateEpisodeRejectsUnknownPeople(t *testing.T) {
+	router := setupPeopleTestRouter(t)
+
+	body := `{"id":"ep004","number":4,"title":"New","audioUrl":"/a.mp3","people":[{"personId":"nobody"}]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/episodes", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
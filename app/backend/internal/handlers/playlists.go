@@ -0,0 +1,581 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+var (
+	// playlistService keeps listener playlists and queues
+	playlistService atomic.Pointer[models.PlaylistService]
+	// publicURL is the scheme and host playlist feed links are built from
+	publicURL atomic.Pointer[string]
+)
+
+func init() {
+	playlistService.Store(models.NewPlaylistService())
+	SetPublicURL("http://localhost:3001")
+}
+
+// SetPlaylistService replaces the service playlists are kept in
+func SetPlaylistService(service *models.PlaylistService) {
+	playlistService.Store(service)
+}
+
+// SetPublicURL sets the scheme and host the site is reached at. Feeds need
+// absolute links, which are not built from the request's Host header.
+func SetPublicURL(url string) {
+	url = strings.TrimRight(url, "/")
+	publicURL.Store(&url)
+}
+
+// PlaylistResponse is a listener's playlist with the links it is available
+// under. Episodes is only filled in for a single playlist, and FeedURL only
+// when the feed is enabled, as its token is not kept.
+type PlaylistResponse struct {
+	models.Playlist
+	ShareURL string           `json:"shareUrl,omitempty"`
+	FeedURL  string           `json:"feedUrl,omitempty"`
+	Episodes []models.Episode `json:"episodes,omitempty"`
+}
+
+// playlistResponse returns playlist with its links, and its episodes when
+// withEpisodes is set
+func playlistResponse(c *gin.Context, playlist *models.Playlist, withEpisodes bool) PlaylistResponse {
+	response := PlaylistResponse{Playlist: *playlist}
+	if playlist.ShareID != "" {
+		response.ShareURL = "/api/playlists/" + playlist.ShareID
+	}
+	if withEpisodes {
+		response.Episodes = playlistEpisodes(playlist)
+	}
+	return response
+}
+
+// playlistEpisodes returns the playlist's episodes that are still
+// reachable, in order, with their credits
+func playlistEpisodes(playlist *models.Playlist) []models.Episode {
+	now := publishingNow()
+	episodes := []models.Episode{}
+	for _, id := range playlist.EpisodeIDs {
+		episode, err := episodeService.Load().GetByID(id)
+		if err != nil || !episode.IsReachable(now) {
+			continue
+		}
+		peopleService.Load().AttachTo(episode)
+		episodes = append(episodes, *episode)
+	}
+	return episodes
+}
+
+// respondPlaylistError writes the response for an error from PlaylistService
+func respondPlaylistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrPlaylistNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Playlist not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, models.ErrEpisodeNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not in playlist",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, models.ErrPlaylistInvalid):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	default:
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":       "playlist_save",
+			"playlist_id": c.Param("id"),
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not save playlists",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
+
+// resolvePlayableEpisode returns the ID of the episode ref refers to, or
+// responds with 404 when it is not published
+func resolvePlayableEpisode(c *gin.Context, ref string) (string, bool) {
+	episode, _, err := episodeService.Load().Resolve(ref)
+	if err != nil || !episode.IsReachable(publishingNow()) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode " + ref + " not found",
+			Code:    http.StatusNotFound,
+		})
+		return "", false
+	}
+	return episode.ID, true
+}
+
+// GetMyPlaylists handles GET /api/me/playlists
+// @Summary List playlists
+// @Description Returns the listener's playlists, starting with the "up next" queue (id queue), without their episodes
+// @Tags playlists
+// @Produce json
+// @Success 200 {array} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists [get]
+func GetMyPlaylists(c *gin.Context) {
+	playlists := playlistService.Load().List(currentListener(c).ID)
+	response := make([]PlaylistResponse, len(playlists))
+	for i := range playlists {
+		response[i] = playlistResponse(c, &playlists[i], false)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyPlaylist handles GET /api/me/playlists/:id
+// @Summary Get a playlist
+// @Description Returns one of the listener's playlists, or the queue, with its published episodes in order
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id} [get]
+func GetMyPlaylist(c *gin.Context) {
+	playlist, err := playlistService.Load().Get(currentListener(c).ID, c.Param("id"))
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// PlaylistRequest is the body of POST /api/me/playlists and
+// PUT /api/me/playlists/:id
+type PlaylistRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CreateMyPlaylist handles POST /api/me/playlists
+// @Summary Create a playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param playlist body PlaylistRequest true "Name and description"
+// @Success 201 {object} PlaylistResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists [post]
+func CreateMyPlaylist(c *gin.Context) {
+	var request PlaylistRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid playlist JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	playlist, err := playlistService.Load().Create(currentListener(c).ID, request.Name, request.Description, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, playlistResponse(c, playlist, true))
+}
+
+// UpdateMyPlaylist handles PUT /api/me/playlists/:id
+// @Summary Rename a playlist
+// @Description Replaces the playlist's name and description. The queue keeps its name.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Param playlist body PlaylistRequest true "Name and description"
+// @Success 200 {object} PlaylistResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id} [put]
+func UpdateMyPlaylist(c *gin.Context) {
+	var request PlaylistRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Invalid playlist JSON",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	playlist, err := playlistService.Load().Update(currentListener(c).ID, c.Param("id"), request.Name, request.Description, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// DeleteMyPlaylist handles DELETE /api/me/playlists/:id
+// @Summary Delete a playlist
+// @Description Deletes the playlist, its share link and its feed. The queue cannot be deleted; clear it with PUT /api/me/playlists/queue/episodes instead.
+// @Tags playlists
+// @Param id path string true "Playlist ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id} [delete]
+func DeleteMyPlaylist(c *gin.Context) {
+	if err := playlistService.Load().Delete(currentListener(c).ID, c.Param("id")); err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PlaylistEpisodeRequest is the body of POST /api/me/playlists/:id/episodes
+type PlaylistEpisodeRequest struct {
+	EpisodeID string `json:"episodeId"`
+	// Position is the zero-based index to insert at; the end when omitted
+	Position *int `json:"position"`
+}
+
+// AddMyPlaylistEpisode handles POST /api/me/playlists/:id/episodes
+// @Summary Add or move an episode
+// @Description Inserts a published episode at position, or appends it. An episode already in the playlist is moved there.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Param episode body PlaylistEpisodeRequest true "Episode ID, slug, number or sNeN, and position"
+// @Success 200 {object} PlaylistResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/episodes [post]
+func AddMyPlaylistEpisode(c *gin.Context) {
+	var request PlaylistEpisodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.EpisodeID == "" || (request.Position != nil && *request.Position < 0) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "episodeId is required and position must not be negative",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	episodeID, ok := resolvePlayableEpisode(c, request.EpisodeID)
+	if !ok {
+		return
+	}
+	position := -1
+	if request.Position != nil {
+		position = *request.Position
+	}
+
+	playlist, err := playlistService.Load().AddEpisode(currentListener(c).ID, c.Param("id"), episodeID, position, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// RemoveMyPlaylistEpisode handles DELETE /api/me/playlists/:id/episodes/:episodeId
+// @Summary Remove an episode
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Param episodeId path string true "Episode ID"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/episodes/{episodeId} [delete]
+func RemoveMyPlaylistEpisode(c *gin.Context) {
+	// Unpublished episodes can still be removed, so the ID is not resolved
+	// unless it names a current episode
+	episodeID := c.Param("episodeId")
+	if episode, _, err := episodeService.Load().Resolve(episodeID); err == nil {
+		episodeID = episode.ID
+	}
+
+	playlist, err := playlistService.Load().RemoveEpisode(currentListener(c).ID, c.Param("id"), episodeID, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// PlaylistEpisodesRequest is the body of PUT /api/me/playlists/:id/episodes
+type PlaylistEpisodesRequest struct {
+	EpisodeIDs []string `json:"episodeIds"`
+}
+
+// SetMyPlaylistEpisodes handles PUT /api/me/playlists/:id/episodes
+// @Summary Reorder or replace episodes
+// @Description Replaces the playlist's episodes with the given list, in order. An empty list clears the playlist.
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Param episodes body PlaylistEpisodesRequest true "Episode IDs, slugs, numbers or sNeN"
+// @Success 200 {object} PlaylistResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/episodes [put]
+func SetMyPlaylistEpisodes(c *gin.Context) {
+	var request PlaylistEpisodesRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.EpisodeIDs == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "episodeIds must be a list",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	owner := currentListener(c).ID
+	current, err := playlistService.Load().Get(owner, c.Param("id"))
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	kept := make(map[string]bool, len(current.EpisodeIDs))
+	for _, id := range current.EpisodeIDs {
+		kept[id] = true
+	}
+
+	ids := make([]string, 0, len(request.EpisodeIDs))
+	for _, ref := range request.EpisodeIDs {
+		// Episodes already in the playlist may be kept even when they are no
+		// longer published, so a reorder does not fail
+		if kept[ref] {
+			ids = append(ids, ref)
+			continue
+		}
+		id, ok := resolvePlayableEpisode(c, ref)
+		if !ok {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	playlist, err := playlistService.Load().SetEpisodes(owner, c.Param("id"), ids, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// ShareMyPlaylist handles POST /api/me/playlists/:id/share
+// @Summary Share a playlist
+// @Description Makes the playlist public at shareUrl. Sharing again replaces the link, so the old one stops working.
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/share [post]
+func ShareMyPlaylist(c *gin.Context) {
+	setPlaylistLink(c, playlistService.Load().Share, true)
+}
+
+// UnshareMyPlaylist handles DELETE /api/me/playlists/:id/share
+// @Summary Stop sharing a playlist
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/share [delete]
+func UnshareMyPlaylist(c *gin.Context) {
+	setPlaylistLink(c, playlistService.Load().Share, false)
+}
+
+// EnableMyPlaylistFeed handles POST /api/me/playlists/:id/feed
+// @Summary Enable a playlist's private feed
+// @Description Returns feedUrl, a private RSS feed of the playlist for any podcast app. Anyone with the URL can read the feed. The URL is only returned here; enabling the feed again replaces it, so the old one stops working.
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/feed [post]
+func EnableMyPlaylistFeed(c *gin.Context) {
+	playlist, token, err := playlistService.Load().SetFeed(currentListener(c).ID, c.Param("id"), true, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	response := playlistResponse(c, playlist, true)
+	// Podcast apps need an absolute URL
+	response.FeedURL = *publicURL.Load() + "/feeds/playlists/" + token
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableMyPlaylistFeed handles DELETE /api/me/playlists/:id/feed
+// @Summary Disable a playlist's private feed
+// @Tags playlists
+// @Produce json
+// @Param id path string true "Playlist ID, or queue"
+// @Success 200 {object} PlaylistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/playlists/{id}/feed [delete]
+func DisableMyPlaylistFeed(c *gin.Context) {
+	playlist, _, err := playlistService.Load().SetFeed(currentListener(c).ID, c.Param("id"), false, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// setPlaylistLink enables or disables a share link through set
+func setPlaylistLink(c *gin.Context, set func(owner, id string, enabled bool, now time.Time) (*models.Playlist, error), enabled bool) {
+	playlist, err := set(currentListener(c).ID, c.Param("id"), enabled, time.Now())
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, playlistResponse(c, playlist, true))
+}
+
+// SharedPlaylistResponse is a publicly shared playlist
+type SharedPlaylistResponse struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Episodes    []models.Episode `json:"episodes"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+// GetSharedPlaylist handles GET /api/playlists/:shareId
+// @Summary Get a shared playlist
+// @Description Returns a playlist a listener shared, with its published episodes in order
+// @Tags playlists
+// @Produce json
+// @Param shareId path string true "Share ID from shareUrl"
+// @Success 200 {object} SharedPlaylistResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /playlists/{shareId} [get]
+func GetSharedPlaylist(c *gin.Context) {
+	playlist, err := playlistService.Load().GetShared(c.Param("shareId"))
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, SharedPlaylistResponse{
+		Name:        playlist.Name,
+		Description: playlist.Description,
+		Episodes:    playlistEpisodes(playlist),
+		UpdatedAt:   playlist.UpdatedAt,
+	})
+}
+
+// GetPlaylistFeed handles GET /feeds/playlists/:token
+// @Summary Get a playlist feed
+// @Description Returns the playlist as an RSS feed with podcast:person, podcast:transcript and podcast:chapters tags. Enclosures point at /media/audio/:id, so plays from the feed are counted as downloads.
+// @Tags playlists
+// @Produce xml
+// @Param token path string true "Feed token from feedUrl"
+// @Success 200
+// @Failure 404 {object} ErrorResponse
+// @Router /feeds/playlists/{token} [get]
+func GetPlaylistFeed(c *gin.Context) {
+	playlist, err := playlistService.Load().GetByFeedToken(c.Param("token"))
+	if err != nil {
+		respondPlaylistError(c, err)
+		return
+	}
+
+	base := *publicURL.Load()
+	loc := publishingNow().Location()
+	channel := models.FeedChannel{
+		Title:       playlist.Name,
+		Link:        base,
+		Description: playlist.Description,
+		Generator:   "Podsite",
+		Block:       "Yes",
+	}
+	if channel.Description == "" {
+		channel.Description = playlist.Name
+	}
+	for _, episode := range playlistEpisodes(playlist) {
+		item := models.NewFeedItem(&episode, base+"/media/audio/"+episode.ID, loc)
+		if episode.ArtworkURL != "" {
+			item.Image = &models.FeedImage{Href: absoluteURL(base, episode.ArtworkURL)}
+		}
+		for i := range item.People {
+			item.People[i].Img = absoluteURL(base, item.People[i].Img)
+		}
+		if _, err := transcriptService.Load().Get(episode.ID); err == nil {
+			transcriptURL := base + "/api/episodes/" + episode.ID + "/transcript"
+			item.Transcripts = []models.FeedTranscript{
+				{URL: transcriptURL + "?format=vtt", Type: models.TranscriptVTT.ContentType()},
+				{URL: transcriptURL + "?format=json", Type: models.TranscriptJSON.ContentType()},
+			}
+		}
+		if _, err := chapterService.Load().Get(episode.ID); err == nil {
+			item.Chapters = &models.FeedChapters{
+				URL:  base + "/api/episodes/" + episode.ID + "/chapters",
+				Type: models.ChaptersContentType,
+			}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	var buf bytes.Buffer
+	if err := models.NewFeed(channel).Encode(&buf); err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{"event": "playlist_feed"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not render the feed",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	// The URL is a secret, so the feed stays out of shared caches
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, models.FeedContentType, buf.Bytes())
+}
+
+// absoluteURL resolves a site-relative URL such as /assets/images/ep1.jpg
+// against base; empty and absolute URLs are returned unchanged
+func absoluteURL(base, value string) string {
+	if len(value) > 0 && value[0] == '/' && !(len(value) > 1 && value[1] == '/') {
+		return base + value
+	}
+	return value
+}
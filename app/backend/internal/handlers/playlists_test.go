@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaylistEndpoints(t *testing.T) {
+	router, mail := setupListenersTestRouter(t)
+	token := signInListener(t, router, mail, "jane@example.com")
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) PlaylistResponse {
+		var playlist PlaylistResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+		return playlist
+	}
+
+	w := request("GET", "/api/me/playlists", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var playlists []PlaylistResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlists))
+	require.Len(t, playlists, 1)
+	assert.Equal(t, models.QueueID, playlists[0].ID)
+
+	w = request("POST", "/api/me/playlists", `{"name": "Road trip"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	playlist := decode(w)
+	assert.Equal(t, "Road trip", playlist.Name)
+	path := "/api/me/playlists/" + playlist.ID
+
+	require.Equal(t, http.StatusOK, request("POST", path+"/episodes", `{"episodeId": "ep001"}`).Code)
+	w = request("POST", path+"/episodes", `{"episodeId": "2", "position": 0}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	playlist = decode(w)
+	assert.Equal(t, []string{"ep002", "ep001"}, playlist.EpisodeIDs)
+	require.Len(t, playlist.Episodes, 2)
+	assert.Equal(t, "Two", playlist.Episodes[0].Title)
+
+	w = request("PUT", path+"/episodes", `{"episodeIds": ["ep001", "ep002"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"ep001", "ep002"}, decode(w).EpisodeIDs)
+
+	w = request("DELETE", path+"/episodes/ep001", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"ep002"}, decode(w).EpisodeIDs)
+
+	// Finishing an episode takes it out of the queue
+	require.Equal(t, http.StatusOK, request("POST", "/api/me/playlists/queue/episodes", `{"episodeId": "ep002"}`).Code)
+	require.Equal(t, http.StatusOK, request("PUT", "/api/me/progress/ep002", `{"position": 1790}`).Code)
+	w = request("GET", "/api/me/playlists/queue", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, decode(w).EpisodeIDs)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"blank name", "POST", "/api/me/playlists", `{"name": " "}`, http.StatusBadRequest},
+		{"unknown playlist", "GET", "/api/me/playlists/nope", "", http.StatusNotFound},
+		{"draft episode", "POST", path + "/episodes", `{"episodeId": "ep003"}`, http.StatusNotFound},
+		{"negative position", "POST", path + "/episodes", `{"episodeId": "ep001", "position": -1}`, http.StatusBadRequest},
+		{"duplicate episodes", "PUT", path + "/episodes", `{"episodeIds": ["ep001", "ep001"]}`, http.StatusBadRequest},
+		{"episode not in playlist", "DELETE", path + "/episodes/ep001", "", http.StatusNotFound},
+		{"delete queue", "DELETE", "/api/me/playlists/queue", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, request(tt.method, tt.path, tt.body).Code)
+		})
+	}
+
+	// Other listeners cannot see the playlist
+	other := signInListener(t, router, mail, "sam@example.com")
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+other)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Equal(t, http.StatusNoContent, request("DELETE", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, request("GET", path, "").Code)
+}
+
+func TestPlaylistLinksEndpoints(t *testing.T) {
+	router, mail := setupListenersTestRouter(t)
+	token := signInListener(t, router, mail, "jane@example.com")
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("POST", "/api/me/playlists", `{"name": "Favourites & friends", "description": "The good ones"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var playlist PlaylistResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+	path := "/api/me/playlists/" + playlist.ID
+	require.Equal(t, http.StatusOK, request("PUT", path+"/episodes", `{"episodeIds": ["ep001", "ep002"]}`).Code)
+
+	w = request("POST", path+"/share", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+	require.NotEmpty(t, playlist.ShareURL)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", playlist.ShareURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var shared SharedPlaylistResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(t, "Favourites & friends", shared.Name)
+	assert.Len(t, shared.Episodes, 2)
+	assert.NotContains(t, w.Body.String(), "jane@example.com")
+
+	w = request("POST", path+"/feed", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &playlist))
+	require.True(t, strings.HasPrefix(playlist.FeedURL, "https://podsite.example/feeds/playlists/"), playlist.FeedURL)
+	feedPath := strings.TrimPrefix(playlist.FeedURL, "https://podsite.example")
+
+	// The feed URL is only returned when the feed is enabled
+	w = request("GET", path, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), feedPath)
+
+	// Links are built from the public URL, not the request's Host header
+	req := httptest.NewRequest("GET", feedPath, nil)
+	req.Host = "attacker.example"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.FeedContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "private, max-age=300", w.Header().Get("Cache-Control"))
+	body := w.Body.String()
+	assert.Contains(t, body, `xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`)
+	assert.Contains(t, body, `<title>Favourites &amp; friends</title>`)
+	assert.Contains(t, body, `<itunes:block>Yes</itunes:block>`)
+	assert.Contains(t, body, `<enclosure url="https://podsite.example/media/audio/ep001"`)
+	assert.Less(t, strings.Index(body, "ep001"), strings.Index(body, "ep002"))
+
+	// Turning links off makes them unreachable
+	require.Equal(t, http.StatusOK, request("DELETE", path+"/share", "").Code)
+	require.Equal(t, http.StatusOK, request("DELETE", path+"/feed", "").Code)
+	for _, link := range []string{playlist.ShareURL, feedPath} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", link, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, link)
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/auth"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// Limits for preview tokens minted by POST /api/admin/episodes/:id/preview
+const (
+	defaultPreviewTTL = 24 * time.Hour
+	maxPreviewTTL     = 30 * 24 * time.Hour
+)
+
+// SchedulerActor is the audit actor for episodes published by the scheduler
+const SchedulerActor = "system:scheduler"
+
+// publishing holds the timezone used to interpret publish dates and the
+// signer for preview tokens
+type publishing struct {
+	location   *time.Location
+	signer     *auth.PreviewSigner
+	previewTTL time.Duration
+}
+
+var publishingSettings atomic.Pointer[publishing]
+
+func init() {
+	// Without a configured secret, preview links only survive until restart
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	signer, _ := auth.NewPreviewSigner(secret)
+	publishingSettings.Store(&publishing{location: time.UTC, signer: signer, previewTTL: defaultPreviewTTL})
+}
+
+// SetPublishing configures the publishing timezone, the preview token signer
+// and the default preview token lifetime. A nil signer keeps the current one.
+func SetPublishing(location *time.Location, signer *auth.PreviewSigner, previewTTL time.Duration) {
+	current := publishingSettings.Load()
+	if signer == nil {
+		signer = current.signer
+	}
+	if previewTTL <= 0 {
+		previewTTL = defaultPreviewTTL
+	}
+	publishingSettings.Store(&publishing{location: location, signer: signer, previewTTL: previewTTL})
+}
+
+// publishingNow returns the current time in the publishing timezone, which
+// the episode model uses to read date-only publish dates
+func publishingNow() time.Time {
+	return time.Now().In(publishingSettings.Load().location)
+}
+
+// PreviewResponse is the response of POST /api/admin/episodes/:id/preview
+type PreviewResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateEpisodePreview handles POST /api/admin/episodes/:id/preview
+// @Summary Create a preview link
+// @Description Issues a signed token that shows the episode, whatever its status, until it expires. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Episode ID"
+// @Param ttl query string false "Token lifetime as a Go duration (default 24h, max 720h)"
+// @Success 201 {object} PreviewResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id}/preview [post]
+func CreateEpisodePreview(c *gin.Context) {
+	settings := publishingSettings.Load()
+
+	ttl := settings.previewTTL
+	if value := c.Query("ttl"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxPreviewTTL {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "bad_request",
+				Message: "ttl must be a duration between 1s and 720h",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		ttl = parsed
+	}
+
+	episode, err := episodeService.Load().GetByID(c.Param("id"))
+	if err != nil {
+		respondEpisodeMutationError(c, models.ErrEpisodeNotFound)
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second).UTC()
+	token := settings.signer.Sign(episode.ID, expiresAt)
+
+	setAuditDetails(c, "episode.preview", "episode", episode.ID, nil, nil)
+	c.JSON(http.StatusCreated, PreviewResponse{
+		Token:     token,
+		URL:       "/api/episodes/" + episode.ID + "?preview=" + url.QueryEscape(token),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// hasValidPreview reports whether the request carries a preview token for episodeID
+func hasValidPreview(c *gin.Context, episodeID string) bool {
+	token := c.Query("preview")
+	if token == "" {
+		return false
+	}
+	return publishingSettings.Load().signer.Verify(token, episodeID, time.Now()) == nil
+}
+
+// GetAdminEpisodes handles GET /api/admin/episodes
+// @Summary List every episode
+// @Description Returns all episodes, including drafts, scheduled and unlisted ones. Requires the editor role.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Episode
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes [get]
+func GetAdminEpisodes(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-store")
+	episodes := episodeService.Load().GetAll()
+	peopleService.Load().Attach(episodes)
+	c.JSON(http.StatusOK, episodes)
+}
+
+// PublishDueEpisodes publishes scheduled episodes whose time has come and
+// purges cached responses when the public listing or featured episode
+// changed since last.
+// It returns the episodes that went live.
+func PublishDueEpisodes(last, now time.Time) ([]models.Episode, error) {
+	service := episodeService.Load()
+
+	published, err := service.PublishDue(now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range published {
+		if _, err := auditService.Load().Record(models.AuditEntry{
+			Actor:      SchedulerActor,
+			Action:     "episode.publish",
+			TargetType: "episode",
+			TargetID:   published[i].ID,
+			Changes: []models.FieldChange{{
+				Field:  "status",
+				Before: string(models.StatusScheduled),
+				After:  string(models.StatusPublished),
+			}},
+		}); err != nil {
+			logger.GetLogger().LogError(err, map[string]interface{}{
+				"event":     "audit_record",
+				"actor":     SchedulerActor,
+				"target_id": published[i].ID,
+			})
+		}
+	}
+
+	// Episodes with a future publish date but no schedule also appear on
+	// their own, so their cached absence has to go too, as does a featured
+	// episode whose window or rotation slot has ended
+	if len(published) > 0 || service.ReleasedBetween(last, now) || service.FeaturedChangesBetween(last, now) {
+		middleware.PurgeCache()
+	}
+	return published, nil
+}
+
+// RunPublishScheduler calls PublishDueEpisodes every interval until ctx is done
+func RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := publishingNow()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := publishingNow()
+			published, err := PublishDueEpisodes(last, now)
+			if err != nil {
+				// Keep last so the window is retried on the next tick
+				logger.GetLogger().LogError(err, map[string]interface{}{"event": "publish_scheduled"})
+				continue
+			}
+			for _, episode := range published {
+				logger.GetLogger().LogInfo("Published scheduled episode", map[string]interface{}{
+					"event":      "episode_published",
+					"episode_id": episode.ID,
+				})
+			}
+			last = now
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPublishingTestRouter serves the public and preview handlers over a
+// published episode, a draft and a scheduled episode that is already due
+func setupPublishingTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	due := time.Now().Add(-time.Minute)
+	useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/a.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Draft", AudioURL: "/a.mp3", Status: models.StatusDraft},
+		{ID: "ep003", Number: 3, Title: "Scheduled", AudioURL: "/a.mp3", Status: models.StatusScheduled, PublishAt: &due},
+	})
+
+	previousAudit := auditService.Load()
+	SetAuditService(models.NewAuditService())
+	t.Cleanup(func() { SetAuditService(previousAudit) })
+
+	router := gin.New()
+	router.GET("/api/episodes", GetEpisodes)
+	router.GET("/api/episodes/:id", GetEpisodeByID)
+	router.POST("/api/admin/episodes/:id/preview", CreateEpisodePreview)
+	return router
+}
+
+func TestUnpublishedEpisodesAreHidden(t *testing.T) {
+	router := setupPublishingTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var episodes []models.Episode
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &episodes))
+	var ids []string
+	for _, episode := range episodes {
+		ids = append(ids, episode.ID)
+	}
+	assert.Equal(t, []string{"ep003", "ep001"}, ids)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep002", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestEpisodePreview(t *testing.T) {
+	router := setupPublishingTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"invalid ttl", "/api/admin/episodes/ep002/preview?ttl=forever", http.StatusBadRequest},
+		{"ttl too long", "/api/admin/episodes/ep002/preview?ttl=1000h", http.StatusBadRequest},
+		{"unknown episode", "/api/admin/episodes/ep999/preview", http.StatusNotFound},
+		{"draft", "/api/admin/episodes/ep002/preview?ttl=1h", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/episodes/ep002/preview", nil))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var preview PreviewResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.WithinDuration(t, time.Now().Add(defaultPreviewTTL), preview.ExpiresAt, time.Minute)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", preview.URL, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+
+	// A token only unlocks the episode it was issued for
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep004?preview="+preview.Token, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep002?preview=x"+preview.Token, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPublishDueEpisodes(t *testing.T) {
+	setupPublishingTestRouter(t)
+
+	now := publishingNow()
+	published, err := PublishDueEpisodes(now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	require.Len(t, published, 1)
+	assert.Equal(t, "ep003", published[0].ID)
+
+	episode, err := episodeService.Load().GetByID("ep003")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPublished, episode.Status)
+
+	entries := auditService.Load().Query(models.AuditFilter{Action: "episode.publish"})
+	require.Len(t, entries, 1)
+	assert.Equal(t, SchedulerActor, entries[0].Actor)
+	assert.Equal(t, "ep003", entries[0].TargetID)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+// reactionService keeps listener reactions and ratings
+var reactionService atomic.Pointer[models.ReactionService]
+
+func init() {
+	reactionService.Store(models.NewReactionService())
+}
+
+// SetReactionService replaces the service reactions are kept in
+func SetReactionService(service *models.ReactionService) {
+	reactionService.Store(service)
+}
+
+// ReactionsResponse counts an episode's reactions and ratings. Mine is what
+// the signed-in listener left, on listener endpoints only.
+type ReactionsResponse struct {
+	models.ReactionSummary
+	Mine *models.ListenerReactions `json:"mine,omitempty"`
+}
+
+// RatingRequest is a star rating from 1 to 5
+type RatingRequest struct {
+	Rating int `json:"rating" example:"5"`
+}
+
+// respondReactions writes the episode's summary with what the listener left
+func respondReactions(c *gin.Context, episodeID string, mine models.ListenerReactions) {
+	c.JSON(http.StatusOK, ReactionsResponse{
+		ReactionSummary: reactionService.Load().Summary(episodeID),
+		Mine:            &mine,
+	})
+}
+
+// respondReactionError writes the response for an error from ReactionService
+func respondReactionError(c *gin.Context, err error) {
+	if errors.Is(err, models.ErrInvalidReaction) || errors.Is(err, models.ErrInvalidRating) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	logger.GetLogger().LogError(err, map[string]interface{}{
+		"event":      "reaction_save",
+		"episode_id": c.Param("id"),
+	})
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "internal_error",
+		Message: "Could not save reactions",
+		Code:    http.StatusInternalServerError,
+	})
+}
+
+// GetEpisodeReactions handles GET /api/episodes/:id/reactions
+// @Summary Get episode reactions
+// @Description Returns how often each reaction was left on an episode and its average rating
+// @Tags comments
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Success 200 {object} ReactionsResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /episodes/{id}/reactions [get]
+func GetEpisodeReactions(c *gin.Context) {
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, ReactionsResponse{ReactionSummary: reactionService.Load().Summary(episodeID)})
+}
+
+// GetMyReactions handles GET /api/me/reactions/:episodeId
+// @Summary Get my reactions
+// @Description Returns an episode's reactions and rating with what the listener left in mine
+// @Tags comments
+// @Produce json
+// @Param episodeId path string true "Episode ID, slug, number or sNeN"
+// @Success 200 {object} ReactionsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /me/reactions/{episodeId} [get]
+func GetMyReactions(c *gin.Context) {
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("episodeId"))
+	if !ok {
+		return
+	}
+	respondReactions(c, episodeID, reactionService.Load().Get(episodeID, currentListener(c).ID))
+}
+
+// PutEpisodeReaction handles PUT /api/episodes/:id/reactions/:kind
+// @Summary React to an episode
+// @Description Adds one of the reactions like, love, laugh, insightful or surprised. Listeners can leave several kinds, each once.
+// @Tags comments
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param kind path string true "Reaction"
+// @Success 200 {object} ReactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /episodes/{id}/reactions/{kind} [put]
+func PutEpisodeReaction(c *gin.Context) {
+	setEpisodeReaction(c, true)
+}
+
+// DeleteEpisodeReaction handles DELETE /api/episodes/:id/reactions/:kind
+// @Summary Remove a reaction
+// @Description Removes one of the listener's reactions to an episode
+// @Tags comments
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param kind path string true "Reaction"
+// @Success 200 {object} ReactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /episodes/{id}/reactions/{kind} [delete]
+func DeleteEpisodeReaction(c *gin.Context) {
+	setEpisodeReaction(c, false)
+}
+
+// setEpisodeReaction adds or removes the reaction named in the URL
+func setEpisodeReaction(c *gin.Context, on bool) {
+	kind, err := models.ParseReactionKind(c.Param("kind"))
+	if err != nil {
+		respondReactionError(c, err)
+		return
+	}
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	mine, err := reactionService.Load().React(episodeID, currentListener(c).ID, kind, on)
+	if err != nil {
+		respondReactionError(c, err)
+		return
+	}
+	respondReactions(c, episodeID, mine)
+}
+
+// PutEpisodeRating handles PUT /api/episodes/:id/rating
+// @Summary Rate an episode
+// @Description Sets the listener's rating of an episode from 1 to 5 stars, replacing an earlier one
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param rating body RatingRequest true "Rating"
+// @Success 200 {object} ReactionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /episodes/{id}/rating [put]
+func PutEpisodeRating(c *gin.Context) {
+	var request RatingRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Rating < 1 {
+		respondReactionError(c, models.ErrInvalidRating)
+		return
+	}
+	rateEpisode(c, request.Rating)
+}
+
+// DeleteEpisodeRating handles DELETE /api/episodes/:id/rating
+// @Summary Remove a rating
+// @Description Clears the listener's rating of an episode
+// @Tags comments
+// @Produce json
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Success 200 {object} ReactionsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /episodes/{id}/rating [delete]
+func DeleteEpisodeRating(c *gin.Context) {
+	rateEpisode(c, 0)
+}
+
+// rateEpisode stores the listener's rating, 0 clearing it
+func rateEpisode(c *gin.Context, rating int) {
+	episodeID, ok := resolvePlayableEpisode(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	mine, err := reactionService.Load().Rate(episodeID, currentListener(c).ID, rating)
+	if err != nil {
+		respondReactionError(c, err)
+		return
+	}
+	respondReactions(c, episodeID, mine)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpisodeReactions(t *testing.T) {
+	router, mail := setupCommentsTestRouter(t, models.DefaultCommentPolicy())
+	jane := listenerRequest(router, signInListener(t, router, mail, "jane@example.com"))
+	sam := listenerRequest(router, signInListener(t, router, mail, "sam@example.com"))
+
+	var response ReactionsResponse
+	w := jane("PUT", "/api/episodes/ep001/reactions/love", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, http.StatusOK, sam("PUT", "/api/episodes/ep001/reactions/love", "").Code)
+	require.Equal(t, http.StatusOK, jane("PUT", "/api/episodes/ep001/rating", `{"rating": 5}`).Code)
+	w = sam("PUT", "/api/episodes/ep001/rating", `{"rating": 2}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Reactions[models.ReactionLove])
+	assert.Equal(t, models.RatingSummary{Average: 3.5, Count: 2}, response.Rating)
+	require.NotNil(t, response.Mine)
+	assert.Equal(t, 2, response.Mine.Rating)
+
+	w = jane("DELETE", "/api/episodes/ep001/reactions/love", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = jane("GET", "/api/me/reactions/ep001", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	response = ReactionsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response.Mine.Reactions)
+	assert.Equal(t, 5, response.Mine.Rating)
+
+	w = listenerRequest(router, "")("GET", "/api/episodes/ep001/reactions", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	response = ReactionsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Nil(t, response.Mine)
+	assert.Equal(t, 1, response.Reactions[models.ReactionLove])
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"unknown reaction", "PUT", "/api/episodes/ep001/reactions/angry", "", http.StatusBadRequest},
+		{"rating too high", "PUT", "/api/episodes/ep001/rating", `{"rating": 6}`, http.StatusBadRequest},
+		{"missing rating", "PUT", "/api/episodes/ep001/rating", `{}`, http.StatusBadRequest},
+		{"draft episode", "PUT", "/api/episodes/ep003/reactions/like", "", http.StatusNotFound},
+		{"clear rating", "DELETE", "/api/episodes/ep001/rating", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, jane(tt.method, tt.path, tt.body).Code)
+		})
+	}
+	assert.Equal(t, 1, reactionService.Load().Summary("ep001").Rating.Count)
+}
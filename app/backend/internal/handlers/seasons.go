@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSeasons handles GET /api/seasons
+// @Summary Get seasons
+// @Description Returns every season with published episodes, in ascending order
+// @Tags seasons
+// @Produce json
+// @Success 200 {array} models.Season
+// @Router /seasons [get]
+func GetSeasons(c *gin.Context) {
+	c.JSON(http.StatusOK, episodeService.Load().GetSeasons(publishingNow()))
+}
+
+// GetSeasonEpisodes handles GET /api/seasons/:n/episodes
+// @Summary Get the episodes of a season
+// @Description Returns the published episodes of a season in running order, trailers first
+// @Tags seasons
+// @Param n path int true "Season number"
+// @Produce json
+// @Success 200 {array} models.Episode
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /seasons/{n}/episodes [get]
+func GetSeasonEpisodes(c *gin.Context) {
+	season, err := strconv.Atoi(c.Param("n"))
+	if err != nil || season <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: "Season must be a positive number",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	episodes := episodeService.Load().GetSeasonEpisodes(season, publishingNow())
+	if len(episodes) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Season not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	peopleService.Load().Attach(episodes)
+	c.JSON(http.StatusOK, episodes)
+}
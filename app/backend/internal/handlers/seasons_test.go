@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSeasonsTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	useTestEpisodes(t, []models.Episode{
+		{ID: "pilot", Number: 1, Season: 1, SeasonEpisode: 1, Title: "Pilot", AudioURL: "/a.mp3"},
+		{ID: "return", Number: 2, Season: 2, SeasonEpisode: 1, Title: "Return", AudioURL: "/a.mp3"},
+		{ID: "finale", Number: 1000, Season: 2, SeasonEpisode: 5, Title: "Finale", AudioURL: "/a.mp3"},
+	})
+
+	router := gin.New()
+	router.GET("/api/episodes/:id", GetEpisodeByID)
+	router.GET("/api/seasons", GetSeasons)
+	router.GET("/api/seasons/:n/episodes", GetSeasonEpisodes)
+	return router
+}
+
+func TestSeasonEndpoints(t *testing.T) {
+	router := setupSeasonsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"seasons", "/api/seasons", http.StatusOK, 2},
+		{"season episodes", "/api/seasons/2/episodes", http.StatusOK, 2},
+		{"unknown season", "/api/seasons/9/episodes", http.StatusNotFound, 0},
+		{"invalid season", "/api/seasons/two/episodes", http.StatusBadRequest, 0},
+		{"zero season", "/api/seasons/0/episodes", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var items []map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+				assert.Len(t, items, tt.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetEpisodeByReference(t *testing.T) {
+	router := setupSeasonsTestRouter(t)
+
+	for ref, expectedID := range map[string]string{"1000": "finale", "s2e5": "finale", "s1e1": "pilot", "return": "return"} {
+		t.Run(ref, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/"+ref, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var episode models.Episode
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &episode))
+			assert.Equal(t, expectedID, episode.ID)
+		})
+	}
+}
+
+func TestGetEpisodeByOutdatedSlug(t *testing.T) {
+	router := setupSeasonsTestRouter(t)
+
+	// Rename twice so the outdated slug differs from the ID, which always
+	// resolves without a redirect
+	for _, title := range []string{"Grand Finale", "Last Call"} {
+		episode, err := episodeService.Load().GetByID("finale")
+		require.NoError(t, err)
+		episode.Title = title
+		_, _, err = episodeService.Load().Update("finale", *episode)
+		require.NoError(t, err)
+	}
+
+	for _, path := range []string{"/api/episodes/last-call", "/api/episodes/finale"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/grand-finale?preview=abc", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/episodes/last-call?preview=abc", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/grand-finale?redirect=false", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var moved MovedEpisodeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &moved))
+	assert.Equal(t, "/api/episodes/last-call", moved.RedirectTo)
+	assert.Equal(t, "Last Call", moved.Title)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+)
+
+// Limits for GET /api/episodes/:id/related
+const (
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 20
+)
+
+// tagService normalizes tags and resolves aliases
+var tagService atomic.Pointer[models.TagService]
+
+func init() {
+	tagService.Store(models.NewTagService())
+}
+
+// SetTagService replaces the tag service used by the handlers
+func SetTagService(service *models.TagService) {
+	tagService.Store(service)
+}
+
+// GetTags handles GET /api/tags
+// @Summary Get tags
+// @Description Returns every tag on published episodes with its episode count, most used first. Aliases are merged into their canonical tag.
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.TagCount
+// @Router /tags [get]
+func GetTags(c *gin.Context) {
+	counts := tagService.Load().Counts(episodeService.Load().GetPublished(publishingNow()))
+	if counts == nil {
+		counts = []models.TagCount{}
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetTagEpisodes handles GET /api/tags/:tag/episodes
+// @Summary Get the episodes with a tag
+// @Description Returns the published episodes carrying a tag or any of its aliases, newest first
+// @Tags tags
+// @Param tag path string true "Tag or alias"
+// @Produce json
+// @Success 200 {array} models.Episode
+// @Failure 404 {object} ErrorResponse
+// @Router /tags/{tag}/episodes [get]
+func GetTagEpisodes(c *gin.Context) {
+	episodes := tagService.Load().Filter(c.Param("tag"), episodeService.Load().GetPublished(publishingNow()))
+	if len(episodes) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Tag not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	peopleService.Load().Attach(episodes)
+	c.JSON(http.StatusOK, episodes)
+}
+
+// GetRelatedEpisodes handles GET /api/episodes/:id/related
+// @Summary Get related episodes
+// @Description Returns published episodes similar to an episode, ranked by shared tags and by the words in their titles and descriptions
+// @Tags episodes
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param limit query int false "Maximum episodes (default 5, max 20)"
+// @Produce json
+// @Success 200 {array} models.RelatedEpisode
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /episodes/{id}/related [get]
+func GetRelatedEpisodes(c *gin.Context) {
+	limit := defaultRelatedLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxRelatedLimit {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "bad_request",
+				Message: "limit must be between 1 and " + strconv.Itoa(maxRelatedLimit),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+	}
+
+	now := publishingNow()
+	episodes := episodeService.Load()
+	episode, _, err := episodes.Resolve(c.Param("id"))
+	if err != nil || !episode.IsReachable(now) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	candidates := episodes.GetPublished(now)
+	peopleService.Load().Attach(candidates)
+	c.JSON(http.StatusOK, tagService.Load().Related(*episode, candidates, limit))
+}
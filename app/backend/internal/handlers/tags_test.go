@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTagsTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "intro", Number: 1, Title: "Welcome", AudioURL: "/a.mp3", PublishDate: "2024-01-01", Tags: []string{"Welcome", "JS"}},
+		{ID: "async", Number: 2, Title: "Async JavaScript", AudioURL: "/a.mp3", PublishDate: "2024-02-01", Tags: []string{"javascript", "async"}},
+		{ID: "promises", Number: 3, Title: "Promises", AudioURL: "/a.mp3", PublishDate: "2024-03-01", Tags: []string{"JavaScript", "async"}},
+		{ID: "unaired", Number: 4, Title: "Unaired", AudioURL: "/a.mp3", Status: models.StatusDraft, Tags: []string{"javascript"}},
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tags.json"), []byte(`[{"tag":"javascript","name":"JavaScript","aliases":["js"]}]`), 0o644))
+
+	tagSvc, err := models.LoadTagService(dir)
+	require.NoError(t, err)
+
+	previousTags := tagService.Load()
+	SetTagService(tagSvc)
+	t.Cleanup(func() { SetTagService(previousTags) })
+
+	router := gin.New()
+	router.GET("/api/episodes/:id/related", GetRelatedEpisodes)
+	router.GET("/api/tags", GetTags)
+	router.GET("/api/tags/:tag/episodes", GetTagEpisodes)
+	return router
+}
+
+func TestGetTags(t *testing.T) {
+	router := setupTagsTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/tags", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var tags []models.TagCount
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.Equal(t, []models.TagCount{
+		{Tag: "javascript", Name: "JavaScript", Count: 3},
+		{Tag: "async", Name: "async", Count: 2},
+		{Tag: "welcome", Name: "Welcome", Count: 1},
+	}, tags)
+}
+
+func TestGetTagEpisodes(t *testing.T) {
+	router := setupTagsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{"canonical", "/api/tags/javascript/episodes", http.StatusOK, []string{"promises", "async", "intro"}},
+		{"alias", "/api/tags/JS/episodes", http.StatusOK, []string{"promises", "async", "intro"}},
+		{"unknown", "/api/tags/rust/episodes", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedIDs == nil {
+				return
+			}
+
+			var episodes []models.Episode
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &episodes))
+			var ids []string
+			for _, episode := range episodes {
+				ids = append(ids, episode.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestGetRelatedEpisodes(t *testing.T) {
+	router := setupTagsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedIDs    []string
+	}{
+		{"ranked", "/api/episodes/async/related", http.StatusOK, []string{"promises", "intro"}},
+		{"by number", "/api/episodes/2/related?limit=1", http.StatusOK, []string{"promises"}},
+		{"bad limit", "/api/episodes/async/related?limit=0", http.StatusBadRequest, nil},
+		{"draft", "/api/episodes/unaired/related", http.StatusNotFound, nil},
+		{"unknown", "/api/episodes/missing/related", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedIDs == nil {
+				return
+			}
+
+			var related []models.RelatedEpisode
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &related))
+			var ids []string
+			for _, episode := range related {
+				ids = append(ids, episode.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
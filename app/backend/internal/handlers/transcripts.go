@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+)
+
+// maxTranscriptSize caps uploaded transcript files
+const maxTranscriptSize = 5 << 20
+
+// transcriptService stores episode transcripts
+var transcriptService atomic.Pointer[models.TranscriptService]
+
+func init() {
+	transcriptService.Store(models.NewTranscriptService())
+}
+
+// SetTranscriptService replaces the transcript service used by the handlers
+func SetTranscriptService(service *models.TranscriptService) {
+	transcriptService.Store(service)
+}
+
+// negotiateTranscriptFormat picks the format from ?format= or, failing that,
+// the most preferred media type in Accept. JSON is the default.
+func negotiateTranscriptFormat(c *gin.Context) (models.TranscriptFormat, bool) {
+	if format := models.TranscriptFormat(strings.ToLower(c.Query("format"))); format != "" {
+		return format, format.ContentType() != ""
+	}
+
+	accept := c.GetHeader("Accept")
+	if accept == "" {
+		return models.TranscriptJSON, true
+	}
+
+	type candidate struct {
+		mediaType string
+		quality   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{mediaType, quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, candidate := range candidates {
+		if candidate.mediaType == "*/*" || candidate.mediaType == "application/*" {
+			return models.TranscriptJSON, true
+		}
+		if format, ok := models.TranscriptFormatFor(candidate.mediaType); ok {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// GetEpisodeTranscript handles GET /api/episodes/:id/transcript
+// @Summary Get an episode transcript
+// @Description Returns the transcript as Podcasting 2.0 JSON, WebVTT, SRT, plain text or HTML, chosen by ?format= or the Accept header. Text and HTML start each paragraph with its timestamp; HTML paragraphs link to #t=<seconds> for seeking the player.
+// @Tags episodes
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param format query string false "json, vtt, srt, txt or html"
+// @Param preview query string false "Preview token"
+// @Produce json,text/vtt,application/x-subrip,text/plain,text/html
+// @Success 200 {object} models.Transcript
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 406 {object} ErrorResponse
+// @Router /episodes/{id}/transcript [get]
+func GetEpisodeTranscript(c *gin.Context) {
+	// Added to, not replaced, so CORS and compression keep their values
+	c.Writer.Header().Add("Vary", "Accept")
+
+	format, ok := negotiateTranscriptFormat(c)
+	if !ok {
+		if c.Query("format") != "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "bad_request",
+				Message: "format must be json, vtt, srt, txt or html",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		c.JSON(http.StatusNotAcceptable, ErrorResponse{
+			Error:   "not_acceptable",
+			Message: "Transcripts are available as application/json, text/vtt, application/x-subrip, text/plain and text/html",
+			Code:    http.StatusNotAcceptable,
+		})
+		return
+	}
+
+	episode, _, err := episodeService.Load().Resolve(c.Param("id"))
+	if err == nil && !episode.IsReachable(publishingNow()) && !hasValidPreview(c, episode.ID) {
+		err = models.ErrEpisodeNotFound
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if c.Query("preview") != "" {
+		c.Header("Cache-Control", "private, no-store")
+	}
+
+	transcript, err := transcriptService.Load().Get(episode.ID)
+	if errors.Is(err, models.ErrTranscriptNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Transcript not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		respondTranscriptError(c, "Could not read transcript")
+		return
+	}
+
+	data, err := transcript.Encode(format)
+	if err != nil {
+		respondTranscriptError(c, "Could not encode transcript")
+		return
+	}
+	contentType := format.ContentType()
+	if format != models.TranscriptJSON {
+		contentType += "; charset=utf-8"
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// PutEpisodeTranscript handles PUT /api/admin/episodes/:id/transcript
+// @Summary Upload an episode transcript
+// @Description Stores a transcript sent as WebVTT, SRT or Podcasting 2.0 JSON, replacing any previous one. The format comes from ?format=, the Content-Type or the content itself. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Accept json,text/vtt,application/x-subrip
+// @Produce json
+// @Param id path string true "Episode ID"
+// @Param format query string false "json, vtt or srt"
+// @Success 200 {object} models.TranscriptSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id}/transcript [put]
+func PutEpisodeTranscript(c *gin.Context) {
+	badRequest := func(message string) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "bad_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	format := models.TranscriptFormat(strings.ToLower(c.Query("format")))
+	if format == "" {
+		// Anything else, such as text/plain, is detected from the content
+		if mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type")); err == nil {
+			if byType, ok := models.TranscriptFormatFor(mediaType); ok && byType.Readable() {
+				format = byType
+			}
+		}
+	}
+	if format != "" && !format.Readable() {
+		badRequest("Transcripts can be uploaded as json, vtt or srt")
+		return
+	}
+
+	episode, err := episodeService.Load().GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxTranscriptSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Error:   "payload_too_large",
+				Message: "Transcripts may be at most " + strconv.Itoa(maxTranscriptSize>>20) + " MiB",
+				Code:    http.StatusRequestEntityTooLarge,
+			})
+			return
+		}
+		badRequest("Failed to read transcript")
+		return
+	}
+
+	transcript, err := models.ParseTranscript(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	service := transcriptService.Load()
+	var before interface{}
+	if previous, err := service.Get(episode.ID); err == nil {
+		before = previous.Summary()
+	}
+	if err := service.Save(episode.ID, transcript); err != nil {
+		respondTranscriptError(c, "Could not save transcript")
+		return
+	}
+
+	summary := transcript.Summary()
+	setAuditDetails(c, "transcript.update", "episode", episode.ID, before, summary)
+	middleware.PurgeCache()
+	c.JSON(http.StatusOK, summary)
+}
+
+// DeleteEpisodeTranscript handles DELETE /api/admin/episodes/:id/transcript
+// @Summary Delete an episode transcript
+// @Description Removes the transcript of an episode. Requires the editor role and the episodes:write scope.
+// @Tags admin
+// @Param id path string true "Episode ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /admin/episodes/{id}/transcript [delete]
+func DeleteEpisodeTranscript(c *gin.Context) {
+	service := transcriptService.Load()
+	previous, err := service.Get(c.Param("id"))
+	if err == nil {
+		err = service.Delete(c.Param("id"))
+	}
+	if errors.Is(err, models.ErrTranscriptNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Transcript not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		respondTranscriptError(c, "Could not delete transcript")
+		return
+	}
+
+	setAuditDetails(c, "transcript.delete", "episode", c.Param("id"), previous.Summary(), nil)
+	middleware.PurgeCache()
+	c.Status(http.StatusNoContent)
+}
+
+// respondTranscriptError answers a transcript storage failure
+func respondTranscriptError(c *gin.Context, message string) {
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "internal_error",
+		Message: message,
+		Code:    http.StatusInternalServerError,
+	})
+}
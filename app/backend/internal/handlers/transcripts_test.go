@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/middleware"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTranscriptVTT = "WEBVTT\n\n00:00:00.000 --> 00:00:04.000\n<v Alice>Welcome to the show.\n\n00:01:02.000 --> 00:01:05.000\n<v Bob>Thanks for having me.\n"
+
+func setupTranscriptsTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/a.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Draft", AudioURL: "/a.mp3", Status: models.StatusDraft},
+	})
+
+	previousTranscripts := transcriptService.Load()
+	SetTranscriptService(models.NewTranscriptServiceFromDir(dir))
+	t.Cleanup(func() { SetTranscriptService(previousTranscripts) })
+
+	previousAudit := auditService.Load()
+	SetAuditService(models.NewAuditService())
+	t.Cleanup(func() { SetAuditService(previousAudit) })
+
+	router := gin.New()
+	router.GET("/api/episodes/:id/transcript", GetEpisodeTranscript)
+	router.PUT("/api/admin/episodes/:id/transcript", PutEpisodeTranscript)
+	router.DELETE("/api/admin/episodes/:id/transcript", DeleteEpisodeTranscript)
+	return router
+}
+
+func uploadTestTranscript(t *testing.T, router *gin.Engine, id string) {
+	req := httptest.NewRequest("PUT", "/api/admin/episodes/"+id+"/transcript", strings.NewReader(testTranscriptVTT))
+	req.Header.Set("Content-Type", "text/vtt")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestPutEpisodeTranscript(t *testing.T) {
+	router := setupTranscriptsTestRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{"vtt", "/api/admin/episodes/ep001/transcript", "text/vtt", testTranscriptVTT, http.StatusOK},
+		{"srt detected", "/api/admin/episodes/ep001/transcript", "text/plain", "1\n00:00:00,000 --> 00:00:01,000\nHi\n", http.StatusOK},
+		{"json by query", "/api/admin/episodes/ep001/transcript?format=json", "", `{"segments":[{"startTime":0,"endTime":1,"body":"Hi"}]}`, http.StatusOK},
+		{"output-only format", "/api/admin/episodes/ep001/transcript?format=html", "", "<p>Hi</p>", http.StatusBadRequest},
+		{"invalid", "/api/admin/episodes/ep001/transcript", "text/vtt", "WEBVTT\n\nnonsense\n", http.StatusBadRequest},
+		{"unknown episode", "/api/admin/episodes/ep999/transcript", "text/vtt", testTranscriptVTT, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestGetEpisodeTranscript(t *testing.T) {
+	router := setupTranscriptsTestRouter(t)
+	uploadTestTranscript(t, router, "ep001")
+	uploadTestTranscript(t, router, "ep002")
+
+	tests := []struct {
+		name                string
+		path                string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"default json", "/api/episodes/ep001/transcript", "", http.StatusOK, "application/json", `"speaker":"Alice"`},
+		{"accept vtt", "/api/episodes/ep001/transcript", "text/vtt", http.StatusOK, "text/vtt; charset=utf-8", "<v Bob>Thanks"},
+		{"accept quality", "/api/episodes/1/transcript", "text/html;q=0.5, application/x-subrip", http.StatusOK, "application/x-subrip; charset=utf-8", "2\n00:01:02,000"},
+		{"accept wildcard", "/api/episodes/ep001/transcript", "text/csv, */*;q=0.1", http.StatusOK, "application/json", `"version"`},
+		{"format overrides accept", "/api/episodes/ep001/transcript?format=txt", "text/vtt", http.StatusOK, "text/plain; charset=utf-8", "[01:02] Bob: Thanks"},
+		{"html", "/api/episodes/ep001/transcript?format=html", "", http.StatusOK, "text/html; charset=utf-8", `href="#t=62"`},
+		{"unknown format", "/api/episodes/ep001/transcript?format=pdf", "", http.StatusBadRequest, "", ""},
+		{"not acceptable", "/api/episodes/ep001/transcript", "application/pdf", http.StatusNotAcceptable, "", ""},
+		{"draft", "/api/episodes/ep002/transcript", "", http.StatusNotFound, "", ""},
+		{"unknown episode", "/api/episodes/ep999/transcript", "", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestGetEpisodeTranscriptVary(t *testing.T) {
+	uploadTestTranscript(t, setupTranscriptsTestRouter(t), "ep001")
+
+	router := gin.New()
+	router.Use(middleware.CORS([]string{"https://podsite.com"}), middleware.Compression())
+	router.GET("/api/episodes/:id/transcript", GetEpisodeTranscript)
+
+	req := httptest.NewRequest("GET", "/api/episodes/ep001/transcript", nil)
+	req.Header.Set("Origin", "https://podsite.com")
+	req.Header.Set("Accept-Encoding", "gzip")
+	// Compression decides by the request's Content-Type
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	vary := w.Header().Values("Vary")
+	for _, header := range []string{"Origin", "Accept-Encoding", "Accept"} {
+		assert.Contains(t, vary, header)
+	}
+}
+
+func TestDeleteEpisodeTranscript(t *testing.T) {
+	router := setupTranscriptsTestRouter(t)
+	uploadTestTranscript(t, router, "ep001")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/admin/episodes/ep001/transcript", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep001/transcript", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/admin/episodes/ep001/transcript", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/logger"
+	"github.com/podsite/backend/internal/models"
+)
+
+// waveformService generates and stores episode waveforms
+var waveformService atomic.Pointer[models.WaveformService]
+
+func init() {
+	waveformService.Store(models.NewWaveformService())
+}
+
+// SetWaveformService replaces the waveform service used by the handlers and
+// the waveform worker
+func SetWaveformService(service *models.WaveformService) {
+	waveformService.Store(service)
+}
+
+// GetEpisodeWaveform handles GET /api/episodes/:id/waveform
+// @Summary Get episode waveform
+// @Description Returns peak data for the episode's audio in the audiowaveform JSON format (version 2), reduced to points min/max pairs
+// @Tags episodes
+// @Param id path string true "Episode ID, slug, number or sNeN"
+// @Param points query int false "Number of points, 1 to 4000 (default 1000)"
+// @Param preview query string false "Preview token"
+// @Produce json
+// @Success 200 {object} models.Waveform
+// @Success 304
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /episodes/{id}/waveform [get]
+func GetEpisodeWaveform(c *gin.Context) {
+	points := models.DefaultWaveformPoints
+	if value := c.Query("points"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > models.WaveformResolution {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "bad_request",
+				Message: "points must be a number from 1 to 4000",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		points = parsed
+	}
+
+	episode, _, err := episodeService.Load().Resolve(c.Param("id"))
+	if err == nil && !episode.IsReachable(publishingNow()) && !hasValidPreview(c, episode.ID) {
+		err = models.ErrEpisodeNotFound
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Episode not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	waveform, err := waveformService.Load().Get(episode.ID)
+	if errors.Is(err, models.ErrWaveformNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Waveform not generated yet",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+	if err != nil {
+		logger.GetLogger().LogError(err, map[string]interface{}{
+			"event":      "waveform_read",
+			"episode_id": episode.ID,
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Could not read waveform",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	if c.Query("preview") != "" {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	// Waveforms only change with their audio file
+	if waveform.Source != nil {
+		etag := fmt.Sprintf(`"%s-%d-%x-%d"`, episode.ID, waveform.Source.Length, waveform.Source.Modified.UnixNano(), points)
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, waveform.Resample(points))
+}
+
+// GenerateWaveforms generates the waveforms of all episodes whose audio has
+// none or changed since. Files that failed before are skipped until they
+// change, as recorded in failed.
+func GenerateWaveforms(failed map[string]models.WaveformSource) int {
+	service := waveformService.Load()
+	generated := 0
+	for _, episode := range episodeService.Load().GetAll() {
+		source, pending := service.Pending(&episode)
+		if !pending {
+			continue
+		}
+		if previous, ok := failed[episode.ID]; ok && previous.Equal(source) {
+			continue
+		}
+
+		if _, err := service.Generate(&episode); err != nil {
+			failed[episode.ID] = source
+			logger.GetLogger().LogWarn("Could not generate waveform", map[string]interface{}{
+				"event":      "waveform_generate",
+				"episode_id": episode.ID,
+				"audio_url":  episode.AudioURL,
+				"error":      err.Error(),
+			})
+			continue
+		}
+		delete(failed, episode.ID)
+		generated++
+		logger.GetLogger().LogInfo("Generated waveform", map[string]interface{}{
+			"event":      "waveform_generated",
+			"episode_id": episode.ID,
+		})
+	}
+	return generated
+}
+
+// RunWaveformWorker calls GenerateWaveforms right away and then every
+// interval until ctx is done
+func RunWaveformWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failed := make(map[string]models.WaveformSource)
+	for {
+		GenerateWaveforms(failed)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
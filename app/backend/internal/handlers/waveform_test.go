@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWaveformMP3 is an MP3 of 20 silent MPEG-1 Layer III frames
+func testWaveformMP3() []byte {
+	data := make([]byte, 0, 20*417)
+	for i := 0; i < 20; i++ {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+		data = append(data, frame...)
+	}
+	return data
+}
+
+func setupWaveformTestRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	media := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(media, "ep.mp3"), testWaveformMP3(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(media, "ep.m4a"), []byte("\x00\x00\x00\x0cftypM4A "), 0o644))
+
+	dir := useTestEpisodes(t, []models.Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/ep.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Draft", AudioURL: "/ep.mp3", Status: models.StatusDraft},
+		{ID: "ep003", Number: 3, Title: "AAC", AudioURL: "/ep.m4a", PublishDate: "2024-01-03"},
+		{ID: "ep004", Number: 4, Title: "Remote", AudioURL: "https://cdn.example.com/ep.mp3", PublishDate: "2024-01-04"},
+	})
+
+	previousWaveforms := waveformService.Load()
+	SetWaveformService(models.NewWaveformServiceFromDir(dir, models.NewMediaProber(media)))
+	t.Cleanup(func() { SetWaveformService(previousWaveforms) })
+
+	router := gin.New()
+	router.GET("/api/episodes/:id/waveform", GetEpisodeWaveform)
+	return router
+}
+
+func TestGenerateWaveforms(t *testing.T) {
+	setupWaveformTestRouter(t)
+
+	failed := make(map[string]models.WaveformSource)
+	assert.Equal(t, 2, GenerateWaveforms(failed))
+	assert.Contains(t, failed, "ep003")
+	assert.NotContains(t, failed, "ep004")
+
+	// Neither generated nor failed files are processed again
+	assert.Equal(t, 0, GenerateWaveforms(failed))
+}
+
+func TestGetEpisodeWaveform(t *testing.T) {
+	router := setupWaveformTestRouter(t)
+	GenerateWaveforms(make(map[string]models.WaveformSource))
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedLength int
+	}{
+		{"default points", "/api/episodes/ep001/waveform", http.StatusOK, 40},
+		{"fewer points", "/api/episodes/ep001/waveform?points=10", http.StatusOK, 10},
+		{"by number", "/api/episodes/1/waveform?points=4000", http.StatusOK, 40},
+		{"zero points", "/api/episodes/ep001/waveform?points=0", http.StatusBadRequest, 0},
+		{"too many points", "/api/episodes/ep001/waveform?points=4001", http.StatusBadRequest, 0},
+		{"draft", "/api/episodes/ep002/waveform", http.StatusNotFound, 0},
+		{"not generated", "/api/episodes/ep003/waveform", http.StatusNotFound, 0},
+		{"unknown episode", "/api/episodes/ep999/waveform", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			require.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var waveform map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &waveform))
+			assert.Equal(t, float64(2), waveform["version"])
+			assert.Equal(t, float64(tt.expectedLength), waveform["length"])
+			assert.Len(t, waveform["data"], tt.expectedLength*2)
+			assert.NotContains(t, waveform, "source")
+			assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+			assert.NotEmpty(t, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestGetEpisodeWaveformNotModified(t *testing.T) {
+	router := setupWaveformTestRouter(t)
+	GenerateWaveforms(make(map[string]models.WaveformSource))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/episodes/ep001/waveform?points=10", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+
+	req := httptest.NewRequest("GET", "/api/episodes/ep001/waveform?points=10", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Each resolution has its own tag
+	req = httptest.NewRequest("GET", "/api/episodes/ep001/waveform?points=20", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
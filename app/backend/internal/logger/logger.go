@@ -1,10 +1,13 @@
 package logger
 
 import (
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/middleware"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,26 +16,16 @@ type Logger struct {
 	*logrus.Logger
 }
 
-// NewLogger creates a new structured logger
-func NewLogger(level string) *Logger {
+// NewLogger creates a new structured logger. format is "json" or "text",
+// the default; config.LogFormat picks the format for the environment.
+func NewLogger(level, format string) *Logger {
 	logger := logrus.New()
 
 	// Set log level
-	switch level {
-	case "debug":
-		logger.SetLevel(logrus.DebugLevel)
-	case "info":
-		logger.SetLevel(logrus.InfoLevel)
-	case "warn":
-		logger.SetLevel(logrus.WarnLevel)
-	case "error":
-		logger.SetLevel(logrus.ErrorLevel)
-	default:
-		logger.SetLevel(logrus.InfoLevel)
-	}
+	logger.SetLevel(parseLevel(level))
 
 	// Set JSON formatter for production
-	if os.Getenv("GO_ENV") == "production" {
+	if format == "json" {
 		logger.SetFormatter(&logrus.JSONFormatter{
 			TimestampFormat: time.RFC3339,
 		})
@@ -49,12 +42,69 @@ func NewLogger(level string) *Logger {
 	return &Logger{Logger: logger}
 }
 
-// LogRequest creates a request logger middleware
-func (l *Logger) LogRequest() gin.HandlerFunc {
+// parseLevel converts a level name to a logrus level, defaulting to info
+func parseLevel(level string) logrus.Level {
+	switch level {
+	case "debug":
+		return logrus.DebugLevel
+	case "info":
+		return logrus.InfoLevel
+	case "warn":
+		return logrus.WarnLevel
+	case "error":
+		return logrus.ErrorLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
+
+// SetLevelName changes the log level at runtime
+func (l *Logger) SetLevelName(level string) {
+	l.SetLevel(parseLevel(level))
+}
+
+// secretPathPrefixes are routes whose remaining path is a bearer token,
+// such as the private playlist feeds
+var secretPathPrefixes = []string{"/feeds/playlists/"}
+
+// secretParams are query parameters that carry bearer tokens
+var secretParams = map[string]bool{"preview": true}
+
+// redacted replaces secrets in logged paths and queries
+const redacted = "REDACTED"
+
+// redactRequest hides the tokens in a request path and raw query so access
+// logs cannot be used to replay them
+func redactRequest(path, rawQuery string) (string, string) {
+	for _, prefix := range secretPathPrefixes {
+		if strings.HasPrefix(path, prefix) && len(path) > len(prefix) {
+			path = prefix + redacted
+		}
+	}
+
+	if rawQuery == "" {
+		return path, rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, found := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && found && secretParams[name] {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return path, strings.Join(params, "&")
+}
+
+// RequestObserver is called after each request and returns extra fields
+// for its log entry
+type RequestObserver func(c *gin.Context) map[string]interface{}
+
+// LogRequest creates a request logger middleware. Observers see every
+// request once it has been handled. Feed and preview tokens are redacted.
+func (l *Logger) LogRequest(observers ...RequestObserver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+		path, raw := redactRequest(c.Request.URL.Path, c.Request.URL.RawQuery)
 
 		// Process request
 		c.Next()
@@ -84,7 +134,11 @@ func (l *Logger) LogRequest() gin.HandlerFunc {
 			"client_ip":  clientIP,
 			"body_size":  bodySize,
 			"user_agent": c.Request.UserAgent(),
+			"request_id": c.GetString(middleware.RequestIDKey),
 		})
+		for _, observe := range observers {
+			entry = entry.WithFields(logrus.Fields(observe(c)))
+		}
 
 		// Log based on status code
 		if statusCode >= 500 {
@@ -125,14 +179,14 @@ func (l *Logger) LogWarn(message string, context map[string]interface{}) {
 var GlobalLogger *Logger
 
 // InitLogger initializes the global logger
-func InitLogger(level string) {
-	GlobalLogger = NewLogger(level)
+func InitLogger(level, format string) {
+	GlobalLogger = NewLogger(level, format)
 }
 
 // GetLogger returns the global logger instance
 func GetLogger() *Logger {
 	if GlobalLogger == nil {
-		GlobalLogger = NewLogger("info")
+		GlobalLogger = NewLogger("info", "")
 	}
 	return GlobalLogger
 }
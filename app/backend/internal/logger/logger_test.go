@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		path, query   string
+		expectedPath  string
+		expectedQuery string
+	}{
+		{"playlist feed", "/feeds/playlists/abc123", "", "/feeds/playlists/REDACTED", ""},
+		{"feed index", "/feeds/playlists/", "", "/feeds/playlists/", ""},
+		{"preview", "/api/episodes/ep001", "preview=abc123&redirect=false", "/api/episodes/ep001", "preview=REDACTED&redirect=false"},
+		{"escaped preview", "/api/episodes/ep001", "%70review=abc123", "/api/episodes/ep001", "%70review=REDACTED"},
+		{"other params", "/api/episodes", "page=2&tag=preview", "/api/episodes", "page=2&tag=preview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, query := redactRequest(tt.path, tt.query)
+			assert.Equal(t, tt.expectedPath, path)
+			assert.Equal(t, tt.expectedQuery, query)
+		})
+	}
+}
+
+func TestLogRequestRedactsTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	log := NewLogger("info", "json")
+	log.SetOutput(&out)
+
+	router := gin.New()
+	router.Use(log.LogRequest())
+	router.GET("/feeds/playlists/:token", func(c *gin.Context) {})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/feeds/playlists/secret?preview=secret", nil))
+
+	assert.NotContains(t, out.String(), "secret")
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "/feeds/playlists/REDACTED", entry["path"])
+	assert.Equal(t, "preview=REDACTED", entry["query"])
+}
@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewFileMailer(dir, "Podsite <noreply@example.com>")
+
+	err := m.Send(context.Background(), Message{
+		To:      "jane@example.com",
+		Subject: "Sign in — Podsite",
+		Body:    "Line one\nLine two",
+	})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one message file, got %v %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := string(data)
+	for _, expected := range []string{
+		"From: Podsite <noreply@example.com>\r\n",
+		"To: jane@example.com\r\n",
+		"Subject: =?utf-8?q?Sign_in_=E2=80=94_Podsite?=\r\n",
+		"\r\n\r\nLine one\r\nLine two\r\n",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected message to contain %q:\n%s", expected, message)
+		}
+	}
+}
+
+func TestFileMailerRejectsHeaderInjection(t *testing.T) {
+	m := NewFileMailer(t.TempDir(), "noreply@example.com")
+
+	tests := []Message{
+		{To: "not an address", Subject: "Hello"},
+		{To: "jane@example.com", Subject: "Hello\r\nBcc: victim@example.com"},
+	}
+	for _, msg := range tests {
+		if err := m.Send(context.Background(), msg); err == nil {
+			t.Errorf("Expected an error for %+v", msg)
+		}
+	}
+}
+
+// fakeSMTPServer accepts one connection and answers every command, sending
+// the message it receives to the returned channel. A stalled server greets
+// and then never replies.
+func fakeSMTPServer(t *testing.T, stalled bool) (host string, port int, received chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	received = make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("220 localhost ESMTP\r\n"))
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case stalled:
+			case inData && line == ".\r\n":
+				inData = false
+				received <- data.String()
+				conn.Write([]byte("250 queued\r\n"))
+			case inData:
+				data.WriteString(line)
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				conn.Write([]byte("354 go ahead\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			default:
+				conn.Write([]byte("250 ok\r\n"))
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	host, port, received := fakeSMTPServer(t, false)
+	m := NewSMTPMailer(host, port, "", "", "noreply@example.com")
+
+	if err := m.Send(context.Background(), Message{To: "jane@example.com", Subject: "Hello", Body: "Hi"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if message := <-received; !strings.Contains(message, "To: jane@example.com\r\n") {
+		t.Errorf("Unexpected message:\n%s", message)
+	}
+}
+
+func TestSMTPMailerStalledServer(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t, true)
+	m := NewSMTPMailer(host, port, "", "", "noreply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := m.Send(ctx, Message{To: "jane@example.com", Subject: "Hello", Body: "Hi"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %v against a stalled server", elapsed)
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth returns a Gin middleware that requires a static bearer token.
+// An empty token rejects every request so the admin surface is never open by accident.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// A raw token without the Bearer scheme is rejected too
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "Valid admin credentials are required",
+				"code":    http.StatusUnauthorized,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AltSvc returns a Gin middleware that advertises an alternative service,
+// such as an HTTP/3 listener, on responses to TLS requests. setHeaders adds
+// the Alt-Svc header; it may fail while the alternative is not listening
+// yet, in which case nothing is advertised.
+func AltSvc(setHeaders func(http.Header) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Clients only switch to an alternative reached over TLS, and requests
+		// that already arrived over HTTP/3 need no advertisement
+		if c.Request.TLS != nil && c.Request.ProtoMajor < 3 {
+			_ = setHeaders(c.Writer.Header())
+		}
+
+		c.Next()
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,9 +11,10 @@ import (
 
 // CacheEntry represents a cached response
 type CacheEntry struct {
-	Data      []byte
-	Timestamp time.Time
-	TTL       time.Duration
+	Data        []byte
+	ContentType string
+	Timestamp   time.Time
+	TTL         time.Duration
 }
 
 // CacheManager manages in-memory cache
@@ -34,6 +37,15 @@ func NewCacheManager() *CacheManager {
 
 // Get retrieves a value from cache
 func (cm *CacheManager) Get(key string) ([]byte, bool) {
+	entry, exists := cm.GetEntry(key)
+	if !exists {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+// GetEntry retrieves an unexpired entry, including its content type
+func (cm *CacheManager) GetEntry(key string) (*CacheEntry, bool) {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
@@ -47,18 +59,24 @@ func (cm *CacheManager) Get(key string) ([]byte, bool) {
 		return nil, false
 	}
 
-	return entry.Data, true
+	return entry, true
 }
 
-// Set stores a value in cache
+// Set stores a JSON value in cache
 func (cm *CacheManager) Set(key string, data []byte, ttl time.Duration) {
+	cm.SetEntry(key, data, "application/json", ttl)
+}
+
+// SetEntry stores a value in cache with the content type to replay it with
+func (cm *CacheManager) SetEntry(key string, data []byte, contentType string, ttl time.Duration) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
 	cm.cache[key] = &CacheEntry{
-		Data:      data,
-		Timestamp: time.Now(),
-		TTL:       ttl,
+		Data:        data,
+		ContentType: contentType,
+		Timestamp:   time.Now(),
+		TTL:         ttl,
 	}
 }
 
@@ -70,6 +88,14 @@ func (cm *CacheManager) Delete(key string) {
 	delete(cm.cache, key)
 }
 
+// Clear removes every value from cache
+func (cm *CacheManager) Clear() {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.cache = make(map[string]*CacheEntry)
+}
+
 // cleanup removes expired entries periodically
 func (cm *CacheManager) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -90,8 +116,21 @@ func (cm *CacheManager) cleanup() {
 // Global cache manager instance
 var cacheManager = NewCacheManager()
 
+// PurgeCache removes all cached responses
+func PurgeCache() {
+	cacheManager.Clear()
+}
+
 // Cache returns a Gin middleware for caching responses
 func Cache(ttl time.Duration) gin.HandlerFunc {
+	return CacheDynamic(func() time.Duration { return ttl })
+}
+
+// CacheDynamic returns a caching middleware that reads the TTL on every
+// request, so it can be replaced at runtime. Responses are cached separately
+// for each value of the vary request headers, such as Accept for handlers
+// that negotiate the format.
+func CacheDynamic(ttl func() time.Duration, vary ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Only cache GET requests
 		if c.Request.Method != "GET" {
@@ -104,12 +143,18 @@ func Cache(ttl time.Duration) gin.HandlerFunc {
 		if c.Request.URL.RawQuery != "" {
 			cacheKey += "?" + c.Request.URL.RawQuery
 		}
+		for _, header := range vary {
+			cacheKey += "\n" + header + ": " + c.GetHeader(header)
+		}
 
 		// Check if response is cached
-		if cachedData, exists := cacheManager.Get(cacheKey); exists {
+		if cached, exists := cacheManager.GetEntry(cacheKey); exists {
 			c.Header("X-Cache", "HIT")
-			c.Header("Content-Type", "application/json")
-			c.Data(200, "application/json", cachedData)
+			for _, header := range vary {
+				c.Writer.Header().Add("Vary", header)
+			}
+			c.Header("Content-Type", cached.ContentType)
+			c.Data(200, cached.ContentType, cached.Data)
 			c.Abort()
 			return
 		}
@@ -124,14 +169,25 @@ func Cache(ttl time.Duration) gin.HandlerFunc {
 		// Process the request
 		c.Next()
 
-		// Cache the response if it was successful
-		if c.Writer.Status() == 200 && len(writer.body) > 0 {
-			cacheManager.Set(cacheKey, writer.body, ttl)
+		// Cache the response if it was successful and not marked private,
+		// such as a draft opened with a preview token
+		if c.Writer.Status() == 200 && len(writer.body) > 0 && !isPrivateResponse(c.Writer.Header()) {
+			contentType := c.Writer.Header().Get("Content-Type")
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			cacheManager.SetEntry(cacheKey, writer.body, contentType, ttl())
 			c.Header("X-Cache", "MISS")
 		}
 	}
 }
 
+// isPrivateResponse reports whether the handler asked for the response not to be shared
+func isPrivateResponse(header http.Header) bool {
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+	return strings.Contains(cacheControl, "private") || strings.Contains(cacheControl, "no-store")
+}
+
 // responseWriter captures the response body
 type responseWriter struct {
 	gin.ResponseWriter
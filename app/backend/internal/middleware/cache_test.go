@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheSkipsPrivateResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	PurgeCache()
+	t.Cleanup(PurgeCache)
+
+	calls := 0
+	router := gin.New()
+	router.Use(Cache(time.Minute))
+	router.GET("/public", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+	router.GET("/private", func(c *gin.Context) {
+		calls++
+		c.Header("Cache-Control", "private, no-store")
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	tests := []struct {
+		path          string
+		expectedCalls int
+	}{
+		{"/public", 1},
+		{"/public", 1},
+		{"/private", 2},
+		{"/private", 3},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tt.expectedCalls, calls, tt.path)
+	}
+}
+
+func TestCacheVariesByHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	PurgeCache()
+	t.Cleanup(PurgeCache)
+
+	calls := 0
+	router := gin.New()
+	router.GET("/transcript", CacheDynamic(func() time.Duration { return time.Minute }, "Accept"), func(c *gin.Context) {
+		calls++
+		c.Header("Vary", "Accept")
+		if c.GetHeader("Accept") == "text/vtt" {
+			c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte("WEBVTT\n"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	tests := []struct {
+		accept              string
+		expectedCalls       int
+		expectedContentType string
+	}{
+		{"text/vtt", 1, "text/vtt; charset=utf-8"},
+		{"application/json", 2, "application/json; charset=utf-8"},
+		{"text/vtt", 2, "text/vtt; charset=utf-8"},
+		{"application/json", 2, "application/json; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/transcript", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tt.expectedCalls, calls, tt.accept)
+		assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Values("Vary"), "Accept")
+	}
+}
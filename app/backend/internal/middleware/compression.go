@@ -56,7 +56,7 @@ func Compression() gin.HandlerFunc {
 
 		// Set headers
 		c.Header("Content-Encoding", "gzip")
-		c.Header("Vary", "Accept-Encoding")
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
 
 		c.Next()
 	}
@@ -117,7 +117,7 @@ func CompressionLevel(level int) gin.HandlerFunc {
 
 		// Set headers
 		c.Header("Content-Encoding", "gzip")
-		c.Header("Vary", "Accept-Encoding")
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
 
 		c.Next()
 	}
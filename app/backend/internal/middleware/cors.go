@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default CORS settings used when a policy leaves a field empty
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Requested-With"}
+	DefaultCORSExposed = []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Cache"}
+	DefaultCORSMaxAge  = 24 * time.Hour
+)
+
+// CORSPolicy describes which cross-origin requests are allowed
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// compiledCORSPolicy is a CORSPolicy prepared for fast matching
+type compiledCORSPolicy struct {
+	anyOrigin        bool
+	exact            map[string]bool
+	patterns         []originPattern
+	methods          map[string]bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// originPattern matches origins such as https://*.podsite.com
+type originPattern struct {
+	prefix string
+	suffix string
+}
+
+// CORSPolicySet selects a policy by the longest matching path prefix
+type CORSPolicySet struct {
+	policies routePolicies[*compiledCORSPolicy]
+}
+
+// NewCORSPolicySet compiles a default policy and optional per-route policies
+// keyed by path prefix
+func NewCORSPolicySet(defaultPolicy CORSPolicy, routes map[string]CORSPolicy) (*CORSPolicySet, error) {
+	compiledDefault, err := compileCORSPolicy(defaultPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledRoutes := make(map[string]*compiledCORSPolicy, len(routes))
+	for prefix, policy := range routes {
+		compiled, err := compileCORSPolicy(policy)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", prefix, err)
+		}
+		compiledRoutes[prefix] = compiled
+	}
+
+	return &CORSPolicySet{policies: newRoutePolicies(compiledDefault, compiledRoutes)}, nil
+}
+
+// policyFor returns the policy that applies to a request path
+func (s *CORSPolicySet) policyFor(path string) *compiledCORSPolicy {
+	return s.policies.forPath(path)
+}
+
+// compileCORSPolicy validates a policy and fills in defaults
+func compileCORSPolicy(policy CORSPolicy) (*compiledCORSPolicy, error) {
+	if len(policy.AllowedMethods) == 0 {
+		policy.AllowedMethods = DefaultCORSMethods
+	}
+	if len(policy.AllowedHeaders) == 0 {
+		policy.AllowedHeaders = DefaultCORSHeaders
+	}
+	if policy.ExposedHeaders == nil {
+		policy.ExposedHeaders = DefaultCORSExposed
+	}
+	if policy.MaxAge == 0 {
+		policy.MaxAge = DefaultCORSMaxAge
+	}
+
+	compiled := &compiledCORSPolicy{
+		exact:            make(map[string]bool),
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowMethods:     strings.Join(policy.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(policy.AllowedHeaders, ", "),
+		exposeHeaders:    strings.Join(policy.ExposedHeaders, ", "),
+		allowCredentials: policy.AllowCredentials,
+		maxAge:           strconv.Itoa(int(policy.MaxAge.Seconds())),
+	}
+
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			compiled.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			pattern, err := parseOriginPattern(origin)
+			if err != nil {
+				return nil, err
+			}
+			compiled.patterns = append(compiled.patterns, pattern)
+		default:
+			compiled.exact[origin] = true
+		}
+	}
+
+	if compiled.anyOrigin && compiled.allowCredentials {
+		return nil, fmt.Errorf("credentials cannot be allowed for the wildcard origin \"*\"")
+	}
+
+	for _, method := range policy.AllowedMethods {
+		compiled.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range policy.AllowedHeaders {
+		compiled.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	return compiled, nil
+}
+
+// parseOriginPattern parses an origin with a single leading subdomain wildcard
+func parseOriginPattern(origin string) (originPattern, error) {
+	u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+	if err != nil || u.Scheme == "" || !strings.HasPrefix(u.Host, "wildcard.") || strings.Count(origin, "*") != 1 {
+		return originPattern{}, fmt.Errorf("invalid origin pattern %q, expected a form like https://*.example.com", origin)
+	}
+
+	star := strings.Index(origin, "*")
+	return originPattern{prefix: origin[:star], suffix: origin[star+1:]}, nil
+}
+
+// matches reports whether origin matches the pattern with a non-empty subdomain
+func (p originPattern) matches(origin string) bool {
+	if len(origin) <= len(p.prefix)+len(p.suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+
+	subdomain := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	for _, r := range subdomain {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return !strings.HasPrefix(subdomain, ".") && !strings.HasSuffix(subdomain, ".")
+}
+
+// allowsOrigin reports whether the policy accepts the origin
+func (p *compiledCORSPolicy) allowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.exact[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether every header in a preflight request is allowed
+func (p *compiledCORSPolicy) allowsHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// setAllowOrigin writes the origin and credential headers for an allowed request
+func (p *compiledCORSPolicy) setAllowOrigin(c *gin.Context, origin string) {
+	if p.anyOrigin {
+		c.Header("Access-Control-Allow-Origin", "*")
+	} else {
+		c.Header("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// CORS returns a Gin middleware for handling CORS with a single policy for the given origins
+func CORS(origins []string) gin.HandlerFunc {
+	set, err := NewCORSPolicySet(CORSPolicy{AllowedOrigins: origins}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return CORSWithPolicies(func() *CORSPolicySet { return set })
+}
+
+// CORSWithPolicies returns a CORS middleware that reads the policy set on
+// every request, so policies can be replaced at runtime
+func CORSWithPolicies(policies func() *CORSPolicySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		policy := policies().policyFor(c.Request.URL.Path)
+
+		// Responses differ by origin, so shared caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		requestedMethod := c.Request.Header.Get("Access-Control-Request-Method")
+		isPreflight := c.Request.Method == http.MethodOptions && requestedMethod != ""
+
+		if !isPreflight {
+			if policy.allowsOrigin(origin) {
+				policy.setAllowOrigin(c, origin)
+				if policy.exposeHeaders != "" {
+					c.Header("Access-Control-Expose-Headers", policy.exposeHeaders)
+				}
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		requestedHeaders := c.Request.Header.Get("Access-Control-Request-Headers")
+		if !policy.allowsOrigin(origin) ||
+			!policy.methods[strings.ToUpper(requestedMethod)] ||
+			!policy.allowsHeaders(requestedHeaders) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		policy.setAllowOrigin(c, origin)
+		c.Header("Access-Control-Allow-Methods", policy.allowMethods)
+		c.Header("Access-Control-Allow-Headers", policy.allowHeaders)
+		c.Header("Access-Control-Max-Age", policy.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCORSTestRouter(t *testing.T, defaultPolicy CORSPolicy, routes map[string]CORSPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	set, err := NewCORSPolicySet(defaultPolicy, routes)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(CORSWithPolicies(func() *CORSPolicySet { return set }))
+	router.GET("/api/episodes", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/api/admin/episodes", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	router := setupCORSTestRouter(t, CORSPolicy{AllowedOrigins: []string{"https://podsite.com"}}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	req.Header.Set("Origin", "https://podsite.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://podsite.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-RateLimit-Remaining")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	router := setupCORSTestRouter(t, CORSPolicy{AllowedOrigins: []string{"https://podsite.com"}}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+}
+
+func TestCORSWildcardSubdomain(t *testing.T) {
+	router := setupCORSTestRouter(t, CORSPolicy{AllowedOrigins: []string{"https://*.podsite.com"}}, nil)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.podsite.com", true},
+		{"https://a.b.podsite.com", true},
+		{"https://podsite.com", false},
+		{"http://app.podsite.com", false},
+		{"https://evilpodsite.com", false},
+		{"https://app.podsite.com.evil.example", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/episodes", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.allowed {
+				assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := setupCORSTestRouter(t, CORSPolicy{
+		AllowedOrigins: []string{"https://podsite.com"},
+		AllowedMethods: []string{"GET"},
+	}, nil)
+
+	tests := []struct {
+		name           string
+		origin         string
+		method         string
+		headers        string
+		expectedStatus int
+	}{
+		{"allowed", "https://podsite.com", "GET", "Content-Type", http.StatusNoContent},
+		{"disallowed origin", "https://evil.example", "GET", "", http.StatusForbidden},
+		{"disallowed method", "https://podsite.com", "DELETE", "", http.StatusForbidden},
+		{"disallowed header", "https://podsite.com", "GET", "X-Custom", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("OPTIONS", "/api/episodes", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusNoContent {
+				assert.Equal(t, tt.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "GET", w.Header().Get("Access-Control-Allow-Methods"))
+				assert.Equal(t, "86400", w.Header().Get("Access-Control-Max-Age"))
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCORSAnyOriginWithoutCredentials(t *testing.T) {
+	router := setupCORSTestRouter(t, CORSPolicy{AllowedOrigins: []string{"*"}}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	_, err := NewCORSPolicySet(CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}, nil)
+	assert.Error(t, err)
+}
+
+func TestCORSRoutePolicy(t *testing.T) {
+	router := setupCORSTestRouter(t,
+		CORSPolicy{AllowedOrigins: []string{"*"}},
+		map[string]CORSPolicy{
+			"/api/admin": {AllowedOrigins: []string{"https://admin.podsite.com"}, AllowCredentials: true},
+		},
+	)
+
+	req, _ := http.NewRequest("GET", "/api/admin/episodes", nil)
+	req.Header.Set("Origin", "https://admin.podsite.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "https://admin.podsite.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	req, _ = http.NewRequest("GET", "/api/admin/episodes", nil)
+	req.Header.Set("Origin", "https://podsite.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
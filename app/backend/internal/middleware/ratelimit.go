@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -65,6 +66,23 @@ func (rl *RateLimiter) IsAllowed(ip string) bool {
 	return true
 }
 
+// Policy returns the current limit and window
+func (rl *RateLimiter) Policy() (int, time.Duration) {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	return rl.limit, rl.window
+}
+
+// SetPolicy replaces the limit and window; request history is kept
+func (rl *RateLimiter) SetPolicy(limit int, window time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	rl.limit = limit
+	rl.window = window
+}
+
 // cleanup removes old entries periodically
 func (rl *RateLimiter) cleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -93,40 +111,20 @@ func (rl *RateLimiter) cleanup() {
 	}
 }
 
-// Global rate limiter instance
-var rateLimiter = NewRateLimiter(100, time.Minute) // 100 requests per minute
-
-// RateLimit returns a Gin middleware for rate limiting
-func RateLimit() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-
-		if !rateLimiter.IsAllowed(clientIP) {
-			c.Header("X-RateLimit-Limit", "100")
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", time.Now().Add(time.Minute).Format(time.RFC3339))
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "rate_limit_exceeded",
-				"message": "Too many requests. Please try again later.",
-				"code":    http.StatusTooManyRequests,
-			})
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
-}
-
 // RateLimitWithConfig returns a Gin middleware with custom rate limiting configuration
 func RateLimitWithConfig(limit int, window time.Duration) gin.HandlerFunc {
-	rl := NewRateLimiter(limit, window)
+	return RateLimitWithLimiter(NewRateLimiter(limit, window))
+}
 
+// RateLimitWithLimiter returns a Gin middleware backed by the given limiter,
+// whose policy may be changed at runtime with SetPolicy
+func RateLimitWithLimiter(rl *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientIP := c.ClientIP()
 
 		if !rl.IsAllowed(clientIP) {
-			c.Header("X-RateLimit-Limit", string(rune(limit)))
+			limit, window := rl.Policy()
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
 			c.Header("X-RateLimit-Remaining", "0")
 			c.Header("X-RateLimit-Reset", time.Now().Add(window).Format(time.RFC3339))
 			c.JSON(http.StatusTooManyRequests, gin.H{
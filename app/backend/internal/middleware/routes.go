@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+)
+
+// routePolicies selects a per-route value by the longest matching path prefix
+type routePolicies[T any] struct {
+	defaultPolicy T
+	prefixes      []string
+	routes        map[string]T
+}
+
+// newRoutePolicies creates a selector from a default and prefix-keyed overrides
+func newRoutePolicies[T any](defaultPolicy T, routes map[string]T) routePolicies[T] {
+	rp := routePolicies[T]{
+		defaultPolicy: defaultPolicy,
+		routes:        routes,
+	}
+
+	for prefix := range routes {
+		rp.prefixes = append(rp.prefixes, prefix)
+	}
+
+	// Longest prefix first so the most specific route wins
+	sort.Slice(rp.prefixes, func(i, j int) bool {
+		return len(rp.prefixes[i]) > len(rp.prefixes[j])
+	})
+
+	return rp
+}
+
+// forPath returns the value that applies to a request path. Prefixes
+// match whole path segments, so /api/admin covers /api/admin and
+// /api/admin/episodes but not /api/administrators.
+func (rp routePolicies[T]) forPath(path string) T {
+	for _, prefix := range rp.prefixes {
+		base := strings.TrimSuffix(prefix, "/")
+		if path == base || strings.HasPrefix(path, base+"/") {
+			return rp.routes[prefix]
+		}
+	}
+	return rp.defaultPolicy
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePoliciesForPath(t *testing.T) {
+	policies := newRoutePolicies("default", map[string]string{
+		"/api/admin":          "admin",
+		"/api/admin/episodes": "episodes",
+		"/feeds/":             "feeds",
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/admin", "admin"},
+		{"/api/admin/comments", "admin"},
+		{"/api/admin/episodes/ep001", "episodes"},
+		{"/api/administrators", "default"},
+		{"/api/admin-tools", "default"},
+		{"/feeds", "feeds"},
+		{"/feeds/playlists/abc", "feeds"},
+		{"/feedsx", "default"},
+		{"/", "default"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policies.forPath(tt.path), tt.path)
+	}
+}
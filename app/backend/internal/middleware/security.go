@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/podsite/backend/internal/config"
+)
+
+// CSPNonceKey is the Gin context key holding the per-request CSP nonce
+const CSPNonceKey = "cspNonce"
+
+// cspNoncePlaceholder is replaced with 'nonce-<value>' in a policy
+const cspNoncePlaceholder = "{nonce}"
+
+// cspReportGroup is the Reporting API group name used in report-to
+const cspReportGroup = "csp-endpoint"
+
+// SecurityPolicy describes the security headers sent for a group of routes.
+// Empty string fields omit the corresponding header.
+type SecurityPolicy struct {
+	// ContentSecurityPolicy may contain {nonce}, which is replaced by a fresh
+	// 'nonce-...' source on every request
+	ContentSecurityPolicy     string
+	CSPReportOnly             bool
+	CSPReportURI              string
+	FrameOptions              string
+	ReferrerPolicy            string
+	PermissionsPolicy         string
+	CrossOriginOpenerPolicy   string
+	CrossOriginResourcePolicy string
+	HSTSMaxAge                time.Duration
+	HSTSIncludeSubdomains     bool
+	HSTSPreload               bool
+}
+
+// DefaultSecurityPolicy returns the policy applied when nothing is configured
+func DefaultSecurityPolicy() SecurityPolicy {
+	return NewSecurityPolicy(config.Default().Security)
+}
+
+// NewSecurityPolicy returns the site-wide policy of the security
+// configuration, without its per-route overrides
+func NewSecurityPolicy(cfg config.SecurityConfig) SecurityPolicy {
+	return SecurityPolicy{
+		ContentSecurityPolicy:     cfg.ContentSecurityPolicy,
+		CSPReportOnly:             cfg.CSPReportOnly,
+		CSPReportURI:              cfg.CSPReportURI,
+		FrameOptions:              cfg.FrameOptions,
+		ReferrerPolicy:            cfg.ReferrerPolicy,
+		PermissionsPolicy:         cfg.PermissionsPolicy,
+		CrossOriginOpenerPolicy:   cfg.CrossOriginOpenerPolicy,
+		CrossOriginResourcePolicy: cfg.CrossOriginResourcePolicy,
+		HSTSMaxAge:                cfg.HSTS.MaxAge,
+		HSTSIncludeSubdomains:     cfg.HSTS.IncludeSubdomains,
+		HSTSPreload:               cfg.HSTS.Preload,
+	}
+}
+
+// compiledSecurityPolicy holds a policy with header values prepared once
+type compiledSecurityPolicy struct {
+	policy         SecurityPolicy
+	cspHeader      string
+	usesNonce      bool
+	hsts           string
+	reportingGroup string
+}
+
+// SecurityPolicySet selects a security policy by the longest matching path prefix
+type SecurityPolicySet struct {
+	policies routePolicies[*compiledSecurityPolicy]
+}
+
+// NewSecurityPolicySet compiles a default policy and optional per-route policies
+// keyed by path prefix
+func NewSecurityPolicySet(defaultPolicy SecurityPolicy, routes map[string]SecurityPolicy) *SecurityPolicySet {
+	compiledRoutes := make(map[string]*compiledSecurityPolicy, len(routes))
+	for prefix, policy := range routes {
+		compiledRoutes[prefix] = compileSecurityPolicy(policy)
+	}
+
+	return &SecurityPolicySet{
+		policies: newRoutePolicies(compileSecurityPolicy(defaultPolicy), compiledRoutes),
+	}
+}
+
+// compileSecurityPolicy prepares header values that do not change per request
+func compileSecurityPolicy(policy SecurityPolicy) *compiledSecurityPolicy {
+	compiled := &compiledSecurityPolicy{
+		policy:    policy,
+		cspHeader: "Content-Security-Policy",
+		usesNonce: strings.Contains(policy.ContentSecurityPolicy, cspNoncePlaceholder),
+	}
+
+	if policy.CSPReportOnly {
+		compiled.cspHeader = "Content-Security-Policy-Report-Only"
+	}
+
+	if policy.CSPReportURI != "" && policy.ContentSecurityPolicy != "" {
+		compiled.policy.ContentSecurityPolicy = fmt.Sprintf("%s; report-uri %s; report-to %s",
+			strings.TrimRight(policy.ContentSecurityPolicy, "; "), policy.CSPReportURI, cspReportGroup)
+		compiled.reportingGroup = fmt.Sprintf("%s=%q", cspReportGroup, policy.CSPReportURI)
+	}
+
+	if policy.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(int(policy.HSTSMaxAge.Seconds()))
+		if policy.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if policy.HSTSPreload {
+			hsts += "; preload"
+		}
+		compiled.hsts = hsts
+	}
+
+	return compiled
+}
+
+// CSPNonce returns the CSP nonce generated for the request, for use in
+// templates as <script nonce="{{ .nonce }}">. It is empty when the active
+// policy does not use {nonce}.
+func CSPNonce(c *gin.Context) string {
+	return c.GetString(CSPNonceKey)
+}
+
+// newNonce returns a random base64 value suitable for a CSP nonce
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// Security returns a Gin middleware for adding the default security headers
+func Security() gin.HandlerFunc {
+	set := NewSecurityPolicySet(DefaultSecurityPolicy(), nil)
+	return SecurityWithPolicies(func() *SecurityPolicySet { return set })
+}
+
+// SecurityWithPolicies returns a security header middleware that reads the
+// policy set on every request, so policies can be replaced at runtime
+func SecurityWithPolicies(policies func() *SecurityPolicySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		compiled := policies().policies.forPath(c.Request.URL.Path)
+		policy := compiled.policy
+
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("X-XSS-Protection", "1; mode=block")
+		setHeaderIfNotEmpty(c, "X-Frame-Options", policy.FrameOptions)
+		setHeaderIfNotEmpty(c, "Referrer-Policy", policy.ReferrerPolicy)
+		setHeaderIfNotEmpty(c, "Permissions-Policy", policy.PermissionsPolicy)
+		setHeaderIfNotEmpty(c, "Cross-Origin-Opener-Policy", policy.CrossOriginOpenerPolicy)
+		setHeaderIfNotEmpty(c, "Cross-Origin-Resource-Policy", policy.CrossOriginResourcePolicy)
+		setHeaderIfNotEmpty(c, "Reporting-Endpoints", compiled.reportingGroup)
+
+		if policy.ContentSecurityPolicy != "" {
+			csp := policy.ContentSecurityPolicy
+			if compiled.usesNonce {
+				nonce, err := newNonce()
+				if err != nil {
+					// Without a nonce the placeholder would weaken the policy; drop nonce sources instead
+					csp = strings.ReplaceAll(csp, cspNoncePlaceholder, "")
+				} else {
+					c.Set(CSPNonceKey, nonce)
+					csp = strings.ReplaceAll(csp, cspNoncePlaceholder, "'nonce-"+nonce+"'")
+				}
+			}
+			c.Header(compiled.cspHeader, csp)
+		}
+
+		// HSTS is only meaningful, and only honoured by browsers, over TLS
+		if compiled.hsts != "" && c.Request.TLS != nil {
+			c.Header("Strict-Transport-Security", compiled.hsts)
+		}
+
+		c.Next()
+	}
+}
+
+// setHeaderIfNotEmpty sets a response header when value is not empty
+func setHeaderIfNotEmpty(c *gin.Context, key, value string) {
+	if value != "" {
+		c.Header(key, value)
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSecurityTestRouter(defaultPolicy SecurityPolicy, routes map[string]SecurityPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	set := NewSecurityPolicySet(defaultPolicy, routes)
+
+	router := gin.New()
+	router.Use(SecurityWithPolicies(func() *SecurityPolicySet { return set }))
+	handler := func(c *gin.Context) { c.String(http.StatusOK, CSPNonce(c)) }
+	router.GET("/api/episodes", handler)
+	router.GET("/swagger/index.html", handler)
+	return router
+}
+
+func TestSecurityDefaultHeaders(t *testing.T) {
+	router := setupSecurityTestRouter(DefaultSecurityPolicy(), nil)
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+	assert.NotEmpty(t, w.Header().Get("Permissions-Policy"))
+	assert.Equal(t, "same-origin", w.Header().Get("Cross-Origin-Opener-Policy"))
+	assert.Equal(t, "same-site", w.Header().Get("Cross-Origin-Resource-Policy"))
+
+	// No HSTS over plain HTTP
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestSecurityHSTSOverTLS(t *testing.T) {
+	policy := DefaultSecurityPolicy()
+	policy.HSTSMaxAge = 365 * 24 * time.Hour
+	policy.HSTSIncludeSubdomains = true
+	router := setupSecurityTestRouter(policy, nil)
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestSecurityCSPNonce(t *testing.T) {
+	policy := DefaultSecurityPolicy()
+	policy.ContentSecurityPolicy = "default-src 'self'; script-src 'self' {nonce}"
+	router := setupSecurityTestRouter(policy, nil)
+
+	nonces := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/api/episodes", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		nonce := w.Body.String()
+		assert.NotEmpty(t, nonce)
+		assert.Contains(t, w.Header().Get("Content-Security-Policy"), "'nonce-"+nonce+"'")
+		assert.NotContains(t, w.Header().Get("Content-Security-Policy"), "{nonce}")
+		nonces[nonce] = true
+	}
+
+	assert.Len(t, nonces, 2, "every request must get a fresh nonce")
+}
+
+func TestSecurityReportOnlyAndRoutes(t *testing.T) {
+	policy := DefaultSecurityPolicy()
+	policy.CSPReportOnly = true
+	policy.CSPReportURI = "/csp-report"
+
+	swagger := policy
+	swagger.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'"
+	swagger.CSPReportOnly = false
+
+	router := setupSecurityTestRouter(policy, map[string]SecurityPolicy{"/swagger": swagger})
+
+	req, _ := http.NewRequest("GET", "/api/episodes", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	reportOnly := w.Header().Get("Content-Security-Policy-Report-Only")
+	assert.True(t, strings.HasSuffix(reportOnly, "report-uri /csp-report; report-to csp-endpoint"))
+	assert.Equal(t, `csp-endpoint="/csp-report"`, w.Header().Get("Reporting-Endpoints"))
+
+	req, _ = http.NewRequest("GET", "/swagger/index.html", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "'unsafe-inline'")
+	assert.Empty(t, w.Header().Get("Content-Security-Policy-Report-Only"))
+}
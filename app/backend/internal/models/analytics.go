@@ -0,0 +1,269 @@
+package models
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Download counting rules, after the IAB Podcast Measurement Technical
+// Guidelines
+const (
+	// DownloadWindow is how long requests from one listener for one episode
+	// count as a single download
+	DownloadWindow = 24 * time.Hour
+	// downloadMinimumSeconds of audio must be served for a download to count
+	downloadMinimumSeconds = 60
+	// defaultDownloadBitrate is assumed, in kbit/s, for unprobed audio
+	defaultDownloadBitrate = 128
+)
+
+// Download is one counted episode download. Listeners are not recorded.
+type Download struct {
+	Timestamp time.Time `json:"timestamp"`
+	EpisodeID string    `json:"episodeId"`
+	App       string    `json:"app"`
+}
+
+// DownloadRequest is one audio request served for an episode
+type DownloadRequest struct {
+	EpisodeID string
+	IP        string
+	UserAgent string
+	// Client is the classification of UserAgent
+	Client Client
+	// Bytes is the number of audio bytes served
+	Bytes int64
+	// MinimumBytes is what a listener's requests must add up to within
+	// the download window to count, see Episode.MinimumDownloadBytes
+	MinimumBytes int64
+	Time         time.Time
+}
+
+// DownloadStats aggregates the downloads of an episode
+type DownloadStats struct {
+	EpisodeID string           `json:"episodeId"`
+	Total     int              `json:"total"`
+	Apps      map[string]int   `json:"apps"`
+	Days      []DailyDownloads `json:"days"`
+}
+
+// DailyDownloads aggregates the downloads of an episode on one day
+type DailyDownloads struct {
+	Date  string         `json:"date"`
+	Total int            `json:"total"`
+	Apps  map[string]int `json:"apps"`
+}
+
+// MinimumDownloadBytes returns the size of a minute of the episode's audio,
+// or of the whole file when it is shorter
+func (e *Episode) MinimumDownloadBytes() int64 {
+	bitrate, length := defaultDownloadBitrate, int64(0)
+	if e.Audio != nil {
+		if e.Audio.Bitrate > 0 {
+			bitrate = e.Audio.Bitrate
+		}
+		length = e.Audio.Length
+	}
+	minimum := int64(bitrate) * 1000 / 8 * downloadMinimumSeconds
+	if length > 0 && length < minimum {
+		return length
+	}
+	return minimum
+}
+
+// listenerWindow tracks the requests of one listener for one episode
+type listenerWindow struct {
+	start   time.Time
+	bytes   int64
+	counted bool
+}
+
+// AnalyticsService counts downloads, deduplicating listeners by a keyed
+// hash of IP address and user agent that is only kept in memory for the
+// download window. Counted downloads are stored in an append-only JSON
+// Lines file.
+type AnalyticsService struct {
+	mutex     sync.Mutex
+	downloads []Download
+	windows   map[string]*listenerWindow
+	pruned    time.Time
+	key       []byte
+	file      *os.File
+}
+
+// NewAnalyticsService returns an in-memory download store whose counts are
+// lost on restart
+func NewAnalyticsService() *AnalyticsService {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &AnalyticsService{windows: make(map[string]*listenerWindow), key: key}
+}
+
+// OpenAnalyticsService loads the downloads in path and appends new ones to
+// it. The file is created if it does not exist.
+func OpenAnalyticsService(path string) (*AnalyticsService, error) {
+	service := NewAnalyticsService()
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var download Download
+			if err := json.Unmarshal(scanner.Bytes(), &download); err != nil {
+				existing.Close()
+				return nil, fmt.Errorf("failed to parse analytics file %s line %d: %w", path, line, err)
+			}
+			service.downloads = append(service.downloads, download)
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read analytics file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open analytics file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics file for writing: %w", err)
+	}
+	service.file = file
+
+	return service, nil
+}
+
+// Record adds the bytes of a request to its listener's window and reports
+// whether that made it a counted download. Bots are never counted, and
+// each listener counts at most once per episode and window.
+func (s *AnalyticsService) Record(request DownloadRequest) (bool, error) {
+	if request.Client.Bot {
+		return false, nil
+	}
+	if request.Time.IsZero() {
+		request.Time = time.Now()
+	}
+	listener := s.listenerKey(request)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneWindows(request.Time)
+	window := s.windows[listener]
+	if window == nil || request.Time.Sub(window.start) >= DownloadWindow {
+		window = &listenerWindow{start: request.Time}
+		s.windows[listener] = window
+	}
+	window.bytes += request.Bytes
+	if window.counted || window.bytes < request.MinimumBytes {
+		return false, nil
+	}
+	window.counted = true
+
+	download := Download{
+		Timestamp: request.Time.UTC(),
+		EpisodeID: request.EpisodeID,
+		App:       request.Client.App,
+	}
+	if s.file != nil {
+		data, err := json.Marshal(download)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode download: %w", err)
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return false, fmt.Errorf("failed to write download: %w", err)
+		}
+	}
+	s.downloads = append(s.downloads, download)
+	return true, nil
+}
+
+// listenerKey hashes what identifies a listener of an episode so that no
+// IP address is kept
+func (s *AnalyticsService) listenerKey(request DownloadRequest) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(request.IP + "\x00" + request.UserAgent + "\x00" + request.EpisodeID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pruneWindows drops expired listener windows, at most once an hour.
+// The caller must hold the mutex.
+func (s *AnalyticsService) pruneWindows(now time.Time) {
+	if now.Sub(s.pruned) < time.Hour {
+		return
+	}
+	for listener, window := range s.windows {
+		if now.Sub(window.start) >= DownloadWindow {
+			delete(s.windows, listener)
+		}
+	}
+	s.pruned = now
+}
+
+// EpisodeDownloads aggregates the downloads of episodeID between from
+// (inclusive) and to (exclusive) by app and by day in loc. Zero times
+// leave the range open.
+func (s *AnalyticsService) EpisodeDownloads(episodeID string, from, to time.Time, loc *time.Location) DownloadStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := DownloadStats{EpisodeID: episodeID, Apps: map[string]int{}, Days: []DailyDownloads{}}
+	days := make(map[string]*DailyDownloads)
+	for _, download := range s.downloads {
+		if download.EpisodeID != episodeID {
+			continue
+		}
+		if !from.IsZero() && download.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !download.Timestamp.Before(to) {
+			continue
+		}
+
+		date := download.Timestamp.In(loc).Format("2006-01-02")
+		day := days[date]
+		if day == nil {
+			day = &DailyDownloads{Date: date, Apps: map[string]int{}}
+			days[date] = day
+		}
+		day.Total++
+		day.Apps[download.App]++
+		stats.Total++
+		stats.Apps[download.App]++
+	}
+
+	for _, day := range days {
+		stats.Days = append(stats.Days, *day)
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Date < stats.Days[j].Date
+	})
+	return stats
+}
+
+// Close closes the analytics file
+func (s *AnalyticsService) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testListenerAgent = "AppleCoreMedia/1.0.0.21E236 (iPhone; U; CPU OS 17_4 like Mac OS X; en_us)"
+
+func TestAnalyticsRecord(t *testing.T) {
+	service := NewAnalyticsService()
+	rules := DefaultUserAgentRules()
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	request := func(ip, userAgent string, bytes int64, at time.Duration) DownloadRequest {
+		return DownloadRequest{EpisodeID: "ep001", IP: ip, UserAgent: userAgent, Client: rules.Classify(userAgent), Bytes: bytes, MinimumBytes: 1000, Time: start.Add(at)}
+	}
+
+	tests := []struct {
+		name     string
+		request  DownloadRequest
+		expected bool
+	}{
+		{"full download", request("192.0.2.1", testListenerAgent, 5000, 0), true},
+		{"same listener", request("192.0.2.1", testListenerAgent, 5000, time.Hour), false},
+		{"other app", request("192.0.2.1", "Overcast/3.0", 5000, time.Hour), true},
+		{"bot", request("192.0.2.2", "Googlebot/2.1", 5000, 0), false},
+		{"no user agent", request("192.0.2.2", "", 5000, 0), false},
+		{"probe", request("192.0.2.3", testListenerAgent, 2, 0), false},
+		{"first chunk", request("192.0.2.3", testListenerAgent, 600, time.Minute), false},
+		{"second chunk", request("192.0.2.3", testListenerAgent, 600, 2*time.Minute), true},
+		{"next day", request("192.0.2.1", testListenerAgent, 5000, 25*time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		counted, err := service.Record(tt.request)
+		if err != nil {
+			t.Fatalf("%s: Record returned error: %v", tt.name, err)
+		}
+		if counted != tt.expected {
+			t.Errorf("%s: expected counted %v, got %v", tt.name, tt.expected, counted)
+		}
+	}
+
+	if other, _ := service.Record(DownloadRequest{EpisodeID: "ep002", IP: "192.0.2.1", UserAgent: testListenerAgent, Client: rules.Classify(testListenerAgent), Bytes: 5000, MinimumBytes: 1000, Time: start}); !other {
+		t.Error("Expected another episode to count separately")
+	}
+}
+
+func TestAnalyticsEpisodeDownloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "downloads.jsonl")
+	service, err := OpenAnalyticsService(path)
+	if err != nil {
+		t.Fatalf("OpenAnalyticsService returned error: %v", err)
+	}
+
+	for i, at := range []string{"2024-03-01T10:00:00Z", "2024-03-01T23:30:00Z", "2024-03-02T08:00:00Z"} {
+		timestamp, _ := time.Parse(time.RFC3339, at)
+		userAgent := testListenerAgent
+		if i == 1 {
+			userAgent = "Spotify/8.9 Android/34"
+		}
+		ip := fmt.Sprintf("192.0.2.%d", i+1)
+		request := DownloadRequest{EpisodeID: "ep001", IP: ip, UserAgent: userAgent, Client: DefaultUserAgentRules().Classify(userAgent), Bytes: 1, Time: timestamp}
+		if _, err := service.Record(request); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+	}
+	service.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "192.0.2.1") || strings.Contains(string(data), "AppleCoreMedia") {
+		t.Errorf("Expected no IP addresses or user agents on disk, got %s", data)
+	}
+
+	reopened, err := OpenAnalyticsService(path)
+	if err != nil {
+		t.Fatalf("OpenAnalyticsService returned error: %v", err)
+	}
+	defer reopened.Close()
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	stats := reopened.EpisodeDownloads("ep001", time.Time{}, time.Time{}, berlin)
+	if stats.Total != 3 || stats.Apps["Apple Podcasts"] != 2 || stats.Apps["Spotify"] != 1 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	// 23:30 UTC is already the next day in Berlin
+	if len(stats.Days) != 2 || stats.Days[0].Date != "2024-03-01" || stats.Days[0].Total != 1 || stats.Days[1].Total != 2 {
+		t.Errorf("Unexpected days: %+v", stats.Days)
+	}
+
+	from, _ := time.Parse(time.RFC3339, "2024-03-02T00:00:00Z")
+	if ranged := reopened.EpisodeDownloads("ep001", from, time.Time{}, time.UTC); ranged.Total != 1 {
+		t.Errorf("Expected 1 download from %v, got %+v", from, ranged)
+	}
+	if other := reopened.EpisodeDownloads("ep002", time.Time{}, time.Time{}, time.UTC); other.Total != 0 || other.Days == nil {
+		t.Errorf("Expected empty stats, got %+v", other)
+	}
+}
+
+func TestMinimumDownloadBytes(t *testing.T) {
+	tests := []struct {
+		episode  Episode
+		expected int64
+	}{
+		{Episode{}, 960000},
+		{Episode{Audio: &MediaInfo{Bitrate: 64, Length: 50_000_000}}, 480000},
+		{Episode{Audio: &MediaInfo{Bitrate: 64, Length: 100_000}}, 100_000},
+	}
+
+	for _, tt := range tests {
+		if got := tt.episode.MinimumDownloadBytes(); got != tt.expected {
+			t.Errorf("%+v: expected %d, got %d", tt.episode.Audio, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Artwork errors
+var (
+	ErrArtworkUnsupported = errors.New("unsupported artwork format")
+	ErrArtworkInvalid     = errors.New("invalid artwork options")
+)
+
+// Podcast directories such as Apple Podcasts require square artwork within
+// these bounds
+const (
+	MinDirectoryArtworkSize = 1400
+	MaxDirectoryArtworkSize = 3000
+)
+
+// ArtworkSizes are the widths and heights artwork can be resized to, so
+// that only a few derivatives of each file are rendered and cached
+var ArtworkSizes = []int{64, 128, 256, 300, 512, 600, 1024, 1400, 2048, MaxDirectoryArtworkSize}
+
+// Limits on the images decoded, the renders running at once and the size
+// of the derivatives kept
+const (
+	maxArtworkPixels    = 8000 * 8000
+	maxArtworkRenders   = 4
+	defaultArtworkCache = 256 << 20
+	placeholderSize     = 16
+	artworkJPEGQuality  = 85
+)
+
+// artworkRenders holds a slot for each render in progress. It is shared by
+// every ArtworkService, since a reload replaces the service while earlier
+// renders finish.
+var artworkRenders = make(chan struct{}, maxArtworkRenders)
+
+// ArtworkFormat is an encoding artwork can be converted to
+type ArtworkFormat string
+
+// Artwork formats
+const (
+	ArtworkJPEG ArtworkFormat = "jpeg"
+	ArtworkPNG  ArtworkFormat = "png"
+)
+
+// ParseArtworkFormat accepts jpeg, jpg or png
+func ParseArtworkFormat(value string) (ArtworkFormat, bool) {
+	switch strings.ToLower(value) {
+	case "jpeg", "jpg":
+		return ArtworkJPEG, true
+	case "png":
+		return ArtworkPNG, true
+	}
+	return "", false
+}
+
+// ContentType returns the media type of the format
+func (f ArtworkFormat) ContentType() string {
+	return "image/" + string(f)
+}
+
+// ArtworkInfo describes raster artwork for progressive loading: its size, a
+// tiny blurred-looking placeholder as a data URI and its dominant color
+type ArtworkInfo struct {
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	Placeholder   string `json:"placeholder"`
+	DominantColor string `json:"dominantColor"`
+}
+
+// ArtworkOptions selects a derivative: Width and Height of 0 keep the
+// aspect ratio, both set crop to fill, and an empty Format keeps the
+// source format
+type ArtworkOptions struct {
+	Width  int
+	Height int
+	Format ArtworkFormat
+}
+
+// Validate checks that the requested sizes are among ArtworkSizes
+func (o ArtworkOptions) Validate() error {
+	for _, size := range []int{o.Width, o.Height} {
+		if size != 0 && !slices.Contains(ArtworkSizes, size) {
+			return fmt.Errorf("%w: sizes must be one of %s pixels", ErrArtworkInvalid, ArtworkSizeList())
+		}
+	}
+	return nil
+}
+
+// ArtworkSizeList returns ArtworkSizes for messages, as "64, 128, … or 3000"
+func ArtworkSizeList() string {
+	sizes := make([]string, len(ArtworkSizes))
+	for i, size := range ArtworkSizes {
+		sizes[i] = strconv.Itoa(size)
+	}
+	return strings.Join(sizes[:len(sizes)-1], ", ") + " or " + sizes[len(sizes)-1]
+}
+
+// ArtworkService resizes and converts JPEG and PNG artwork served from the
+// media directory, keeping up to cacheLimit bytes of derivatives in
+// cacheDir
+type ArtworkService struct {
+	media      *MediaProber
+	cacheDir   string
+	cacheLimit int64
+}
+
+// NewArtworkService creates an artwork service for files found by media,
+// caching derivatives in cacheDir
+func NewArtworkService(media *MediaProber, cacheDir string) *ArtworkService {
+	return &ArtworkService{media: media, cacheDir: cacheDir, cacheLimit: defaultArtworkCache}
+}
+
+// IsRasterArtwork reports whether artworkURL names a JPEG or PNG file
+func IsRasterArtwork(artworkURL string) bool {
+	switch strings.ToLower(path.Ext(strings.SplitN(artworkURL, "?", 2)[0])) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// Render returns the path of the derivative of artworkURL described by
+// opts, creating it on first use. The cache key includes the source's size
+// and modification time, so replaced artwork is picked up. Only a few
+// renders run at once; the others wait for a slot.
+func (s *ArtworkService) Render(artworkURL string, opts ArtworkOptions) (string, ArtworkFormat, error) {
+	if err := opts.Validate(); err != nil {
+		return "", "", err
+	}
+	file, stat, err := s.media.Open(artworkURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	format := opts.Format
+	if format == "" {
+		if format, err = sniffArtwork(file); err != nil {
+			return "", "", err
+		}
+	}
+
+	key := sha256.Sum256([]byte(strings.Join([]string{
+		artworkURL,
+		strconv.FormatInt(stat.Size(), 10),
+		strconv.FormatInt(stat.ModTime().UnixNano(), 10),
+		strconv.Itoa(opts.Width),
+		strconv.Itoa(opts.Height),
+		string(format),
+	}, "\x00")))
+	cached := filepath.Join(s.cacheDir, hex.EncodeToString(key[:16])+"."+string(format))
+	if s.useCached(cached) {
+		return cached, format, nil
+	}
+
+	artworkRenders <- struct{}{}
+	defer func() { <-artworkRenders }()
+	// The same derivative may have been rendered while this one waited
+	if s.useCached(cached) {
+		return cached, format, nil
+	}
+
+	src, _, err := decodeArtwork(file)
+	if err != nil {
+		return "", "", err
+	}
+	var buf bytes.Buffer
+	if err := encodeArtwork(&buf, resizeArtwork(src, opts.Width, opts.Height), format); err != nil {
+		return "", "", fmt.Errorf("failed to encode artwork: %w", err)
+	}
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create artwork cache: %w", err)
+	}
+	if err := writeFileAtomic(cached, buf.Bytes()); err != nil {
+		return "", "", fmt.Errorf("failed to cache artwork: %w", err)
+	}
+	if err := s.pruneCache(cached); err != nil {
+		return "", "", fmt.Errorf("failed to prune artwork cache: %w", err)
+	}
+	return cached, format, nil
+}
+
+// useCached reports whether the derivative at path exists, marking it as
+// recently used by its modification time
+func (s *ArtworkService) useCached(path string) bool {
+	now := time.Now()
+	return os.Chtimes(path, now, now) == nil
+}
+
+// artworkCacheName matches the file names of derivatives, so that other
+// files in the cache directory are left alone
+var artworkCacheName = regexp.MustCompile(`^[0-9a-f]{32}\.(jpeg|png)$`)
+
+// pruneCache removes the least recently used derivatives until the cache
+// fits its limit, keeping the one just rendered at path
+func (s *ArtworkService) pruneCache(path string) error {
+	entries, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		return err
+	}
+	type derivative struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var derivatives []derivative
+	total := int64(0)
+	for _, entry := range entries {
+		if !artworkCacheName.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		derivatives = append(derivatives, derivative{filepath.Join(s.cacheDir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	slices.SortFunc(derivatives, func(a, b derivative) int { return a.used.Compare(b.used) })
+	for _, d := range derivatives {
+		if total <= s.cacheLimit {
+			break
+		}
+		if d.path == path {
+			continue
+		}
+		// Another render may have pruned it already
+		if err := os.Remove(d.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= d.size
+	}
+	return nil
+}
+
+// Analyze measures artworkURL and computes its placeholder and dominant
+// color
+func (p *MediaProber) Analyze(artworkURL string) (*ArtworkInfo, error) {
+	file, _, err := p.Open(artworkURL)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	src, _, err := decodeArtwork(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	info := &ArtworkInfo{Width: bounds.Dx(), Height: bounds.Dy()}
+
+	tiny := resizeArtwork(src, placeholderSize, 0)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flatten(tiny), &jpeg.Options{Quality: 50}); err != nil {
+		return nil, fmt.Errorf("failed to encode placeholder: %w", err)
+	}
+	info.Placeholder = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	info.DominantColor = dominantColor(resizeArtwork(src, 64, 0))
+	return info, nil
+}
+
+// checkDirectoryArtwork reports artwork that podcast directories would reject
+func checkDirectoryArtwork(info *ArtworkInfo) error {
+	if info.Width != info.Height || info.Width < MinDirectoryArtworkSize || info.Width > MaxDirectoryArtworkSize {
+		return fmt.Errorf("artwork is %d×%d; podcast directories require square artwork of %d to %d pixels",
+			info.Width, info.Height, MinDirectoryArtworkSize, MaxDirectoryArtworkSize)
+	}
+	return nil
+}
+
+// sniffArtwork returns the format of a JPEG or PNG image, refusing huge
+// ones before they are decoded, and rewinds file
+func sniffArtwork(file *os.File) (ArtworkFormat, error) {
+	config, name, err := image.DecodeConfig(file)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrArtworkUnsupported, err)
+	}
+	if name != "jpeg" && name != "png" {
+		return "", fmt.Errorf("%w: %s", ErrArtworkUnsupported, name)
+	}
+	if config.Width*config.Height > maxArtworkPixels {
+		return "", fmt.Errorf("%w: %d×%d is too large", ErrArtworkUnsupported, config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return ArtworkFormat(name), nil
+}
+
+// decodeArtwork decodes a JPEG or PNG image
+func decodeArtwork(file *os.File) (image.Image, ArtworkFormat, error) {
+	format, err := sniffArtwork(file)
+	if err != nil {
+		return nil, "", err
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrArtworkUnsupported, err)
+	}
+	return src, format, nil
+}
+
+// encodeArtwork writes img in format; JPEG has no transparency, so images
+// are flattened onto white first
+func encodeArtwork(buf *bytes.Buffer, img *image.RGBA, format ArtworkFormat) error {
+	if format == ArtworkPNG {
+		return png.Encode(buf, img)
+	}
+	return jpeg.Encode(buf, flatten(img), &jpeg.Options{Quality: artworkJPEGQuality})
+}
+
+// flatten composes img over a white background
+func flatten(img *image.RGBA) *image.RGBA {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
+
+// resizeArtwork scales src down to width×height by averaging the source
+// pixels each output pixel covers. A zero dimension keeps the aspect ratio;
+// with both set the image is cropped around its center to fill. Images are
+// never enlarged.
+func resizeArtwork(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	crop := bounds
+
+	switch {
+	case width == 0 && height == 0:
+		width, height = sw, sh
+	case height == 0:
+		height = max(1, sh*width/sw)
+	case width == 0:
+		width = max(1, sw*height/sh)
+	default:
+		// Crop the source to the requested aspect ratio, keeping at least a
+		// pixel of extreme ones
+		if sw*height > sh*width {
+			cw := max(1, sh*width/height)
+			crop = image.Rect(bounds.Min.X+(sw-cw)/2, bounds.Min.Y, bounds.Min.X+(sw-cw)/2+cw, bounds.Max.Y)
+		} else {
+			ch := max(1, sw*height/width)
+			crop = image.Rect(bounds.Min.X, bounds.Min.Y+(sh-ch)/2, bounds.Max.X, bounds.Min.Y+(sh-ch)/2+ch)
+		}
+	}
+	cw, ch := crop.Dx(), crop.Dy()
+	if width > cw || height > ch {
+		// Shrink the request to the source, keeping its aspect ratio
+		scale := min(float64(cw)/float64(width), float64(ch)/float64(height))
+		width, height = max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, cw, ch))
+	draw.Draw(rgba, rgba.Bounds(), src, crop.Min, draw.Src)
+	if width == cw && height == ch {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*ch/height, max((y+1)*ch/height, y*ch/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*cw/width, max((x+1)*cw/width, x*cw/width+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, b, a = r+int(p[0]), g+int(p[1]), b+int(p[2]), a+int(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+// dominantColor returns the average of the most common color bucket, as
+// #rrggbb, ignoring transparent pixels
+func dominantColor(img *image.RGBA) string {
+	type bucket struct{ r, g, b, n int }
+	buckets := make(map[int]*bucket)
+	var best *bucket
+	for i := 0; i+4 <= len(img.Pix); i += 4 {
+		p := img.Pix[i : i+4]
+		if p[3] < 128 {
+			continue
+		}
+		// Un-premultiply, then group colors by their top three bits
+		r, g, b := int(p[0])*255/int(p[3]), int(p[1])*255/int(p[3]), int(p[2])*255/int(p[3])
+		key := r>>5<<6 | g>>5<<3 | b>>5
+		entry := buckets[key]
+		if entry == nil {
+			entry = &bucket{}
+			buckets[key] = entry
+		}
+		entry.r, entry.g, entry.b, entry.n = entry.r+r, entry.g+g, entry.b+b, entry.n+1
+		if best == nil || entry.n > best.n {
+			best = entry
+		}
+	}
+	if best == nil {
+		return "#ffffff"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
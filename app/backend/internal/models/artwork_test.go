@@ -0,0 +1,241 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testArtwork returns a width×height image, red on the left half and blue
+// on the right
+func testArtwork(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 200, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 200, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func writeTestArtwork(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(path, ".png") {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResizeArtwork(t *testing.T) {
+	src := testArtwork(400, 200)
+
+	tests := []struct {
+		name           string
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"original", 0, 0, 400, 200},
+		{"width", 100, 0, 100, 50},
+		{"height", 0, 100, 200, 100},
+		{"crop to square", 100, 100, 100, 100},
+		{"never enlarged", 800, 0, 400, 200},
+		{"crop never enlarged", 300, 300, 200, 200},
+	}
+
+	for _, tt := range tests {
+		got := resizeArtwork(src, tt.width, tt.height).Bounds()
+		if got.Dx() != tt.expectedWidth || got.Dy() != tt.expectedHeight {
+			t.Errorf("%s: expected %dx%d, got %dx%d", tt.name, tt.expectedWidth, tt.expectedHeight, got.Dx(), got.Dy())
+		}
+	}
+
+	// Averaging keeps the halves apart
+	small := resizeArtwork(src, 4, 0)
+	if left, right := small.RGBAAt(0, 0), small.RGBAAt(3, 1); left.R != 200 || right.B != 200 {
+		t.Errorf("Expected red and blue halves, got %v and %v", left, right)
+	}
+}
+
+func TestResizeArtworkExtremeAspect(t *testing.T) {
+	tests := []struct {
+		name                          string
+		srcWidth, srcHeight           int
+		width, height                 int
+		expectedWidth, expectedHeight int
+	}{
+		{"wide source, tall crop", 1000, 1, 64, 3000, 1, 1},
+		{"tall source, wide crop", 1, 1000, 3000, 64, 1, 1},
+	}
+
+	for _, tt := range tests {
+		got := resizeArtwork(testArtwork(tt.srcWidth, tt.srcHeight), tt.width, tt.height).Bounds()
+		if got.Dx() != tt.expectedWidth || got.Dy() != tt.expectedHeight {
+			t.Errorf("%s: expected %dx%d, got %dx%d", tt.name, tt.expectedWidth, tt.expectedHeight, got.Dx(), got.Dy())
+		}
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	img := testArtwork(10, 10)
+	// Make red the larger area
+	for y := 0; y < 10; y++ {
+		img.Set(5, y, color.RGBA{R: 200, A: 255})
+	}
+	if got := dominantColor(img); got != "#c80000" {
+		t.Errorf("Expected #c80000, got %s", got)
+	}
+	if got := dominantColor(image.NewRGBA(image.Rect(0, 0, 2, 2))); got != "#ffffff" {
+		t.Errorf("Expected white for transparent artwork, got %s", got)
+	}
+}
+
+func TestArtworkService(t *testing.T) {
+	root := t.TempDir()
+	writeTestArtwork(t, filepath.Join(root, "images", "ep.png"), testArtwork(400, 200))
+	if err := os.WriteFile(filepath.Join(root, "images", "broken.jpg"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := t.TempDir()
+	service := NewArtworkService(NewMediaProber(root), cacheDir)
+
+	path, format, err := service.Render("/images/ep.png", ArtworkOptions{Width: 64, Height: 64, Format: ArtworkJPEG})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if format != ArtworkJPEG || filepath.Dir(path) != cacheDir {
+		t.Errorf("Expected a cached JPEG, got %s at %s", format, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, name, err := image.DecodeConfig(file)
+	file.Close()
+	if err != nil || name != "jpeg" || config.Width != 64 || config.Height != 64 {
+		t.Errorf("Expected a 64x64 JPEG, got %s %dx%d (%v)", name, config.Width, config.Height, err)
+	}
+
+	again, _, err := service.Render("/images/ep.png", ArtworkOptions{Width: 64, Height: 64, Format: ArtworkJPEG})
+	if err != nil || again != path {
+		t.Errorf("Expected the cached derivative %s, got %s (%v)", path, again, err)
+	}
+	if _, format, _ := service.Render("/images/ep.png", ArtworkOptions{Width: 128}); format != ArtworkPNG {
+		t.Errorf("Expected the source format, got %s", format)
+	}
+
+	for _, opts := range []ArtworkOptions{{Width: 5}, {Width: 65}, {Width: 64, Height: 4000}} {
+		if _, _, err := service.Render("/images/ep.png", opts); !errors.Is(err, ErrArtworkInvalid) {
+			t.Errorf("%+v: expected ErrArtworkInvalid, got %v", opts, err)
+		}
+	}
+	if _, _, err := service.Render("/images/broken.jpg", ArtworkOptions{}); !errors.Is(err, ErrArtworkUnsupported) {
+		t.Errorf("Expected ErrArtworkUnsupported, got %v", err)
+	}
+	if _, _, err := service.Render("/images/missing.jpg", ArtworkOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file, got %v", err)
+	}
+}
+
+func TestArtworkCacheLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTestArtwork(t, filepath.Join(root, "ep.png"), testArtwork(400, 200))
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "notes.txt"), make([]byte, 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewArtworkService(NewMediaProber(root), cacheDir)
+
+	render := func(width int) string {
+		t.Helper()
+		path, _, err := service.Render("/ep.png", ArtworkOptions{Width: width, Format: ArtworkPNG})
+		if err != nil {
+			t.Fatalf("Render returned error: %v", err)
+		}
+		return path
+	}
+	small, medium, large := render(64), render(128), render(256)
+	total := int64(0)
+	for _, path := range []string{small, medium, large} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += stat.Size()
+	}
+	if err := os.Remove(large); err != nil {
+		t.Fatal(err)
+	}
+	// One derivative too many
+	service.cacheLimit = total - 1
+
+	// Using the first derivative again keeps it over the second
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(small, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(medium, old.Add(time.Minute), old.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	render(64)
+	render(256)
+
+	for path, expected := range map[string]bool{small: true, medium: false, large: true, filepath.Join(cacheDir, "notes.txt"): true} {
+		if _, err := os.Stat(path); (err == nil) != expected {
+			t.Errorf("Expected %s to be kept: %v, got %v", filepath.Base(path), expected, err)
+		}
+	}
+}
+
+func TestApplyMediaArtwork(t *testing.T) {
+	root := t.TempDir()
+	writeTestArtwork(t, filepath.Join(root, "images", "square.jpg"), testArtwork(1400, 1400))
+	writeTestArtwork(t, filepath.Join(root, "images", "small.png"), testArtwork(300, 300))
+
+	service := &EpisodeService{episodes: []Episode{
+		{ID: "square", ArtworkURL: "/images/square.jpg", AudioURL: "https://cdn.example.com/a.mp3"},
+		{ID: "small", ArtworkURL: "/images/small.png", AudioURL: "https://cdn.example.com/a.mp3"},
+		{ID: "vector", ArtworkURL: "/images/ep.svg", AudioURL: "https://cdn.example.com/a.mp3"},
+	}}
+
+	issues := service.ApplyMedia(NewMediaProber(root))
+	if len(issues) != 1 || issues[0].EpisodeID != "small" || !strings.Contains(issues[0].Error, "square artwork of 1400 to 3000 pixels") {
+		t.Errorf("Expected one issue for the small artwork, got %+v", issues)
+	}
+
+	episodes := service.GetAll()
+	square := episodes[0].Artwork
+	if square == nil || square.Width != 1400 || !strings.HasPrefix(square.Placeholder, "data:image/jpeg;base64,") || len(square.Placeholder) > 2000 {
+		t.Fatalf("Unexpected artwork info %+v", square)
+	}
+	if episodes[1].Artwork == nil || episodes[1].Artwork.Height != 300 {
+		t.Errorf("Expected small artwork to be measured, got %+v", episodes[1].Artwork)
+	}
+	if episodes[2].Artwork != nil {
+		t.Errorf("Expected no info for vector artwork, got %+v", episodes[2].Artwork)
+	}
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AuditEntry records one mutating administrative request
+type AuditEntry struct {
+	ID         string        `json:"id"`
+	Timestamp  time.Time     `json:"timestamp"`
+	Actor      string        `json:"actor"`
+	ActorRole  string        `json:"actorRole,omitempty"`
+	AuthMethod string        `json:"authMethod,omitempty"`
+	Action     string        `json:"action"`
+	TargetType string        `json:"targetType,omitempty"`
+	TargetID   string        `json:"targetId,omitempty"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Status     int           `json:"status"`
+	RequestID  string        `json:"requestId,omitempty"`
+	Changes    []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a single field that differs between the before and after state
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter selects audit entries; zero values match everything
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+// AuditService stores audit entries in an append-only JSON Lines file and
+// keeps them in memory for querying
+type AuditService struct {
+	mutex   sync.RWMutex
+	entries []AuditEntry
+	file    *os.File
+}
+
+// NewAuditService returns an in-memory audit store whose entries are lost on restart
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// OpenAuditService loads the entries in path and appends new entries to it.
+// The file is created if it does not exist.
+func OpenAuditService(path string) (*AuditService, error) {
+	service := &AuditService{}
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var entry AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				existing.Close()
+				return nil, fmt.Errorf("failed to parse audit log %s line %d: %w", path, line, err)
+			}
+			service.entries = append(service.entries, entry)
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log for writing: %w", err)
+	}
+	service.file = file
+
+	return service, nil
+}
+
+// Record assigns an ID and timestamp to the entry, when missing, and appends it
+func (s *AuditService) Record(entry AuditEntry) (*AuditEntry, error) {
+	if entry.ID == "" {
+		entry.ID = newAuditID()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file != nil {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return nil, fmt.Errorf("failed to write audit entry: %w", err)
+		}
+		if err := s.file.Sync(); err != nil {
+			return nil, fmt.Errorf("failed to sync audit log: %w", err)
+		}
+	}
+
+	s.entries = append(s.entries, entry)
+	return &entry, nil
+}
+
+// Query returns matching entries, most recently recorded first
+func (s *AuditService) Query(filter AuditFilter) []AuditEntry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var matches []AuditEntry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		entry := s.entries[i]
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if filter.TargetType != "" && entry.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != "" && entry.TargetID != filter.TargetID {
+			continue
+		}
+		if !filter.From.IsZero() && entry.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !entry.Timestamp.Before(filter.To) {
+			continue
+		}
+		matches = append(matches, entry)
+		if filter.Limit > 0 && len(matches) == filter.Limit {
+			break
+		}
+	}
+
+	return matches
+}
+
+// Close closes the audit log file
+func (s *AuditService) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// DiffFields compares the JSON representation of two values, such as an
+// Episode before and after an update, and returns the fields that differ.
+// A nil before or after describes a creation or deletion.
+func DiffFields(before, after interface{}) []FieldChange {
+	beforeFields := jsonFields(before)
+	afterFields := jsonFields(after)
+
+	names := make(map[string]bool, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+
+	var changes []FieldChange
+	for name := range names {
+		if !reflect.DeepEqual(beforeFields[name], afterFields[name]) {
+			changes = append(changes, FieldChange{
+				Field:  name,
+				Before: beforeFields[name],
+				After:  afterFields[name],
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// jsonFields returns the top-level JSON fields of v
+func jsonFields(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// newAuditID returns a random identifier for an audit entry
+func newAuditID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
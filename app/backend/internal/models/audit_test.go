@@ -0,0 +1,91 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditServicePersistsAndFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	service, err := OpenAuditService(path)
+	if err != nil {
+		t.Fatalf("OpenAuditService returned error: %v", err)
+	}
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []AuditEntry{
+		{Timestamp: start, Actor: "alice", Action: "episode.create", TargetType: "episode", TargetID: "ep001"},
+		{Timestamp: start.Add(time.Hour), Actor: "bob", Action: "episode.update", TargetType: "episode", TargetID: "ep001"},
+		{Timestamp: start.Add(2 * time.Hour), Actor: "alice", Action: "episode.delete", TargetType: "episode", TargetID: "ep002"},
+	}
+	for _, entry := range entries {
+		if _, err := service.Record(entry); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+	}
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// Entries must survive reopening the file
+	service, err = OpenAuditService(path)
+	if err != nil {
+		t.Fatalf("OpenAuditService returned error: %v", err)
+	}
+	defer service.Close()
+
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected []string
+	}{
+		{"all, newest first", AuditFilter{}, []string{"episode.delete", "episode.update", "episode.create"}},
+		{"by actor", AuditFilter{Actor: "alice"}, []string{"episode.delete", "episode.create"}},
+		{"by target", AuditFilter{TargetID: "ep001"}, []string{"episode.update", "episode.create"}},
+		{"by time range", AuditFilter{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)}, []string{"episode.update"}},
+		{"with limit", AuditFilter{Limit: 1}, []string{"episode.delete"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := service.Query(tt.filter)
+			if len(matches) != len(tt.expected) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.expected), len(matches))
+			}
+			for i, action := range tt.expected {
+				if matches[i].Action != action {
+					t.Errorf("Entry %d: expected action %s, got %s", i, action, matches[i].Action)
+				}
+				if matches[i].ID == "" {
+					t.Errorf("Entry %d has no ID", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	before := &Episode{ID: "ep001", Number: 1, Title: "Old", Tags: []string{"a"}}
+	after := &Episode{ID: "ep001", Number: 1, Title: "New", Tags: []string{"a", "b"}}
+
+	changes := DiffFields(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].Field != "tags" || changes[1].Field != "title" {
+		t.Errorf("Expected tags and title to change, got %s and %s", changes[0].Field, changes[1].Field)
+	}
+	if changes[1].Before != "Old" || changes[1].After != "New" {
+		t.Errorf("Unexpected title change: %+v", changes[1])
+	}
+
+	var deleted *Episode
+	created := DiffFields(deleted, after)
+	for _, change := range created {
+		if change.Before != nil {
+			t.Errorf("Creation should have no before value for %s", change.Field)
+		}
+	}
+}
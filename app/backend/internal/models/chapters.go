@@ -0,0 +1,169 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Chapter errors
+var (
+	ErrChaptersNotFound = errors.New("chapters not found")
+	ErrChaptersInvalid  = errors.New("invalid chapters")
+)
+
+// ChaptersContentType is the media type of Podcasting 2.0 JSON chapters
+const ChaptersContentType = "application/json+chapters"
+
+// chaptersVersion is the Podcasting 2.0 JSON chapters version written
+const chaptersVersion = "1.2.0"
+
+// Chapter is one chapter marker, with times in seconds from the start of
+// the audio. TOC is false for chapters that should not be listed in the
+// player's table of contents.
+type Chapter struct {
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Img       string  `json:"img,omitempty"`
+	URL       string  `json:"url,omitempty"`
+	TOC       *bool   `json:"toc,omitempty"`
+}
+
+// Chapters is an episode's chapter list in Podcasting 2.0 JSON form
+type Chapters struct {
+	Version  string    `json:"version"`
+	Chapters []Chapter `json:"chapters"`
+}
+
+// ChaptersSummary describes chapters in audit entries
+type ChaptersSummary struct {
+	Chapters int      `json:"chapters"`
+	Titles   []string `json:"titles,omitempty"`
+}
+
+// Summary lists the chapter titles
+func (c *Chapters) Summary() ChaptersSummary {
+	summary := ChaptersSummary{Chapters: len(c.Chapters)}
+	for _, chapter := range c.Chapters {
+		summary.Titles = append(summary.Titles, chapter.Title)
+	}
+	return summary
+}
+
+// normalize orders the chapters, rounds times to milliseconds and checks
+// their time ranges
+func (c *Chapters) normalize() error {
+	c.Version = chaptersVersion
+	var problems []string
+	if len(c.Chapters) == 0 {
+		problems = append(problems, "there are no chapters")
+	}
+	for i := range c.Chapters {
+		chapter := &c.Chapters[i]
+		chapter.Title = strings.TrimSpace(chapter.Title)
+		chapter.StartTime = math.Round(chapter.StartTime*1000) / 1000
+		chapter.EndTime = math.Round(chapter.EndTime*1000) / 1000
+		if chapter.StartTime < 0 {
+			problems = append(problems, fmt.Sprintf("chapter %d starts before the audio", i+1))
+		}
+		if chapter.EndTime != 0 && chapter.EndTime < chapter.StartTime {
+			problems = append(problems, fmt.Sprintf("chapter %d ends before it starts", i+1))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrChaptersInvalid, strings.Join(problems, "; "))
+	}
+	sort.SliceStable(c.Chapters, func(i, j int) bool {
+		return c.Chapters[i].StartTime < c.Chapters[j].StartTime
+	})
+	return nil
+}
+
+// ParseChapters reads a Podcasting 2.0 JSON chapters file
+func ParseChapters(data []byte) (*Chapters, error) {
+	var chapters Chapters
+	if err := json.Unmarshal(data, &chapters); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChaptersInvalid, err)
+	}
+	if err := chapters.normalize(); err != nil {
+		return nil, err
+	}
+	return &chapters, nil
+}
+
+// ChapterService stores one chapter list per episode as
+// chapters/<episode id>.json in the content directory
+type ChapterService struct {
+	dir string
+}
+
+// NewChapterService creates a chapter service using the default content directory
+func NewChapterService() *ChapterService {
+	return NewChapterServiceFromDir(DefaultContentDir())
+}
+
+// NewChapterServiceFromDir creates a chapter service for the content directory dir
+func NewChapterServiceFromDir(dir string) *ChapterService {
+	return &ChapterService{dir: filepath.Join(dir, "chapters")}
+}
+
+// path returns the file of the chapters of episodeID
+func (s *ChapterService) path(episodeID string) (string, error) {
+	if !episodeIDPattern.MatchString(episodeID) {
+		return "", ErrChaptersNotFound
+	}
+	return filepath.Join(s.dir, episodeID+".json"), nil
+}
+
+// Get returns the chapters of episodeID
+func (s *ChapterService) Get(episodeID string) (*Chapters, error) {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrChaptersNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %w", err)
+	}
+
+	var chapters Chapters
+	if err := json.Unmarshal(data, &chapters); err != nil {
+		return nil, fmt.Errorf("failed to parse chapters JSON: %w", err)
+	}
+	return &chapters, nil
+}
+
+// Save stores the chapters of episodeID, replacing any previous ones
+func (s *ChapterService) Save(episodeID string, chapters *Chapters) error {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create chapters directory: %w", err)
+	}
+	return writeJSONFile(path, chapters)
+}
+
+// Delete removes the chapters of episodeID
+func (s *ChapterService) Delete(episodeID string) error {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return ErrChaptersNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to delete chapters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// id3TestFrame encodes an ID3v2 frame, with a syncsafe size for v2.4
+func id3TestFrame(major byte, id string, body []byte) []byte {
+	frame := []byte(id)
+	size := make([]byte, 4)
+	if major == 4 {
+		size = id3TestSyncsafe(len(body))
+	} else {
+		binary.BigEndian.PutUint32(size, uint32(len(body)))
+	}
+	frame = append(frame, size...)
+	frame = append(frame, 0, 0)
+	return append(frame, body...)
+}
+
+func id3TestSyncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// id3TestChapter encodes a CHAP frame with a title and a link
+func id3TestChapter(major byte, id string, start, end uint32, title, url string) []byte {
+	body := append([]byte(id), 0)
+	times := make([]byte, 16)
+	binary.BigEndian.PutUint32(times[0:], start)
+	binary.BigEndian.PutUint32(times[4:], end)
+	for i := 8; i < 16; i++ {
+		times[i] = 0xff
+	}
+	body = append(body, times...)
+	body = append(body, id3TestFrame(major, "TIT2", append([]byte{3}, title...))...)
+	if url != "" {
+		body = append(body, id3TestFrame(major, "WXXX", append([]byte{0, 0}, url...))...)
+	}
+	return id3TestFrame(major, "CHAP", body)
+}
+
+// id3TestTag builds an MP3 start: an ID3 tag with chapters and a TOC
+// listing only the first two, followed by audio
+func id3TestTag(major byte) []byte {
+	var frames []byte
+	frames = append(frames, id3TestFrame(major, "TIT2", []byte("\x00Episode"))...)
+	// Chapters are deliberately out of order
+	frames = append(frames, id3TestChapter(major, "chp2", 60000, 125500, "Interview", "https://example.com/guest")...)
+	frames = append(frames, id3TestChapter(major, "chp1", 0, 60000, "Intro", "")...)
+	frames = append(frames, id3TestChapter(major, "ad", 125500, 150000, "Sponsor", "")...)
+	toc := append([]byte("toc\x00"), 0x03, 2)
+	toc = append(toc, "chp1\x00chp2\x00"...)
+	frames = append(frames, id3TestFrame(major, "CTOC", toc)...)
+	frames = append(frames, 0, 0, 0, 0) // padding
+
+	tag := append([]byte{'I', 'D', '3', major, 0, 0}, id3TestSyncsafe(len(frames))...)
+	tag = append(tag, frames...)
+	return append(tag, 0xff, 0xfb, 0x90, 0x00)
+}
+
+func TestReadID3Chapters(t *testing.T) {
+	for _, major := range []byte{3, 4} {
+		chapters, err := ReadID3Chapters(bytes.NewReader(id3TestTag(major)))
+		if err != nil {
+			t.Fatalf("ID3v2.%d: ReadID3Chapters returned error: %v", major, err)
+		}
+		if len(chapters.Chapters) != 3 {
+			t.Fatalf("ID3v2.%d: expected 3 chapters, got %+v", major, chapters.Chapters)
+		}
+
+		intro, interview, sponsor := chapters.Chapters[0], chapters.Chapters[1], chapters.Chapters[2]
+		if intro.Title != "Intro" || intro.StartTime != 0 || intro.EndTime != 60 || intro.TOC != nil {
+			t.Errorf("ID3v2.%d: unexpected first chapter %+v", major, intro)
+		}
+		if interview.Title != "Interview" || interview.StartTime != 60 || interview.EndTime != 125.5 || interview.URL != "https://example.com/guest" {
+			t.Errorf("ID3v2.%d: unexpected second chapter %+v", major, interview)
+		}
+		if sponsor.TOC == nil || *sponsor.TOC {
+			t.Errorf("ID3v2.%d: expected the sponsor chapter to be hidden from the TOC, got %+v", major, sponsor)
+		}
+	}
+}
+
+func TestReadID3ChaptersErrors(t *testing.T) {
+	title := id3TestFrame(3, "TIT2", []byte("\x00E"))
+	noChapters := append([]byte{'I', 'D', '3', 3, 0, 0}, id3TestSyncsafe(len(title))...)
+	noChapters = append(noChapters, title...)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not tagged", []byte("RIFF....WAVE")},
+		{"unsupported version", []byte{'I', 'D', '3', 2, 0, 0, 0, 0, 0, 0}},
+		{"truncated", append([]byte{'I', 'D', '3', 3, 0, 0}, id3TestSyncsafe(100)...)},
+		{"no chapters", noChapters},
+	}
+
+	for _, tt := range tests {
+		if _, err := ReadID3Chapters(bytes.NewReader(tt.data)); !errors.Is(err, ErrChaptersInvalid) {
+			t.Errorf("%s: expected ErrChaptersInvalid, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeID3String(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding byte
+		expected string
+	}{
+		{"latin1", []byte("Caf\xe9"), 0, "Café"},
+		{"utf-16 little endian", []byte{0xff, 0xfe, 'H', 0, 'i', 0}, 1, "Hi"},
+		{"utf-16 big endian", []byte{0, 'H', 0, 'i'}, 2, "Hi"},
+		{"utf-8", []byte("Café"), 3, "Café"},
+	}
+
+	for _, tt := range tests {
+		if got := decodeID3String(tt.data, tt.encoding); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectError bool
+	}{
+		{"valid", `{"version":"1.2.0","chapters":[{"startTime":30,"title":"B"},{"startTime":0,"title":"A"}]}`, false},
+		{"empty", `{"version":"1.2.0","chapters":[]}`, true},
+		{"negative start", `{"chapters":[{"startTime":-1}]}`, true},
+		{"ends before start", `{"chapters":[{"startTime":10,"endTime":5}]}`, true},
+		{"not json", `WEBVTT`, true},
+	}
+
+	for _, tt := range tests {
+		chapters, err := ParseChapters([]byte(tt.data))
+		if tt.expectError {
+			if !errors.Is(err, ErrChaptersInvalid) {
+				t.Errorf("%s: expected ErrChaptersInvalid, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: ParseChapters returned error: %v", tt.name, err)
+		}
+		if chapters.Chapters[0].Title != "A" || chapters.Version != chaptersVersion {
+			t.Errorf("%s: expected sorted chapters, got %+v", tt.name, chapters)
+		}
+	}
+}
+
+func TestChapterService(t *testing.T) {
+	service := NewChapterServiceFromDir(t.TempDir())
+
+	if _, err := service.Get("ep001"); !errors.Is(err, ErrChaptersNotFound) {
+		t.Errorf("Expected ErrChaptersNotFound, got %v", err)
+	}
+	if _, err := service.Get("../secrets"); !errors.Is(err, ErrChaptersNotFound) {
+		t.Errorf("Expected ErrChaptersNotFound for a path, got %v", err)
+	}
+
+	chapters, err := ParseChapters([]byte(`{"chapters":[{"startTime":0,"title":"Intro"}]}`))
+	if err != nil {
+		t.Fatalf("ParseChapters returned error: %v", err)
+	}
+	if err := service.Save("ep001", chapters); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	stored, err := service.Get("ep001")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if summary := stored.Summary(); summary.Chapters != 1 || strings.Join(summary.Titles, ",") != "Intro" {
+		t.Errorf("Unexpected stored chapters %+v", stored)
+	}
+
+	if err := service.Delete("ep001"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := service.Delete("ep001"); !errors.Is(err, ErrChaptersNotFound) {
+		t.Errorf("Expected ErrChaptersNotFound, got %v", err)
+	}
+}
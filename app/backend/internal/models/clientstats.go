@@ -0,0 +1,172 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Request kinds counted by ClientStatsService
+const (
+	RequestKindAPI   = "api"
+	RequestKindMedia = "media"
+)
+
+// clientStatsRetentionDays is how many days of request counts are kept
+const clientStatsRetentionDays = 400
+
+// ClientCount is the number of requests of one kind made by one class of
+// client on one day
+type ClientCount struct {
+	Date     string `json:"date"`
+	Kind     string `json:"kind"`
+	App      string `json:"app"`
+	OS       string `json:"os"`
+	Device   string `json:"device"`
+	Requests int    `json:"requests"`
+}
+
+// ClientStats aggregates request counts by app, operating system, device
+// class and day
+type ClientStats struct {
+	Requests int             `json:"requests"`
+	Apps     map[string]int  `json:"apps"`
+	OS       map[string]int  `json:"os"`
+	Devices  map[string]int  `json:"devices"`
+	Days     []DailyRequests `json:"days"`
+}
+
+// DailyRequests is the number of requests on one day
+type DailyRequests struct {
+	Date     string `json:"date"`
+	Requests int    `json:"requests"`
+}
+
+// ClientStatsService counts requests per day by client class. Counts are
+// kept in memory and written to a JSON file by Save.
+type ClientStatsService struct {
+	mutex  sync.Mutex
+	counts map[ClientCount]int
+	path   string
+	dirty  bool
+}
+
+// NewClientStatsService returns an in-memory request counter whose counts
+// are lost on restart
+func NewClientStatsService() *ClientStatsService {
+	return &ClientStatsService{counts: make(map[ClientCount]int)}
+}
+
+// OpenClientStatsService loads the counts in path, which Save writes back.
+// A missing file starts empty.
+func OpenClientStatsService(path string) (*ClientStatsService, error) {
+	service := NewClientStatsService()
+	service.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return service, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client stats: %w", err)
+	}
+	var counts []ClientCount
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return nil, fmt.Errorf("failed to parse client stats %s: %w", path, err)
+	}
+	for _, count := range counts {
+		requests := count.Requests
+		count.Requests = 0
+		service.counts[count] += requests
+	}
+	return service, nil
+}
+
+// Record counts a request of kind from client on date (YYYY-MM-DD)
+func (s *ClientStatsService) Record(kind string, client Client, date string) {
+	key := ClientCount{Date: date, Kind: kind, App: client.App, OS: client.OS, Device: client.Device}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.counts[key]++
+	s.dirty = true
+}
+
+// Query aggregates the requests of kind, or of every kind when empty,
+// between the dates from and to (YYYY-MM-DD, inclusive). Empty dates leave
+// the range open.
+func (s *ClientStatsService) Query(kind, from, to string) ClientStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := ClientStats{Apps: map[string]int{}, OS: map[string]int{}, Devices: map[string]int{}, Days: []DailyRequests{}}
+	days := make(map[string]int)
+	for key, requests := range s.counts {
+		if kind != "" && key.Kind != kind {
+			continue
+		}
+		if (from != "" && key.Date < from) || (to != "" && key.Date > to) {
+			continue
+		}
+		stats.Requests += requests
+		stats.Apps[key.App] += requests
+		stats.OS[key.OS] += requests
+		stats.Devices[key.Device] += requests
+		days[key.Date] += requests
+	}
+
+	for date, requests := range days {
+		stats.Days = append(stats.Days, DailyRequests{Date: date, Requests: requests})
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Date < stats.Days[j].Date
+	})
+	return stats
+}
+
+// Save drops counts older than the retention period and writes the rest
+// to the file the service was opened from, if any and if they changed
+func (s *ClientStatsService) Save(now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	oldest := now.AddDate(0, 0, -clientStatsRetentionDays).Format("2006-01-02")
+	for key := range s.counts {
+		if key.Date < oldest {
+			delete(s.counts, key)
+		}
+	}
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+
+	counts := make([]ClientCount, 0, len(s.counts))
+	for key, requests := range s.counts {
+		key.Requests = requests
+		counts = append(counts, key)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.App != b.App {
+			return a.App < b.App
+		}
+		if a.OS != b.OS {
+			return a.OS < b.OS
+		}
+		return a.Device < b.Device
+	})
+	if err := writeJSONFile(s.path, counts); err != nil {
+		return fmt.Errorf("failed to save client stats: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clients.json")
+	service, err := OpenClientStatsService(path)
+	if err != nil {
+		t.Fatalf("OpenClientStatsService returned error: %v", err)
+	}
+
+	phone := Client{App: "Overcast", OS: "iOS", Device: "Phone"}
+	desktop := Client{App: "Firefox", OS: "Linux", Device: "Desktop"}
+	service.Record(RequestKindMedia, phone, "2024-03-01")
+	service.Record(RequestKindMedia, phone, "2024-03-01")
+	service.Record(RequestKindAPI, desktop, "2024-03-01")
+	service.Record(RequestKindAPI, phone, "2024-03-02")
+	service.Record(RequestKindAPI, desktop, "2023-01-01")
+
+	if err := service.Save(time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	reopened, err := OpenClientStatsService(path)
+	if err != nil {
+		t.Fatalf("OpenClientStatsService returned error: %v", err)
+	}
+
+	all := reopened.Query("", "", "")
+	if all.Requests != 4 || all.Apps["Overcast"] != 3 || all.OS["Linux"] != 1 || all.Devices["Phone"] != 3 {
+		t.Errorf("Expected counts older than the retention period to be dropped, got %+v", all)
+	}
+	if len(all.Days) != 2 || all.Days[0] != (DailyRequests{Date: "2024-03-01", Requests: 3}) {
+		t.Errorf("Unexpected days: %+v", all.Days)
+	}
+
+	media := reopened.Query(RequestKindMedia, "", "")
+	if media.Requests != 2 || media.Apps["Firefox"] != 0 {
+		t.Errorf("Unexpected media counts: %+v", media)
+	}
+	if ranged := reopened.Query("", "2024-03-02", "2024-03-02"); ranged.Requests != 1 {
+		t.Errorf("Expected 1 request on 2024-03-02, got %+v", ranged)
+	}
+}
@@ -0,0 +1,400 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Comment errors
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentInvalid   = errors.New("invalid comment")
+	ErrCommentThrottled = errors.New("too many comments")
+)
+
+// CommentStatus is where a comment is in moderation
+type CommentStatus string
+
+// Comment statuses. Only approved comments are shown on the site.
+const (
+	CommentPending  CommentStatus = "pending"
+	CommentApproved CommentStatus = "approved"
+	CommentRejected CommentStatus = "rejected"
+)
+
+// ParseCommentStatus validates a comment status
+func ParseCommentStatus(value string) (CommentStatus, error) {
+	switch status := CommentStatus(value); status {
+	case CommentPending, CommentApproved, CommentRejected:
+		return status, nil
+	}
+	return "", fmt.Errorf("%w: status must be pending, approved or rejected (got %q)", ErrCommentInvalid, value)
+}
+
+// Comment limits
+const (
+	MaxCommentDepth  = 5
+	maxCommentBody   = 2000
+	maxCommentAuthor = 50
+)
+
+// Comment is a listener's comment on an episode, or a reply to another
+// comment when ParentID is set. Reason says why the spam checks rejected it.
+type Comment struct {
+	ID          string        `json:"id"`
+	EpisodeID   string        `json:"episodeId"`
+	ParentID    string        `json:"parentId,omitempty"`
+	ListenerID  string        `json:"listenerId"`
+	AuthorName  string        `json:"authorName"`
+	Body        string        `json:"body"`
+	Status      CommentStatus `json:"status"`
+	Reason      string        `json:"reason,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	ModeratedAt *time.Time    `json:"moderatedAt,omitempty"`
+}
+
+// NewComment is a comment as submitted, before moderation
+type NewComment struct {
+	EpisodeID  string
+	ParentID   string
+	ListenerID string
+	AuthorName string
+	Body       string
+	// IP is the client address, used only for rate limiting
+	IP string
+}
+
+// CommentPolicy holds the spam checks applied to new comments
+type CommentPolicy struct {
+	// AutoApprove publishes comments that pass the checks; otherwise they
+	// wait for a moderator
+	AutoApprove bool
+	// MaxLinks is the most links a comment may contain
+	MaxLinks int
+	// BannedWords reject comments containing any of them as a whole word
+	BannedWords []string
+	// ListenerLimit and IPLimit are how many comments one account or
+	// address may post per Window
+	ListenerLimit int
+	IPLimit       int
+	Window        time.Duration
+}
+
+// DefaultCommentPolicy holds comments for moderation and allows five
+// comments per account and twenty per address an hour
+func DefaultCommentPolicy() CommentPolicy {
+	return CommentPolicy{MaxLinks: 2, ListenerLimit: 5, IPLimit: 20, Window: time.Hour}
+}
+
+// CommentFilter selects comments for moderation; empty fields match all
+type CommentFilter struct {
+	Status    CommentStatus
+	EpisodeID string
+}
+
+// linkPattern matches a link in comment text: a URL, or a bare domain with
+// a TLD common in spam
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|co|ru|xyz|info|biz|top)\b`)
+
+// CommentService keeps episode comments and applies the comment policy
+type CommentService struct {
+	mutex    sync.RWMutex
+	path     string
+	policy   CommentPolicy
+	banned   *regexp.Regexp
+	comments []Comment
+	// recent holds the times of recent comments by listener and address;
+	// it is kept in memory only, so addresses are never stored
+	recent map[string][]time.Time
+}
+
+// NewCommentService returns an in-memory comment store whose comments are
+// lost on restart
+func NewCommentService(policy CommentPolicy) *CommentService {
+	return &CommentService{
+		policy:   policy,
+		banned:   bannedWordsPattern(policy.BannedWords),
+		comments: []Comment{},
+		recent:   make(map[string][]time.Time),
+	}
+}
+
+// OpenCommentService loads the comments in path, a JSON file that is
+// rewritten on every change and created if it does not exist
+func OpenCommentService(path string, policy CommentPolicy) (*CommentService, error) {
+	service := NewCommentService(policy)
+	service.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Comments awaiting moderation are not public, so the file is
+		// created private
+		if err := os.WriteFile(path, []byte("[]\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create comments file: %w", err)
+		}
+		return service, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments file: %w", err)
+	}
+	if err := json.Unmarshal(data, &service.comments); err != nil {
+		return nil, fmt.Errorf("failed to parse comments file %s: %w", path, err)
+	}
+	if service.comments == nil {
+		service.comments = []Comment{}
+	}
+	return service, nil
+}
+
+// Post checks and stores a new comment. Comments that fail the spam checks
+// are stored as rejected, with the reason, so a moderator can still approve
+// them; rate limited comments are not stored at all.
+func (s *CommentService) Post(input NewComment, now time.Time) (*Comment, error) {
+	author := strings.TrimSpace(input.AuthorName)
+	body := strings.TrimSpace(input.Body)
+	switch {
+	case author == "":
+		return nil, fmt.Errorf("%w: authorName is required", ErrCommentInvalid)
+	case len([]rune(author)) > maxCommentAuthor:
+		return nil, fmt.Errorf("%w: authorName must be at most %d characters", ErrCommentInvalid, maxCommentAuthor)
+	case body == "":
+		return nil, fmt.Errorf("%w: body is required", ErrCommentInvalid)
+	case len([]rune(body)) > maxCommentBody:
+		return nil, fmt.Errorf("%w: body must be at most %d characters", ErrCommentInvalid, maxCommentBody)
+	}
+	id, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if input.ParentID != "" {
+		parent := s.find(input.ParentID)
+		if parent == nil || parent.EpisodeID != input.EpisodeID || parent.Status != CommentApproved {
+			return nil, ErrCommentNotFound
+		}
+		if s.depth(parent) >= MaxCommentDepth {
+			return nil, fmt.Errorf("%w: replies can be nested at most %d deep", ErrCommentInvalid, MaxCommentDepth)
+		}
+	}
+
+	listenerKey := "listener:" + input.ListenerID
+	ipKey := "ip:" + input.IP
+	if s.exceeded(listenerKey, s.policy.ListenerLimit, now) || (input.IP != "" && s.exceeded(ipKey, s.policy.IPLimit, now)) {
+		return nil, ErrCommentThrottled
+	}
+
+	comment := Comment{
+		ID:         id[:12],
+		EpisodeID:  input.EpisodeID,
+		ParentID:   input.ParentID,
+		ListenerID: input.ListenerID,
+		AuthorName: author,
+		Body:       body,
+		Status:     CommentPending,
+		CreatedAt:  now.UTC(),
+	}
+	if reason := s.spamReason(author, body); reason != "" {
+		comment.Status = CommentRejected
+		comment.Reason = reason
+	} else if s.policy.AutoApprove {
+		comment.Status = CommentApproved
+	}
+
+	if err := s.commit(append(slices.Clone(s.comments), comment)); err != nil {
+		return nil, err
+	}
+	s.recent[listenerKey] = append(s.recent[listenerKey], now)
+	if input.IP != "" {
+		s.recent[ipKey] = append(s.recent[ipKey], now)
+	}
+	return &comment, nil
+}
+
+// Approved returns an episode's approved comments, oldest first
+func (s *CommentService) Approved(episodeID string) []Comment {
+	return s.List(CommentFilter{Status: CommentApproved, EpisodeID: episodeID})
+}
+
+// List returns the comments matching filter, oldest first
+func (s *CommentService) List(filter CommentFilter) []Comment {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	comments := []Comment{}
+	for _, comment := range s.comments {
+		if (filter.Status == "" || comment.Status == filter.Status) && (filter.EpisodeID == "" || comment.EpisodeID == filter.EpisodeID) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments
+}
+
+// Get returns a comment by ID
+func (s *CommentService) Get(id string) (*Comment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if comment := s.find(id); comment != nil {
+		found := *comment
+		return &found, nil
+	}
+	return nil, ErrCommentNotFound
+}
+
+// Moderate sets a comment's status and returns it
+func (s *CommentService) Moderate(id string, status CommentStatus, now time.Time) (*Comment, error) {
+	if _, err := ParseCommentStatus(string(status)); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comments := slices.Clone(s.comments)
+	i := slices.IndexFunc(comments, func(c Comment) bool { return c.ID == id })
+	if i < 0 {
+		return nil, ErrCommentNotFound
+	}
+	moderatedAt := now.UTC()
+	comments[i].Status = status
+	comments[i].ModeratedAt = &moderatedAt
+	if err := s.commit(comments); err != nil {
+		return nil, err
+	}
+	comment := comments[i]
+	return &comment, nil
+}
+
+// Delete removes a comment and every reply below it, and returns the
+// removed comments
+func (s *CommentService) Delete(id string) ([]Comment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.find(id) == nil {
+		return nil, ErrCommentNotFound
+	}
+	removed := map[string]bool{id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, comment := range s.comments {
+			if !removed[comment.ID] && removed[comment.ParentID] {
+				removed[comment.ID] = true
+				changed = true
+			}
+		}
+	}
+
+	var deleted []Comment
+	kept := make([]Comment, 0, len(s.comments))
+	for _, comment := range s.comments {
+		if removed[comment.ID] {
+			deleted = append(deleted, comment)
+		} else {
+			kept = append(kept, comment)
+		}
+	}
+	if err := s.commit(kept); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// DeleteEpisode removes every comment on an episode
+func (s *CommentService) DeleteEpisode(episodeID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	kept := slices.DeleteFunc(slices.Clone(s.comments), func(c Comment) bool { return c.EpisodeID == episodeID })
+	if len(kept) == len(s.comments) {
+		return nil
+	}
+	return s.commit(kept)
+}
+
+// find returns the comment with id, or nil. The caller must hold the lock.
+func (s *CommentService) find(id string) *Comment {
+	for i := range s.comments {
+		if s.comments[i].ID == id {
+			return &s.comments[i]
+		}
+	}
+	return nil
+}
+
+// depth returns how many comments there are above comment, plus one. The
+// caller must hold the lock.
+func (s *CommentService) depth(comment *Comment) int {
+	depth := 1
+	for comment.ParentID != "" && depth <= MaxCommentDepth {
+		if comment = s.find(comment.ParentID); comment == nil {
+			break
+		}
+		depth++
+	}
+	return depth
+}
+
+// exceeded reports whether key has posted limit comments within the
+// policy window, dropping older entries. The caller must hold the lock.
+func (s *CommentService) exceeded(key string, limit int, now time.Time) bool {
+	times := slices.DeleteFunc(s.recent[key], func(t time.Time) bool { return now.Sub(t) >= s.policy.Window })
+	if len(times) == 0 {
+		delete(s.recent, key)
+		return false
+	}
+	s.recent[key] = times
+	return len(times) >= limit
+}
+
+// spamReason returns why a comment fails the spam checks, or ""
+func (s *CommentService) spamReason(author, body string) string {
+	if s.banned != nil && (s.banned.MatchString(author) || s.banned.MatchString(body)) {
+		return "banned word"
+	}
+	if links := len(linkPattern.FindAllStringIndex(body, -1)); links > s.policy.MaxLinks {
+		return fmt.Sprintf("%d links", links)
+	}
+	return ""
+}
+
+// commit writes comments to disk, when the service is file-backed, and then
+// makes them current. The caller must hold the write lock.
+func (s *CommentService) commit(comments []Comment) error {
+	if s.path != "" {
+		if err := writeJSONFile(s.path, comments); err != nil {
+			return fmt.Errorf("failed to save comments: %w", err)
+		}
+	}
+	s.comments = comments
+	return nil
+}
+
+// bannedWordsPattern returns a case-insensitive pattern matching any of
+// words as a whole word, or nil when there are none
+func bannedWordsPattern(words []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(?:^|\PL)(?:` + strings.Join(quoted, "|") + `)(?:\PL|$)`)
+}
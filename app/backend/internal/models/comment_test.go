@@ -0,0 +1,151 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCommentModeration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comments.json")
+	service, err := OpenCommentService(path, DefaultCommentPolicy())
+	if err != nil {
+		t.Fatalf("OpenCommentService returned error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected a private comments file, got %v %v", info, err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	comment, err := service.Post(NewComment{EpisodeID: "ep001", ListenerID: "l1", AuthorName: " Jane ", Body: "Great episode!"}, now)
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+	if comment.Status != CommentPending || comment.AuthorName != "Jane" {
+		t.Errorf("Expected a pending comment by Jane, got %+v", comment)
+	}
+	if approved := service.Approved("ep001"); len(approved) != 0 {
+		t.Errorf("Expected pending comments to be hidden, got %+v", approved)
+	}
+
+	// Replies need an approved parent on the same episode
+	reply := NewComment{EpisodeID: "ep001", ParentID: comment.ID, ListenerID: "l2", AuthorName: "Sam", Body: "Agreed"}
+	if _, err := service.Post(reply, now); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Expected a reply to a pending comment to fail, got %v", err)
+	}
+	moderated, err := service.Moderate(comment.ID, CommentApproved, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Moderate returned error: %v", err)
+	}
+	if moderated.Status != CommentApproved || moderated.ModeratedAt == nil {
+		t.Errorf("Unexpected moderated comment %+v", moderated)
+	}
+	if _, err := service.Moderate(comment.ID, "hidden", now); !errors.Is(err, ErrCommentInvalid) {
+		t.Errorf("Expected an unknown status to be rejected, got %v", err)
+	}
+	posted, err := service.Post(reply, now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("Post returned error for a reply: %v", err)
+	}
+	reply.EpisodeID = "ep002"
+	if _, err := service.Post(reply, now); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Expected a reply on another episode to fail, got %v", err)
+	}
+
+	reopened, err := OpenCommentService(path, DefaultCommentPolicy())
+	if err != nil {
+		t.Fatalf("OpenCommentService returned error: %v", err)
+	}
+	if pending := reopened.List(CommentFilter{Status: CommentPending}); len(pending) != 1 || pending[0].ID != posted.ID {
+		t.Errorf("Expected the reply to be pending after a restart, got %+v", pending)
+	}
+
+	// Deleting a comment removes its replies
+	deleted, err := reopened.Delete(comment.ID)
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("Expected Delete to remove the comment and its reply, got %+v %v", deleted, err)
+	}
+	if all := reopened.List(CommentFilter{}); len(all) != 0 {
+		t.Errorf("Expected no comments after Delete, got %+v", all)
+	}
+	if _, err := reopened.Delete(comment.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Expected ErrCommentNotFound, got %v", err)
+	}
+}
+
+func TestCommentSpamChecks(t *testing.T) {
+	policy := DefaultCommentPolicy()
+	policy.AutoApprove = true
+	policy.BannedWords = []string{"casino", "spam bot"}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		author string
+		body   string
+		status CommentStatus
+		reason string
+	}{
+		{"clean", "Jane", "Loved the interview", CommentApproved, ""},
+		{"two links", "Jane", "See https://example.com and www.example.org", CommentApproved, ""},
+		{"three links", "Jane", "https://a.example http://b.example cheap.xyz", CommentRejected, "3 links"},
+		{"banned word", "Jane", "Best CASINO deals", CommentRejected, "banned word"},
+		{"banned phrase", "Jane", "I am a spam bot.", CommentRejected, "banned word"},
+		{"banned word in name", "casino", "Hello", CommentRejected, "banned word"},
+		{"banned word inside another", "Jane", "Casinos are in the news", CommentApproved, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewCommentService(policy)
+			comment, err := service.Post(NewComment{EpisodeID: "ep001", ListenerID: "l1", AuthorName: tt.author, Body: tt.body}, now)
+			if err != nil {
+				t.Fatalf("Post returned error: %v", err)
+			}
+			if comment.Status != tt.status || comment.Reason != tt.reason {
+				t.Errorf("Expected %s (%q), got %s (%q)", tt.status, tt.reason, comment.Status, comment.Reason)
+			}
+		})
+	}
+
+	invalid := []NewComment{
+		{EpisodeID: "ep001", ListenerID: "l1", AuthorName: "", Body: "Hi"},
+		{EpisodeID: "ep001", ListenerID: "l1", AuthorName: "Jane", Body: "   "},
+	}
+	for _, input := range invalid {
+		if _, err := NewCommentService(policy).Post(input, now); !errors.Is(err, ErrCommentInvalid) {
+			t.Errorf("Expected ErrCommentInvalid for %+v, got %v", input, err)
+		}
+	}
+}
+
+func TestCommentRateLimits(t *testing.T) {
+	policy := DefaultCommentPolicy()
+	policy.ListenerLimit = 2
+	policy.IPLimit = 3
+	service := NewCommentService(policy)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	post := func(listenerID, ip string, at time.Time) error {
+		_, err := service.Post(NewComment{EpisodeID: "ep001", ListenerID: listenerID, AuthorName: "Jane", Body: "Hi", IP: ip}, at)
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := post("l1", "192.0.2.1", now); err != nil {
+			t.Fatalf("Post returned error: %v", err)
+		}
+	}
+	if err := post("l1", "192.0.2.9", now); !errors.Is(err, ErrCommentThrottled) {
+		t.Errorf("Expected the account limit to apply, got %v", err)
+	}
+	if err := post("l2", "192.0.2.1", now); err != nil {
+		t.Errorf("Expected another account on the address to post, got %v", err)
+	}
+	if err := post("l3", "192.0.2.1", now); !errors.Is(err, ErrCommentThrottled) {
+		t.Errorf("Expected the address limit to apply, got %v", err)
+	}
+	if err := post("l1", "192.0.2.1", now.Add(time.Hour)); err != nil {
+		t.Errorf("Expected the limits to reset after the window, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -34,14 +35,55 @@ type ContentService struct {
 	faqContent   *FAQContent
 }
 
-// NewContentService creates a new content service
+// NewContentService creates a new content service using the default content directory
 func NewContentService() *ContentService {
+	return NewContentServiceFromDir(DefaultContentDir())
+}
+
+// NewContentServiceFromDir creates a new content service that reads about.md and faq.json from dir
+func NewContentServiceFromDir(dir string) *ContentService {
 	service := &ContentService{}
-	service.loadAboutContent()
-	service.loadFAQContent()
+	service.loadAboutContent(filepath.Join(dir, "about.md"))
+	service.loadFAQContent(filepath.Join(dir, "faq.json"))
 	return service
 }
 
+// LoadContentService creates a content service from dir, failing if faq.json cannot be parsed
+func LoadContentService(dir string) (*ContentService, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "faq.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FAQ file: %w", err)
+	}
+
+	var faq FAQContent
+	if err := json.Unmarshal(data, &faq); err != nil {
+		return nil, fmt.Errorf("failed to parse FAQ JSON: %w", err)
+	}
+
+	service := &ContentService{faqContent: &faq}
+	service.loadAboutContent(filepath.Join(dir, "about.md"))
+	return service, nil
+}
+
+// DefaultContentDir returns the first known location of the frontend content
+// directory, relative to the working directory, that exists
+func DefaultContentDir() string {
+	candidates := []string{
+		filepath.Join("..", "..", "frontend", "site", "content"),
+		filepath.Join("..", "frontend", "site", "content"),
+		filepath.Join("app", "frontend", "site", "content"),
+		"content",
+	}
+
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+
+	return candidates[0]
+}
+
 // GetAbout returns the about page content
 func (s *ContentService) GetAbout() *AboutContent {
 	return s.aboutContent
@@ -52,16 +94,8 @@ func (s *ContentService) GetFAQ() *FAQContent {
 	return s.faqContent
 }
 
-// loadAboutContent loads about content from the frontend content file
-func (s *ContentService) loadAboutContent() {
-	// Try to load from the frontend content directory
-	contentPath := filepath.Join("..", "..", "frontend", "site", "content", "about.md")
-	
-	// If that doesn't exist, try relative to current working directory
-	if _, err := os.Stat(contentPath); os.IsNotExist(err) {
-		contentPath = filepath.Join("app", "frontend", "site", "content", "about.md")
-	}
-	
+// loadAboutContent loads about content from the given content file
+func (s *ContentService) loadAboutContent(contentPath string) {
 	file, err := os.Open(contentPath)
 	if err != nil {
 		// Use default content if file doesn't exist
@@ -80,16 +114,8 @@ func (s *ContentService) loadAboutContent() {
 	s.aboutContent = parseAboutMarkdown(string(data))
 }
 
-// loadFAQContent loads FAQ content from the frontend content file
-func (s *ContentService) loadFAQContent() {
-	// Try to load from the frontend content directory
-	contentPath := filepath.Join("..", "..", "frontend", "site", "content", "faq.json")
-	
-	// If that doesn't exist, try relative to current working directory
-	if _, err := os.Stat(contentPath); os.IsNotExist(err) {
-		contentPath = filepath.Join("app", "frontend", "site", "content", "faq.json")
-	}
-	
+// loadFAQContent loads FAQ content from the given content file
+func (s *ContentService) loadFAQContent(contentPath string) {
 	file, err := os.Open(contentPath)
 	if err != nil {
 		// Use default content if file doesn't exist
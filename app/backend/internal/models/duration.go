@@ -0,0 +1,186 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Accepted duration spellings: H:MM:SS, MM:SS, whole seconds and ISO 8601
+var (
+	clockDurationPattern = regexp.MustCompile(`^(?:([0-9]+):([0-5][0-9]):([0-5][0-9])|([0-9]+):([0-5][0-9]))$`)
+	isoDurationPattern   = regexp.MustCompile(`^P(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+(?:\.[0-9]+)?)S)?)?$`)
+)
+
+// Duration is the length of an episode. It reads "H:MM:SS", "MM:SS", a
+// number of seconds or an ISO 8601 duration such as "PT42M15S", and is
+// written as "H:MM:SS" or "MM:SS" like the original string field.
+type Duration time.Duration
+
+// ParseDuration parses any accepted duration spelling. An empty string is a
+// zero duration.
+func ParseDuration(value string) (Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if parts := clockDurationPattern.FindStringSubmatch(value); parts != nil {
+		var hours, minutes, seconds int
+		if parts[1] != "" {
+			hours, _ = strconv.Atoi(parts[1])
+			minutes, _ = strconv.Atoi(parts[2])
+			seconds, _ = strconv.Atoi(parts[3])
+		} else {
+			minutes, _ = strconv.Atoi(parts[4])
+			seconds, _ = strconv.Atoi(parts[5])
+		}
+		return Duration(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second), nil
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return Duration(time.Duration(seconds) * time.Second), nil
+	}
+
+	upper := strings.ToUpper(value)
+	if parts := isoDurationPattern.FindStringSubmatch(upper); parts != nil && upper != "P" && !strings.HasSuffix(upper, "T") {
+		var total time.Duration
+		for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+			if parts[i+1] != "" {
+				n, _ := strconv.Atoi(parts[i+1])
+				total += time.Duration(n) * unit
+			}
+		}
+		if parts[4] != "" {
+			seconds, _ := strconv.ParseFloat(parts[4], 64)
+			total += time.Duration(seconds * float64(time.Second))
+		}
+		return Duration(total), nil
+	}
+
+	return 0, fmt.Errorf("invalid duration %q: use H:MM:SS, MM:SS, seconds or ISO 8601", value)
+}
+
+// Seconds returns the duration in whole seconds, rounded
+func (d Duration) Seconds() int {
+	return int(time.Duration(d).Round(time.Second) / time.Second)
+}
+
+// String formats the duration as "H:MM:SS", or "MM:SS" under an hour
+func (d Duration) String() string {
+	total := d.Seconds()
+	hours, minutes, seconds := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+// ISO8601 formats the duration as an ISO 8601 duration such as "PT1H2M3S"
+func (d Duration) ISO8601() string {
+	total := d.Seconds()
+	if total == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours := total / 3600; hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes := total / 60 % 60; minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if seconds := total % 60; seconds > 0 {
+		fmt.Fprintf(&b, "%dS", seconds)
+	}
+	return b.String()
+}
+
+// MarshalJSON writes the duration as a clock string, or "" when zero
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d == 0 {
+		return json.Marshal("")
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads any accepted duration spelling, or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		if seconds < 0 {
+			return fmt.Errorf("invalid duration %v: must not be negative", seconds)
+		}
+		*d = Duration(time.Duration(seconds * float64(time.Second)))
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string or a number of seconds")
+	}
+	parsed, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// dateLayout is how dates are stored and written
+const dateLayout = "2006-01-02"
+
+// Date is a calendar day written as "YYYY-MM-DD", so dates still compare as
+// strings. It also reads RFC 3339 timestamps, keeping their local date.
+type Date string
+
+// ParseDate parses "YYYY-MM-DD" or an RFC 3339 timestamp. An empty string is
+// an unset date.
+func ParseDate(value string) (Date, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return Date(t.Format(dateLayout)), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return Date(t.Format(dateLayout)), nil
+	}
+	return "", fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+}
+
+// IsZero reports whether the date is unset
+func (d Date) IsZero() bool {
+	return d == ""
+}
+
+// In returns midnight of the date in loc. The boolean is false when the date
+// is unset or malformed.
+func (d Date) In(loc *time.Location) (time.Time, bool) {
+	if d == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(dateLayout, string(d), loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// UnmarshalJSON reads "YYYY-MM-DD" or an RFC 3339 timestamp
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("date must be a string")
+	}
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{"", 0, false},
+		{"42:15", 42*time.Minute + 15*time.Second, false},
+		{"75:00", 75 * time.Minute, false},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{"2535", 2535 * time.Second, false},
+		{"PT42M15S", 42*time.Minute + 15*time.Second, false},
+		{"pt1h", time.Hour, false},
+		{"PT1.5S", 1500 * time.Millisecond, false},
+		{"P1DT1S", 24*time.Hour + time.Second, false},
+		{"42:75", 0, true},
+		{"1:2:3", 0, true},
+		{"PT", 0, true},
+		{"P", 0, true},
+		{"forty minutes", 0, true},
+		{"-5", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", time.Duration(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if time.Duration(got) != tt.expected {
+				t.Errorf("ParseDuration(%q) = %v, expected %v", tt.input, time.Duration(got), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDurationFormatting(t *testing.T) {
+	tests := []struct {
+		duration Duration
+		clock    string
+		iso      string
+		seconds  int
+	}{
+		{Duration(42*time.Minute + 15*time.Second), "42:15", "PT42M15S", 2535},
+		{Duration(5*time.Minute + 3*time.Second), "05:03", "PT5M3S", 303},
+		{Duration(time.Hour + 3*time.Second), "1:00:03", "PT1H3S", 3603},
+		{0, "00:00", "PT0S", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clock, func(t *testing.T) {
+			if got := tt.duration.String(); got != tt.clock {
+				t.Errorf("String() = %q, expected %q", got, tt.clock)
+			}
+			if got := tt.duration.ISO8601(); got != tt.iso {
+				t.Errorf("ISO8601() = %q, expected %q", got, tt.iso)
+			}
+			if got := tt.duration.Seconds(); got != tt.seconds {
+				t.Errorf("Seconds() = %d, expected %d", got, tt.seconds)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Date
+		expectError bool
+	}{
+		{"", "", false},
+		{"2025-01-05", "2025-01-05", false},
+		{"2025-01-05T23:30:00-05:00", "2025-01-05", false},
+		{"2025-02-30", "", true},
+		{"05/01/2025", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDate(tt.input)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ParseDate(%q) error = %v, expected error %t", tt.input, err, tt.expectError)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDate(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEpisodeJSONCompatibility(t *testing.T) {
+	var episode Episode
+	input := `{"id":"ep001","duration":"PT1H2M3S","publishDate":"2025-01-05"}`
+	if err := json.Unmarshal([]byte(input), &episode); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	episode.computeFields()
+
+	data, err := json.Marshal(episode)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var output map[string]interface{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if output["duration"] != "1:02:03" {
+		t.Errorf("Expected duration 1:02:03, got %v", output["duration"])
+	}
+	if output["durationSeconds"] != float64(3723) {
+		t.Errorf("Expected durationSeconds 3723, got %v", output["durationSeconds"])
+	}
+	if output["durationIso"] != "PT1H2M3S" {
+		t.Errorf("Expected durationIso PT1H2M3S, got %v", output["durationIso"])
+	}
+	if output["publishDate"] != "2025-01-05" {
+		t.Errorf("Expected publishDate 2025-01-05, got %v", output["publishDate"])
+	}
+
+	loc := time.FixedZone("EST", -5*3600)
+	if got := episode.RFC2822Date(loc); got != "Sun, 05 Jan 2025 00:00:00 -0500" {
+		t.Errorf("Unexpected RFC 2822 date %q", got)
+	}
+}
+
+func TestLoadRejectsInvalidDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"duration", `[{"id":"ep001","number":1,"title":"A","audioUrl":"/a.mp3","duration":"soon"}]`},
+		{"date", `[{"id":"ep001","number":1,"title":"A","audioUrl":"/a.mp3","publishDate":"next week"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "episodes.json"), []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write fixture: %v", err)
+			}
+			if _, err := LoadEpisodeService(dir); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSortEpisodes(t *testing.T) {
+	episodes := []Episode{
+		{ID: "short", Number: 1, Duration: Duration(10 * time.Minute)},
+		{ID: "long", Number: 2, Duration: Duration(time.Hour)},
+		{ID: "medium", Number: 3, Duration: Duration(30 * time.Minute)},
+		{ID: "medium-newer", Number: 4, Duration: Duration(30 * time.Minute)},
+	}
+
+	tests := []struct {
+		order    EpisodeOrder
+		expected []string
+	}{
+		{OrderNewest, []string{"medium-newer", "medium", "long", "short"}},
+		{OrderLongest, []string{"long", "medium-newer", "medium", "short"}},
+		{OrderShortest, []string{"short", "medium-newer", "medium", "long"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			sorted := append([]Episode(nil), episodes...)
+			if !SortEpisodes(sorted, tt.order) {
+				t.Fatal("SortEpisodes rejected a known order")
+			}
+			for i, id := range tt.expected {
+				if sorted[i].ID != id {
+					t.Errorf("Position %d: expected %s, got %s", i, id, sorted[i].ID)
+				}
+			}
+		})
+	}
+
+	if SortEpisodes(episodes, "random") {
+		t.Error("Expected an unknown order to be rejected")
+	}
+}
@@ -2,44 +2,291 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-// Episode represents a podcast episode
+// Errors returned by episode mutations
+var (
+	ErrEpisodeNotFound = errors.New("episode not found")
+	ErrEpisodeConflict = errors.New("episode already exists")
+	ErrEpisodeInvalid  = errors.New("invalid episode")
+)
+
+// episodeIDPattern restricts IDs to URL-safe slugs
+var episodeIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Episode references that are not IDs: an overall number such as "12" or a
+// season and episode such as "s2e5". IDs may not take either form, so a
+// reference always resolves one way.
+var (
+	numberRefPattern        = regexp.MustCompile(`^[0-9]+$`)
+	seasonEpisodeRefPattern = regexp.MustCompile(`^s([0-9]+)e([0-9]+)$`)
+)
+
+// Episode represents a podcast episode. Number counts every episode of the
+// show; SeasonEpisode is the position within Season, as in s2e5. Slug is
+// derived from the title and SlugHistory keeps the slugs it replaced so old
+// links still resolve. Audio and Artwork are measured from the files behind
+// AudioURL and ArtworkURL when they are served from the media directory.
 type Episode struct {
-	ID          string    `json:"id"`
-	Number      int       `json:"number"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Duration    string    `json:"duration"`
-	PublishDate string    `json:"publishDate"`
-	ArtworkURL  string    `json:"artworkUrl"`
-	ArtworkAlt  string    `json:"artworkAlt,omitempty"`
-	AudioURL    string    `json:"audioUrl"`
-	Tags        []string  `json:"tags"`
+	ID            string          `json:"id"`
+	Slug          string          `json:"slug,omitempty"`
+	SlugHistory   []string        `json:"slugHistory,omitempty"`
+	Number        int             `json:"number"`
+	Season        int             `json:"season,omitempty"`
+	SeasonEpisode int             `json:"seasonEpisode,omitempty"`
+	EpisodeType   EpisodeType     `json:"episodeType,omitempty"`
+	Title         string          `json:"title"`
+	Description   string          `json:"description"`
+	Duration      Duration        `json:"duration"`
+	PublishDate   Date            `json:"publishDate"`
+	ArtworkURL    string          `json:"artworkUrl"`
+	ArtworkAlt    string          `json:"artworkAlt,omitempty"`
+	AudioURL      string          `json:"audioUrl"`
+	Audio         *MediaInfo      `json:"audio,omitempty"`
+	Artwork       *ArtworkInfo    `json:"artwork,omitempty"`
+	Tags          []string        `json:"tags"`
+	People        []EpisodeCredit `json:"people,omitempty"`
+	Status        EpisodeStatus   `json:"status,omitempty"`
+	// DurationSeconds and DurationISO are computed from Duration
+	DurationSeconds int    `json:"durationSeconds"`
+	DurationISO     string `json:"durationIso,omitempty"`
+	// PublishAt is the exact release time; when empty, PublishDate is used
+	// from midnight in the publishing timezone
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+}
+
+// EpisodeType distinguishes regular episodes from trailers and bonus content,
+// matching the itunes:episodeType values
+type EpisodeType string
+
+// Episode types. An empty type is treated as full.
+const (
+	EpisodeTypeFull    EpisodeType = "full"
+	EpisodeTypeTrailer EpisodeType = "trailer"
+	EpisodeTypeBonus   EpisodeType = "bonus"
+)
+
+// EpisodeStatus controls where an episode is visible
+type EpisodeStatus string
+
+// Episode statuses. An empty status is treated as published so existing
+// content keeps working.
+const (
+	// StatusDraft episodes are only visible to editors and preview links
+	StatusDraft EpisodeStatus = "draft"
+	// StatusScheduled episodes go live at their publish time
+	StatusScheduled EpisodeStatus = "scheduled"
+	// StatusPublished episodes are listed once their publish time has passed
+	StatusPublished EpisodeStatus = "published"
+	// StatusUnlisted episodes are reachable by ID but never listed
+	StatusUnlisted EpisodeStatus = "unlisted"
+)
+
+// PublishTime returns when the episode is released, reading a date-only
+// PublishDate in loc. The boolean is false when no publish time is set.
+func (e *Episode) PublishTime(loc *time.Location) (time.Time, bool) {
+	if e.PublishAt != nil {
+		return *e.PublishAt, true
+	}
+	return e.PublishDate.In(loc)
+}
+
+// RFC2822Date formats the publish time in loc for feeds, or "" when unset
+func (e *Episode) RFC2822Date(loc *time.Location) string {
+	at, ok := e.PublishTime(loc)
+	if !ok {
+		return ""
+	}
+	return at.In(loc).Format(time.RFC1123Z)
+}
+
+// computeFields fills in the fields derived from others
+func (e *Episode) computeFields() {
+	e.DurationSeconds = e.Duration.Seconds()
+	e.DurationISO = ""
+	if e.Duration > 0 {
+		e.DurationISO = e.Duration.ISO8601()
+	}
+}
+
+// released reports whether the publish time, if any, has passed at now.
+// The location of now is the publishing timezone.
+func (e *Episode) released(now time.Time) bool {
+	at, ok := e.PublishTime(now.Location())
+	return !ok || !at.After(now)
+}
+
+// effectiveStatus resolves an empty status to published
+func (e *Episode) effectiveStatus() EpisodeStatus {
+	if e.Status == "" {
+		return StatusPublished
+	}
+	return e.Status
+}
+
+// IsListed reports whether the episode appears in public lists and feeds at now
+func (e *Episode) IsListed(now time.Time) bool {
+	switch e.effectiveStatus() {
+	case StatusPublished, StatusScheduled:
+		return e.released(now)
+	default:
+		return false
+	}
+}
+
+// IsReachable reports whether the episode can be fetched by ID at now
+func (e *Episode) IsReachable(now time.Time) bool {
+	return e.IsListed(now) || (e.effectiveStatus() == StatusUnlisted && e.released(now))
+}
+
+// Validate checks the fields required to publish an episode
+func (e *Episode) Validate() error {
+	var problems []string
+	if !episodeIDPattern.MatchString(e.ID) {
+		problems = append(problems, "id must contain only lowercase letters, digits and hyphens")
+	} else if numberRefPattern.MatchString(e.ID) || seasonEpisodeRefPattern.MatchString(e.ID) {
+		problems = append(problems, "id must not look like an episode number or sNeN reference")
+	}
+	if e.Slug != "" {
+		if !episodeIDPattern.MatchString(e.Slug) {
+			problems = append(problems, "slug must contain only lowercase letters, digits and hyphens")
+		} else if isReferenceShaped(e.Slug) {
+			problems = append(problems, "slug must not look like an episode number or sNeN reference")
+		}
+	}
+	switch e.EpisodeType {
+	case "", EpisodeTypeFull:
+		if e.Number <= 0 {
+			problems = append(problems, "number must be greater than zero")
+		}
+	case EpisodeTypeTrailer, EpisodeTypeBonus:
+		// Trailers and bonus episodes may be unnumbered
+		if e.Number < 0 {
+			problems = append(problems, "number must not be negative")
+		}
+	default:
+		problems = append(problems, "episodeType must be full, trailer or bonus")
+	}
+	if e.Season < 0 {
+		problems = append(problems, "season must not be negative")
+	}
+	if e.SeasonEpisode < 0 {
+		problems = append(problems, "seasonEpisode must not be negative")
+	} else if e.SeasonEpisode > 0 && e.Season == 0 {
+		problems = append(problems, "seasonEpisode requires a season")
+	}
+	credited := make(map[string]bool, len(e.People))
+	for _, credit := range e.People {
+		if credit.PersonID == "" {
+			problems = append(problems, "people entries need a personId")
+		} else if credited[credit.PersonID] {
+			problems = append(problems, fmt.Sprintf("%s is credited more than once", credit.PersonID))
+		}
+		credited[credit.PersonID] = true
+		if !validPersonRole(credit.Role) {
+			problems = append(problems, "people roles must be host, co-host, guest or producer")
+		}
+	}
+	if strings.TrimSpace(e.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	if strings.TrimSpace(e.AudioURL) == "" {
+		problems = append(problems, "audioUrl is required")
+	}
+	switch e.Status {
+	case "", StatusDraft, StatusPublished, StatusUnlisted:
+	case StatusScheduled:
+		if e.PublishAt == nil && e.PublishDate == "" {
+			problems = append(problems, "scheduled episodes need publishAt or publishDate")
+		}
+	default:
+		problems = append(problems, "status must be draft, scheduled, published or unlisted")
+	}
+	if _, ok := e.PublishDate.In(time.UTC); !ok && !e.PublishDate.IsZero() {
+		problems = append(problems, "publishDate must be formatted as YYYY-MM-DD")
+	}
+	if e.Duration < 0 {
+		problems = append(problems, "duration must not be negative")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrEpisodeInvalid, strings.Join(problems, "; "))
+	}
+	return nil
 }
 
 // EpisodeService handles episode data operations
 type EpisodeService struct {
+	mutex    sync.RWMutex
 	episodes []Episode
+	// path is the episodes.json file that mutations are written to; empty
+	// when the service holds built-in defaults
+	path string
+	// featured is the editorial featured choice, saved to featuredPath
+	featured     FeaturedSettings
+	featuredPath string
+	// prober measures the audio of created and updated episodes once
+	// ApplyMedia has been called
+	prober *MediaProber
 }
 
-// NewEpisodeService creates a new episode service
+// NewEpisodeService creates a new episode service using the default content directory
 func NewEpisodeService() *EpisodeService {
-	service := &EpisodeService{}
-	if err := service.loadEpisodes(); err != nil {
+	return NewEpisodeServiceFromDir(DefaultContentDir())
+}
+
+// NewEpisodeServiceFromDir creates a new episode service that reads episodes.json from dir
+func NewEpisodeServiceFromDir(dir string) *EpisodeService {
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
 		// If loading fails, use default episodes
-		service.episodes = getDefaultEpisodes()
+		service = &EpisodeService{
+			episodes: getDefaultEpisodes(),
+			featured: FeaturedSettings{Mode: FeaturedLatest},
+		}
+		service.prepareEpisodes()
 	}
 	return service
 }
 
+// LoadEpisodeService creates an episode service from dir without falling back to defaults
+func LoadEpisodeService(dir string) (*EpisodeService, error) {
+	service := &EpisodeService{
+		path:         filepath.Join(dir, "episodes.json"),
+		featuredPath: filepath.Join(dir, "featured.json"),
+	}
+	generated, err := service.loadEpisodes(service.path)
+	if err != nil {
+		return nil, err
+	}
+	if generated {
+		// Saving generated slugs keeps them when a title is later edited by
+		// hand; a read-only directory just generates them on every load
+		writeJSONFile(service.path, service.episodes)
+	}
+	featured, err := loadFeaturedSettings(service.featuredPath)
+	if err != nil {
+		return nil, err
+	}
+	service.featured = featured
+	return service, nil
+}
+
 // GetAll returns all episodes sorted by number (descending)
 func (s *EpisodeService) GetAll() []Episode {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	episodes := make([]Episode, len(s.episodes))
 	copy(episodes, s.episodes)
 	
@@ -53,6 +300,9 @@ func (s *EpisodeService) GetAll() []Episode {
 
 // GetByID returns an episode by its ID
 func (s *EpisodeService) GetByID(id string) (*Episode, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	for _, episode := range s.episodes {
 		if episode.ID == id {
 			return &episode, nil
@@ -61,49 +311,510 @@ func (s *EpisodeService) GetByID(id string) (*Episode, error) {
 	return nil, fmt.Errorf("episode with ID %s not found", id)
 }
 
-// GetFeatured returns the most recent episode as featured
-func (s *EpisodeService) GetFeatured() (*Episode, error) {
-	if len(s.episodes) == 0 {
-		return nil, fmt.Errorf("no episodes available")
+// GetPublished returns the episodes listed at now, sorted by number (descending).
+// The location of now is the publishing timezone.
+func (s *EpisodeService) GetPublished(now time.Time) []Episode {
+	episodes := s.GetAll()
+
+	published := episodes[:0]
+	for _, episode := range episodes {
+		if episode.IsListed(now) {
+			published = append(published, episode)
+		}
 	}
-	
-	// Find the episode with the highest number
+	return published
+}
+
+// EpisodeOrder is a sort order for episode lists
+type EpisodeOrder string
+
+// Episode orders
+const (
+	// OrderNewest sorts by number, descending; it is the default
+	OrderNewest   EpisodeOrder = "newest"
+	OrderLongest  EpisodeOrder = "longest"
+	OrderShortest EpisodeOrder = "shortest"
+)
+
+// SortEpisodes sorts episodes in order, keeping the newest first among
+// episodes of the same length. It returns false for an unknown order.
+func SortEpisodes(episodes []Episode, order EpisodeOrder) bool {
+	var less func(a, b *Episode) bool
+	switch order {
+	case "", OrderNewest:
+		less = func(a, b *Episode) bool { return false }
+	case OrderLongest:
+		less = func(a, b *Episode) bool { return a.Duration > b.Duration }
+	case OrderShortest:
+		less = func(a, b *Episode) bool { return a.Duration < b.Duration }
+	default:
+		return false
+	}
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := &episodes[i], &episodes[j]
+		if less(a, b) || less(b, a) {
+			return less(a, b)
+		}
+		return a.Number > b.Number
+	})
+	return true
+}
+
+// GetFeatured returns the featured episode at now
+func (s *EpisodeService) GetFeatured(now time.Time) (*Episode, error) {
+	episode, _, err := s.SelectFeatured(now)
+	return episode, err
+}
+
+// SelectFeatured applies the featured settings at now and returns the
+// chosen episode with the reason it was chosen. A pin or rotation outside
+// its window, or whose episodes are not listed, falls back to the most
+// recent listed episode.
+func (s *EpisodeService) SelectFeatured(now time.Time) (*Episode, FeaturedReason, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	reason := ReasonLatest
+	if s.featured.Mode != FeaturedLatest {
+		if !s.featured.activeAt(now) {
+			reason = ReasonWindowInactive
+		} else {
+			var available []*Episode
+			for _, id := range s.featured.curatedIDs() {
+				if episode := s.findListed(id, now); episode != nil {
+					available = append(available, episode)
+				}
+			}
+			if len(available) > 0 {
+				if s.featured.Mode == FeaturedPinned {
+					episode := *available[0]
+					return &episode, ReasonPinned, nil
+				}
+				episode := *available[s.featured.rotationSlot(now)%int64(len(available))]
+				return &episode, ReasonRotation, nil
+			}
+			reason = ReasonUnavailable
+		}
+	}
+
+	// Find the listed episode with the highest number
 	var featured *Episode
 	for i := range s.episodes {
+		if !s.episodes[i].IsListed(now) {
+			continue
+		}
 		if featured == nil || s.episodes[i].Number > featured.Number {
 			featured = &s.episodes[i]
 		}
 	}
+	if featured == nil {
+		return nil, reason, fmt.Errorf("no episodes available")
+	}
 	
-	return featured, nil
+	// Return a copy so later mutations do not change the caller's value
+	episode := *featured
+	return &episode, reason, nil
 }
 
-// loadEpisodes loads episodes from the frontend content file
-func (s *EpisodeService) loadEpisodes() error {
-	// Try to load from the frontend content directory
-	contentPath := filepath.Join("..", "..", "frontend", "site", "content", "episodes.json")
-	
-	// If that doesn't exist, try relative to current working directory
-	if _, err := os.Stat(contentPath); os.IsNotExist(err) {
-		contentPath = filepath.Join("app", "frontend", "site", "content", "episodes.json")
+// checkConflicts reports another episode that already uses the number or
+// season position of episode. The caller must hold the mutex.
+func (s *EpisodeService) checkConflicts(episode Episode) error {
+	for _, existing := range s.episodes {
+		if existing.ID == episode.ID {
+			continue
+		}
+		if episode.Number > 0 && existing.Number == episode.Number {
+			return fmt.Errorf("%w: number %d is taken by %s", ErrEpisodeConflict, episode.Number, existing.ID)
+		}
+		if episode.SeasonEpisode > 0 && existing.Season == episode.Season && existing.SeasonEpisode == episode.SeasonEpisode {
+			return fmt.Errorf("%w: s%de%d is taken by %s", ErrEpisodeConflict, episode.Season, episode.SeasonEpisode, existing.ID)
+		}
 	}
-	
+	return nil
+}
+
+// assignSlug sets the slug of an episode being created (previous is nil) or
+// updated. A new explicit slug is used if free; otherwise the slug is kept
+// until the title changes, when a new one is generated. A replaced slug
+// moves to SlugHistory. The caller must hold the mutex.
+func (s *EpisodeService) assignSlug(episode *Episode, previous *Episode) error {
+	if previous != nil {
+		// The history is maintained here, never taken from the request
+		episode.SlugHistory = append([]string(nil), previous.SlugHistory...)
+	}
+
+	switch {
+	case episode.Slug != "" && (previous == nil || episode.Slug != previous.Slug):
+		if owner := s.slugOwner(episode.Slug, episode.ID); owner >= 0 {
+			return fmt.Errorf("%w: slug %s is used by %s", ErrEpisodeConflict, episode.Slug, s.episodes[owner].ID)
+		}
+	case previous != nil && previous.Slug != "" && previous.Title == episode.Title:
+		episode.Slug = previous.Slug
+	default:
+		episode.Slug = s.uniqueSlug(episode.Title, episode.ID)
+	}
+
+	if previous == nil || previous.Slug == "" || previous.Slug == episode.Slug {
+		return nil
+	}
+	history := episode.SlugHistory[:0]
+	for _, old := range episode.SlugHistory {
+		if old != episode.Slug && old != previous.Slug {
+			history = append(history, old)
+		}
+	}
+	episode.SlugHistory = append(history, previous.Slug)
+	return nil
+}
+
+// Resolve finds an episode by reference: an overall number ("12"), a season
+// and episode ("s2e5", case-insensitive), an ID or a current or former slug.
+// moved is true when ref is a former slug and links should use episode.Slug.
+func (s *EpisodeService) Resolve(ref string) (episode *Episode, moved bool, err error) {
+	ref = strings.ToLower(ref)
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	match := func(matches func(*Episode) bool) (*Episode, error) {
+		for i := range s.episodes {
+			if matches(&s.episodes[i]) {
+				episode := s.episodes[i]
+				return &episode, nil
+			}
+		}
+		return nil, ErrEpisodeNotFound
+	}
+
+	if numberRefPattern.MatchString(ref) {
+		number, err := strconv.Atoi(ref)
+		if err != nil || number == 0 {
+			return nil, false, ErrEpisodeNotFound
+		}
+		episode, err := match(func(e *Episode) bool { return e.Number == number })
+		return episode, false, err
+	}
+	if parts := seasonEpisodeRefPattern.FindStringSubmatch(ref); parts != nil {
+		season, err1 := strconv.Atoi(parts[1])
+		position, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil || position == 0 {
+			return nil, false, ErrEpisodeNotFound
+		}
+		episode, err := match(func(e *Episode) bool { return e.Season == season && e.SeasonEpisode == position })
+		return episode, false, err
+	}
+	if episode, err := match(func(e *Episode) bool { return e.ID == ref || e.Slug == ref }); err == nil {
+		return episode, false, nil
+	}
+	episode, err = match(func(e *Episode) bool {
+		for _, old := range e.SlugHistory {
+			if old == ref {
+				return true
+			}
+		}
+		return false
+	})
+	return episode, err == nil, err
+}
+
+// storedCredits copies credits without the fields filled in for responses
+func storedCredits(credits []EpisodeCredit) []EpisodeCredit {
+	if len(credits) == 0 {
+		return nil
+	}
+	stored := make([]EpisodeCredit, len(credits))
+	for i, credit := range credits {
+		stored[i] = EpisodeCredit{PersonID: credit.PersonID, Role: credit.Role}
+	}
+	return stored
+}
+
+// indexOf returns the position of the episode with id, or -1.
+// The caller must hold the mutex.
+func (s *EpisodeService) indexOf(id string) int {
+	for i := range s.episodes {
+		if s.episodes[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findListed returns the episode with id if it is listed at now.
+// The caller must hold the mutex.
+func (s *EpisodeService) findListed(id string, now time.Time) *Episode {
+	if i := s.indexOf(id); i >= 0 && s.episodes[i].IsListed(now) {
+		return &s.episodes[i]
+	}
+	return nil
+}
+
+// GetFeaturedSettings returns the current featured settings
+func (s *EpisodeService) GetFeaturedSettings() FeaturedSettings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	settings := s.featured
+	settings.Rotation = append([]string(nil), s.featured.Rotation...)
+	return settings
+}
+
+// SetFeaturedSettings validates and persists new featured settings, returning
+// them with the previous settings. Every episode named must exist, though it
+// may not be listed yet.
+func (s *EpisodeService) SetFeaturedSettings(settings FeaturedSettings, now time.Time) (*FeaturedSettings, *FeaturedSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if settings.Mode != FeaturedRotation {
+		settings.Rotation = nil
+		settings.RotationInterval = ""
+	}
+	if settings.Mode != FeaturedPinned {
+		settings.EpisodeID = ""
+	}
+	settings.UpdatedAt = now.UTC()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, id := range settings.curatedIDs() {
+		if s.indexOf(id) < 0 {
+			return nil, nil, fmt.Errorf("%w: episode %s does not exist", ErrFeaturedInvalid, id)
+		}
+	}
+
+	if s.featuredPath != "" {
+		if err := writeJSONFile(s.featuredPath, settings); err != nil {
+			return nil, nil, fmt.Errorf("failed to save featured settings: %w", err)
+		}
+	}
+
+	previous := s.featured
+	s.featured = settings
+	return &settings, &previous, nil
+}
+
+// FeaturedChangesBetween reports whether the featured choice can change in
+// (from, to] without an edit, so cached responses need purging
+func (s *EpisodeService) FeaturedChangesBetween(from, to time.Time) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.featured.ChangesBetween(from, to)
+}
+
+// Create adds a new episode and persists the episode list
+func (s *EpisodeService) Create(episode Episode) (*Episode, error) {
+	if err := episode.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	episode.People = storedCredits(episode.People)
+	if s.slugOwner(episode.ID, "") >= 0 {
+		return nil, fmt.Errorf("%w: id %s is taken", ErrEpisodeConflict, episode.ID)
+	}
+	if err := s.checkConflicts(episode); err != nil {
+		return nil, err
+	}
+	episode.SlugHistory = nil
+	if err := s.assignSlug(&episode, nil); err != nil {
+		return nil, err
+	}
+	episode.computeFields()
+	if s.prober != nil {
+		s.prober.probeEpisode(&episode)
+	}
+
+	episodes := append(append([]Episode(nil), s.episodes...), episode)
+	if err := s.commit(episodes); err != nil {
+		return nil, err
+	}
+	return &episode, nil
+}
+
+// Update replaces the episode with the given ID and persists the episode list.
+// The ID itself cannot be changed. It returns the updated episode and the
+// episode it replaced.
+func (s *EpisodeService) Update(id string, episode Episode) (*Episode, *Episode, error) {
+	episode.ID = id
+	if err := episode.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := s.indexOf(id)
+	if index < 0 {
+		return nil, nil, ErrEpisodeNotFound
+	}
+	if err := s.checkConflicts(episode); err != nil {
+		return nil, nil, err
+	}
+
+	previous := s.episodes[index]
+	episode.People = storedCredits(episode.People)
+	if err := s.assignSlug(&episode, &previous); err != nil {
+		return nil, nil, err
+	}
+	episode.computeFields()
+	if s.prober != nil {
+		s.prober.probeEpisode(&episode)
+	}
+	episodes := append([]Episode(nil), s.episodes...)
+	episodes[index] = episode
+	if err := s.commit(episodes); err != nil {
+		return nil, nil, err
+	}
+	return &episode, &previous, nil
+}
+
+// Delete removes the episode with the given ID, persists the episode list and
+// returns the removed episode
+func (s *EpisodeService) Delete(id string) (*Episode, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var removed *Episode
+	episodes := make([]Episode, 0, len(s.episodes))
+	for i, existing := range s.episodes {
+		if existing.ID == id {
+			removed = &s.episodes[i]
+			continue
+		}
+		episodes = append(episodes, existing)
+	}
+	if removed == nil {
+		return nil, ErrEpisodeNotFound
+	}
+
+	deleted := *removed
+	if err := s.commit(episodes); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+// PublishDue marks scheduled episodes whose publish time has passed at now
+// as published, persists the change and returns the episodes that went live
+func (s *EpisodeService) PublishDue(now time.Time) ([]Episode, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var due []Episode
+	episodes := append([]Episode(nil), s.episodes...)
+	for i := range episodes {
+		if episodes[i].Status == StatusScheduled && episodes[i].released(now) {
+			episodes[i].Status = StatusPublished
+			due = append(due, episodes[i])
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	if err := s.commit(episodes); err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+// ReleasedBetween reports whether any episode's publish time falls in
+// (from, to], which changes what is listed even without a status change
+func (s *EpisodeService) ReleasedBetween(from, to time.Time) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for i := range s.episodes {
+		if at, ok := s.episodes[i].PublishTime(to.Location()); ok && at.After(from) && !at.After(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// commit writes episodes to disk, when the service is file-backed, and then
+// makes them current. The caller must hold the write lock.
+func (s *EpisodeService) commit(episodes []Episode) error {
+	if s.path != "" {
+		if err := writeJSONFile(s.path, episodes); err != nil {
+			return fmt.Errorf("failed to save episodes: %w", err)
+		}
+	}
+	s.episodes = episodes
+	return nil
+}
+
+// writeJSONFile atomically replaces path with the indented JSON encoding of v
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeFileAtomic replaces path with data through a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp uses 0600; keep the permissions of the file being replaced
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadEpisodes loads episodes from the given content file and reports
+// whether any slugs had to be generated
+func (s *EpisodeService) loadEpisodes(contentPath string) (bool, error) {
 	file, err := os.Open(contentPath)
 	if err != nil {
-		return fmt.Errorf("failed to open episodes file: %w", err)
+		return false, fmt.Errorf("failed to open episodes file: %w", err)
 	}
 	defer file.Close()
 	
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read episodes file: %w", err)
+		return false, fmt.Errorf("failed to read episodes file: %w", err)
 	}
 	
 	if err := json.Unmarshal(data, &s.episodes); err != nil {
-		return fmt.Errorf("failed to parse episodes JSON: %w", err)
+		return false, fmt.Errorf("failed to parse episodes JSON: %w", err)
 	}
 	
-	return nil
+	return s.prepareEpisodes(), nil
+}
+
+// prepareEpisodes assigns missing slugs and computes derived fields of
+// freshly loaded episodes, reporting whether any slugs were generated
+func (s *EpisodeService) prepareEpisodes() bool {
+	generated := s.assignMissingSlugs()
+	for i := range s.episodes {
+		s.episodes[i].computeFields()
+	}
+	return generated
 }
 
 // getDefaultEpisodes returns a set of default episodes if loading fails
@@ -114,7 +825,7 @@ func getDefaultEpisodes() []Episode {
 			Number:      1,
 			Title:       "Welcome to Our Podcast",
 			Description: "In our inaugural episode, we introduce ourselves and share what you can expect from this podcast.",
-			Duration:    "25:30",
+			Duration:    Duration(25*time.Minute + 30*time.Second),
 			PublishDate: "2025-01-01",
 			ArtworkURL:  "/assets/images/ep001.svg",
 			ArtworkAlt:  "Episode 1 artwork",
@@ -126,7 +837,7 @@ func getDefaultEpisodes() []Episode {
 			Number:      2,
 			Title:       "Getting Started",
 			Description: "We dive into the basics and share some fundamental concepts.",
-			Duration:    "32:15",
+			Duration:    Duration(32*time.Minute + 15*time.Second),
 			PublishDate: "2025-01-08",
 			ArtworkURL:  "/assets/images/ep002.svg",
 			ArtworkAlt:  "Episode 2 artwork",
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewEpisodeService(t *testing.T) {
@@ -19,7 +22,7 @@ func TestNewEpisodeService(t *testing.T) {
 func TestGetFeatured(t *testing.T) {
 	service := NewEpisodeService()
 	
-	featured, err := service.GetFeatured()
+	featured, err := service.GetFeatured(time.Now())
 	if err != nil {
 		t.Fatalf("GetFeatured returned error: %v", err)
 	}
@@ -121,6 +124,149 @@ func BenchmarkGetFeatured(b *testing.B) {
 	
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = service.GetFeatured()
+		_, _ = service.GetFeatured(time.Now())
+	}
+}
+
+func TestEpisodeMutationsPersist(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeJSONFile(filepath.Join(dir, "episodes.json"), getDefaultEpisodes()); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+
+	episode := Episode{ID: "ep003", Number: 3, Title: "Third", AudioURL: "/assets/audio/ep003.mp3"}
+	if _, err := service.Create(episode); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if _, err := service.Create(episode); !errors.Is(err, ErrEpisodeConflict) {
+		t.Errorf("Expected ErrEpisodeConflict for a duplicate, got %v", err)
+	}
+	if _, err := service.Create(Episode{ID: "Bad ID", Number: 4}); !errors.Is(err, ErrEpisodeInvalid) {
+		t.Errorf("Expected ErrEpisodeInvalid, got %v", err)
+	}
+
+	episode.Title = "Third, revised"
+	if _, _, err := service.Update("ep003", episode); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if _, _, err := service.Update("missing", episode); !errors.Is(err, ErrEpisodeNotFound) {
+		t.Errorf("Expected ErrEpisodeNotFound, got %v", err)
+	}
+	if _, err := service.Delete("ep001"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	// Changes must survive a reload from disk
+	reloaded, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	if _, err := reloaded.GetByID("ep001"); err == nil {
+		t.Error("Deleted episode was loaded again")
+	}
+	saved, err := reloaded.GetByID("ep003")
+	if err != nil {
+		t.Fatalf("Created episode was not saved: %v", err)
+	}
+	if saved.Title != "Third, revised" {
+		t.Errorf("Expected updated title, got %q", saved.Title)
+	}
+}
+
+func TestEpisodeVisibility(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// 23:30 UTC on June 30 is already July 1 in Berlin
+	now := time.Date(2024, 6, 30, 23, 30, 0, 0, time.UTC).In(berlin)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		episode   Episode
+		listed    bool
+		reachable bool
+	}{
+		{"no status", Episode{PublishDate: "2024-06-01"}, true, true},
+		{"future date", Episode{PublishDate: "2024-07-02"}, false, false},
+		{"date starts in publishing timezone", Episode{PublishDate: "2024-07-01"}, true, true},
+		{"draft", Episode{Status: StatusDraft, PublishDate: "2024-06-01"}, false, false},
+		{"scheduled due", Episode{Status: StatusScheduled, PublishAt: &past}, true, true},
+		{"scheduled pending", Episode{Status: StatusScheduled, PublishAt: &future}, false, false},
+		{"unlisted", Episode{Status: StatusUnlisted}, false, true},
+		{"published with future time", Episode{Status: StatusPublished, PublishAt: &future}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.episode.IsListed(now); got != tt.listed {
+				t.Errorf("IsListed = %v, want %v", got, tt.listed)
+			}
+			if got := tt.episode.IsReachable(now); got != tt.reachable {
+				t.Errorf("IsReachable = %v, want %v", got, tt.reachable)
+			}
+		})
+	}
+}
+
+func TestPublishDue(t *testing.T) {
+	now := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	dir := t.TempDir()
+	episodes := []Episode{
+		{ID: "ep001", Number: 1, Title: "Live", AudioURL: "/a.mp3", PublishDate: "2024-06-01"},
+		{ID: "ep002", Number: 2, Title: "Due", AudioURL: "/a.mp3", Status: StatusScheduled, PublishAt: &past},
+		{ID: "ep003", Number: 3, Title: "Later", AudioURL: "/a.mp3", Status: StatusScheduled, PublishAt: &future},
+		{ID: "ep004", Number: 4, Title: "Draft", AudioURL: "/a.mp3", Status: StatusDraft},
+	}
+	if err := writeJSONFile(filepath.Join(dir, "episodes.json"), episodes); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+
+	featured, err := service.GetFeatured(now)
+	if err != nil || featured.ID != "ep002" {
+		t.Errorf("Expected ep002 to be featured, got %v (%v)", featured, err)
+	}
+	if published := service.GetPublished(now); len(published) != 2 {
+		t.Errorf("Expected 2 published episodes, got %d", len(published))
+	}
+
+	due, err := service.PublishDue(now)
+	if err != nil {
+		t.Fatalf("PublishDue returned error: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != "ep002" {
+		t.Fatalf("Expected only ep002 to be published, got %v", due)
+	}
+	if due, _ := service.PublishDue(now); len(due) != 0 {
+		t.Errorf("Expected nothing left to publish, got %v", due)
+	}
+
+	reloaded, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	if saved, _ := reloaded.GetByID("ep002"); saved == nil || saved.Status != StatusPublished {
+		t.Errorf("Expected ep002 to be saved as published, got %v", saved)
+	}
+
+	if !service.ReleasedBetween(now, future) {
+		t.Error("Expected ep003 to be released within the next hour")
+	}
+	if service.ReleasedBetween(future, future.Add(time.Hour)) {
+		t.Error("Expected no release after ep003")
 	}
 }
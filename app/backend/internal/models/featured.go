@@ -0,0 +1,177 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrFeaturedInvalid is returned when featured settings are rejected
+var ErrFeaturedInvalid = errors.New("invalid featured settings")
+
+// defaultRotationInterval is used when a rotation has no interval
+const defaultRotationInterval = 24 * time.Hour
+
+// FeaturedMode selects how the featured episode is chosen
+type FeaturedMode string
+
+// Featured modes
+const (
+	// FeaturedLatest features the most recent listed episode
+	FeaturedLatest FeaturedMode = "latest"
+	// FeaturedPinned features one episode chosen by an editor
+	FeaturedPinned FeaturedMode = "pinned"
+	// FeaturedRotation cycles through a curated set of episodes
+	FeaturedRotation FeaturedMode = "rotation"
+)
+
+// FeaturedReason explains why an episode was featured
+type FeaturedReason string
+
+// Featured reasons
+const (
+	ReasonPinned   FeaturedReason = "pinned"
+	ReasonRotation FeaturedReason = "rotation"
+	ReasonLatest   FeaturedReason = "latest"
+	// ReasonWindowInactive means the pin or rotation is outside its window
+	// and the latest episode is featured instead
+	ReasonWindowInactive FeaturedReason = "window_inactive"
+	// ReasonUnavailable means none of the curated episodes is listed and the
+	// latest episode is featured instead
+	ReasonUnavailable FeaturedReason = "unavailable"
+)
+
+// FeaturedSettings is the editorial choice of featured episode, stored in
+// featured.json beside episodes.json
+type FeaturedSettings struct {
+	Mode      FeaturedMode `json:"mode"`
+	EpisodeID string       `json:"episodeId,omitempty"`
+	Rotation  []string     `json:"rotation,omitempty"`
+	// RotationInterval is a Go duration such as "24h"; defaults to 24h
+	RotationInterval string `json:"rotationInterval,omitempty"`
+	// StartsAt and EndsAt bound when a pin or rotation applies
+	StartsAt  *time.Time `json:"startsAt,omitempty"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Validate checks the settings without looking up the episodes they name
+func (f *FeaturedSettings) Validate() error {
+	var problems []string
+
+	switch f.Mode {
+	case FeaturedLatest:
+	case FeaturedPinned:
+		if f.EpisodeID == "" {
+			problems = append(problems, "episodeId is required when pinned")
+		}
+	case FeaturedRotation:
+		if len(f.Rotation) == 0 {
+			problems = append(problems, "rotation needs at least one episode")
+		}
+		seen := make(map[string]bool, len(f.Rotation))
+		for _, id := range f.Rotation {
+			if seen[id] {
+				problems = append(problems, fmt.Sprintf("rotation lists %s more than once", id))
+			}
+			seen[id] = true
+		}
+		if f.RotationInterval != "" {
+			if interval, err := time.ParseDuration(f.RotationInterval); err != nil || interval < time.Minute {
+				problems = append(problems, "rotationInterval must be a duration of at least 1m")
+			}
+		}
+	default:
+		problems = append(problems, "mode must be latest, pinned or rotation")
+	}
+	if f.StartsAt != nil && f.EndsAt != nil && !f.EndsAt.After(*f.StartsAt) {
+		problems = append(problems, "endsAt must be after startsAt")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrFeaturedInvalid, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// curatedIDs returns the episodes named by a pin or rotation
+func (f *FeaturedSettings) curatedIDs() []string {
+	switch f.Mode {
+	case FeaturedPinned:
+		return []string{f.EpisodeID}
+	case FeaturedRotation:
+		return f.Rotation
+	}
+	return nil
+}
+
+// activeAt reports whether the window, if any, contains now
+func (f *FeaturedSettings) activeAt(now time.Time) bool {
+	if f.StartsAt != nil && now.Before(*f.StartsAt) {
+		return false
+	}
+	if f.EndsAt != nil && !now.Before(*f.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// rotationInterval returns the parsed interval or the default
+func (f *FeaturedSettings) rotationInterval() time.Duration {
+	if interval, err := time.ParseDuration(f.RotationInterval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultRotationInterval
+}
+
+// rotationSlot returns how many intervals have passed since the rotation
+// started, which picks the episode to feature
+func (f *FeaturedSettings) rotationSlot(now time.Time) int64 {
+	anchor := f.UpdatedAt
+	if f.StartsAt != nil {
+		anchor = *f.StartsAt
+	}
+	elapsed := now.Sub(anchor)
+	if elapsed < 0 {
+		return 0
+	}
+	return int64(elapsed / f.rotationInterval())
+}
+
+// ChangesBetween reports whether the featured choice can change in
+// (from, to] because the window opens or closes or the rotation advances
+func (f *FeaturedSettings) ChangesBetween(from, to time.Time) bool {
+	within := func(t *time.Time) bool {
+		return t != nil && t.After(from) && !t.After(to)
+	}
+	if f.Mode == FeaturedLatest {
+		return false
+	}
+	if within(f.StartsAt) || within(f.EndsAt) {
+		return true
+	}
+	return f.Mode == FeaturedRotation && f.rotationSlot(from) != f.rotationSlot(to)
+}
+
+// loadFeaturedSettings reads path, treating a missing file as the latest mode
+func loadFeaturedSettings(path string) (FeaturedSettings, error) {
+	settings := FeaturedSettings{Mode: FeaturedLatest}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read featured settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to parse featured settings JSON: %w", err)
+	}
+	if err := settings.Validate(); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newFeaturedTestService returns a service over four listed episodes and a draft
+func newFeaturedTestService(t *testing.T) *EpisodeService {
+	t.Helper()
+
+	dir := t.TempDir()
+	episodes := []Episode{
+		{ID: "ep001", Number: 1, Title: "One", AudioURL: "/a.mp3"},
+		{ID: "ep002", Number: 2, Title: "Two", AudioURL: "/a.mp3"},
+		{ID: "ep003", Number: 3, Title: "Three", AudioURL: "/a.mp3"},
+		{ID: "ep004", Number: 4, Title: "Four", AudioURL: "/a.mp3"},
+		{ID: "ep005", Number: 5, Title: "Draft", AudioURL: "/a.mp3", Status: StatusDraft},
+	}
+	if err := writeJSONFile(filepath.Join(dir, "episodes.json"), episodes); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	return service
+}
+
+func TestSelectFeatured(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	start := now.Add(-49 * time.Hour)
+
+	tests := []struct {
+		name       string
+		settings   FeaturedSettings
+		expectedID string
+		reason     FeaturedReason
+	}{
+		{"latest", FeaturedSettings{Mode: FeaturedLatest}, "ep004", ReasonLatest},
+		{"pinned", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep002"}, "ep002", ReasonPinned},
+		{"pinned within window", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep002", StartsAt: &earlier, EndsAt: &later}, "ep002", ReasonPinned},
+		{"pin not started", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep002", StartsAt: &later}, "ep004", ReasonWindowInactive},
+		{"pin ended", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep002", EndsAt: &earlier}, "ep004", ReasonWindowInactive},
+		{"pinned draft", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep005"}, "ep004", ReasonUnavailable},
+		// 49 hours of daily rotation is slot 2, which skips the draft
+		{"rotation", FeaturedSettings{Mode: FeaturedRotation, Rotation: []string{"ep001", "ep005", "ep002", "ep003"}, StartsAt: &start}, "ep003", ReasonRotation},
+		{"rotation interval", FeaturedSettings{Mode: FeaturedRotation, Rotation: []string{"ep001", "ep002"}, RotationInterval: "1h", StartsAt: &start}, "ep002", ReasonRotation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newFeaturedTestService(t)
+			if _, _, err := service.SetFeaturedSettings(tt.settings, now); err != nil {
+				t.Fatalf("SetFeaturedSettings returned error: %v", err)
+			}
+
+			episode, reason, err := service.SelectFeatured(now)
+			if err != nil {
+				t.Fatalf("SelectFeatured returned error: %v", err)
+			}
+			if episode.ID != tt.expectedID {
+				t.Errorf("Expected %s, got %s", tt.expectedID, episode.ID)
+			}
+			if reason != tt.reason {
+				t.Errorf("Expected reason %s, got %s", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestSetFeaturedSettings(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	invalid := []FeaturedSettings{
+		{Mode: "random"},
+		{Mode: FeaturedPinned},
+		{Mode: FeaturedPinned, EpisodeID: "ep999"},
+		{Mode: FeaturedRotation},
+		{Mode: FeaturedRotation, Rotation: []string{"ep001", "ep001"}},
+		{Mode: FeaturedRotation, Rotation: []string{"ep001"}, RotationInterval: "1s"},
+		{Mode: FeaturedPinned, EpisodeID: "ep001", StartsAt: &now, EndsAt: &earlier},
+	}
+
+	service := newFeaturedTestService(t)
+	for _, settings := range invalid {
+		if _, _, err := service.SetFeaturedSettings(settings, now); !errors.Is(err, ErrFeaturedInvalid) {
+			t.Errorf("Expected ErrFeaturedInvalid for %+v, got %v", settings, err)
+		}
+	}
+
+	updated, previous, err := service.SetFeaturedSettings(FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep003", Rotation: []string{"ep001"}}, now)
+	if err != nil {
+		t.Fatalf("SetFeaturedSettings returned error: %v", err)
+	}
+	if previous.Mode != FeaturedLatest {
+		t.Errorf("Expected previous mode latest, got %s", previous.Mode)
+	}
+	if updated.Rotation != nil || !updated.UpdatedAt.Equal(now) {
+		t.Errorf("Expected rotation cleared and updatedAt set, got %+v", updated)
+	}
+
+	// Settings must survive a reload from disk
+	reloaded, err := LoadEpisodeService(filepath.Dir(service.path))
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	if settings := reloaded.GetFeaturedSettings(); settings.Mode != FeaturedPinned || settings.EpisodeID != "ep003" {
+		t.Errorf("Expected saved pin on ep003, got %+v", settings)
+	}
+}
+
+func TestFeaturedChangesBetween(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+	rotation := FeaturedSettings{Mode: FeaturedRotation, Rotation: []string{"ep001", "ep002"}, StartsAt: &start, EndsAt: &end}
+
+	tests := []struct {
+		name     string
+		settings FeaturedSettings
+		from, to time.Time
+		expected bool
+	}{
+		{"latest never changes", FeaturedSettings{Mode: FeaturedLatest}, start, end, false},
+		{"window opens", rotation, start.Add(-time.Minute), start, true},
+		{"within a slot", rotation, start.Add(time.Hour), start.Add(2 * time.Hour), false},
+		{"next slot", rotation, start.Add(23 * time.Hour), start.Add(25 * time.Hour), true},
+		{"window closes", FeaturedSettings{Mode: FeaturedPinned, EpisodeID: "ep001", EndsAt: &end}, end.Add(-time.Minute), end, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.ChangesBetween(tt.from, tt.to); got != tt.expected {
+				t.Errorf("ChangesBetween = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Feed namespaces
+const (
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	podcastNamespace = "https://podcastindex.org/namespace/1.0"
+)
+
+// FeedContentType is the media type feeds are served as
+const FeedContentType = "application/rss+xml; charset=utf-8"
+
+// Feed is an RSS 2.0 podcast feed with the iTunes and Podcasting 2.0
+// namespaces
+type Feed struct {
+	XMLName   xml.Name    `xml:"rss"`
+	Version   string      `xml:"version,attr"`
+	ItunesNS  string      `xml:"xmlns:itunes,attr"`
+	PodcastNS string      `xml:"xmlns:podcast,attr"`
+	Channel   FeedChannel `xml:"channel"`
+}
+
+// FeedChannel describes the feed. Block keeps private feeds out of podcast
+// directories.
+type FeedChannel struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Generator   string     `xml:"generator,omitempty"`
+	Block       string     `xml:"itunes:block,omitempty"`
+	Image       *FeedImage `xml:"itunes:image,omitempty"`
+	Items       []FeedItem `xml:"item"`
+}
+
+// FeedImage is an itunes:image reference
+type FeedImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// FeedItem is one episode in a feed
+type FeedItem struct {
+	Title       string           `xml:"title"`
+	Link        string           `xml:"link,omitempty"`
+	GUID        FeedGUID         `xml:"guid"`
+	PubDate     string           `xml:"pubDate,omitempty"`
+	Description string           `xml:"description"`
+	Enclosure   FeedEnclosure    `xml:"enclosure"`
+	Duration    string           `xml:"itunes:duration,omitempty"`
+	EpisodeType EpisodeType      `xml:"itunes:episodeType,omitempty"`
+	Season      int              `xml:"itunes:season,omitempty"`
+	Episode     int              `xml:"itunes:episode,omitempty"`
+	Image       *FeedImage       `xml:"itunes:image,omitempty"`
+	People      []FeedPerson     `xml:"podcast:person"`
+	Transcripts []FeedTranscript `xml:"podcast:transcript"`
+	Chapters    *FeedChapters    `xml:"podcast:chapters,omitempty"`
+}
+
+// FeedGUID identifies an item independently of its URLs
+type FeedGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// FeedEnclosure is the item's audio file; Length is 0 when unknown
+type FeedEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// FeedPerson is a podcast:person credit
+type FeedPerson struct {
+	Role  string `xml:"role,attr,omitempty"`
+	Group string `xml:"group,attr,omitempty"`
+	Img   string `xml:"img,attr,omitempty"`
+	Name  string `xml:",chardata"`
+}
+
+// FeedTranscript is a podcast:transcript link
+type FeedTranscript struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// FeedChapters is a podcast:chapters link
+type FeedChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// NewFeed returns an empty feed for channel
+func NewFeed(channel FeedChannel) *Feed {
+	return &Feed{
+		Version:   "2.0",
+		ItunesNS:  itunesNamespace,
+		PodcastNS: podcastNamespace,
+		Channel:   channel,
+	}
+}
+
+// NewFeedItem describes episode for a feed, leaving the URLs, which depend
+// on where the feed is served, to the caller. Credits must be attached.
+func NewFeedItem(episode *Episode, audioURL string, loc *time.Location) FeedItem {
+	item := FeedItem{
+		Title:       episode.Title,
+		GUID:        FeedGUID{Value: episode.ID},
+		PubDate:     episode.RFC2822Date(loc),
+		Description: episode.Description,
+		Enclosure: FeedEnclosure{
+			URL:    audioURL,
+			Length: episode.EnclosureLength(),
+			Type:   episode.EnclosureType(),
+		},
+		EpisodeType: episode.EpisodeType,
+		Season:      episode.Season,
+		Episode:     episode.SeasonEpisode,
+	}
+	if episode.DurationSeconds > 0 {
+		item.Duration = strconv.Itoa(episode.DurationSeconds)
+	}
+	for _, credit := range episode.People {
+		if credit.Name == "" {
+			continue
+		}
+		person := FeedPerson{Role: string(credit.Role), Img: credit.AvatarURL, Name: credit.Name}
+		if credit.Role == PersonProducer {
+			// The Podcasting 2.0 taxonomy files producers outside the cast
+			person.Group = "creative direction"
+		}
+		item.People = append(item.People, person)
+	}
+	return item
+}
+
+// Encode writes the feed as indented XML with a declaration
+func (f *Feed) Encode(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxID3TagSize bounds the ID3v2 tag read from an MP3
+const maxID3TagSize = 16 << 20
+
+// ID3v2 tag header flags
+const (
+	id3Unsynchronisation = 0x80
+	id3ExtendedHeader    = 0x40
+)
+
+// id3Frame is a frame ID and its decoded body
+type id3Frame struct {
+	id   string
+	body []byte
+}
+
+// IsID3Tagged reports whether data starts with an ID3v2 tag
+func IsID3Tagged(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("ID3"))
+}
+
+// ReadID3Chapters reads the chapters in the ID3v2.3 or ID3v2.4 tag at the
+// start of an MP3 from its CHAP and CTOC frames (ID3v2 Chapter Frame
+// Addendum). Only the tag is read from r, not the audio that follows it.
+// Chapters left out of every table of contents get toc set to false.
+func ReadID3Chapters(r io.Reader) (*Chapters, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil || !IsID3Tagged(header) {
+		return nil, fmt.Errorf("%w: no ID3v2 tag found", ErrChaptersInvalid)
+	}
+	major, flags := header[3], header[5]
+	if major != 3 && major != 4 {
+		return nil, fmt.Errorf("%w: ID3v2.%d tags are not supported", ErrChaptersInvalid, major)
+	}
+	size, ok := syncsafe(header[6:10])
+	if !ok || size > maxID3TagSize {
+		return nil, fmt.Errorf("%w: invalid ID3v2 tag size", ErrChaptersInvalid)
+	}
+
+	tag := make([]byte, size)
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, fmt.Errorf("%w: truncated ID3v2 tag", ErrChaptersInvalid)
+	}
+	unsync := flags&id3Unsynchronisation != 0
+	if unsync && major == 3 {
+		// ID3v2.3 unsynchronises the whole tag, ID3v2.4 each frame
+		tag = removeUnsynchronisation(tag)
+	}
+	if flags&id3ExtendedHeader != 0 && len(tag) >= 4 {
+		skip := int(binary.BigEndian.Uint32(tag)) + 4
+		if major == 4 {
+			skip, _ = syncsafe(tag[:4])
+		}
+		if skip > len(tag) {
+			return nil, fmt.Errorf("%w: invalid ID3v2 extended header", ErrChaptersInvalid)
+		}
+		tag = tag[skip:]
+	}
+
+	frames, err := parseID3Frames(tag, major, unsync && major == 4)
+	if err != nil {
+		return nil, err
+	}
+	return chaptersFromFrames(frames, major)
+}
+
+// chaptersFromFrames builds chapters from the CHAP and CTOC frames of a tag
+func chaptersFromFrames(frames []id3Frame, major byte) (*Chapters, error) {
+	chapters := &Chapters{}
+	listed := make(map[string]bool)
+	hasTOC := false
+	var ids []string
+
+	for _, frame := range frames {
+		switch frame.id {
+		case "CHAP":
+			id, rest := cutID3String(frame.body, 0)
+			if len(rest) < 16 {
+				return nil, fmt.Errorf("%w: CHAP frame %q is too short", ErrChaptersInvalid, id)
+			}
+			chapter := Chapter{
+				StartTime: float64(binary.BigEndian.Uint32(rest[0:4])) / 1000,
+				EndTime:   float64(binary.BigEndian.Uint32(rest[4:8])) / 1000,
+			}
+			subframes, err := parseID3Frames(rest[16:], major, false)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range subframes {
+				switch {
+				case sub.id == "TIT2":
+					chapter.Title = decodeID3Text(sub.body)
+				case sub.id == "WXXX":
+					chapter.URL = decodeID3UserURL(sub.body)
+				case strings.HasPrefix(sub.id, "W") && chapter.URL == "":
+					chapter.URL = latin1(bytes.TrimRight(sub.body, "\x00"))
+				}
+			}
+			chapters.Chapters = append(chapters.Chapters, chapter)
+			ids = append(ids, id)
+		case "CTOC":
+			_, rest := cutID3String(frame.body, 0)
+			if len(rest) < 2 {
+				return nil, fmt.Errorf("%w: CTOC frame is too short", ErrChaptersInvalid)
+			}
+			hasTOC = true
+			count, rest := int(rest[1]), rest[2:]
+			for i := 0; i < count && len(rest) > 0; i++ {
+				var child string
+				child, rest = cutID3String(rest, 0)
+				listed[child] = true
+			}
+		}
+	}
+
+	if hasTOC {
+		hidden := false
+		for i, id := range ids {
+			if !listed[id] {
+				chapters.Chapters[i].TOC = &hidden
+			}
+		}
+	}
+	if len(chapters.Chapters) == 0 {
+		return nil, fmt.Errorf("%w: the ID3v2 tag has no CHAP frames", ErrChaptersInvalid)
+	}
+	if err := chapters.normalize(); err != nil {
+		return nil, err
+	}
+	return chapters, nil
+}
+
+// parseID3Frames splits a tag or CHAP/CTOC body into frames, skipping
+// compressed and encrypted ones
+func parseID3Frames(data []byte, major byte, unsync bool) ([]id3Frame, error) {
+	var frames []id3Frame
+	for len(data) >= 10 && data[0] != 0 {
+		id := string(data[:4])
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		if major == 4 {
+			var ok bool
+			if size, ok = syncsafe(data[4:8]); !ok {
+				return nil, fmt.Errorf("%w: invalid size of frame %s", ErrChaptersInvalid, id)
+			}
+		}
+		if size > len(data)-10 {
+			return nil, fmt.Errorf("%w: truncated frame %s", ErrChaptersInvalid, id)
+		}
+		format := data[9]
+		body := data[10 : 10+size]
+		data = data[10+size:]
+
+		skip := false
+		if major == 4 {
+			skip = format&0x0c != 0
+			if format&0x40 != 0 && len(body) > 0 {
+				body = body[1:]
+			}
+			if format&0x01 != 0 && len(body) >= 4 {
+				body = body[4:]
+			}
+			if unsync || format&0x02 != 0 {
+				body = removeUnsynchronisation(body)
+			}
+		} else {
+			skip = format&0xc0 != 0
+			if format&0x20 != 0 && len(body) > 0 {
+				body = body[1:]
+			}
+		}
+		if !skip {
+			frames = append(frames, id3Frame{id: id, body: body})
+		}
+	}
+	return frames, nil
+}
+
+// syncsafe decodes a 28-bit syncsafe integer
+func syncsafe(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b[:4] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int(c)
+	}
+	return n, true
+}
+
+// removeUnsynchronisation drops the zero bytes inserted after 0xFF
+func removeUnsynchronisation(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// cutID3String splits a string terminated according to encoding (one zero
+// byte, or two for UTF-16) from the bytes after it
+func cutID3String(data []byte, encoding byte) (string, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeID3String(data[:i], encoding), data[i+2:]
+			}
+		}
+		return decodeID3String(data, encoding), nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return decodeID3String(data[:i], encoding), data[i+1:]
+	}
+	return decodeID3String(data, encoding), nil
+}
+
+// decodeID3Text decodes a text frame: an encoding byte and the text
+func decodeID3Text(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	text, _ := cutID3String(body[1:], body[0])
+	return text
+}
+
+// decodeID3UserURL returns the URL of a WXXX frame, which follows an
+// encoding byte and a description
+func decodeID3UserURL(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	_, rest := cutID3String(body[1:], body[0])
+	return latin1(bytes.TrimRight(rest, "\x00"))
+}
+
+// decodeID3String decodes ISO-8859-1 (0), UTF-16 with BOM (1), UTF-16BE (2)
+// or UTF-8 (3)
+func decodeID3String(data []byte, encoding byte) string {
+	switch encoding {
+	case 1, 2:
+		littleEndian := false
+		if encoding == 1 && len(data) >= 2 {
+			switch {
+			case data[0] == 0xff && data[1] == 0xfe:
+				littleEndian, data = true, data[2:]
+			case data[0] == 0xfe && data[1] == 0xff:
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if littleEndian {
+				units[i] = binary.LittleEndian.Uint16(data[2*i:])
+			} else {
+				units[i] = binary.BigEndian.Uint16(data[2*i:])
+			}
+		}
+		return string(utf16.Decode(units))
+	case 3:
+		return strings.ToValidUTF8(string(data), "�")
+	default:
+		return latin1(data)
+	}
+}
+
+// latin1 decodes ISO-8859-1 bytes
+func latin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
@@ -0,0 +1,419 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Listener account errors
+var (
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrLoginThrottled    = errors.New("a sign-in email was sent recently")
+	ErrInvalidLoginToken = errors.New("invalid or expired sign-in token")
+	ErrInvalidSession    = errors.New("invalid or expired session")
+	ErrInvalidProgress   = errors.New("invalid playback progress")
+)
+
+// LoginInterval is the minimum time between sign-in emails to one address
+const LoginInterval = time.Minute
+
+// Listener is a signed-in listener, identified by email address
+type Listener struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Progress is how far a listener got through an episode
+type Progress struct {
+	EpisodeID string `json:"episodeId"`
+	// Position is the playback position in seconds
+	Position  float64   `json:"position"`
+	Completed bool      `json:"completed"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Session is a signed-in session; Token is only known when it is created
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// listenerRecord is the stored form of a listener
+type listenerRecord struct {
+	Listener
+	Sessions []sessionRecord     `json:"sessions,omitempty"`
+	Progress map[string]Progress `json:"progress,omitempty"`
+}
+
+// sessionRecord stores a session by the SHA-256 hash of its token
+type sessionRecord struct {
+	Hash      string    `json:"hash"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// loginToken is a pending sign-in, kept in memory only
+type loginToken struct {
+	email     string
+	expiresAt time.Time
+}
+
+// ListenerService manages listener accounts, their sessions and playback
+// progress. Accounts are created on the first completed sign-in.
+type ListenerService struct {
+	mutex      sync.Mutex
+	path       string
+	linkTTL    time.Duration
+	sessionTTL time.Duration
+	listeners  map[string]*listenerRecord
+	byEmail    map[string]string
+	// bySession maps session token hashes to listener IDs
+	bySession map[string]string
+	logins    map[string]loginToken
+	sent      map[string]time.Time
+	// dirty is set while playback progress is newer than the file
+	dirty bool
+}
+
+// NewListenerService returns an in-memory listener store whose accounts are
+// lost on restart. Sign-in tokens are valid for linkTTL and sessions for
+// sessionTTL.
+func NewListenerService(linkTTL, sessionTTL time.Duration) *ListenerService {
+	return &ListenerService{
+		linkTTL:    linkTTL,
+		sessionTTL: sessionTTL,
+		listeners:  make(map[string]*listenerRecord),
+		byEmail:    make(map[string]string),
+		bySession:  make(map[string]string),
+		logins:     make(map[string]loginToken),
+		sent:       make(map[string]time.Time),
+	}
+}
+
+// OpenListenerService loads the listeners in path, a JSON file that is
+// created if it does not exist. It is rewritten on every change to an
+// account or session; playback progress is only written by Save.
+func OpenListenerService(path string, linkTTL, sessionTTL time.Duration) (*ListenerService, error) {
+	service := NewListenerService(linkTTL, sessionTTL)
+	service.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// The file holds email addresses, so it is created private
+		if err := os.WriteFile(path, []byte("[]\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create listeners file: %w", err)
+		}
+		return service, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read listeners file: %w", err)
+	}
+
+	var records []*listenerRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse listeners file %s: %w", path, err)
+	}
+	for _, record := range records {
+		service.store(record)
+	}
+	return service, nil
+}
+
+// NormalizeEmail returns the lower-cased address of a bare email address
+func NormalizeEmail(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Name != "" || address.Address != value || len(value) > 254 {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// RequestLogin creates a single-use sign-in token for email, to be sent to
+// that address. Only one token is issued per address every LoginInterval.
+func (s *ListenerService) RequestLogin(email string, now time.Time) (string, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for hash, login := range s.logins {
+		if !now.Before(login.expiresAt) {
+			delete(s.logins, hash)
+		}
+	}
+	for address, at := range s.sent {
+		if now.Sub(at) >= LoginInterval {
+			delete(s.sent, address)
+		}
+	}
+	if _, ok := s.sent[email]; ok {
+		return "", ErrLoginThrottled
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	s.logins[hashToken(token)] = loginToken{email: email, expiresAt: now.Add(s.linkTTL)}
+	s.sent[email] = now
+	return token, nil
+}
+
+// CompleteLogin exchanges a sign-in token for a session, creating the
+// listener on first sign-in
+func (s *ListenerService) CompleteLogin(token string, now time.Time) (*Session, *Listener, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hash := hashToken(token)
+	login, ok := s.logins[hash]
+	if !ok || !now.Before(login.expiresAt) {
+		return nil, nil, ErrInvalidLoginToken
+	}
+	delete(s.logins, hash)
+
+	sessionToken, err := newToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	record := s.listeners[s.byEmail[login.email]]
+	if record == nil {
+		id, err := newToken()
+		if err != nil {
+			return nil, nil, err
+		}
+		record = &listenerRecord{Listener: Listener{
+			ID:        id[:16],
+			Email:     login.email,
+			CreatedAt: now.UTC(),
+		}}
+	}
+	session := Session{Token: sessionToken, ExpiresAt: now.Add(s.sessionTTL).UTC()}
+	sessions := record.Sessions[:0:0]
+	for _, existing := range record.Sessions {
+		if now.Before(existing.ExpiresAt) {
+			sessions = append(sessions, existing)
+		}
+	}
+	updated := *record
+	updated.Sessions = append(sessions, sessionRecord{Hash: hashToken(sessionToken), ExpiresAt: session.ExpiresAt})
+
+	if err := s.commit(&updated); err != nil {
+		return nil, nil, err
+	}
+	listener := updated.Listener
+	return &session, &listener, nil
+}
+
+// Authenticate returns the listener a session token belongs to
+func (s *ListenerService) Authenticate(token string, now time.Time) (*Listener, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if record := s.findSession(token, now); record != nil {
+		listener := record.Listener
+		return &listener, nil
+	}
+	return nil, ErrInvalidSession
+}
+
+// Logout ends the session with the given token
+func (s *ListenerService) Logout(token string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hash := hashToken(token)
+	record := s.listeners[s.bySession[hash]]
+	if record == nil {
+		return ErrInvalidSession
+	}
+	updated := *record
+	updated.Sessions = slices.DeleteFunc(slices.Clone(record.Sessions), func(session sessionRecord) bool {
+		return session.Hash == hash
+	})
+	return s.commit(&updated)
+}
+
+// GetProgress returns the listener's progress, most recently updated first
+func (s *ListenerService) GetProgress(listenerID string) []Progress {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record := s.listeners[listenerID]
+	if record == nil {
+		return []Progress{}
+	}
+	progress := make([]Progress, 0, len(record.Progress))
+	for _, p := range record.Progress {
+		progress = append(progress, p)
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		if !progress[i].UpdatedAt.Equal(progress[j].UpdatedAt) {
+			return progress[i].UpdatedAt.After(progress[j].UpdatedAt)
+		}
+		return progress[i].EpisodeID < progress[j].EpisodeID
+	})
+	return progress
+}
+
+// SetProgress stores the listener's progress through an episode. Players
+// report progress every few seconds, so it is kept in memory until Save.
+func (s *ListenerService) SetProgress(listenerID string, progress Progress) (*Progress, error) {
+	if progress.EpisodeID == "" || progress.Position < 0 {
+		return nil, ErrInvalidProgress
+	}
+	if progress.UpdatedAt.IsZero() {
+		progress.UpdatedAt = time.Now()
+	}
+	progress.UpdatedAt = progress.UpdatedAt.UTC()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record := s.listeners[listenerID]
+	if record == nil {
+		return nil, ErrInvalidSession
+	}
+	updated := *record
+	updated.Progress = make(map[string]Progress, len(record.Progress)+1)
+	for id, p := range record.Progress {
+		updated.Progress[id] = p
+	}
+	updated.Progress[progress.EpisodeID] = progress
+
+	s.store(&updated)
+	s.dirty = s.path != ""
+	return &progress, nil
+}
+
+// Save writes playback progress stored since the last write to the file the
+// service was opened from, if any
+func (s *ListenerService) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	return s.commit(nil)
+}
+
+// findSession returns the listener with an unexpired session for token.
+// The caller must hold the lock.
+func (s *ListenerService) findSession(token string, now time.Time) *listenerRecord {
+	if token == "" {
+		return nil
+	}
+	hash := hashToken(token)
+	record := s.listeners[s.bySession[hash]]
+	if record == nil {
+		return nil
+	}
+	for _, session := range record.Sessions {
+		if session.Hash == hash && now.Before(session.ExpiresAt) {
+			return record
+		}
+	}
+	return nil
+}
+
+// commit writes the listeners with record replaced to disk, when the
+// service is file-backed, and then makes them current. A nil record writes
+// the listeners as they are. The caller must hold the lock.
+func (s *ListenerService) commit(record *listenerRecord) error {
+	if s.path != "" {
+		records := make([]*listenerRecord, 0, len(s.listeners)+1)
+		for id, existing := range s.listeners {
+			if record == nil || id != record.ID {
+				records = append(records, existing)
+			}
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		})
+		if err := writeJSONFile(s.path, records); err != nil {
+			return fmt.Errorf("failed to save listeners: %w", err)
+		}
+		// Pending progress of every listener was written with them
+		s.dirty = false
+	}
+	if record != nil {
+		s.store(record)
+	}
+	return nil
+}
+
+// store makes record current and indexes it by email address and session.
+// The caller must hold the lock.
+func (s *ListenerService) store(record *listenerRecord) {
+	if previous := s.listeners[record.ID]; previous != nil {
+		for _, session := range previous.Sessions {
+			delete(s.bySession, session.Hash)
+		}
+	}
+	s.listeners[record.ID] = record
+	s.byEmail[record.Email] = record.ID
+	for _, session := range record.Sessions {
+		s.bySession[session.Hash] = record.ID
+	}
+}
+
+// ListeningEpisode is an episode with the listener's progress through it
+type ListeningEpisode struct {
+	Episode
+	Progress Progress `json:"progress"`
+}
+
+// ContinueListening returns the episodes reachable at now that a listener
+// started but did not finish, in the order of progress (most recent first)
+func (s *EpisodeService) ContinueListening(progress []Progress, now time.Time) []ListeningEpisode {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	episodes := []ListeningEpisode{}
+	for _, p := range progress {
+		if p.Completed || p.Position <= 0 {
+			continue
+		}
+		for _, episode := range s.episodes {
+			if episode.ID == p.EpisodeID && episode.IsReachable(now) {
+				episodes = append(episodes, ListeningEpisode{Episode: episode, Progress: p})
+				break
+			}
+		}
+	}
+	return episodes
+}
+
+// newToken returns a random URL-safe secret
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 digest tokens are stored under
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenerSignIn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listeners.json")
+	service, err := OpenListenerService(path, 15*time.Minute, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenListenerService returned error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected a private listeners file, got %v %v", info, err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := service.RequestLogin("Jane <jane@example.com>", now); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("Expected ErrInvalidEmail for a named address, got %v", err)
+	}
+	token, err := service.RequestLogin("Jane@Example.com", now)
+	if err != nil {
+		t.Fatalf("RequestLogin returned error: %v", err)
+	}
+	if _, err := service.RequestLogin("jane@example.com", now.Add(30*time.Second)); !errors.Is(err, ErrLoginThrottled) {
+		t.Errorf("Expected a second link within a minute to be throttled, got %v", err)
+	}
+
+	session, listener, err := service.CompleteLogin(token, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+	if listener.Email != "jane@example.com" || listener.ID == "" {
+		t.Errorf("Unexpected listener %+v", listener)
+	}
+	if _, _, err := service.CompleteLogin(token, now.Add(time.Minute)); !errors.Is(err, ErrInvalidLoginToken) {
+		t.Errorf("Expected sign-in tokens to be single use, got %v", err)
+	}
+
+	// A second sign-in reaches the same account
+	second, err := service.RequestLogin("jane@example.com", now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("RequestLogin returned error: %v", err)
+	}
+	if _, _, err := service.CompleteLogin(second, now.Add(20*time.Minute)); !errors.Is(err, ErrInvalidLoginToken) {
+		t.Errorf("Expected an expired sign-in token to be rejected, got %v", err)
+	}
+
+	reopened, err := OpenListenerService(path, 15*time.Minute, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenListenerService returned error: %v", err)
+	}
+	got, err := reopened.Authenticate(session.Token, now.Add(time.Hour))
+	if err != nil || got.ID != listener.ID {
+		t.Fatalf("Expected the session to survive a restart, got %+v %v", got, err)
+	}
+	if _, err := reopened.Authenticate(session.Token, now.Add(25*time.Hour)); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected an expired session to be rejected, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) || strings.Contains(string(data), session.Token) {
+		t.Error("Expected tokens to be stored only as hashes")
+	}
+
+	if err := reopened.Logout(session.Token); err != nil {
+		t.Fatalf("Logout returned error: %v", err)
+	}
+	if _, err := reopened.Authenticate(session.Token, now.Add(time.Hour)); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected the session to end on logout, got %v", err)
+	}
+}
+
+func TestListenerProgress(t *testing.T) {
+	service := NewListenerService(time.Minute, time.Hour)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	token, _ := service.RequestLogin("sam@example.com", now)
+	_, listener, err := service.CompleteLogin(token, now)
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+
+	updates := []Progress{
+		{EpisodeID: "ep001", Position: 120, UpdatedAt: now},
+		{EpisodeID: "ep002", Position: 1500, Completed: true, UpdatedAt: now.Add(time.Minute)},
+		{EpisodeID: "ep003", Position: 60, UpdatedAt: now.Add(2 * time.Minute)},
+		{EpisodeID: "ep001", Position: 300, UpdatedAt: now.Add(3 * time.Minute)},
+		{EpisodeID: "ep004", Position: 10, UpdatedAt: now.Add(4 * time.Minute)},
+		{EpisodeID: "gone", Position: 10, UpdatedAt: now.Add(5 * time.Minute)},
+	}
+	for _, p := range updates {
+		if _, err := service.SetProgress(listener.ID, p); err != nil {
+			t.Fatalf("SetProgress returned error: %v", err)
+		}
+	}
+	if _, err := service.SetProgress(listener.ID, Progress{EpisodeID: "ep001", Position: -1}); !errors.Is(err, ErrInvalidProgress) {
+		t.Errorf("Expected ErrInvalidProgress for a negative position, got %v", err)
+	}
+
+	progress := service.GetProgress(listener.ID)
+	if len(progress) != 5 || progress[2].EpisodeID != "ep001" || progress[2].Position != 300 {
+		t.Fatalf("Unexpected progress %+v", progress)
+	}
+
+	dir := t.TempDir()
+	data, err := json.Marshal([]Episode{
+		{ID: "ep001", Number: 1, Title: "One", AudioURL: "/1.mp3", PublishDate: "2024-01-01"},
+		{ID: "ep002", Number: 2, Title: "Two", AudioURL: "/2.mp3", PublishDate: "2024-01-02"},
+		{ID: "ep003", Number: 3, Title: "Three", AudioURL: "/3.mp3", PublishDate: "2024-01-03"},
+		{ID: "ep004", Number: 4, Title: "Draft", AudioURL: "/4.mp3", Status: StatusDraft},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "episodes.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	episodes, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	continueListening := episodes.ContinueListening(progress, time.Now())
+	var ids []string
+	for _, episode := range continueListening {
+		ids = append(ids, episode.ID)
+	}
+	// Completed, unreachable and unknown episodes are left out
+	if len(ids) != 2 || ids[0] != "ep001" || ids[1] != "ep003" {
+		t.Errorf("Expected ep001 and ep003 to continue, got %v", ids)
+	}
+}
+
+func TestListenerProgressSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listeners.json")
+	service, err := OpenListenerService(path, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenListenerService returned error: %v", err)
+	}
+	now := time.Now()
+	token, _ := service.RequestLogin("sam@example.com", now)
+	session, listener, err := service.CompleteLogin(token, now)
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.SetProgress(listener.ID, Progress{EpisodeID: "ep001", Position: 120, UpdatedAt: now}); err != nil {
+		t.Fatalf("SetProgress returned error: %v", err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("Expected progress to be held in memory until Save")
+	}
+	// Sessions keep working on the record progress was stored in
+	if _, err := service.Authenticate(session.Token, now); err != nil {
+		t.Errorf("Authenticate returned error: %v", err)
+	}
+
+	if err := service.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	reopened, err := OpenListenerService(path, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenListenerService returned error: %v", err)
+	}
+	progress := reopened.GetProgress(listener.ID)
+	if len(progress) != 1 || progress[0].Position != 120 {
+		t.Errorf("Expected saved progress after a restart, got %+v", progress)
+	}
+}
@@ -0,0 +1,599 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media probing errors
+var (
+	// ErrMediaNotLocal is returned for audio URLs that are not served from
+	// the media directory, such as files on a CDN
+	ErrMediaNotLocal    = errors.New("media is not served locally")
+	ErrMediaUnsupported = errors.New("unsupported media format")
+	ErrMediaInvalid     = errors.New("invalid media file")
+)
+
+// defaultEnclosureType is assumed for audio of unknown type
+const defaultEnclosureType = "audio/mpeg"
+
+// mediaScanSize is how much of a file is searched for the first MPEG frame,
+// or for the last Ogg page
+const mediaScanSize = 64 << 10
+
+// maxMP4MovieSize bounds the moov atom read from an MP4 file
+const maxMP4MovieSize = 16 << 20
+
+// MediaInfo describes an audio file as measured from its headers. Bitrate is
+// the average in kbit/s.
+type MediaInfo struct {
+	MimeType string   `json:"mimeType"`
+	Length   int64    `json:"length"`
+	Duration Duration `json:"duration"`
+	Bitrate  int      `json:"bitrate,omitempty"`
+}
+
+// MediaIssue is a problem found while probing an episode's audio: either a
+// value in episodes.json that disagrees with the file, or a file that could
+// not be read
+type MediaIssue struct {
+	EpisodeID string `json:"episodeId"`
+	Field     string `json:"field"`
+	Stored    string `json:"stored,omitempty"`
+	Probed    string `json:"probed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// EnclosureType returns the MIME type for the episode's feed enclosure: the
+// probed type, or one guessed from the audio URL's extension
+func (e *Episode) EnclosureType() string {
+	if e.Audio != nil && e.Audio.MimeType != "" {
+		return e.Audio.MimeType
+	}
+	if u, err := url.Parse(e.AudioURL); err == nil {
+		if mimeType := mime.TypeByExtension(path.Ext(u.Path)); strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/") {
+			return mimeType
+		}
+	}
+	return defaultEnclosureType
+}
+
+// EnclosureLength returns the byte length for the episode's feed enclosure,
+// or 0 when it is unknown
+func (e *Episode) EnclosureLength() int64 {
+	if e.Audio == nil {
+		return 0
+	}
+	return e.Audio.Length
+}
+
+// MediaProber measures the audio files behind site-relative audio URLs,
+// such as /assets/audio/ep1.mp3, under a media directory
+type MediaProber struct {
+	root string
+}
+
+// NewMediaProber creates a prober for files served from root
+func NewMediaProber(root string) *MediaProber {
+	return &MediaProber{root: root}
+}
+
+// Probe measures the file behind audioURL. Absolute URLs return
+// ErrMediaNotLocal.
+func (p *MediaProber) Probe(audioURL string) (*MediaInfo, error) {
+	file, stat, err := p.Open(audioURL)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ProbeMedia(file, stat.Size())
+}
+
+// Open opens the file behind a site-relative URL. Other URLs return
+// ErrMediaNotLocal.
+func (p *MediaProber) Open(rawURL string) (*os.File, os.FileInfo, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return nil, nil, ErrMediaNotLocal
+	}
+	// Cleaning the rooted path keeps it inside the media directory
+	file, err := os.Open(filepath.Join(p.root, filepath.FromSlash(path.Clean(u.Path))))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", u.Path, err)
+	}
+
+	stat, err := file.Stat()
+	if err == nil && stat.IsDir() {
+		err = fmt.Errorf("%w: %s is a directory", ErrMediaInvalid, u.Path)
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, stat, nil
+}
+
+// ProbeMedia measures an MP3, MP4/M4A or Ogg (Vorbis or Opus) file of size
+// bytes from its headers, without decoding the audio
+func ProbeMedia(r io.ReaderAt, size int64) (*MediaInfo, error) {
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	var info *MediaInfo
+	var err error
+	switch {
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		info, err = probeMP4(r, size)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		info, err = probeOgg(r, size)
+	case isMPEGAudio(head):
+		info, err = probeMP3(r, size)
+	default:
+		return nil, ErrMediaUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info.Length = size
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int(math.Round(float64(size) * 8 / 1000 / time.Duration(info.Duration).Seconds()))
+	}
+	return info, nil
+}
+
+// isMPEGAudio reports whether head starts an MP3, with or without ID3 tag
+func isMPEGAudio(head []byte) bool {
+	return IsID3Tagged(head) || (len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0)
+}
+
+// mediaDuration converts seconds to a Duration rounded to milliseconds
+func mediaDuration(seconds float64) Duration {
+	return Duration(time.Duration(seconds * float64(time.Second)).Round(time.Millisecond))
+}
+
+// mpegFrame is a parsed MPEG audio frame header
+type mpegFrame struct {
+	version    int // 1, 2, or 25 for MPEG 2.5
+	layer      int
+	bitrate    int // kbit/s
+	sampleRate int
+	samples    int // per frame
+	mono       bool
+	protected  bool // a CRC follows the header
+	length     int  // bytes, including the header
+}
+
+// MPEG audio bitrates in kbit/s by version and layer, indexed by the
+// header's bitrate index minus one
+var (
+	mpeg1Bitrates = [3][14]int{
+		{32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	}
+	mpeg2Bitrates = [3][14]int{
+		{32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mpegSampleRates = [3]int{44100, 48000, 32000}
+)
+
+// parseMPEGFrame reads the frame header at the start of b
+func parseMPEGFrame(b []byte) (mpegFrame, bool) {
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return mpegFrame{}, false
+	}
+	versionBits, layerBits := b[1]>>3&3, b[1]>>1&3
+	bitrateIndex, rateIndex := int(b[2]>>4), int(b[2]>>2&3)
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	frame := mpegFrame{layer: 4 - int(layerBits), mono: b[3]>>6 == 3, protected: b[1]&1 == 0}
+	frame.sampleRate = mpegSampleRates[rateIndex]
+	switch versionBits {
+	case 3:
+		frame.version = 1
+		frame.bitrate = mpeg1Bitrates[frame.layer-1][bitrateIndex-1]
+	case 2:
+		frame.version = 2
+		frame.bitrate = mpeg2Bitrates[frame.layer-1][bitrateIndex-1]
+		frame.sampleRate /= 2
+	default:
+		frame.version = 25
+		frame.bitrate = mpeg2Bitrates[frame.layer-1][bitrateIndex-1]
+		frame.sampleRate /= 4
+	}
+
+	padding := int(b[2] >> 1 & 1)
+	switch {
+	case frame.layer == 1:
+		frame.samples = 384
+		frame.length = (12*frame.bitrate*1000/frame.sampleRate + padding) * 4
+	case frame.layer == 3 && frame.version != 1:
+		frame.samples = 576
+		frame.length = 72*frame.bitrate*1000/frame.sampleRate + padding
+	default:
+		frame.samples = 1152
+		frame.length = 144*frame.bitrate*1000/frame.sampleRate + padding
+	}
+	return frame, true
+}
+
+// mpegStream locates the MPEG audio frames of an MP3 file
+type mpegStream struct {
+	// start and end delimit the frames, between the ID3v2 tags and any
+	// ID3v1 tag
+	start, end int64
+	// first is the first frame's header and head the bytes from its start
+	first mpegFrame
+	head  []byte
+}
+
+// findMPEGStream skips the ID3v2 tags of an MP3 and finds its first frame
+func findMPEGStream(r io.ReaderAt, size int64) (*mpegStream, error) {
+	start := int64(0)
+	header := make([]byte, 10)
+	// Some files carry more than one ID3v2 tag
+	for {
+		if _, err := r.ReadAt(header, start); err != nil || !IsID3Tagged(header) {
+			break
+		}
+		tagSize, ok := syncsafe(header[6:10])
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid ID3v2 tag size", ErrMediaInvalid)
+		}
+		start += 10 + int64(tagSize)
+		if header[5]&0x10 != 0 {
+			start += 10 // footer
+		}
+	}
+
+	buf := make([]byte, mediaScanSize)
+	n, _ := r.ReadAt(buf, start)
+	buf = buf[:n]
+
+	// A frame is only trusted when the next one follows it, since 0xFFE
+	// also occurs in padding and cover art
+	for i := 0; i+4 <= len(buf); i++ {
+		candidate, ok := parseMPEGFrame(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + candidate.length
+		if next+4 <= len(buf) {
+			if _, ok := parseMPEGFrame(buf[next:]); !ok {
+				continue
+			}
+		}
+
+		stream := &mpegStream{start: start + int64(i), end: size, first: candidate, head: buf[i:]}
+		trailer := make([]byte, 3)
+		if _, err := r.ReadAt(trailer, size-128); err == nil && string(trailer) == "TAG" {
+			stream.end -= 128
+		}
+		return stream, nil
+	}
+	return nil, fmt.Errorf("%w: no MPEG audio frames found", ErrMediaInvalid)
+}
+
+// probeMP3 measures an MP3 from its first frame: exactly from a Xing/Info
+// or VBRI header when the encoder wrote one, otherwise as constant bitrate
+func probeMP3(r io.ReaderAt, size int64) (*MediaInfo, error) {
+	stream, err := findMPEGStream(r, size)
+	if err != nil {
+		return nil, err
+	}
+	frame := stream.first
+	audioBytes := stream.end - stream.start
+
+	info := &MediaInfo{MimeType: "audio/mpeg"}
+	if frames, bytesCount := mpegFrameCount(stream.head, frame); frames > 0 {
+		seconds := float64(frames) * float64(frame.samples) / float64(frame.sampleRate)
+		info.Duration = mediaDuration(seconds)
+		if bytesCount > 0 {
+			audioBytes = bytesCount
+		}
+		info.Bitrate = int(math.Round(float64(audioBytes) * 8 / 1000 / seconds))
+		return info, nil
+	}
+
+	info.Bitrate = frame.bitrate
+	info.Duration = mediaDuration(float64(audioBytes) * 8 / float64(frame.bitrate*1000))
+	return info, nil
+}
+
+// mpegFrameCount reads the frame and byte counts of a Xing/Info or VBRI
+// header in the first frame; frames is 0 when there is none
+func mpegFrameCount(b []byte, frame mpegFrame) (frames, bytesCount int64) {
+	// The Xing header follows the side information
+	sideInfo := 32
+	switch {
+	case frame.version == 1 && frame.mono:
+		sideInfo = 17
+	case frame.version != 1 && !frame.mono:
+		sideInfo = 17
+	case frame.version != 1:
+		sideInfo = 9
+	}
+	if x := 4 + sideInfo; len(b) >= x+16 && (string(b[x:x+4]) == "Xing" || string(b[x:x+4]) == "Info") {
+		flags := binary.BigEndian.Uint32(b[x+4:])
+		field := x + 8
+		if flags&1 != 0 {
+			frames = int64(binary.BigEndian.Uint32(b[field:]))
+			field += 4
+		}
+		if flags&2 != 0 {
+			bytesCount = int64(binary.BigEndian.Uint32(b[field:]))
+		}
+		return frames, bytesCount
+	}
+
+	// The VBRI header sits 32 bytes after the frame header
+	if v := 4 + 32; len(b) >= v+18 && string(b[v:v+4]) == "VBRI" {
+		bytesCount = int64(binary.BigEndian.Uint32(b[v+10:]))
+		frames = int64(binary.BigEndian.Uint32(b[v+14:]))
+	}
+	return frames, bytesCount
+}
+
+// mp4Atom is a box of an MP4 file
+type mp4Atom struct {
+	kind string
+	body []byte
+}
+
+// mp4Atoms splits data into its child atoms
+func mp4Atoms(data []byte) []mp4Atom {
+	var atoms []mp4Atom
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+		header := 8
+		if size == 1 && len(data) >= 16 {
+			size, header = int(binary.BigEndian.Uint64(data[8:])), 16
+		} else if size == 0 {
+			size = len(data)
+		}
+		if size < header || size > len(data) {
+			break
+		}
+		atoms = append(atoms, mp4Atom{kind: string(data[4:8]), body: data[header:size]})
+		data = data[size:]
+	}
+	return atoms
+}
+
+// probeMP4 measures an MP4 or M4A file from its movie header. Files with a
+// video track are reported as video/mp4.
+func probeMP4(r io.ReaderAt, size int64) (*MediaInfo, error) {
+	var moov []byte
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			break
+		}
+		atomSize, headerSize := int64(binary.BigEndian.Uint32(header)), int64(8)
+		if atomSize == 1 {
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				break
+			}
+			atomSize, headerSize = int64(binary.BigEndian.Uint64(header[8:])), 16
+		} else if atomSize == 0 {
+			atomSize = size - offset
+		}
+		if atomSize < headerSize || offset+atomSize > size {
+			return nil, fmt.Errorf("%w: truncated %q atom", ErrMediaInvalid, header[4:8])
+		}
+		if string(header[4:8]) == "moov" {
+			if atomSize-headerSize > maxMP4MovieSize {
+				return nil, fmt.Errorf("%w: the movie header is too large", ErrMediaInvalid)
+			}
+			moov = make([]byte, atomSize-headerSize)
+			if _, err := r.ReadAt(moov, offset+headerSize); err != nil {
+				return nil, fmt.Errorf("%w: truncated movie header", ErrMediaInvalid)
+			}
+			break
+		}
+		offset += atomSize
+	}
+	if moov == nil {
+		return nil, fmt.Errorf("%w: no moov atom found", ErrMediaInvalid)
+	}
+
+	info := &MediaInfo{MimeType: "audio/mp4"}
+	found := false
+	for _, atom := range mp4Atoms(moov) {
+		switch atom.kind {
+		case "mvhd":
+			body := atom.body
+			var timescale, duration uint64
+			switch {
+			case len(body) >= 32 && body[0] == 1:
+				timescale, duration = uint64(binary.BigEndian.Uint32(body[20:])), binary.BigEndian.Uint64(body[24:])
+			case len(body) >= 20:
+				timescale, duration = uint64(binary.BigEndian.Uint32(body[12:])), uint64(binary.BigEndian.Uint32(body[16:]))
+			}
+			if timescale == 0 {
+				return nil, fmt.Errorf("%w: invalid movie header", ErrMediaInvalid)
+			}
+			info.Duration = mediaDuration(float64(duration) / float64(timescale))
+			found = true
+		case "trak":
+			if mp4HandlerType(atom.body) == "vide" {
+				info.MimeType = "video/mp4"
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: no movie header found", ErrMediaInvalid)
+	}
+	return info, nil
+}
+
+// mp4HandlerType returns the handler type of a track, such as "soun" or "vide"
+func mp4HandlerType(trak []byte) string {
+	for _, atom := range mp4Atoms(trak) {
+		if atom.kind != "mdia" {
+			continue
+		}
+		for _, child := range mp4Atoms(atom.body) {
+			// Version and flags, then pre_defined, then the handler type
+			if child.kind == "hdlr" && len(child.body) >= 12 {
+				return string(child.body[8:12])
+			}
+		}
+	}
+	return ""
+}
+
+// probeOgg measures an Ogg Vorbis or Opus file from the granule position of
+// its last page
+func probeOgg(r io.ReaderAt, size int64) (*MediaInfo, error) {
+	first := make([]byte, 27+255+19)
+	n, _ := r.ReadAt(first, 0)
+	first = first[:n]
+	if len(first) < 28 {
+		return nil, fmt.Errorf("%w: truncated Ogg page", ErrMediaInvalid)
+	}
+	serial := binary.LittleEndian.Uint32(first[14:])
+	segments := int(first[26])
+	if len(first) < 27+segments {
+		return nil, fmt.Errorf("%w: truncated Ogg page", ErrMediaInvalid)
+	}
+	packet := first[27+segments:]
+
+	var sampleRate, preSkip int64
+	switch {
+	case len(packet) >= 16 && string(packet[:7]) == "\x01vorbis":
+		sampleRate = int64(binary.LittleEndian.Uint32(packet[12:]))
+	case len(packet) >= 12 && string(packet[:8]) == "OpusHead":
+		// Opus granule positions always count 48 kHz samples
+		sampleRate, preSkip = 48000, int64(binary.LittleEndian.Uint16(packet[10:]))
+	default:
+		return nil, fmt.Errorf("%w: only Vorbis and Opus in Ogg are supported", ErrMediaUnsupported)
+	}
+	if sampleRate == 0 {
+		return nil, fmt.Errorf("%w: invalid Ogg sample rate", ErrMediaInvalid)
+	}
+
+	tailStart := size - mediaScanSize
+	if tailStart < 0 {
+		tailStart = 0
+	}
+	tail := make([]byte, size-tailStart)
+	n, _ = r.ReadAt(tail, tailStart)
+	tail = tail[:n]
+
+	granule := int64(-1)
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+27 > len(tail) || binary.LittleEndian.Uint32(tail[i+14:]) != serial {
+			continue
+		}
+		// Pages where no packet ends have a granule position of -1
+		if position := int64(binary.LittleEndian.Uint64(tail[i+6:])); position >= 0 {
+			granule = position
+			break
+		}
+	}
+	if granule < 0 {
+		return nil, fmt.Errorf("%w: no Ogg page with a granule position found", ErrMediaInvalid)
+	}
+
+	samples := granule - preSkip
+	if samples < 0 {
+		samples = 0
+	}
+	return &MediaInfo{
+		MimeType: "audio/ogg",
+		Duration: mediaDuration(float64(samples) / float64(sampleRate)),
+	}, nil
+}
+
+// probeEpisode measures the audio and artwork of e and stores the results
+// on it, returning the values in e that disagreed with the files and
+// artwork that podcast directories would reject. Files that are not served
+// locally keep the values they were given.
+func (p *MediaProber) probeEpisode(e *Episode) []MediaIssue {
+	var issues []MediaIssue
+	failed := func(field, stored string, err error) {
+		issues = append(issues, MediaIssue{EpisodeID: e.ID, Field: field, Stored: stored, Error: err.Error()})
+	}
+	mismatch := func(field, stored, probed string) {
+		issues = append(issues, MediaIssue{EpisodeID: e.ID, Field: field, Stored: stored, Probed: probed})
+	}
+
+	if IsRasterArtwork(e.ArtworkURL) {
+		artwork, err := p.Analyze(e.ArtworkURL)
+		switch {
+		case errors.Is(err, ErrMediaNotLocal):
+		case err != nil:
+			failed("artworkUrl", e.ArtworkURL, err)
+		default:
+			e.Artwork = artwork
+			if err := checkDirectoryArtwork(artwork); err != nil {
+				failed("artworkUrl", e.ArtworkURL, err)
+			}
+		}
+	}
+
+	info, err := p.Probe(e.AudioURL)
+	if errors.Is(err, ErrMediaNotLocal) {
+		return issues
+	}
+	if err != nil {
+		failed("audioUrl", e.AudioURL, err)
+		return issues
+	}
+
+	// Hand-entered durations are only precise to the second
+	duration := Duration(time.Duration(info.Duration).Round(time.Second))
+	if e.Duration != 0 && math.Abs(time.Duration(e.Duration-duration).Seconds()) > 1 {
+		mismatch("duration", e.Duration.String(), duration.String())
+	}
+	if e.Audio != nil {
+		if e.Audio.Length != 0 && e.Audio.Length != info.Length {
+			mismatch("audio.length", strconv.FormatInt(e.Audio.Length, 10), strconv.FormatInt(info.Length, 10))
+		}
+		if e.Audio.MimeType != "" && e.Audio.MimeType != info.MimeType {
+			mismatch("audio.mimeType", e.Audio.MimeType, info.MimeType)
+		}
+	}
+
+	e.Audio = info
+	e.Duration = duration
+	e.computeFields()
+	return issues
+}
+
+// ApplyMedia measures the audio and artwork of every episode with prober,
+// filling in duration, length, bitrate, MIME type and artwork placeholders,
+// and keeps prober for episodes created or updated later. It returns the
+// files that could not be read or fail directory requirements, and the
+// values from episodes.json that disagreed with them; the measured values
+// replace those in memory.
+func (s *EpisodeService) ApplyMedia(prober *MediaProber) []MediaIssue {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var issues []MediaIssue
+	for i := range s.episodes {
+		issues = append(issues, prober.probeEpisode(&s.episodes[i])...)
+	}
+	s.prober = prober
+	return issues
+}
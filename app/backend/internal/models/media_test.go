@@ -0,0 +1,242 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testMP3Frame is an MPEG-1 Layer III frame header for 128 kbit/s at
+// 44.1 kHz stereo; its frames are 417 bytes long
+var testMP3Frame = []byte{0xff, 0xfb, 0x90, 0x00}
+
+// testMP3 builds an MP3 with an ID3 tag and frames, the first of which
+// carries extra as its Xing or VBRI header
+func testMP3(frames int, extra func(frame []byte)) []byte {
+	data := append([]byte{'I', 'D', '3', 3, 0, 0}, id3TestSyncsafe(20)...)
+	data = append(data, make([]byte, 20)...)
+	for i := 0; i < frames; i++ {
+		frame := make([]byte, 417)
+		copy(frame, testMP3Frame)
+		if i == 0 && extra != nil {
+			extra(frame)
+		}
+		data = append(data, frame...)
+	}
+	return data
+}
+
+// testMP4Atom encodes an MP4 atom
+func testMP4Atom(kind string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	atom := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(atom, kind...), body...)
+}
+
+// testMP4 builds an MP4 file lasting duration units of timescale, with a
+// track of the given handler type
+func testMP4(timescale, duration uint32, handler string) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], timescale)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+	hdlr := append(make([]byte, 8), handler...)
+	hdlr = append(hdlr, make([]byte, 13)...)
+
+	var data []byte
+	data = append(data, testMP4Atom("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom"))...)
+	data = append(data, testMP4Atom("mdat", make([]byte, 4000))...)
+	data = append(data, testMP4Atom("moov", testMP4Atom("mvhd", mvhd), testMP4Atom("trak", testMP4Atom("mdia", testMP4Atom("hdlr", hdlr))))...)
+	return data
+}
+
+// testOggPage encodes an Ogg page with a single packet
+func testOggPage(granule int64, packet []byte) []byte {
+	page := []byte("OggS\x00\x00")
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, 7) // serial
+	page = append(page, make([]byte, 8)...)          // sequence and checksum
+	page = append(page, 1, byte(len(packet)))
+	return append(page, packet...)
+}
+
+func TestProbeMedia(t *testing.T) {
+	opusHead := append([]byte("OpusHead\x01\x02"), 0x38, 0x01) // pre-skip 312
+	opusHead = append(opusHead, make([]byte, 7)...)
+	vorbisHead := append([]byte("\x01vorbis\x00\x00\x00\x00\x02"), binary.LittleEndian.AppendUint32(nil, 44100)...)
+	vorbisHead = append(vorbisHead, make([]byte, 14)...)
+
+	tests := []struct {
+		name             string
+		data             []byte
+		expectedType     string
+		expectedDuration time.Duration
+		expectedBitrate  int
+	}{
+		{
+			name:             "mp3 constant bitrate",
+			data:             testMP3(100, nil),
+			expectedType:     "audio/mpeg",
+			expectedDuration: 2606 * time.Millisecond, // 41700 bytes at 128 kbit/s
+			expectedBitrate:  128,
+		},
+		{
+			name: "mp3 xing",
+			data: testMP3(10, func(frame []byte) {
+				copy(frame[36:], "Xing\x00\x00\x00\x03")
+				binary.BigEndian.PutUint32(frame[44:], 3000)   // frames
+				binary.BigEndian.PutUint32(frame[48:], 960000) // bytes
+			}),
+			expectedType:     "audio/mpeg",
+			expectedDuration: 78367 * time.Millisecond, // 3000 × 1152 / 44100
+			expectedBitrate:  98,
+		},
+		{
+			name: "mp3 vbri",
+			data: testMP3(10, func(frame []byte) {
+				copy(frame[36:], "VBRI")
+				binary.BigEndian.PutUint32(frame[46:], 960000)
+				binary.BigEndian.PutUint32(frame[50:], 3000)
+			}),
+			expectedType:     "audio/mpeg",
+			expectedDuration: 78367 * time.Millisecond,
+			expectedBitrate:  98,
+		},
+		{
+			name:             "m4a",
+			data:             testMP4(44100, 44100*90, "soun"),
+			expectedType:     "audio/mp4",
+			expectedDuration: 90 * time.Second,
+		},
+		{
+			name:             "mp4 video",
+			data:             testMP4(600, 600*30, "vide"),
+			expectedType:     "video/mp4",
+			expectedDuration: 30 * time.Second,
+		},
+		{
+			name:             "ogg opus",
+			data:             append(testOggPage(0, opusHead), testOggPage(48000*5+312, []byte("audio"))...),
+			expectedType:     "audio/ogg",
+			expectedDuration: 5 * time.Second,
+		},
+		{
+			name:             "ogg vorbis",
+			data:             append(testOggPage(0, vorbisHead), append(testOggPage(44100*12, []byte("audio")), testOggPage(-1, []byte("partial"))...)...),
+			expectedType:     "audio/ogg",
+			expectedDuration: 12 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		info, err := ProbeMedia(bytes.NewReader(tt.data), int64(len(tt.data)))
+		if err != nil {
+			t.Errorf("%s: ProbeMedia returned error: %v", tt.name, err)
+			continue
+		}
+		if info.MimeType != tt.expectedType {
+			t.Errorf("%s: expected type %s, got %s", tt.name, tt.expectedType, info.MimeType)
+		}
+		if got := time.Duration(info.Duration); got != tt.expectedDuration {
+			t.Errorf("%s: expected duration %v, got %v", tt.name, tt.expectedDuration, got)
+		}
+		if info.Length != int64(len(tt.data)) {
+			t.Errorf("%s: expected length %d, got %d", tt.name, len(tt.data), info.Length)
+		}
+		if tt.expectedBitrate != 0 && info.Bitrate != tt.expectedBitrate {
+			t.Errorf("%s: expected bitrate %d, got %d", tt.name, tt.expectedBitrate, info.Bitrate)
+		}
+	}
+}
+
+func TestProbeMediaErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ErrMediaUnsupported},
+		{"id3 without audio", append([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 4}, make([]byte, 100)...), ErrMediaInvalid},
+		{"mp4 without moov", testMP4Atom("ftyp", []byte("isom")), ErrMediaInvalid},
+		{"ogg flac", testOggPage(0, []byte("\x7fFLAC\x01\x00")), ErrMediaUnsupported},
+	}
+
+	for _, tt := range tests {
+		if _, err := ProbeMedia(bytes.NewReader(tt.data), int64(len(tt.data))); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestApplyMedia(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "audio"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mp3 := testMP3(100, nil)
+	if err := os.WriteFile(filepath.Join(root, "audio", "ep.mp3"), mp3, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &EpisodeService{episodes: []Episode{
+		{ID: "matching", AudioURL: "/audio/ep.mp3"},
+		{ID: "mismatched", AudioURL: "/audio/ep.mp3", Duration: Duration(time.Minute), Audio: &MediaInfo{MimeType: "audio/mp4", Length: 5}},
+		{ID: "missing", AudioURL: "/audio/missing.mp3", Duration: Duration(time.Minute)},
+		{ID: "escaping", AudioURL: "/../../etc/passwd"},
+		{ID: "remote", AudioURL: "https://cdn.example.com/ep.mp3", Duration: Duration(time.Minute), Audio: &MediaInfo{Length: 5}},
+	}}
+	service.prepareEpisodes()
+
+	issues := service.ApplyMedia(NewMediaProber(root))
+	fields := make(map[string]bool)
+	for _, issue := range issues {
+		fields[issue.EpisodeID+" "+issue.Field] = true
+	}
+	for _, expected := range []string{"mismatched duration", "mismatched audio.length", "mismatched audio.mimeType", "missing audioUrl", "escaping audioUrl"} {
+		if !fields[expected] {
+			t.Errorf("Expected an issue for %s, got %+v", expected, issues)
+		}
+	}
+	if len(issues) != 5 {
+		t.Errorf("Expected 5 issues, got %+v", issues)
+	}
+
+	episodes := service.GetAll()
+	for _, episode := range episodes[:2] {
+		if episode.Audio == nil || episode.Audio.Length != int64(len(mp3)) || episode.Duration.String() != "00:03" || episode.DurationSeconds != 3 {
+			t.Errorf("%s: expected probed values, got %+v", episode.ID, episode)
+		}
+	}
+	if remote := episodes[4]; remote.Duration != Duration(time.Minute) || remote.Audio.Length != 5 {
+		t.Errorf("Expected remote audio to keep its values, got %+v", remote)
+	}
+
+	created, err := service.Create(Episode{ID: "new", Number: 9, Title: "New", AudioURL: "/audio/ep.mp3"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if created.Audio == nil || created.Audio.MimeType != "audio/mpeg" {
+		t.Errorf("Expected created episodes to be probed, got %+v", created)
+	}
+}
+
+func TestEnclosureType(t *testing.T) {
+	tests := []struct {
+		episode  Episode
+		expected string
+	}{
+		{Episode{AudioURL: "/a.m4a", Audio: &MediaInfo{MimeType: "audio/ogg"}}, "audio/ogg"},
+		{Episode{AudioURL: "https://cdn.example.com/a.mp3?token=1"}, "audio/mpeg"},
+		{Episode{AudioURL: "/a.ogg"}, "audio/ogg"},
+		{Episode{AudioURL: "/a"}, "audio/mpeg"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.episode.EnclosureType(); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.episode.AudioURL, tt.expected, got)
+		}
+	}
+}
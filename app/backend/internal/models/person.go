@@ -0,0 +1,212 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrPersonNotFound is returned when no person has the requested ID
+var ErrPersonNotFound = errors.New("person not found")
+
+// PersonRole is what someone does on the show or in an episode. The values
+// follow the podcast namespace role taxonomy.
+type PersonRole string
+
+// Person roles
+const (
+	PersonHost     PersonRole = "host"
+	PersonCoHost   PersonRole = "co-host"
+	PersonGuest    PersonRole = "guest"
+	PersonProducer PersonRole = "producer"
+)
+
+// validPersonRole reports whether role is empty or a known role
+func validPersonRole(role PersonRole) bool {
+	switch role {
+	case "", PersonHost, PersonCoHost, PersonGuest, PersonProducer:
+		return true
+	}
+	return false
+}
+
+// PersonLink is a labelled link to a person's website or profile
+type PersonLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Person is a host, guest or crew member, stored in people.json
+type Person struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Bio       string       `json:"bio,omitempty"`
+	AvatarURL string       `json:"avatarUrl,omitempty"`
+	Links     []PersonLink `json:"links,omitempty"`
+	// Role is the person's usual role, used when an episode credit has none
+	Role PersonRole `json:"role,omitempty"`
+}
+
+// Validate checks a person for required fields and well-formed links
+func (p *Person) Validate() error {
+	var problems []string
+	if !episodeIDPattern.MatchString(p.ID) {
+		problems = append(problems, "id must contain only lowercase letters, digits and hyphens")
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if !validPersonRole(p.Role) {
+		problems = append(problems, "role must be host, co-host, guest or producer")
+	}
+	for _, link := range p.Links {
+		if parsed, err := url.Parse(link.URL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("link %q must be an http(s) URL", link.URL))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("person %s: %s", p.ID, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// EpisodeCredit links a person to an episode. Name and AvatarURL are filled
+// in for responses by PeopleService.Attach and are not stored.
+type EpisodeCredit struct {
+	PersonID  string     `json:"personId"`
+	Role      PersonRole `json:"role,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	AvatarURL string     `json:"avatarUrl,omitempty"`
+}
+
+// PeopleService holds the people in people.json
+type PeopleService struct {
+	people []Person
+	byID   map[string]int
+}
+
+// NewPeopleService creates a people service using the default content directory
+func NewPeopleService() *PeopleService {
+	return NewPeopleServiceFromDir(DefaultContentDir())
+}
+
+// NewPeopleServiceFromDir creates a people service that reads people.json
+// from dir, holding nobody if it cannot be loaded
+func NewPeopleServiceFromDir(dir string) *PeopleService {
+	service, err := LoadPeopleService(dir)
+	if err != nil {
+		return newPeopleService(nil)
+	}
+	return service
+}
+
+// LoadPeopleService creates a people service from dir. A missing people.json
+// is not an error; an unreadable or invalid one is.
+func LoadPeopleService(dir string) (*PeopleService, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "people.json"))
+	if os.IsNotExist(err) {
+		return newPeopleService(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read people file: %w", err)
+	}
+
+	var people []Person
+	if err := json.Unmarshal(data, &people); err != nil {
+		return nil, fmt.Errorf("failed to parse people JSON: %w", err)
+	}
+
+	seen := make(map[string]bool, len(people))
+	for i := range people {
+		if err := people[i].Validate(); err != nil {
+			return nil, err
+		}
+		if seen[people[i].ID] {
+			return nil, fmt.Errorf("person %s is listed more than once", people[i].ID)
+		}
+		seen[people[i].ID] = true
+	}
+	return newPeopleService(people), nil
+}
+
+// newPeopleService indexes people by ID
+func newPeopleService(people []Person) *PeopleService {
+	service := &PeopleService{people: people, byID: make(map[string]int, len(people))}
+	for i := range people {
+		service.byID[people[i].ID] = i
+	}
+	return service
+}
+
+// GetAll returns every person sorted by name, optionally only those whose
+// usual role is role
+func (s *PeopleService) GetAll(role PersonRole) []Person {
+	people := make([]Person, 0, len(s.people))
+	for _, person := range s.people {
+		if role == "" || person.Role == role {
+			people = append(people, person)
+		}
+	}
+	sort.Slice(people, func(i, j int) bool {
+		return strings.ToLower(people[i].Name) < strings.ToLower(people[j].Name)
+	})
+	return people
+}
+
+// GetByID returns the person with id
+func (s *PeopleService) GetByID(id string) (*Person, error) {
+	i, ok := s.byID[id]
+	if !ok {
+		return nil, ErrPersonNotFound
+	}
+	person := s.people[i]
+	return &person, nil
+}
+
+// CheckCredits reports credits that name unknown people
+func (s *PeopleService) CheckCredits(credits []EpisodeCredit) error {
+	var unknown []string
+	for _, credit := range credits {
+		if _, ok := s.byID[credit.PersonID]; !ok {
+			unknown = append(unknown, credit.PersonID)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: unknown people %s", ErrEpisodeInvalid, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Attach fills in the people of every episode; see AttachTo
+func (s *PeopleService) Attach(episodes []Episode) {
+	for i := range episodes {
+		s.AttachTo(&episodes[i])
+	}
+}
+
+// AttachTo fills in the name and avatar of every credit, and the role from
+// the person when the credit has none. Credits are copied first, so episodes
+// returned by EpisodeService can be passed directly.
+func (s *PeopleService) AttachTo(episode *Episode) {
+	if len(episode.People) == 0 {
+		return
+	}
+	credits := make([]EpisodeCredit, len(episode.People))
+	for i, credit := range episode.People {
+		if index, ok := s.byID[credit.PersonID]; ok {
+			person := &s.people[index]
+			credit.Name = person.Name
+			credit.AvatarURL = person.AvatarURL
+			if credit.Role == "" {
+				credit.Role = person.Role
+			}
+		}
+		credits[i] = credit
+	}
+	episode.People = credits
+}
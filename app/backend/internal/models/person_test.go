@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPeopleService(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+		expectCount int
+	}{
+		{"missing file", "", false, 0},
+		{"valid", `[{"id":"ada","name":"Ada","role":"host"},{"id":"grace","name":"Grace","links":[{"label":"Site","url":"https://example.com"}]}]`, false, 2},
+		{"duplicate", `[{"id":"ada","name":"Ada"},{"id":"ada","name":"Ada Again"}]`, true, 0},
+		{"missing name", `[{"id":"ada"}]`, true, 0},
+		{"unknown role", `[{"id":"ada","name":"Ada","role":"narrator"}]`, true, 0},
+		{"bad link", `[{"id":"ada","name":"Ada","links":[{"label":"x","url":"javascript:alert(1)"}]}]`, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, "people.json"), []byte(tt.content), 0o644); err != nil {
+					t.Fatalf("failed to write fixture: %v", err)
+				}
+			}
+
+			service, err := LoadPeopleService(dir)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPeopleService returned error: %v", err)
+			}
+			if got := len(service.GetAll("")); got != tt.expectCount {
+				t.Errorf("Expected %d people, got %d", tt.expectCount, got)
+			}
+		})
+	}
+}
+
+func TestPeopleService(t *testing.T) {
+	service := newPeopleService([]Person{
+		{ID: "zed", Name: "Zed", Role: PersonGuest},
+		{ID: "ada", Name: "Ada", AvatarURL: "/ada.jpg", Role: PersonHost},
+	})
+
+	all := service.GetAll("")
+	if len(all) != 2 || all[0].ID != "ada" {
+		t.Errorf("Expected people sorted by name, got %v", all)
+	}
+	if hosts := service.GetAll(PersonHost); len(hosts) != 1 || hosts[0].ID != "ada" {
+		t.Errorf("Expected only ada as host, got %v", hosts)
+	}
+
+	if _, err := service.GetByID("missing"); !errors.Is(err, ErrPersonNotFound) {
+		t.Errorf("Expected ErrPersonNotFound, got %v", err)
+	}
+
+	if err := service.CheckCredits([]EpisodeCredit{{PersonID: "ada"}, {PersonID: "nobody"}}); !errors.Is(err, ErrEpisodeInvalid) {
+		t.Errorf("Expected ErrEpisodeInvalid for an unknown person, got %v", err)
+	}
+
+	stored := []EpisodeCredit{{PersonID: "ada"}, {PersonID: "zed", Role: PersonCoHost}}
+	episodes := []Episode{{ID: "ep001", People: stored}}
+	service.Attach(episodes)
+
+	credits := episodes[0].People
+	if credits[0].Name != "Ada" || credits[0].AvatarURL != "/ada.jpg" || credits[0].Role != PersonHost {
+		t.Errorf("Expected ada's name, avatar and usual role, got %+v", credits[0])
+	}
+	if credits[1].Role != PersonCoHost {
+		t.Errorf("Expected the credit role to win, got %s", credits[1].Role)
+	}
+	if stored[0].Name != "" {
+		t.Error("Attach modified the stored credits")
+	}
+}
+
+func TestEpisodeCreditValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		credits     []EpisodeCredit
+		expectError bool
+	}{
+		{"none", nil, false},
+		{"valid", []EpisodeCredit{{PersonID: "ada", Role: PersonHost}, {PersonID: "grace"}}, false},
+		{"missing person", []EpisodeCredit{{Role: PersonGuest}}, true},
+		{"duplicate", []EpisodeCredit{{PersonID: "ada"}, {PersonID: "ada"}}, true},
+		{"unknown role", []EpisodeCredit{{PersonID: "ada", Role: "narrator"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			episode := Episode{ID: "ep001", Number: 1, Title: "Test", AudioURL: "/a.mp3", People: tt.credits}
+			err := episode.Validate()
+			if tt.expectError && !errors.Is(err, ErrEpisodeInvalid) {
+				t.Errorf("Expected ErrEpisodeInvalid, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
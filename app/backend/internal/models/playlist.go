@@ -0,0 +1,452 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Playlist errors
+var (
+	ErrPlaylistNotFound = errors.New("playlist not found")
+	ErrPlaylistInvalid  = errors.New("invalid playlist")
+)
+
+// QueueID is the ID of every listener's "up next" queue, which exists
+// without being created and cannot be renamed or deleted
+const (
+	QueueID   = "queue"
+	QueueName = "Up Next"
+)
+
+// Playlist limits
+const (
+	MaxPlaylists        = 100
+	MaxPlaylistEpisodes = 500
+	maxPlaylistName     = 100
+	maxPlaylistDesc     = 1000
+)
+
+// Playlist is a listener's ordered list of episodes. ShareID is set while
+// the playlist is shared publicly and FeedTokenHash, the SHA-256 hash of the
+// feed token, while its private feed is enabled; both are replaced when
+// re-enabled, so old links stop working.
+type Playlist struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	EpisodeIDs    []string  `json:"episodeIds"`
+	ShareID       string    `json:"shareId,omitempty"`
+	FeedTokenHash string    `json:"feedTokenHash,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// playlistRef locates one listener's playlist
+type playlistRef struct {
+	owner string
+	id    string
+}
+
+// PlaylistService keeps every listener's playlists
+type PlaylistService struct {
+	mutex sync.RWMutex
+	path  string
+	// playlists maps listener IDs to their playlists, in creation order
+	playlists map[string][]Playlist
+	// shared and feeds map share IDs and feed token hashes to playlists
+	shared map[string]playlistRef
+	feeds  map[string]playlistRef
+}
+
+// NewPlaylistService returns an in-memory playlist store whose playlists are
+// lost on restart
+func NewPlaylistService() *PlaylistService {
+	return &PlaylistService{
+		playlists: make(map[string][]Playlist),
+		shared:    make(map[string]playlistRef),
+		feeds:     make(map[string]playlistRef),
+	}
+}
+
+// OpenPlaylistService loads the playlists in path, a JSON file that is
+// rewritten on every change and created if it does not exist
+func OpenPlaylistService(path string) (*PlaylistService, error) {
+	service := NewPlaylistService()
+	service.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Share IDs grant access, so the file is created private
+		if err := os.WriteFile(path, []byte("{}\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create playlists file: %w", err)
+		}
+		return service, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlists file: %w", err)
+	}
+	// Files written before feed tokens were hashed hold them in feedToken
+	var stored map[string][]struct {
+		Playlist
+		FeedToken string `json:"feedToken,omitempty"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse playlists file %s: %w", path, err)
+	}
+	playlists := make(map[string][]Playlist, len(stored))
+	migrated := false
+	for owner, list := range stored {
+		for _, p := range list {
+			if p.FeedToken != "" {
+				p.FeedTokenHash = hashToken(p.FeedToken)
+				migrated = true
+			}
+			playlists[owner] = append(playlists[owner], p.Playlist)
+		}
+	}
+	if migrated {
+		if err := writeJSONFile(path, playlists); err != nil {
+			return nil, fmt.Errorf("failed to save playlists: %w", err)
+		}
+	}
+	service.store(playlists)
+	return service, nil
+}
+
+// List returns the listener's playlists, the queue first
+func (s *PlaylistService) List(owner string) []Playlist {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	playlists := []Playlist{}
+	if s.indexOf(owner, QueueID) < 0 {
+		playlists = append(playlists, emptyQueue())
+	}
+	for _, playlist := range s.playlists[owner] {
+		playlists = append(playlists, playlist.clone())
+	}
+	slices.SortStableFunc(playlists, func(a, b Playlist) int {
+		if a.ID == QueueID {
+			return -1
+		}
+		if b.ID == QueueID {
+			return 1
+		}
+		return 0
+	})
+	return playlists
+}
+
+// Get returns one of the listener's playlists
+func (s *PlaylistService) Get(owner, id string) (*Playlist, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if i := s.indexOf(owner, id); i >= 0 {
+		playlist := s.playlists[owner][i].clone()
+		return &playlist, nil
+	}
+	if id == QueueID {
+		queue := emptyQueue()
+		return &queue, nil
+	}
+	return nil, ErrPlaylistNotFound
+}
+
+// Create adds a playlist with the given name and description
+func (s *PlaylistService) Create(owner, name, description string, now time.Time) (*Playlist, error) {
+	name, description, err := cleanPlaylistText(name, description)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing := s.playlists[owner]
+	if len(existing) >= MaxPlaylists {
+		return nil, fmt.Errorf("%w: at most %d playlists are allowed", ErrPlaylistInvalid, MaxPlaylists)
+	}
+	playlist := Playlist{
+		ID:          id[:12],
+		Name:        name,
+		Description: description,
+		EpisodeIDs:  []string{},
+		CreatedAt:   now.UTC(),
+		UpdatedAt:   now.UTC(),
+	}
+	if err := s.commit(owner, append(slices.Clone(existing), playlist)); err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// Update renames a playlist and replaces its description
+func (s *PlaylistService) Update(owner, id, name, description string, now time.Time) (*Playlist, error) {
+	if id == QueueID {
+		// The queue keeps its name; only the description can change
+		name = QueueName
+	}
+	name, description, err := cleanPlaylistText(name, description)
+	if err != nil {
+		return nil, err
+	}
+	return s.modify(owner, id, now, func(p *Playlist) error {
+		p.Name = name
+		p.Description = description
+		return nil
+	})
+}
+
+// Delete removes a playlist. The queue cannot be deleted.
+func (s *PlaylistService) Delete(owner, id string) error {
+	if id == QueueID {
+		return fmt.Errorf("%w: the queue cannot be deleted", ErrPlaylistInvalid)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := s.indexOf(owner, id)
+	if i < 0 {
+		return ErrPlaylistNotFound
+	}
+	return s.commit(owner, slices.Delete(slices.Clone(s.playlists[owner]), i, i+1))
+}
+
+// AddEpisode inserts an episode at position, or at the end when position is
+// negative or past the end. An episode already in the playlist is moved.
+func (s *PlaylistService) AddEpisode(owner, id, episodeID string, position int, now time.Time) (*Playlist, error) {
+	return s.modify(owner, id, now, func(p *Playlist) error {
+		ids := slices.DeleteFunc(p.EpisodeIDs, func(existing string) bool { return existing == episodeID })
+		if len(ids) >= MaxPlaylistEpisodes {
+			return fmt.Errorf("%w: at most %d episodes are allowed", ErrPlaylistInvalid, MaxPlaylistEpisodes)
+		}
+		if position < 0 || position > len(ids) {
+			position = len(ids)
+		}
+		p.EpisodeIDs = slices.Insert(ids, position, episodeID)
+		return nil
+	})
+}
+
+// RemoveEpisode removes an episode from the playlist
+func (s *PlaylistService) RemoveEpisode(owner, id, episodeID string, now time.Time) (*Playlist, error) {
+	return s.modify(owner, id, now, func(p *Playlist) error {
+		if !slices.Contains(p.EpisodeIDs, episodeID) {
+			return ErrEpisodeNotFound
+		}
+		p.EpisodeIDs = slices.DeleteFunc(p.EpisodeIDs, func(existing string) bool { return existing == episodeID })
+		return nil
+	})
+}
+
+// SetEpisodes replaces the playlist's episodes, to reorder or clear it
+func (s *PlaylistService) SetEpisodes(owner, id string, episodeIDs []string, now time.Time) (*Playlist, error) {
+	if len(episodeIDs) > MaxPlaylistEpisodes {
+		return nil, fmt.Errorf("%w: at most %d episodes are allowed", ErrPlaylistInvalid, MaxPlaylistEpisodes)
+	}
+	seen := make(map[string]bool, len(episodeIDs))
+	for _, episodeID := range episodeIDs {
+		if seen[episodeID] {
+			return nil, fmt.Errorf("%w: episode %s is listed twice", ErrPlaylistInvalid, episodeID)
+		}
+		seen[episodeID] = true
+	}
+	return s.modify(owner, id, now, func(p *Playlist) error {
+		p.EpisodeIDs = append([]string{}, episodeIDs...)
+		return nil
+	})
+}
+
+// Share makes the playlist public under a new share ID, or stops sharing it
+func (s *PlaylistService) Share(owner, id string, shared bool, now time.Time) (*Playlist, error) {
+	shareID := ""
+	if shared {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		shareID = token[:16]
+	}
+	return s.modify(owner, id, now, func(p *Playlist) error {
+		p.ShareID = shareID
+		return nil
+	})
+}
+
+// SetFeed enables the playlist's private feed under a new token, or
+// disables it. Only the token's hash is kept, so the token is returned
+// here and nowhere else.
+func (s *PlaylistService) SetFeed(owner, id string, enabled bool, now time.Time) (*Playlist, string, error) {
+	feedToken, feedTokenHash := "", ""
+	if enabled {
+		token, err := newToken()
+		if err != nil {
+			return nil, "", err
+		}
+		feedToken, feedTokenHash = token, hashToken(token)
+	}
+	playlist, err := s.modify(owner, id, now, func(p *Playlist) error {
+		p.FeedTokenHash = feedTokenHash
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return playlist, feedToken, nil
+}
+
+// GetShared returns the playlist shared under shareID
+func (s *PlaylistService) GetShared(shareID string) (*Playlist, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ref, ok := s.shared[shareID]
+	if !ok {
+		return nil, ErrPlaylistNotFound
+	}
+	return s.lookup(ref)
+}
+
+// GetByFeedToken returns the playlist whose private feed uses token
+func (s *PlaylistService) GetByFeedToken(token string) (*Playlist, error) {
+	if token == "" {
+		return nil, ErrPlaylistNotFound
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ref, ok := s.feeds[hashToken(token)]
+	if !ok {
+		return nil, ErrPlaylistNotFound
+	}
+	return s.lookup(ref)
+}
+
+// lookup returns a copy of the playlist ref points at. The caller must hold
+// the lock.
+func (s *PlaylistService) lookup(ref playlistRef) (*Playlist, error) {
+	i := s.indexOf(ref.owner, ref.id)
+	if i < 0 {
+		return nil, ErrPlaylistNotFound
+	}
+	playlist := s.playlists[ref.owner][i].clone()
+	return &playlist, nil
+}
+
+// modify applies change to a copy of a playlist and stores it. The queue is
+// created on its first change.
+func (s *PlaylistService) modify(owner, id string, now time.Time, change func(p *Playlist) error) (*Playlist, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	playlists := slices.Clone(s.playlists[owner])
+	i := s.indexOf(owner, id)
+	if i < 0 {
+		if id != QueueID {
+			return nil, ErrPlaylistNotFound
+		}
+		queue := emptyQueue()
+		queue.CreatedAt = now.UTC()
+		playlists = append(playlists, queue)
+		i = len(playlists) - 1
+	}
+
+	playlist := playlists[i].clone()
+	if err := change(&playlist); err != nil {
+		return nil, err
+	}
+	playlist.UpdatedAt = now.UTC()
+	playlists[i] = playlist
+
+	if err := s.commit(owner, playlists); err != nil {
+		return nil, err
+	}
+	result := playlist.clone()
+	return &result, nil
+}
+
+// indexOf returns the index of the listener's playlist, or -1. The caller
+// must hold the lock.
+func (s *PlaylistService) indexOf(owner, id string) int {
+	return slices.IndexFunc(s.playlists[owner], func(p Playlist) bool { return p.ID == id })
+}
+
+// commit writes the playlists with the listener's replaced to disk, when
+// the service is file-backed, and then makes them current. The caller must
+// hold the write lock.
+func (s *PlaylistService) commit(owner string, playlists []Playlist) error {
+	next := make(map[string][]Playlist, len(s.playlists)+1)
+	for id, existing := range s.playlists {
+		next[id] = existing
+	}
+	if len(playlists) == 0 {
+		delete(next, owner)
+	} else {
+		next[owner] = playlists
+	}
+
+	if s.path != "" {
+		if err := writeJSONFile(s.path, next); err != nil {
+			return fmt.Errorf("failed to save playlists: %w", err)
+		}
+	}
+	s.store(next)
+	return nil
+}
+
+// store makes playlists current and indexes their share IDs and feed
+// tokens. The caller must hold the write lock.
+func (s *PlaylistService) store(playlists map[string][]Playlist) {
+	s.playlists = playlists
+	clear(s.shared)
+	clear(s.feeds)
+	for owner, list := range playlists {
+		for _, p := range list {
+			if p.ShareID != "" {
+				s.shared[p.ShareID] = playlistRef{owner: owner, id: p.ID}
+			}
+			if p.FeedTokenHash != "" {
+				s.feeds[p.FeedTokenHash] = playlistRef{owner: owner, id: p.ID}
+			}
+		}
+	}
+}
+
+// clone returns a copy that shares no slices with p
+func (p Playlist) clone() Playlist {
+	p.EpisodeIDs = append([]string{}, p.EpisodeIDs...)
+	return p
+}
+
+// emptyQueue returns the queue of a listener who has not used it yet
+func emptyQueue() Playlist {
+	return Playlist{ID: QueueID, Name: QueueName, EpisodeIDs: []string{}}
+}
+
+// cleanPlaylistText trims and checks a playlist name and description
+func cleanPlaylistText(name, description string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	switch {
+	case name == "":
+		return "", "", fmt.Errorf("%w: name is required", ErrPlaylistInvalid)
+	case len([]rune(name)) > maxPlaylistName:
+		return "", "", fmt.Errorf("%w: name must be at most %d characters", ErrPlaylistInvalid, maxPlaylistName)
+	case len([]rune(description)) > maxPlaylistDesc:
+		return "", "", fmt.Errorf("%w: description must be at most %d characters", ErrPlaylistInvalid, maxPlaylistDesc)
+	}
+	return name, description, nil
+}
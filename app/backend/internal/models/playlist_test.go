@@ -0,0 +1,213 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaylists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playlists.json")
+	service, err := OpenPlaylistService(path)
+	if err != nil {
+		t.Fatalf("OpenPlaylistService returned error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected a private playlists file, got %v %v", info, err)
+	}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lists := service.List("alice")
+	if len(lists) != 1 || lists[0].ID != QueueID || lists[0].Name != QueueName {
+		t.Fatalf("Expected only the empty queue, got %+v", lists)
+	}
+
+	if _, err := service.Create("alice", "  ", "", now); !errors.Is(err, ErrPlaylistInvalid) {
+		t.Errorf("Expected ErrPlaylistInvalid for a blank name, got %v", err)
+	}
+	playlist, err := service.Create("alice", " Road trip ", "Long ones", now)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if playlist.Name != "Road trip" || len(playlist.EpisodeIDs) != 0 {
+		t.Errorf("Unexpected playlist %+v", playlist)
+	}
+	if _, err := service.Get("bob", playlist.ID); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected other listeners not to see the playlist, got %v", err)
+	}
+
+	for _, id := range []string{"ep001", "ep002", "ep003"} {
+		if _, err := service.AddEpisode("alice", playlist.ID, id, -1, now); err != nil {
+			t.Fatalf("AddEpisode returned error: %v", err)
+		}
+	}
+	// Adding an episode again moves it
+	playlist, err = service.AddEpisode("alice", playlist.ID, "ep003", 0, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("AddEpisode returned error: %v", err)
+	}
+	if want := []string{"ep003", "ep001", "ep002"}; !slices.Equal(playlist.EpisodeIDs, want) {
+		t.Errorf("Expected %v, got %v", want, playlist.EpisodeIDs)
+	}
+	if !playlist.UpdatedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected UpdatedAt to change, got %v", playlist.UpdatedAt)
+	}
+
+	if _, err := service.SetEpisodes("alice", playlist.ID, []string{"ep001", "ep001"}, now); !errors.Is(err, ErrPlaylistInvalid) {
+		t.Errorf("Expected duplicates to be rejected, got %v", err)
+	}
+	playlist, err = service.SetEpisodes("alice", playlist.ID, []string{"ep002", "ep001"}, now)
+	if err != nil {
+		t.Fatalf("SetEpisodes returned error: %v", err)
+	}
+	if _, err := service.RemoveEpisode("alice", playlist.ID, "ep003", now); !errors.Is(err, ErrEpisodeNotFound) {
+		t.Errorf("Expected ErrEpisodeNotFound for an episode not in the playlist, got %v", err)
+	}
+	if playlist, err = service.RemoveEpisode("alice", playlist.ID, "ep002", now); err != nil || !slices.Equal(playlist.EpisodeIDs, []string{"ep001"}) {
+		t.Errorf("Unexpected result of RemoveEpisode: %+v %v", playlist, err)
+	}
+
+	// The queue exists without being created and cannot be deleted or renamed
+	queue, err := service.AddEpisode("alice", QueueID, "ep002", -1, now)
+	if err != nil {
+		t.Fatalf("AddEpisode to the queue returned error: %v", err)
+	}
+	if !queue.CreatedAt.Equal(now) {
+		t.Errorf("Expected the queue to be created on first use, got %v", queue.CreatedAt)
+	}
+	if queue, err = service.Update("alice", QueueID, "Later", "Next up", now); err != nil || queue.Name != QueueName || queue.Description != "Next up" {
+		t.Errorf("Unexpected result of renaming the queue: %+v %v", queue, err)
+	}
+	if err := service.Delete("alice", QueueID); !errors.Is(err, ErrPlaylistInvalid) {
+		t.Errorf("Expected the queue not to be deletable, got %v", err)
+	}
+	if lists = service.List("alice"); len(lists) != 2 || lists[0].ID != QueueID || lists[1].ID != playlist.ID {
+		t.Errorf("Expected the queue first, got %+v", lists)
+	}
+
+	reopened, err := OpenPlaylistService(path)
+	if err != nil {
+		t.Fatalf("OpenPlaylistService returned error: %v", err)
+	}
+	got, err := reopened.Get("alice", playlist.ID)
+	if err != nil || !slices.Equal(got.EpisodeIDs, []string{"ep001"}) {
+		t.Errorf("Expected playlists to survive a restart, got %+v %v", got, err)
+	}
+
+	if err := reopened.Delete("alice", playlist.ID); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := reopened.Get("alice", playlist.ID); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected ErrPlaylistNotFound after Delete, got %v", err)
+	}
+}
+
+func TestPlaylistLinks(t *testing.T) {
+	service := NewPlaylistService()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	playlist, err := service.Create("alice", "Favourites", "", now)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	shared, err := service.Share("alice", playlist.ID, true, now)
+	if err != nil || shared.ShareID == "" {
+		t.Fatalf("Unexpected result of Share: %+v %v", shared, err)
+	}
+	if got, err := service.GetShared(shared.ShareID); err != nil || got.ID != playlist.ID {
+		t.Errorf("Expected GetShared to find the playlist, got %+v %v", got, err)
+	}
+	reshared, err := service.Share("alice", playlist.ID, true, now)
+	if err != nil || reshared.ShareID == shared.ShareID {
+		t.Errorf("Expected sharing again to replace the link, got %+v %v", reshared, err)
+	}
+	if _, err := service.GetShared(shared.ShareID); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected the old share link to stop working, got %v", err)
+	}
+
+	withFeed, feedToken, err := service.SetFeed("alice", playlist.ID, true, now)
+	if err != nil || len(feedToken) != 64 || withFeed.FeedTokenHash != hashToken(feedToken) {
+		t.Fatalf("Unexpected result of SetFeed: %+v %q %v", withFeed, feedToken, err)
+	}
+	if got, err := service.GetByFeedToken(feedToken); err != nil || got.ID != playlist.ID {
+		t.Errorf("Expected GetByFeedToken to find the playlist, got %+v %v", got, err)
+	}
+	if _, err := service.GetByFeedToken(withFeed.FeedTokenHash); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected the stored hash not to open the feed, got %v", err)
+	}
+	if _, _, err := service.SetFeed("alice", playlist.ID, false, now); err != nil {
+		t.Fatalf("SetFeed returned error: %v", err)
+	}
+	if _, err := service.GetByFeedToken(feedToken); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected a disabled feed to be unreachable, got %v", err)
+	}
+	if _, err := service.GetByFeedToken(""); !errors.Is(err, ErrPlaylistNotFound) {
+		t.Errorf("Expected an empty token to match nothing, got %v", err)
+	}
+}
+
+func TestOpenPlaylistServiceHashesFeedTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playlists.json")
+	legacy := `{"alice": [{"id": "p1", "name": "Mine", "episodeIds": [], "feedToken": "secret"}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := OpenPlaylistService(path)
+	if err != nil {
+		t.Fatalf("OpenPlaylistService returned error: %v", err)
+	}
+	if got, err := service.GetByFeedToken("secret"); err != nil || got.ID != "p1" {
+		t.Errorf("Expected the old feed link to keep working, got %+v %v", got, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"secret"`) {
+		t.Error("Expected the feed token to be rewritten as a hash")
+	}
+}
+
+func TestFeedEncode(t *testing.T) {
+	episode := &Episode{
+		ID:              "ep001",
+		Title:           "Pilot & more",
+		Description:     "The first one",
+		AudioURL:        "/1.mp3",
+		PublishDate:     "2024-01-01",
+		DurationSeconds: 1200,
+		People: []EpisodeCredit{
+			{Name: "Jane", Role: PersonHost},
+			{Name: "Sam", Role: PersonProducer},
+		},
+	}
+	item := NewFeedItem(episode, "https://example.com/media/audio/ep001", time.UTC)
+	feed := NewFeed(FeedChannel{Title: "Favourites", Link: "https://example.com", Block: "Yes", Items: []FeedItem{item}})
+
+	var buf bytes.Buffer
+	if err := feed.Encode(&buf); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:podcast="https://podcastindex.org/namespace/1.0">`,
+		`<itunes:block>Yes</itunes:block>`,
+		`<title>Pilot &amp; more</title>`,
+		`<guid isPermaLink="false">ep001</guid>`,
+		`<enclosure url="https://example.com/media/audio/ep001"`,
+		`<itunes:duration>1200</itunes:duration>`,
+		`<podcast:person role="host">Jane</podcast:person>`,
+		`<podcast:person role="producer" group="creative direction">Sam</podcast:person>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected feed to contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Reaction errors
+var (
+	ErrInvalidReaction = errors.New("invalid reaction")
+	ErrInvalidRating   = errors.New("rating must be between 1 and 5")
+)
+
+// ReactionKind is one of the fixed reactions listeners can leave
+type ReactionKind string
+
+// Reaction kinds
+const (
+	ReactionLike       ReactionKind = "like"
+	ReactionLove       ReactionKind = "love"
+	ReactionLaugh      ReactionKind = "laugh"
+	ReactionInsightful ReactionKind = "insightful"
+	ReactionSurprised  ReactionKind = "surprised"
+)
+
+// ReactionKinds lists every reaction kind, in display order
+var ReactionKinds = []ReactionKind{ReactionLike, ReactionLove, ReactionLaugh, ReactionInsightful, ReactionSurprised}
+
+// ParseReactionKind validates a reaction kind
+func ParseReactionKind(value string) (ReactionKind, error) {
+	kind := ReactionKind(value)
+	if !slices.Contains(ReactionKinds, kind) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidReaction, value)
+	}
+	return kind, nil
+}
+
+// MaxRating is the highest star rating
+const MaxRating = 5
+
+// ListenerReactions are one listener's reactions to and rating of an
+// episode; Rating is 0 when the listener has not rated it
+type ListenerReactions struct {
+	Reactions []ReactionKind `json:"reactions"`
+	Rating    int            `json:"rating,omitempty"`
+}
+
+// ReactionSummary counts an episode's reactions and ratings
+type ReactionSummary struct {
+	Reactions map[ReactionKind]int `json:"reactions"`
+	Rating    RatingSummary        `json:"rating"`
+}
+
+// RatingSummary is the average of an episode's ratings, rounded to one
+// decimal place
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// ReactionService keeps listener reactions and ratings per episode
+type ReactionService struct {
+	mutex sync.RWMutex
+	path  string
+	// reactions maps episode IDs to listener IDs to what they left
+	reactions map[string]map[string]ListenerReactions
+}
+
+// NewReactionService returns an in-memory reaction store whose reactions
+// are lost on restart
+func NewReactionService() *ReactionService {
+	return &ReactionService{reactions: make(map[string]map[string]ListenerReactions)}
+}
+
+// OpenReactionService loads the reactions in path, a JSON file that is
+// rewritten on every change and created if it does not exist
+func OpenReactionService(path string) (*ReactionService, error) {
+	service := NewReactionService()
+	service.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if err := writeJSONFile(path, service.reactions); err != nil {
+			return nil, fmt.Errorf("failed to create reactions file: %w", err)
+		}
+		return service, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reactions file: %w", err)
+	}
+	if err := json.Unmarshal(data, &service.reactions); err != nil {
+		return nil, fmt.Errorf("failed to parse reactions file %s: %w", path, err)
+	}
+	if service.reactions == nil {
+		service.reactions = make(map[string]map[string]ListenerReactions)
+	}
+	return service, nil
+}
+
+// Summary counts the reactions and ratings left on an episode
+func (s *ReactionService) Summary(episodeID string) ReactionSummary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	summary := ReactionSummary{Reactions: make(map[ReactionKind]int, len(ReactionKinds))}
+	for _, kind := range ReactionKinds {
+		summary.Reactions[kind] = 0
+	}
+	total := 0
+	for _, left := range s.reactions[episodeID] {
+		for _, kind := range left.Reactions {
+			summary.Reactions[kind]++
+		}
+		if left.Rating > 0 {
+			total += left.Rating
+			summary.Rating.Count++
+		}
+	}
+	if summary.Rating.Count > 0 {
+		summary.Rating.Average = math.Round(float64(total)/float64(summary.Rating.Count)*10) / 10
+	}
+	return summary
+}
+
+// Get returns what a listener left on an episode
+func (s *ReactionService) Get(episodeID, listenerID string) ListenerReactions {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	left := s.reactions[episodeID][listenerID]
+	left.Reactions = append([]ReactionKind{}, left.Reactions...)
+	return left
+}
+
+// React adds or removes one of a listener's reactions to an episode
+func (s *ReactionService) React(episodeID, listenerID string, kind ReactionKind, on bool) (ListenerReactions, error) {
+	if _, err := ParseReactionKind(string(kind)); err != nil {
+		return ListenerReactions{}, err
+	}
+	return s.modify(episodeID, listenerID, func(left *ListenerReactions) {
+		left.Reactions = slices.DeleteFunc(left.Reactions, func(existing ReactionKind) bool { return existing == kind })
+		if on {
+			left.Reactions = append(left.Reactions, kind)
+			slices.SortFunc(left.Reactions, func(a, b ReactionKind) int {
+				return slices.Index(ReactionKinds, a) - slices.Index(ReactionKinds, b)
+			})
+		}
+	})
+}
+
+// Rate sets a listener's rating of an episode, from 1 to MaxRating, or
+// clears it when rating is 0
+func (s *ReactionService) Rate(episodeID, listenerID string, rating int) (ListenerReactions, error) {
+	if rating < 0 || rating > MaxRating {
+		return ListenerReactions{}, ErrInvalidRating
+	}
+	return s.modify(episodeID, listenerID, func(left *ListenerReactions) {
+		left.Rating = rating
+	})
+}
+
+// DeleteEpisode forgets every reaction to and rating of an episode
+func (s *ReactionService) DeleteEpisode(episodeID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.reactions[episodeID]; !ok {
+		return nil
+	}
+	next := make(map[string]map[string]ListenerReactions, len(s.reactions))
+	for id, listeners := range s.reactions {
+		if id != episodeID {
+			next[id] = listeners
+		}
+	}
+	return s.commit(next)
+}
+
+// modify applies change to a copy of what a listener left on an episode
+// and stores it
+func (s *ReactionService) modify(episodeID, listenerID string, change func(left *ListenerReactions)) (ListenerReactions, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	left := s.reactions[episodeID][listenerID]
+	left.Reactions = append([]ReactionKind{}, left.Reactions...)
+	change(&left)
+
+	next := make(map[string]map[string]ListenerReactions, len(s.reactions)+1)
+	for id, listeners := range s.reactions {
+		next[id] = listeners
+	}
+	listeners := make(map[string]ListenerReactions, len(s.reactions[episodeID])+1)
+	for id, existing := range s.reactions[episodeID] {
+		listeners[id] = existing
+	}
+	if len(left.Reactions) == 0 && left.Rating == 0 {
+		delete(listeners, listenerID)
+	} else {
+		listeners[listenerID] = left
+	}
+	if len(listeners) == 0 {
+		delete(next, episodeID)
+	} else {
+		next[episodeID] = listeners
+	}
+
+	if err := s.commit(next); err != nil {
+		return ListenerReactions{}, err
+	}
+	result := left
+	result.Reactions = append([]ReactionKind{}, left.Reactions...)
+	return result, nil
+}
+
+// commit writes reactions to disk, when the service is file-backed, and
+// then makes them current. The caller must hold the write lock.
+func (s *ReactionService) commit(reactions map[string]map[string]ListenerReactions) error {
+	if s.path != "" {
+		if err := writeJSONFile(s.path, reactions); err != nil {
+			return fmt.Errorf("failed to save reactions: %w", err)
+		}
+	}
+	s.reactions = reactions
+	return nil
+}
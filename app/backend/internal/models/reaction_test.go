@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reactions.json")
+	service, err := OpenReactionService(path)
+	if err != nil {
+		t.Fatalf("OpenReactionService returned error: %v", err)
+	}
+
+	if _, err := service.React("ep001", "l1", "angry", true); !errors.Is(err, ErrInvalidReaction) {
+		t.Errorf("Expected ErrInvalidReaction, got %v", err)
+	}
+	for _, kind := range []ReactionKind{ReactionLaugh, ReactionLike, ReactionLike} {
+		if _, err := service.React("ep001", "l1", kind, true); err != nil {
+			t.Fatalf("React returned error: %v", err)
+		}
+	}
+	if _, err := service.React("ep001", "l2", ReactionLike, true); err != nil {
+		t.Fatalf("React returned error: %v", err)
+	}
+
+	for _, rating := range []int{-1, 6} {
+		if _, err := service.Rate("ep001", "l1", rating); !errors.Is(err, ErrInvalidRating) {
+			t.Errorf("Expected ErrInvalidRating for %d, got %v", rating, err)
+		}
+	}
+	if _, err := service.Rate("ep001", "l1", 5); err != nil {
+		t.Fatalf("Rate returned error: %v", err)
+	}
+	if _, err := service.Rate("ep001", "l2", 4); err != nil {
+		t.Fatalf("Rate returned error: %v", err)
+	}
+	if _, err := service.Rate("ep001", "l3", 1); err != nil {
+		t.Fatalf("Rate returned error: %v", err)
+	}
+
+	left := service.Get("ep001", "l1")
+	if want := []ReactionKind{ReactionLike, ReactionLaugh}; !slices.Equal(left.Reactions, want) || left.Rating != 5 {
+		t.Errorf("Expected %v rated 5, got %+v", want, left)
+	}
+	summary := service.Summary("ep001")
+	if summary.Reactions[ReactionLike] != 2 || summary.Reactions[ReactionLaugh] != 1 || summary.Reactions[ReactionLove] != 0 {
+		t.Errorf("Unexpected reaction counts %v", summary.Reactions)
+	}
+	if summary.Rating.Count != 3 || summary.Rating.Average != 3.3 {
+		t.Errorf("Expected an average of 3.3 from 3 ratings, got %+v", summary.Rating)
+	}
+
+	// Clearing everything a listener left forgets them
+	if _, err := service.Rate("ep001", "l3", 0); err != nil {
+		t.Fatalf("Rate returned error: %v", err)
+	}
+	if _, err := service.React("ep001", "l2", ReactionLike, false); err != nil {
+		t.Fatalf("React returned error: %v", err)
+	}
+
+	reopened, err := OpenReactionService(path)
+	if err != nil {
+		t.Fatalf("OpenReactionService returned error: %v", err)
+	}
+	summary = reopened.Summary("ep001")
+	if summary.Reactions[ReactionLike] != 1 || summary.Rating.Count != 2 || summary.Rating.Average != 4.5 {
+		t.Errorf("Unexpected summary after a restart %+v", summary)
+	}
+	if empty := reopened.Summary("ep002"); empty.Rating.Count != 0 || len(empty.Reactions) != len(ReactionKinds) {
+		t.Errorf("Expected zero counts for every kind, got %+v", empty)
+	}
+}
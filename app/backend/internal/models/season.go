@@ -0,0 +1,92 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Season summarises the listed episodes of one season
+type Season struct {
+	Number       int `json:"number"`
+	EpisodeCount int `json:"episodeCount"`
+	TrailerCount int `json:"trailerCount"`
+	BonusCount   int `json:"bonusCount"`
+	// FirstPublishDate and LatestPublishDate span the season's episodes
+	FirstPublishDate  Date `json:"firstPublishDate,omitempty"`
+	LatestPublishDate Date `json:"latestPublishDate,omitempty"`
+	// DurationSeconds is the total listening time of the season
+	DurationSeconds int `json:"durationSeconds"`
+}
+
+// GetSeasons returns the seasons with at least one episode listed at now,
+// in ascending order. Episodes without a season are not counted.
+func (s *EpisodeService) GetSeasons(now time.Time) []Season {
+	bySeason := make(map[int]*Season)
+	for _, episode := range s.GetPublished(now) {
+		if episode.Season == 0 {
+			continue
+		}
+		season, ok := bySeason[episode.Season]
+		if !ok {
+			season = &Season{Number: episode.Season}
+			bySeason[episode.Season] = season
+		}
+
+		switch episode.EpisodeType {
+		case EpisodeTypeTrailer:
+			season.TrailerCount++
+		case EpisodeTypeBonus:
+			season.BonusCount++
+		default:
+			season.EpisodeCount++
+		}
+		season.DurationSeconds += episode.DurationSeconds
+		if date := episode.PublishDate; date != "" {
+			// Dates are YYYY-MM-DD, so they compare as strings
+			if season.FirstPublishDate == "" || date < season.FirstPublishDate {
+				season.FirstPublishDate = date
+			}
+			if date > season.LatestPublishDate {
+				season.LatestPublishDate = date
+			}
+		}
+	}
+
+	seasons := make([]Season, 0, len(bySeason))
+	for _, season := range bySeason {
+		seasons = append(seasons, *season)
+	}
+	sort.Slice(seasons, func(i, j int) bool {
+		return seasons[i].Number < seasons[j].Number
+	})
+	return seasons
+}
+
+// GetSeasonEpisodes returns the episodes of season listed at now in running
+// order: trailers first, then by publish time, position within the season
+// and number, so bonus episodes fall between the episodes they followed
+func (s *EpisodeService) GetSeasonEpisodes(season int, now time.Time) []Episode {
+	var episodes []Episode
+	for _, episode := range s.GetPublished(now) {
+		if episode.Season == season {
+			episodes = append(episodes, episode)
+		}
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := episodes[i], episodes[j]
+		if (a.EpisodeType == EpisodeTypeTrailer) != (b.EpisodeType == EpisodeTypeTrailer) {
+			return a.EpisodeType == EpisodeTypeTrailer
+		}
+		aTime, _ := a.PublishTime(now.Location())
+		bTime, _ := b.PublishTime(now.Location())
+		if !aTime.Equal(bTime) {
+			return aTime.Before(bTime)
+		}
+		if a.SeasonEpisode != b.SeasonEpisode {
+			return a.SeasonEpisode < b.SeasonEpisode
+		}
+		return a.Number < b.Number
+	})
+	return episodes
+}
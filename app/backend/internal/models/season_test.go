@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newSeasonTestService returns a service with two seasons, a trailer, a bonus
+// episode and an episode numbered past 999
+func newSeasonTestService(t *testing.T) *EpisodeService {
+	t.Helper()
+
+	dir := t.TempDir()
+	episodes := []Episode{
+		{ID: "pilot", Number: 1, Season: 1, SeasonEpisode: 1, Title: "Pilot", AudioURL: "/a.mp3", PublishDate: "2023-01-01"},
+		{ID: "season-two-trailer", Season: 2, EpisodeType: EpisodeTypeTrailer, Title: "Trailer", AudioURL: "/a.mp3", PublishDate: "2023-12-01"},
+		{ID: "return", Number: 2, Season: 2, SeasonEpisode: 1, Title: "Return", AudioURL: "/a.mp3", PublishDate: "2024-01-01"},
+		{ID: "outtakes", Season: 2, EpisodeType: EpisodeTypeBonus, Title: "Outtakes", AudioURL: "/a.mp3", PublishDate: "2024-01-15"},
+		{ID: "finale", Number: 1000, Season: 2, SeasonEpisode: 5, Title: "Finale", AudioURL: "/a.mp3", PublishDate: "2024-02-01"},
+		{ID: "unaired", Number: 1001, Season: 3, SeasonEpisode: 1, Title: "Unaired", AudioURL: "/a.mp3", Status: StatusDraft},
+	}
+	if err := writeJSONFile(filepath.Join(dir, "episodes.json"), episodes); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	return service
+}
+
+func TestResolve(t *testing.T) {
+	service := newSeasonTestService(t)
+
+	tests := []struct {
+		ref        string
+		expectedID string
+	}{
+		{"1000", "finale"},
+		{"1", "pilot"},
+		{"001", "pilot"},
+		{"s2e5", "finale"},
+		{"S2E5", "finale"},
+		{"s02e01", "return"},
+		{"outtakes", "outtakes"},
+		{"0", ""},
+		{"s2e0", ""},
+		{"s9e1", ""},
+		{"999", ""},
+		{"missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			episode, _, err := service.Resolve(tt.ref)
+			if tt.expectedID == "" {
+				if !errors.Is(err, ErrEpisodeNotFound) {
+					t.Errorf("Expected ErrEpisodeNotFound, got %v, %v", episode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve returned error: %v", err)
+			}
+			if episode.ID != tt.expectedID {
+				t.Errorf("Expected %s, got %s", tt.expectedID, episode.ID)
+			}
+		})
+	}
+}
+
+func TestSeasonValidationAndConflicts(t *testing.T) {
+	service := newSeasonTestService(t)
+
+	invalid := []Episode{
+		{ID: "123", Number: 5, Title: "Numeric ID", AudioURL: "/a.mp3"},
+		{ID: "s1e2", Number: 5, Title: "Reference ID", AudioURL: "/a.mp3"},
+		{ID: "no-number", Title: "Full episodes need a number", AudioURL: "/a.mp3"},
+		{ID: "odd-type", Number: 5, EpisodeType: "teaser", Title: "Odd", AudioURL: "/a.mp3"},
+		{ID: "no-season", Number: 5, SeasonEpisode: 2, Title: "No season", AudioURL: "/a.mp3"},
+	}
+	for _, episode := range invalid {
+		if _, err := service.Create(episode); !errors.Is(err, ErrEpisodeInvalid) {
+			t.Errorf("Expected ErrEpisodeInvalid for %s, got %v", episode.ID, err)
+		}
+	}
+
+	if _, err := service.Create(Episode{ID: "duplicate", Number: 7, Season: 2, SeasonEpisode: 5, Title: "Dup", AudioURL: "/a.mp3"}); !errors.Is(err, ErrEpisodeConflict) {
+		t.Errorf("Expected ErrEpisodeConflict for a taken season position, got %v", err)
+	}
+	if _, err := service.Create(Episode{ID: "more-bonus", Season: 2, EpisodeType: EpisodeTypeBonus, Title: "More", AudioURL: "/a.mp3"}); err != nil {
+		t.Errorf("Unnumbered bonus episodes must not conflict: %v", err)
+	}
+}
+
+func TestGetSeasons(t *testing.T) {
+	service := newSeasonTestService(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	seasons := service.GetSeasons(now)
+	if len(seasons) != 2 {
+		t.Fatalf("Expected 2 seasons with published episodes, got %v", seasons)
+	}
+	expected := Season{Number: 2, EpisodeCount: 2, TrailerCount: 1, BonusCount: 1, FirstPublishDate: "2023-12-01", LatestPublishDate: "2024-02-01"}
+	if seasons[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, seasons[1])
+	}
+
+	var order []string
+	for _, episode := range service.GetSeasonEpisodes(2, now) {
+		order = append(order, episode.ID)
+	}
+	want := []string{"season-two-trailer", "return", "outtakes", "finale"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, order)
+			break
+		}
+	}
+
+	if episodes := service.GetSeasonEpisodes(3, now); len(episodes) != 0 {
+		t.Errorf("Expected draft-only season to be hidden, got %v", episodes)
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength keeps generated slugs readable in URLs
+const maxSlugLength = 80
+
+// slugReplacements spells out letters that do not decompose into ASCII
+var slugReplacements = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'ø': "o",
+	'œ': "oe",
+	'đ': "d",
+	'ł': "l",
+	'þ': "th",
+	'&': " and ",
+}
+
+// Slugify turns a title into a lowercase, hyphen-separated ASCII slug, for
+// example "Café Society & You" becomes "cafe-society-and-you"
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		if replacement, ok := slugReplacements[r]; ok {
+			for _, c := range replacement {
+				if c == ' ' {
+					hyphen = b.Len() > 0
+					continue
+				}
+				if hyphen {
+					b.WriteByte('-')
+					hyphen = false
+				}
+				b.WriteRune(c)
+			}
+			continue
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents left over from decomposition
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen {
+				b.WriteByte('-')
+				hyphen = false
+			}
+			b.WriteRune(r)
+		default:
+			hyphen = b.Len() > 0
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if cut := strings.LastIndexByte(slug, '-'); cut > maxSlugLength/2 {
+			slug = slug[:cut]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// isReferenceShaped reports whether value would be read as a number or sNeN
+// reference instead of an ID or slug
+func isReferenceShaped(value string) bool {
+	return numberRefPattern.MatchString(value) || seasonEpisodeRefPattern.MatchString(value)
+}
+
+// slugOwner returns the index of the episode, other than the one with
+// excludeID, that uses name as its ID, slug or former slug, or -1.
+// The caller must hold the mutex.
+func (s *EpisodeService) slugOwner(name, excludeID string) int {
+	for i := range s.episodes {
+		episode := &s.episodes[i]
+		if episode.ID == excludeID {
+			continue
+		}
+		if episode.ID == name || episode.Slug == name {
+			return i
+		}
+		for _, old := range episode.SlugHistory {
+			if old == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// uniqueSlug derives a free slug for the episode with id from its title,
+// adding -2, -3, ... on collisions. Titles without usable characters, or
+// whose slug would read as a reference, fall back to the ID.
+// The caller must hold the mutex.
+func (s *EpisodeService) uniqueSlug(title, id string) string {
+	base := Slugify(title)
+	if base == "" || isReferenceShaped(base) {
+		base = id
+	}
+
+	slug := base
+	for n := 2; s.slugOwner(slug, id) >= 0; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// assignMissingSlugs gives every episode without a slug one derived from its
+// title and reports whether it generated any. Slugs are assigned by episode
+// number and then ID, so until they are saved, reordering the file keeps
+// every link and a newer episode with the same title is the one that gets a
+// -2 suffix. The caller must hold the mutex or own the service exclusively.
+func (s *EpisodeService) assignMissingSlugs() bool {
+	var missing []int
+	for i := range s.episodes {
+		if s.episodes[i].Slug == "" {
+			missing = append(missing, i)
+		}
+	}
+	slices.SortFunc(missing, func(a, b int) int {
+		return cmp.Or(
+			cmp.Compare(s.episodes[a].Number, s.episodes[b].Number),
+			strings.Compare(s.episodes[a].ID, s.episodes[b].ID),
+		)
+	})
+	for _, i := range missing {
+		s.episodes[i].Slug = s.uniqueSlug(s.episodes[i].Title, s.episodes[i].ID)
+	}
+	return len(missing) > 0
+}
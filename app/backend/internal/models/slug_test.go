@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"Welcome to Podsite", "welcome-to-podsite"},
+		{"Café Society & You", "cafe-society-and-you"},
+		{"  Straße, Ærø -- Łódź!  ", "strasse-aero-lodz"},
+		{"Go 1.25: What's New?", "go-1-25-what-s-new"},
+		{"日本語", ""},
+		{strings.Repeat("long title ", 20), "long-title-long-title-long-title-long-title-long-title-long-title-long-title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := Slugify(tt.title); got != tt.expected {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSlugHistory(t *testing.T) {
+	dir := t.TempDir()
+	// The file lists the newer episode first
+	episodes := []Episode{
+		{ID: "ep002", Number: 2, Title: "Hello World", AudioURL: "/a.mp3"},
+		{ID: "ep001", Number: 1, Title: "Hello World", AudioURL: "/a.mp3"},
+		{ID: "ep003", Number: 3, Title: "2024", AudioURL: "/a.mp3"},
+	}
+	if err := writeJSONFile(filepath.Join(dir, "episodes.json"), episodes); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+
+	// Missing slugs are generated in episode order, whatever the file
+	// order, avoiding collisions and slugs that would read as a number
+	for id, expected := range map[string]string{"ep001": "hello-world", "ep002": "hello-world-2", "ep003": "ep003"} {
+		if episode, _ := service.GetByID(id); episode.Slug != expected {
+			t.Errorf("Expected %s to get slug %s, got %s", id, expected, episode.Slug)
+		}
+	}
+
+	// Renaming moves the old slug into the history, even when the request
+	// repeats the current slug
+	renamed, _ := service.GetByID("ep001")
+	renamed.Title = "Goodbye World"
+	updated, _, err := service.Update("ep001", *renamed)
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if updated.Slug != "goodbye-world" || len(updated.SlugHistory) != 1 || updated.SlugHistory[0] != "hello-world" {
+		t.Errorf("Expected slug goodbye-world with history [hello-world], got %s %v", updated.Slug, updated.SlugHistory)
+	}
+
+	episode, moved, err := service.Resolve("hello-world")
+	if err != nil || !moved || episode.ID != "ep001" {
+		t.Errorf("Expected hello-world to redirect to ep001, got %v moved=%v err=%v", episode, moved, err)
+	}
+	if episode, moved, _ := service.Resolve("goodbye-world"); episode == nil || moved {
+		t.Errorf("Expected goodbye-world to resolve without redirect")
+	}
+
+	// Former slugs stay reserved for their episode
+	if _, err := service.Create(Episode{ID: "ep004", Number: 4, Title: "Hello World", AudioURL: "/a.mp3"}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if created, _ := service.GetByID("ep004"); created.Slug != "hello-world-3" {
+		t.Errorf("Expected hello-world-3, got %s", created.Slug)
+	}
+	if _, err := service.Create(Episode{ID: "ep005", Number: 5, Slug: "hello-world", Title: "Taken", AudioURL: "/a.mp3"}); !errors.Is(err, ErrEpisodeConflict) {
+		t.Errorf("Expected ErrEpisodeConflict for a former slug, got %v", err)
+	}
+
+	// Restoring the title brings the original slug back out of the history
+	updated.Title = "Hello World"
+	updated.Slug = ""
+	restored, _, err := service.Update("ep001", *updated)
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if restored.Slug != "hello-world" || len(restored.SlugHistory) != 1 || restored.SlugHistory[0] != "goodbye-world" {
+		t.Errorf("Expected slug hello-world with history [goodbye-world], got %s %v", restored.Slug, restored.SlugHistory)
+	}
+}
+
+func TestGeneratedSlugsSaved(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "episodes.json")
+	if err := writeJSONFile(path, []Episode{{ID: "ep001", Number: 1, Title: "Helo World", AudioURL: "/a.mp3"}}); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if _, err := LoadEpisodeService(dir); err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+
+	// Fixing a typo in the title by hand keeps the slug old links use
+	var saved []Episode
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read episodes: %v", err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved) != 1 || saved[0].Slug != "helo-world" {
+		t.Fatalf("Expected the generated slug helo-world to be saved, got %s (%v)", data, err)
+	}
+	saved[0].Title = "Hello World"
+	if err := writeJSONFile(path, saved); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	service, err := LoadEpisodeService(dir)
+	if err != nil {
+		t.Fatalf("LoadEpisodeService returned error: %v", err)
+	}
+	episode, moved, err := service.Resolve("helo-world")
+	if err != nil || moved || episode.ID != "ep001" || episode.Title != "Hello World" {
+		t.Errorf("Expected helo-world to resolve to the renamed ep001, got %v moved=%v err=%v", episode, moved, err)
+	}
+}
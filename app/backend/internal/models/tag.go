@@ -0,0 +1,305 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Weights of tag overlap and text similarity in related-episode scores
+const (
+	relatedTagWeight  = 0.7
+	relatedTextWeight = 0.3
+)
+
+// relatedStopWords are common words ignored when comparing episode text
+var relatedStopWords = map[string]bool{
+	"and": true, "are": true, "but": true, "for": true, "from": true,
+	"our": true, "the": true, "this": true, "that": true, "what": true,
+	"with": true, "you": true, "your": true, "about": true, "some": true,
+	"into": true, "how": true, "can": true, "who": true, "why": true,
+	"episode": true, "podcast": true,
+}
+
+// NormalizeTag turns a free-form tag into its lowercase, hyphenated form,
+// for example "Machine Learning" becomes "machine-learning"
+func NormalizeTag(tag string) string {
+	return Slugify(tag)
+}
+
+// TagDefinition names a canonical tag and the spellings that mean the same,
+// stored in tags.json
+type TagDefinition struct {
+	Tag     string   `json:"tag"`
+	Name    string   `json:"name,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// TagCount is a tag with the number of published episodes carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// RelatedEpisode is an episode recommended alongside another. Score is
+// between 0 and 1, higher is closer.
+type RelatedEpisode struct {
+	Episode
+	Score      float64  `json:"score"`
+	SharedTags []string `json:"sharedTags,omitempty"`
+}
+
+// TagService normalizes tags and resolves aliases to canonical tags
+type TagService struct {
+	definitions map[string]TagDefinition
+	aliases     map[string]string
+}
+
+// NewTagService creates a tag service using the default content directory
+func NewTagService() *TagService {
+	return NewTagServiceFromDir(DefaultContentDir())
+}
+
+// NewTagServiceFromDir creates a tag service that reads tags.json from dir,
+// only normalizing tags if it cannot be loaded
+func NewTagServiceFromDir(dir string) *TagService {
+	service, err := LoadTagService(dir)
+	if err != nil {
+		return newTagService(nil)
+	}
+	return service
+}
+
+// LoadTagService creates a tag service from dir. A missing tags.json is not
+// an error; an unreadable or inconsistent one is.
+func LoadTagService(dir string) (*TagService, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "tags.json"))
+	if os.IsNotExist(err) {
+		return newTagService(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags file: %w", err)
+	}
+
+	var definitions []TagDefinition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("failed to parse tags JSON: %w", err)
+	}
+
+	owners := make(map[string]string)
+	for _, definition := range definitions {
+		if definition.Tag == "" || NormalizeTag(definition.Tag) != definition.Tag {
+			return nil, fmt.Errorf("tag %q must be lowercase letters, digits and hyphens", definition.Tag)
+		}
+		names := append([]string{definition.Tag}, definition.Aliases...)
+		for _, name := range names {
+			alias := NormalizeTag(name)
+			if alias == "" {
+				return nil, fmt.Errorf("tag %s has an empty alias", definition.Tag)
+			}
+			if owner, ok := owners[alias]; ok && owner != definition.Tag {
+				return nil, fmt.Errorf("tag %s: %s already belongs to %s", definition.Tag, alias, owner)
+			}
+			owners[alias] = definition.Tag
+		}
+	}
+	return newTagService(definitions), nil
+}
+
+// newTagService indexes definitions by tag and alias
+func newTagService(definitions []TagDefinition) *TagService {
+	service := &TagService{
+		definitions: make(map[string]TagDefinition, len(definitions)),
+		aliases:     make(map[string]string),
+	}
+	for _, definition := range definitions {
+		service.definitions[definition.Tag] = definition
+		for _, alias := range definition.Aliases {
+			service.aliases[NormalizeTag(alias)] = definition.Tag
+		}
+	}
+	return service
+}
+
+// Canonical returns the canonical form of tag, or "" if nothing is left
+// after normalizing
+func (s *TagService) Canonical(tag string) string {
+	normalized := NormalizeTag(tag)
+	if canonical, ok := s.aliases[normalized]; ok {
+		return canonical
+	}
+	return normalized
+}
+
+// Normalize returns tags in canonical form without duplicates or empty
+// tags, keeping their order. nil stays nil.
+func (s *TagService) Normalize(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		canonical := s.Canonical(tag)
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		normalized = append(normalized, canonical)
+	}
+	return normalized
+}
+
+// Counts returns every tag on episodes with the number of episodes carrying
+// it, most used first. Names come from tags.json or the first spelling seen.
+func (s *TagService) Counts(episodes []Episode) []TagCount {
+	index := make(map[string]int)
+	var counts []TagCount
+	for i := range episodes {
+		seen := make(map[string]bool, len(episodes[i].Tags))
+		for _, tag := range episodes[i].Tags {
+			canonical := s.Canonical(tag)
+			if canonical == "" || seen[canonical] {
+				continue
+			}
+			seen[canonical] = true
+
+			if at, ok := index[canonical]; ok {
+				counts[at].Count++
+				continue
+			}
+			name := s.definitions[canonical].Name
+			if name == "" {
+				name = strings.TrimSpace(tag)
+			}
+			index[canonical] = len(counts)
+			counts = append(counts, TagCount{Tag: canonical, Name: name, Count: 1})
+		}
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+	return counts
+}
+
+// Filter returns the episodes carrying tag or one of its aliases, in order
+func (s *TagService) Filter(tag string, episodes []Episode) []Episode {
+	canonical := s.Canonical(tag)
+	filtered := []Episode{}
+	if canonical == "" {
+		return filtered
+	}
+	for _, episode := range episodes {
+		for _, t := range episode.Tags {
+			if s.Canonical(t) == canonical {
+				filtered = append(filtered, episode)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Related ranks candidates by similarity to episode and returns at most
+// limit of them. The score combines the Jaccard overlap of their tags with
+// the cosine similarity of the words in their titles and descriptions.
+// Candidates with nothing in common are left out and ties keep the order of
+// candidates.
+func (s *TagService) Related(episode Episode, candidates []Episode, limit int) []RelatedEpisode {
+	tags := s.Normalize(episode.Tags)
+	words := termFrequencies(episode.Title + " " + episode.Description)
+
+	related := []RelatedEpisode{}
+	for _, candidate := range candidates {
+		if candidate.ID == episode.ID {
+			continue
+		}
+		shared, overlap := tagOverlap(tags, s.Normalize(candidate.Tags))
+		similarity := cosineSimilarity(words, termFrequencies(candidate.Title+" "+candidate.Description))
+		score := relatedTagWeight*overlap + relatedTextWeight*similarity
+		if score <= 0 {
+			continue
+		}
+		related = append(related, RelatedEpisode{
+			Episode:    candidate,
+			Score:      math.Round(score*1000) / 1000,
+			SharedTags: shared,
+		})
+	}
+
+	sort.SliceStable(related, func(i, j int) bool {
+		return related[i].Score > related[j].Score
+	})
+	if limit > 0 && len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
+
+// tagOverlap returns the tags in both lists and their Jaccard index
+func tagOverlap(a, b []string) ([]string, float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, 0
+	}
+	inA := make(map[string]bool, len(a))
+	for _, tag := range a {
+		inA[tag] = true
+	}
+	var shared []string
+	for _, tag := range b {
+		if inA[tag] {
+			shared = append(shared, tag)
+		}
+	}
+	union := len(a) + len(b) - len(shared)
+	return shared, float64(len(shared)) / float64(union)
+}
+
+// termFrequencies counts the words of text worth comparing: lowercased,
+// accents removed, at least three characters and not a stop word
+func termFrequencies(text string) map[string]int {
+	terms := make(map[string]int)
+	folded := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(strings.ToLower(text)))
+	for _, word := range strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 3 || relatedStopWords[word] {
+			continue
+		}
+		terms[word]++
+	}
+	return terms
+}
+
+// cosineSimilarity compares two term frequency vectors
+func cosineSimilarity(a, b map[string]int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for term, count := range a {
+		normA += float64(count * count)
+		dot += float64(count * b[term])
+	}
+	for _, count := range b {
+		normB += float64(count * count)
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
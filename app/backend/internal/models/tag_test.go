@@ -0,0 +1,128 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTagTestService() *TagService {
+	return newTagService([]TagDefinition{
+		{Tag: "machine-learning", Name: "Machine Learning", Aliases: []string{"ML", "machine learning"}},
+		{Tag: "javascript", Name: "JavaScript", Aliases: []string{"JS"}},
+	})
+}
+
+func TestLoadTagService(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{"missing file", "", false},
+		{"valid", `[{"tag":"javascript","aliases":["js","ECMAScript"]}]`, false},
+		{"not normalized", `[{"tag":"JavaScript"}]`, true},
+		{"alias owned twice", `[{"tag":"javascript","aliases":["js"]},{"tag":"json","aliases":["js"]}]`, true},
+		{"alias is another tag", `[{"tag":"javascript"},{"tag":"js","aliases":["javascript"]}]`, true},
+		{"empty alias", `[{"tag":"javascript","aliases":["!!"]}]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, "tags.json"), []byte(tt.content), 0o644); err != nil {
+					t.Fatalf("failed to write fixture: %v", err)
+				}
+			}
+			_, err := LoadTagService(dir)
+			if tt.expectError && err == nil {
+				t.Error("Expected an error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTagNormalize(t *testing.T) {
+	service := newTagTestService()
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{"nil", nil, nil},
+		{"case and spaces", []string{"  Getting Started ", "Café"}, []string{"getting-started", "cafe"}},
+		{"aliases", []string{"ML", "js", "Machine Learning"}, []string{"machine-learning", "javascript"}},
+		{"empty after normalizing", []string{"!!", "basics"}, []string{"basics"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.Normalize(tt.tags); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Normalize(%q) = %q, expected %q", tt.tags, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTagCountsAndFilter(t *testing.T) {
+	service := newTagTestService()
+	episodes := []Episode{
+		{ID: "c", Tags: []string{"ML", "Basics"}},
+		{ID: "b", Tags: []string{"machine-learning", "machine learning"}},
+		{ID: "a", Tags: []string{"JS"}},
+	}
+
+	expected := []TagCount{
+		{Tag: "machine-learning", Name: "Machine Learning", Count: 2},
+		{Tag: "basics", Name: "Basics", Count: 1},
+		{Tag: "javascript", Name: "JavaScript", Count: 1},
+	}
+	if got := service.Counts(episodes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Counts() = %+v, expected %+v", got, expected)
+	}
+
+	filtered := service.Filter("ml", episodes)
+	if len(filtered) != 2 || filtered[0].ID != "c" || filtered[1].ID != "b" {
+		t.Errorf("Expected episodes c and b for ml, got %v", filtered)
+	}
+	if filtered := service.Filter("", episodes); len(filtered) != 0 {
+		t.Errorf("Expected no episodes for an empty tag, got %v", filtered)
+	}
+}
+
+func TestRelated(t *testing.T) {
+	service := newTagTestService()
+	target := Episode{ID: "target", Title: "Neural networks", Description: "Training neural networks from scratch", Tags: []string{"ML", "python"}}
+	candidates := []Episode{
+		target,
+		{ID: "unrelated", Title: "Gardening", Description: "Growing tomatoes", Tags: []string{"garden"}},
+		{ID: "words", Title: "More neural networks", Description: "Deeper networks", Tags: []string{"history"}},
+		{ID: "tags", Title: "Data pipelines", Description: "Cleaning data", Tags: []string{"machine-learning", "python"}},
+		{ID: "one-tag", Title: "Snakes", Description: "Reptiles", Tags: []string{"python", "zoology"}},
+	}
+
+	related := service.Related(target, candidates, 0)
+	var ids []string
+	for _, episode := range related {
+		ids = append(ids, episode.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"tags", "one-tag", "words"}) {
+		t.Fatalf("Expected tags, one-tag, words, got %v", ids)
+	}
+	if !reflect.DeepEqual(related[0].SharedTags, []string{"machine-learning", "python"}) {
+		t.Errorf("Expected shared tags, got %v", related[0].SharedTags)
+	}
+	if related[0].Score != relatedTagWeight {
+		t.Errorf("Expected a full tag match to score %v, got %v", relatedTagWeight, related[0].Score)
+	}
+
+	if limited := service.Related(target, candidates, 1); len(limited) != 1 || limited[0].ID != "tags" {
+		t.Errorf("Expected only the best match, got %v", limited)
+	}
+}
@@ -0,0 +1,431 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transcript errors
+var (
+	ErrTranscriptNotFound = errors.New("transcript not found")
+	ErrTranscriptInvalid  = errors.New("invalid transcript")
+)
+
+// transcriptVersion is the Podcasting 2.0 JSON transcript version written
+const transcriptVersion = "1.0.0"
+
+// TranscriptFormat is a transcript file format
+type TranscriptFormat string
+
+// Transcript formats. JSON is the Podcasting 2.0 transcript format and the
+// one transcripts are stored in; text and HTML are output only.
+const (
+	TranscriptJSON TranscriptFormat = "json"
+	TranscriptVTT  TranscriptFormat = "vtt"
+	TranscriptSRT  TranscriptFormat = "srt"
+	TranscriptText TranscriptFormat = "txt"
+	TranscriptHTML TranscriptFormat = "html"
+)
+
+// transcriptContentTypes maps formats to the media types they are served as
+var transcriptContentTypes = map[TranscriptFormat]string{
+	TranscriptJSON: "application/json",
+	TranscriptVTT:  "text/vtt",
+	TranscriptSRT:  "application/x-subrip",
+	TranscriptText: "text/plain",
+	TranscriptHTML: "text/html",
+}
+
+// ContentType returns the media type of the format, without parameters
+func (f TranscriptFormat) ContentType() string {
+	return transcriptContentTypes[f]
+}
+
+// Readable reports whether transcripts can be ingested in the format
+func (f TranscriptFormat) Readable() bool {
+	return f == TranscriptJSON || f == TranscriptVTT || f == TranscriptSRT
+}
+
+// TranscriptFormatFor returns the format served as mediaType, such as
+// "text/vtt", and false if there is none
+func TranscriptFormatFor(mediaType string) (TranscriptFormat, bool) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "application/srt" || mediaType == "text/srt" {
+		return TranscriptSRT, true
+	}
+	for format, contentType := range transcriptContentTypes {
+		if contentType == mediaType {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// TranscriptSegment is one cue: who speaks, when, in seconds from the start
+// of the audio, and what they say
+type TranscriptSegment struct {
+	Speaker   string  `json:"speaker,omitempty"`
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime"`
+	Body      string  `json:"body"`
+}
+
+// Transcript is an episode transcript in Podcasting 2.0 JSON form
+type Transcript struct {
+	Version  string              `json:"version"`
+	Segments []TranscriptSegment `json:"segments"`
+}
+
+// TranscriptSummary describes a transcript in audit entries
+type TranscriptSummary struct {
+	Segments int      `json:"segments"`
+	Speakers []string `json:"speakers,omitempty"`
+	EndTime  float64  `json:"endTime"`
+}
+
+// Summary counts the segments and lists the speakers in order of appearance
+func (t *Transcript) Summary() TranscriptSummary {
+	summary := TranscriptSummary{Segments: len(t.Segments)}
+	seen := make(map[string]bool)
+	for _, segment := range t.Segments {
+		if segment.Speaker != "" && !seen[segment.Speaker] {
+			seen[segment.Speaker] = true
+			summary.Speakers = append(summary.Speakers, segment.Speaker)
+		}
+		summary.EndTime = math.Max(summary.EndTime, segment.EndTime)
+	}
+	return summary
+}
+
+// normalize trims and orders the segments, rounds times to milliseconds and
+// checks that every segment has text and a sensible time range
+func (t *Transcript) normalize() error {
+	t.Version = transcriptVersion
+	var problems []string
+	segments := make([]TranscriptSegment, 0, len(t.Segments))
+	for i, segment := range t.Segments {
+		segment.Speaker = strings.TrimSpace(segment.Speaker)
+		segment.Body = strings.TrimSpace(segment.Body)
+		segment.StartTime = math.Round(segment.StartTime*1000) / 1000
+		segment.EndTime = math.Round(segment.EndTime*1000) / 1000
+		if segment.Body == "" {
+			continue
+		}
+		if segment.StartTime < 0 || segment.EndTime < segment.StartTime {
+			problems = append(problems, fmt.Sprintf("segment %d ends before it starts", i+1))
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		problems = append(problems, "transcript has no segments")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrTranscriptInvalid, strings.Join(problems, "; "))
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].StartTime < segments[j].StartTime
+	})
+	t.Segments = segments
+	return nil
+}
+
+// ParseTranscript reads a transcript in format, detecting the format from
+// the content when format is empty
+func ParseTranscript(data []byte, format TranscriptFormat) (*Transcript, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\r", "\n")
+	if format == "" {
+		format = detectTranscriptFormat(text)
+	}
+
+	var transcript *Transcript
+	var err error
+	switch format {
+	case TranscriptJSON:
+		transcript = &Transcript{}
+		if jsonErr := json.Unmarshal(data, transcript); jsonErr != nil {
+			err = fmt.Errorf("%w: %v", ErrTranscriptInvalid, jsonErr)
+		}
+	case TranscriptVTT:
+		transcript, err = parseCues(text, true)
+	case TranscriptSRT:
+		transcript, err = parseCues(text, false)
+	default:
+		return nil, fmt.Errorf("%w: transcripts can only be read from json, vtt or srt", ErrTranscriptInvalid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := transcript.normalize(); err != nil {
+		return nil, err
+	}
+	return transcript, nil
+}
+
+// detectTranscriptFormat guesses the format of a transcript from its content
+func detectTranscriptFormat(text string) TranscriptFormat {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "WEBVTT"):
+		return TranscriptVTT
+	case strings.HasPrefix(trimmed, "{"):
+		return TranscriptJSON
+	default:
+		return TranscriptSRT
+	}
+}
+
+var (
+	// cueTimestampPattern matches [hh:]mm:ss.ttt and, for SRT, hh:mm:ss,ttt
+	cueTimestampPattern = regexp.MustCompile(`^(?:([0-9]+):)?([0-5][0-9]):([0-5][0-9])[.,]([0-9]{1,3})$`)
+	// voicePattern matches a WebVTT voice span such as <v.loud Alice>
+	voicePattern = regexp.MustCompile(`^<v(?:\.[^ >]*)?[ \t]+([^>]+)>`)
+	// cueTagPattern matches any markup inside cue text
+	cueTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// parseCues reads WebVTT (vtt) or SRT cue blocks. Blocks are separated by
+// blank lines; each has an optional identifier, a timing line and text.
+func parseCues(text string, vtt bool) (*Transcript, error) {
+	blocks := strings.Split(strings.TrimSpace(text), "\n\n")
+	if vtt {
+		if !strings.HasPrefix(blocks[0], "WEBVTT") {
+			return nil, fmt.Errorf("%w: WebVTT files must start with WEBVTT", ErrTranscriptInvalid)
+		}
+		blocks = blocks[1:]
+	}
+
+	transcript := &Transcript{}
+	for _, block := range blocks {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if vtt && (strings.HasPrefix(lines[0], "NOTE") || lines[0] == "STYLE" || lines[0] == "REGION") {
+			continue
+		}
+
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 || timing > 1 {
+			if strings.TrimSpace(block) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: cue without timing: %q", ErrTranscriptInvalid, lines[0])
+		}
+
+		parts := strings.SplitN(lines[timing], "-->", 2)
+		start, err := parseCueTimestamp(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		// WebVTT cue settings may follow the end time
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: cue without end time: %q", ErrTranscriptInvalid, lines[timing])
+		}
+		end, err := parseCueTimestamp(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		body := strings.Join(lines[timing+1:], " ")
+		speaker := ""
+		if match := voicePattern.FindStringSubmatch(body); match != nil {
+			speaker = html.UnescapeString(strings.TrimSpace(match[1]))
+		}
+		body = html.UnescapeString(cueTagPattern.ReplaceAllString(body, ""))
+		transcript.Segments = append(transcript.Segments, TranscriptSegment{
+			Speaker:   speaker,
+			StartTime: start,
+			EndTime:   end,
+			Body:      strings.Join(strings.Fields(body), " "),
+		})
+	}
+	return transcript, nil
+}
+
+// parseCueTimestamp reads a cue timestamp as seconds
+func parseCueTimestamp(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	parts := cueTimestampPattern.FindStringSubmatch(value)
+	if parts == nil {
+		return 0, fmt.Errorf("%w: invalid timestamp %q", ErrTranscriptInvalid, value)
+	}
+	hours, _ := strconv.Atoi(parts[1])
+	minutes, _ := strconv.Atoi(parts[2])
+	seconds, _ := strconv.Atoi(parts[3])
+	fraction, _ := strconv.ParseFloat("0."+parts[4], 64)
+	return float64(hours*3600+minutes*60+seconds) + fraction, nil
+}
+
+// vttEscaper escapes the characters WebVTT cue text reserves for markup
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// cueText folds text onto one line, as a blank line would end the cue
+func cueText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// formatCueTimestamp writes seconds as hh:mm:ss followed by sep and
+// milliseconds
+func formatCueTimestamp(seconds float64, sep string) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// Encode writes the transcript in format
+func (t *Transcript) Encode(format TranscriptFormat) ([]byte, error) {
+	var b bytes.Buffer
+	switch format {
+	case TranscriptJSON:
+		return json.Marshal(t)
+	case TranscriptVTT:
+		b.WriteString("WEBVTT\n")
+		for _, segment := range t.Segments {
+			body := vttEscaper.Replace(cueText(segment.Body))
+			if segment.Speaker != "" {
+				body = "<v " + vttEscaper.Replace(cueText(segment.Speaker)) + ">" + body
+			}
+			fmt.Fprintf(&b, "\n%s --> %s\n%s\n", formatCueTimestamp(segment.StartTime, "."), formatCueTimestamp(segment.EndTime, "."), body)
+		}
+	case TranscriptSRT:
+		for i, segment := range t.Segments {
+			// SRT has no escapes; an arrow in the text could pass for timing
+			body := strings.ReplaceAll(cueText(segment.Body), "-->", "->")
+			if segment.Speaker != "" {
+				body = strings.ReplaceAll(cueText(segment.Speaker), "-->", "->") + ": " + body
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1, formatCueTimestamp(segment.StartTime, ","), formatCueTimestamp(segment.EndTime, ","), body)
+		}
+	case TranscriptText:
+		for i, paragraph := range t.paragraphs() {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "[%s] ", Duration(paragraph.StartTime*float64(time.Second)))
+			if paragraph.Speaker != "" {
+				b.WriteString(paragraph.Speaker + ": ")
+			}
+			b.WriteString(paragraph.Body + "\n")
+		}
+	case TranscriptHTML:
+		// Each paragraph links to its start time as a media fragment (#t=62)
+		// so the episode page can seek the player
+		b.WriteString("<div class=\"transcript\">\n")
+		for _, paragraph := range t.paragraphs() {
+			start := int(paragraph.StartTime)
+			fmt.Fprintf(&b, "<p id=\"t-%d\" data-start=\"%.3f\"><a class=\"transcript-time\" href=\"#t=%d\">%s</a> ",
+				start, paragraph.StartTime, start, Duration(paragraph.StartTime*float64(time.Second)))
+			if paragraph.Speaker != "" {
+				fmt.Fprintf(&b, "<strong class=\"transcript-speaker\">%s</strong> ", html.EscapeString(paragraph.Speaker))
+			}
+			b.WriteString(html.EscapeString(paragraph.Body) + "</p>\n")
+		}
+		b.WriteString("</div>\n")
+	default:
+		return nil, fmt.Errorf("unknown transcript format %q", format)
+	}
+	return b.Bytes(), nil
+}
+
+// paragraphs merges consecutive segments by the same speaker for reading
+func (t *Transcript) paragraphs() []TranscriptSegment {
+	var paragraphs []TranscriptSegment
+	for _, segment := range t.Segments {
+		if last := len(paragraphs) - 1; last >= 0 && paragraphs[last].Speaker == segment.Speaker {
+			paragraphs[last].Body += " " + segment.Body
+			paragraphs[last].EndTime = segment.EndTime
+			continue
+		}
+		paragraphs = append(paragraphs, segment)
+	}
+	return paragraphs
+}
+
+// TranscriptService stores one transcript per episode as
+// transcripts/<episode id>.json in the content directory
+type TranscriptService struct {
+	dir string
+}
+
+// NewTranscriptService creates a transcript service using the default content directory
+func NewTranscriptService() *TranscriptService {
+	return NewTranscriptServiceFromDir(DefaultContentDir())
+}
+
+// NewTranscriptServiceFromDir creates a transcript service for the content directory dir
+func NewTranscriptServiceFromDir(dir string) *TranscriptService {
+	return &TranscriptService{dir: filepath.Join(dir, "transcripts")}
+}
+
+// path returns the file of the transcript of episodeID
+func (s *TranscriptService) path(episodeID string) (string, error) {
+	if !episodeIDPattern.MatchString(episodeID) {
+		return "", ErrTranscriptNotFound
+	}
+	return filepath.Join(s.dir, episodeID+".json"), nil
+}
+
+// Get returns the transcript of episodeID
+func (s *TranscriptService) Get(episodeID string) (*Transcript, error) {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrTranscriptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript JSON: %w", err)
+	}
+	return &transcript, nil
+}
+
+// Save stores the transcript of episodeID, replacing any previous one
+func (s *TranscriptService) Save(episodeID string, transcript *Transcript) error {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	return writeJSONFile(path, transcript)
+}
+
+// Delete removes the transcript of episodeID
+func (s *TranscriptService) Delete(episodeID string) error {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return ErrTranscriptNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to delete transcript: %w", err)
+	}
+	return nil
+}
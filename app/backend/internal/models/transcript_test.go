@@ -0,0 +1,214 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const sampleVTT = `WEBVTT
+Kind: captions
+
+NOTE produced by hand
+
+intro
+00:00:00.000 --> 00:00:04.500 align:start
+<v Alice>Welcome to the show &amp; thanks
+
+00:00:04.500 --> 00:00:08.000
+<v.host Alice>for listening.
+
+00:01:02.250 --> 00:01:05.000
+<v Bob>Happy to <i>be</i> here.
+`
+
+const sampleSRT = "1\r\n00:00:00,000 --> 00:00:04,500\r\nWelcome to the show\r\n\r\n2\r\n00:00:04,500 --> 00:00:08,000\r\n<i>for listening.</i>\r\n"
+
+func TestParseTranscript(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		format           TranscriptFormat
+		expectError      bool
+		expectedSegments int
+	}{
+		{"vtt", sampleVTT, TranscriptVTT, false, 3},
+		{"vtt detected", sampleVTT, "", false, 3},
+		{"srt detected", sampleSRT, "", false, 2},
+		{"json", `{"version":"1.0.0","segments":[{"speaker":"Alice","startTime":1.5,"endTime":3,"body":"Hi"}]}`, TranscriptJSON, false, 1},
+		{"vtt without header", "00:00.000 --> 00:01.000\nHi\n", TranscriptVTT, true, 0},
+		{"bad timestamp", "WEBVTT\n\n00:00 --> 00:01\nHi\n", "", true, 0},
+		{"no end time", "WEBVTT\n\n00:00:01.000 -->\nhello\n", "", true, 0},
+		{"end before start", `{"segments":[{"startTime":5,"endTime":1,"body":"Hi"}]}`, "", true, 0},
+		{"empty", "WEBVTT\n", "", true, 0},
+		{"text is output only", "Hi", TranscriptText, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcript, err := ParseTranscript([]byte(tt.data), tt.format)
+			if tt.expectError {
+				if !errors.Is(err, ErrTranscriptInvalid) {
+					t.Errorf("Expected ErrTranscriptInvalid, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTranscript returned error: %v", err)
+			}
+			if len(transcript.Segments) != tt.expectedSegments {
+				t.Errorf("Expected %d segments, got %d", tt.expectedSegments, len(transcript.Segments))
+			}
+		})
+	}
+}
+
+func TestParseWebVTTCues(t *testing.T) {
+	transcript, err := ParseTranscript([]byte(sampleVTT), TranscriptVTT)
+	if err != nil {
+		t.Fatalf("ParseTranscript returned error: %v", err)
+	}
+
+	first := transcript.Segments[0]
+	if first.Speaker != "Alice" || first.Body != "Welcome to the show & thanks" || first.EndTime != 4.5 {
+		t.Errorf("Unexpected first segment %+v", first)
+	}
+	last := transcript.Segments[2]
+	if last.Speaker != "Bob" || last.Body != "Happy to be here." || last.StartTime != 62.25 {
+		t.Errorf("Unexpected last segment %+v", last)
+	}
+	if summary := transcript.Summary(); summary.Segments != 3 || len(summary.Speakers) != 2 || summary.EndTime != 65 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+func TestEncodeTranscript(t *testing.T) {
+	transcript, err := ParseTranscript([]byte(sampleVTT), TranscriptVTT)
+	if err != nil {
+		t.Fatalf("ParseTranscript returned error: %v", err)
+	}
+
+	tests := []struct {
+		format   TranscriptFormat
+		contains []string
+	}{
+		{TranscriptVTT, []string{"WEBVTT\n", "00:01:02.250 --> 00:01:05.000\n<v Bob>Happy to be here.\n"}},
+		{TranscriptSRT, []string{"1\n00:00:00,000 --> 00:00:04,500\nAlice: Welcome", "3\n00:01:02,250"}},
+		{TranscriptJSON, []string{`"version":"1.0.0"`, `"startTime":62.25`}},
+		{TranscriptText, []string{"[00:00] Alice: Welcome to the show & thanks for listening.\n", "[01:02] Bob: Happy to be here.\n"}},
+		{TranscriptHTML, []string{`<p id="t-62" data-start="62.250"><a class="transcript-time" href="#t=62">01:02</a> <strong class="transcript-speaker">Bob</strong>`, "show &amp; thanks"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data, err := transcript.Encode(tt.format)
+			if err != nil {
+				t.Fatalf("Encode returned error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	original, err := ParseTranscript([]byte(sampleVTT), TranscriptVTT)
+	if err != nil {
+		t.Fatalf("ParseTranscript returned error: %v", err)
+	}
+	for _, format := range []TranscriptFormat{TranscriptVTT, TranscriptJSON} {
+		data, err := original.Encode(format)
+		if err != nil {
+			t.Fatalf("Encode(%s) returned error: %v", format, err)
+		}
+		parsed, err := ParseTranscript(data, format)
+		if err != nil {
+			t.Fatalf("ParseTranscript(%s) returned error: %v", format, err)
+		}
+		for i := range original.Segments {
+			if parsed.Segments[i] != original.Segments[i] {
+				t.Errorf("%s: segment %d changed from %+v to %+v", format, i, original.Segments[i], parsed.Segments[i])
+			}
+		}
+	}
+}
+
+func TestTranscriptRoundTripSpecialText(t *testing.T) {
+	original := &Transcript{Version: "1.0.0", Segments: []TranscriptSegment{
+		{Speaker: "Q&A <Host>", StartTime: 0, EndTime: 2, Body: "Q&A time <3 & a <b>tag</b>"},
+		{Speaker: "Bob", StartTime: 2, EndTime: 4, Body: "First line\n\nafter a blank line"},
+		{StartTime: 4, EndTime: 6, Body: "before --> after\n00:00:09.000 --> 00:00:10.000"},
+	}}
+
+	tests := []struct {
+		format   TranscriptFormat
+		expected []TranscriptSegment
+	}{
+		{TranscriptVTT, []TranscriptSegment{
+			{Speaker: "Q&A <Host>", StartTime: 0, EndTime: 2, Body: "Q&A time <3 & a <b>tag</b>"},
+			{Speaker: "Bob", StartTime: 2, EndTime: 4, Body: "First line after a blank line"},
+			{StartTime: 4, EndTime: 6, Body: "before --> after 00:00:09.000 --> 00:00:10.000"},
+		}},
+		{TranscriptSRT, []TranscriptSegment{
+			{StartTime: 2, EndTime: 4, Body: "Bob: First line after a blank line"},
+			{StartTime: 4, EndTime: 6, Body: "before -> after 00:00:09.000 -> 00:00:10.000"},
+		}},
+	}
+
+	for _, tt := range tests {
+		data, err := original.Encode(tt.format)
+		if err != nil {
+			t.Fatalf("Encode(%s) returned error: %v", tt.format, err)
+		}
+		parsed, err := ParseTranscript(data, tt.format)
+		if err != nil {
+			t.Fatalf("ParseTranscript(%s) returned error: %v\n%s", tt.format, err, data)
+		}
+		if len(parsed.Segments) != len(original.Segments) {
+			t.Fatalf("%s: expected %d cues, got %d:\n%s", tt.format, len(original.Segments), len(parsed.Segments), data)
+		}
+		// SRT markup is stripped on parsing, so only plain cues are compared
+		segments := parsed.Segments[len(parsed.Segments)-len(tt.expected):]
+		for i := range tt.expected {
+			if segments[i] != tt.expected[i] {
+				t.Errorf("%s: expected %+v, got %+v", tt.format, tt.expected[i], segments[i])
+			}
+		}
+	}
+}
+
+func TestTranscriptService(t *testing.T) {
+	service := NewTranscriptServiceFromDir(t.TempDir())
+
+	if _, err := service.Get("ep001"); !errors.Is(err, ErrTranscriptNotFound) {
+		t.Errorf("Expected ErrTranscriptNotFound, got %v", err)
+	}
+	if _, err := service.Get("../secrets"); !errors.Is(err, ErrTranscriptNotFound) {
+		t.Errorf("Expected ErrTranscriptNotFound for a path, got %v", err)
+	}
+
+	transcript, err := ParseTranscript([]byte(sampleSRT), TranscriptSRT)
+	if err != nil {
+		t.Fatalf("ParseTranscript returned error: %v", err)
+	}
+	if err := service.Save("ep001", transcript); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	stored, err := service.Get("ep001")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if len(stored.Segments) != 2 || stored.Segments[1].Body != "for listening." {
+		t.Errorf("Unexpected stored transcript %+v", stored)
+	}
+
+	if err := service.Delete("ep001"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := service.Delete("ep001"); !errors.Is(err, ErrTranscriptNotFound) {
+		t.Errorf("Expected ErrTranscriptNotFound, got %v", err)
+	}
+}
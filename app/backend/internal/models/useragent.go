@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names given to clients no rule matches, and to bots
+const (
+	UnknownClient = "Other"
+	BotClient     = "Bot"
+)
+
+// UserAgentRule names the clients whose user agent contains any of Match,
+// compared case-insensitively
+type UserAgentRule struct {
+	Name  string   `yaml:"name" json:"name"`
+	Match []string `yaml:"match" json:"match"`
+}
+
+// UserAgentRules classify user agents into an app, an operating system and
+// a device class. Within each list the first matching rule wins, so
+// specific rules go before general ones.
+type UserAgentRules struct {
+	// Bots are fragments of crawler, monitoring and library user agents
+	Bots    []string        `yaml:"bots" json:"bots"`
+	Apps    []UserAgentRule `yaml:"apps" json:"apps"`
+	OS      []UserAgentRule `yaml:"os" json:"os"`
+	Devices []UserAgentRule `yaml:"devices" json:"devices"`
+}
+
+// Client is the classification of a user agent
+type Client struct {
+	App    string `json:"app"`
+	OS     string `json:"os"`
+	Device string `json:"device"`
+	Bot    bool   `json:"bot"`
+}
+
+// DefaultUserAgentRules returns the built-in rules, covering the common
+// podcast apps, browsers and bots
+func DefaultUserAgentRules() *UserAgentRules {
+	return &UserAgentRules{
+		Bots: []string{
+			"bot", "crawl", "spider", "slurp", "preview", "monitor", "uptime",
+			"lighthouse", "headlesschrome", "facebookexternalhit", "feedfetcher",
+			"curl/", "wget/", "python-", "go-http-client", "java/", "httpclient",
+		},
+		Apps: []UserAgentRule{
+			{"Spotify", []string{"spotify"}},
+			{"Overcast", []string{"overcast"}},
+			{"Pocket Casts", []string{"pocketcasts", "pocket casts"}},
+			{"AntennaPod", []string{"antennapod"}},
+			{"Castro", []string{"castro"}},
+			{"Podcast Addict", []string{"podcastaddict"}},
+			{"Castbox", []string{"castbox"}},
+			{"Player FM", []string{"player fm", "playerfm"}},
+			{"Podcast Republic", []string{"podcastrepublic"}},
+			{"Podbean", []string{"podbean"}},
+			{"Amazon Music", []string{"amazonmusic", "amazon music"}},
+			{"Alexa", []string{"alexa"}},
+			{"Sonos", []string{"sonos"}},
+			{"Apple Podcasts", []string{"applecoremedia", "itunes", "podcasts/", "applepodcasts"}},
+			{"Edge", []string{"edg/", "edga/", "edgios/"}},
+			{"Firefox", []string{"firefox/", "fxios/"}},
+			{"Chrome", []string{"chrome/", "crios/"}},
+			{"Safari", []string{"safari/"}},
+			{"Browser", []string{"mozilla/"}},
+		},
+		OS: []UserAgentRule{
+			{"watchOS", []string{"watchos", "watch os"}},
+			{"tvOS", []string{"tvos", "appletv", "apple tv"}},
+			{"iOS", []string{"iphone", "ipad", "ipod", "ios/", " ios ", "cpu os"}},
+			{"Android", []string{"android"}},
+			{"Windows", []string{"windows"}},
+			{"ChromeOS", []string{"cros"}},
+			{"macOS", []string{"macintosh", "mac os x", "macos"}},
+			{"Linux", []string{"linux"}},
+		},
+		Devices: []UserAgentRule{
+			{"Watch", []string{"watch"}},
+			{"TV", []string{"tvos", "appletv", "smart-tv", "smarttv", "roku", "android tv", "googletv"}},
+			{"Speaker", []string{"alexa", "echo", "sonos", "homepod"}},
+			{"Tablet", []string{"ipad", "tablet", "kindle", "silk/"}},
+			{"Phone", []string{"iphone", "ipod", "mobile", "android"}},
+			{"Desktop", []string{"windows nt", "macintosh", "x11", "cros", "mac os x"}},
+		},
+	}
+}
+
+// LoadUserAgentRules reads rules from a YAML (or JSON) file
+func LoadUserAgentRules(path string) (*UserAgentRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user agent rules: %w", err)
+	}
+	var rules UserAgentRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse user agent rules %s: %w", path, err)
+	}
+
+	for list, ruleList := range map[string][]UserAgentRule{"apps": rules.Apps, "os": rules.OS, "devices": rules.Devices} {
+		for i, rule := range ruleList {
+			if strings.TrimSpace(rule.Name) == "" || len(rule.Match) == 0 {
+				return nil, fmt.Errorf("user agent rules %s: %s[%d] needs a name and at least one match", path, list, i)
+			}
+		}
+	}
+	return &rules, nil
+}
+
+// Classify returns the client sending userAgent. Requests without a user
+// agent are treated as bots.
+func (r *UserAgentRules) Classify(userAgent string) Client {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" || containsAny(ua, r.Bots) {
+		return Client{App: BotClient, OS: UnknownClient, Device: BotClient, Bot: true}
+	}
+	return Client{
+		App:    firstMatch(ua, r.Apps),
+		OS:     firstMatch(ua, r.OS),
+		Device: firstMatch(ua, r.Devices),
+	}
+}
+
+// firstMatch returns the name of the first rule matching ua
+func firstMatch(ua string, rules []UserAgentRule) string {
+	for _, rule := range rules {
+		if containsAny(ua, rule.Match) {
+			return rule.Name
+		}
+	}
+	return UnknownClient
+}
+
+// containsAny reports whether ua contains any of fragments, ignoring case
+func containsAny(ua string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if fragment != "" && strings.Contains(ua, strings.ToLower(fragment)) {
+			return true
+		}
+	}
+	return false
+}
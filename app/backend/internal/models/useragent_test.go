@@ -0,0 +1,106 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	rules := DefaultUserAgentRules()
+	tests := []struct {
+		userAgent string
+		expected  Client
+	}{
+		{
+			"AppleCoreMedia/1.0.0.21E236 (iPhone; U; CPU OS 17_4 like Mac OS X; en_us)",
+			Client{App: "Apple Podcasts", OS: "iOS", Device: "Phone"},
+		},
+		{
+			"Podcasts/1555.2.1 CFNetwork/1494.0.7 Darwin/23.4.0",
+			Client{App: "Apple Podcasts", OS: "Other", Device: "Other"},
+		},
+		{
+			"Spotify/8.9.18 iOS/17.4 (iPhone15,3)",
+			Client{App: "Spotify", OS: "iOS", Device: "Phone"},
+		},
+		{
+			"AntennaPod/3.3.2 (Linux;Android 14) ExoPlayerLib/2.19.1",
+			Client{App: "AntennaPod", OS: "Android", Device: "Phone"},
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 Version/17.4 Mobile/15E148 Safari/604.1",
+			Client{App: "Safari", OS: "iOS", Device: "Tablet"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/123.0 Safari/537.36 Edg/123.0",
+			Client{App: "Edge", OS: "Windows", Device: "Desktop"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/537.36 Chrome/123.0 Safari/537.36",
+			Client{App: "Chrome", OS: "macOS", Device: "Desktop"},
+		},
+		{
+			"Mozilla/5.0 (X11; Linux x86_64; rv:124.0) Gecko/20100101 Firefox/124.0",
+			Client{App: "Firefox", OS: "Linux", Device: "Desktop"},
+		},
+		{
+			"AlexaMediaPlayer/2.1.4676.0 (Linux;Android 5.1.1) ExoPlayerLib/1.5.9",
+			Client{App: "Alexa", OS: "Android", Device: "Speaker"},
+		},
+		{"Mozilla/5.0 (compatible; bingbot/2.0)", Client{App: "Bot", OS: "Other", Device: "Bot", Bot: true}},
+		{"curl/8.4.0", Client{App: "Bot", OS: "Other", Device: "Bot", Bot: true}},
+		{"", Client{App: "Bot", OS: "Other", Device: "Bot", Bot: true}},
+		{"SomeNewApp/1.0", Client{App: "Other", OS: "Other", Device: "Other"}},
+	}
+
+	for _, tt := range tests {
+		if got := rules.Classify(tt.userAgent); got != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.userAgent, tt.expected, got)
+		}
+	}
+}
+
+func TestLoadUserAgentRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+	data := `bots: [Monitor]
+apps:
+  - name: Fountain
+    match: [fountain]
+os:
+  - name: Android
+    match: [Android]
+devices:
+  - name: Phone
+    match: [android]
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadUserAgentRules(path)
+	if err != nil {
+		t.Fatalf("LoadUserAgentRules returned error: %v", err)
+	}
+	if got := rules.Classify("Fountain/1.0 (Android 14)"); got != (Client{App: "Fountain", OS: "Android", Device: "Phone"}) {
+		t.Errorf("Unexpected client %+v", got)
+	}
+	if got := rules.Classify("Spotify/8.9"); got.App != UnknownClient {
+		t.Errorf("Expected rules to replace the defaults, got %+v", got)
+	}
+	if got := rules.Classify("UptimeMonitor/1.0"); !got.Bot {
+		t.Errorf("Expected a bot, got %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("apps:\n  - name: Nameless\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadUserAgentRules(path); err == nil || !strings.Contains(err.Error(), "apps[0]") {
+		t.Errorf("Expected an error for a rule without matches, got %v", err)
+	}
+	if _, err := LoadUserAgentRules(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
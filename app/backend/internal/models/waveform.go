@@ -0,0 +1,268 @@
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// ErrWaveformNotFound is returned for episodes without a generated waveform
+var ErrWaveformNotFound = errors.New("waveform not found")
+
+// Waveform resolutions, in points
+const (
+	// WaveformResolution is the number of points stored per episode
+	WaveformResolution = 4000
+	// DefaultWaveformPoints is the number of points served by default
+	DefaultWaveformPoints = 1000
+)
+
+// waveformChunkSamples is the number of samples whose extremes are
+// collected before they are combined into points; it is the length of a
+// Layer III granule
+const waveformChunkSamples = 576
+
+// waveformGenerator versions how waveforms are computed, so that those
+// stored by an earlier version are generated again
+const waveformGenerator = 2
+
+// WaveformSource identifies the audio file a waveform was generated from
+type WaveformSource struct {
+	AudioURL  string    `json:"audioUrl"`
+	Length    int64     `json:"length"`
+	Modified  time.Time `json:"modified"`
+	Generator int       `json:"generator,omitempty"`
+}
+
+// Equal reports whether both describe the same file contents, generated
+// the same way
+func (s WaveformSource) Equal(other WaveformSource) bool {
+	return s.AudioURL == other.AudioURL && s.Length == other.Length && s.Modified.Equal(other.Modified) && s.Generator == other.Generator
+}
+
+// Waveform holds peak data in the audiowaveform JSON format (version 2) read
+// by waveform renderers such as peaks.js and wavesurfer.js, hence its snake
+// case keys. Data has a minimum and a maximum for each of the Length points,
+// each covering SamplesPerPixel samples.
+type Waveform struct {
+	Version         int             `json:"version"`
+	Channels        int             `json:"channels"`
+	SampleRate      int             `json:"sample_rate"`
+	SamplesPerPixel int             `json:"samples_per_pixel"`
+	Bits            int             `json:"bits"`
+	Length          int             `json:"length"`
+	Data            []int8          `json:"data"`
+	Source          *WaveformSource `json:"source,omitempty"`
+}
+
+// Resample returns the waveform reduced to at most points points, keeping
+// the extremes of the points each new one covers. The source is left out.
+func (w *Waveform) Resample(points int) *Waveform {
+	resampled := *w
+	resampled.Source = nil
+	if points <= 0 || points >= w.Length {
+		return &resampled
+	}
+
+	resampled.Length = points
+	resampled.SamplesPerPixel = int(math.Ceil(float64(w.SamplesPerPixel) * float64(w.Length) / float64(points)))
+	resampled.Data = make([]int8, 0, points*2)
+	for i := 0; i < points; i++ {
+		from, to := i*w.Length/points, (i+1)*w.Length/points
+		low, high := int8(math.MaxInt8), int8(math.MinInt8)
+		for j := from; j < to; j++ {
+			low, high = min(low, w.Data[2*j]), max(high, w.Data[2*j+1])
+		}
+		resampled.Data = append(resampled.Data, low, high)
+	}
+	return &resampled
+}
+
+// GenerateWaveform decodes a Layer III MP3 and computes the minimum and
+// maximum sample of each point, with the channels mixed down to one.
+// Samples are scaled to 8 bits as audiowaveform does, so a quiet recording
+// has a small waveform. Decoding stops at the first damaged frame.
+func GenerateWaveform(r io.ReaderAt, size int64) (*Waveform, error) {
+	head := make([]byte, 10)
+	n, _ := r.ReadAt(head, 0)
+	if !isMPEGAudio(head[:n]) {
+		return nil, fmt.Errorf("%w: only MP3 audio has waveforms", ErrMediaUnsupported)
+	}
+	stream, err := findMPEGStream(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if stream.first.layer != 3 {
+		return nil, fmt.Errorf("%w: only MPEG Layer III audio has waveforms", ErrMediaUnsupported)
+	}
+
+	// The Xing, Info or VBRI frame carries no audio
+	start := stream.start
+	if frames, _ := mpegFrameCount(stream.head, stream.first); frames > 0 {
+		start += int64(stream.first.length)
+	}
+	decoder, err := mp3.NewDecoder(bufio.NewReaderSize(io.NewSectionReader(r, start, stream.end-start), 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMediaInvalid, err)
+	}
+
+	// The extremes of each chunk are collected first, since the number of
+	// samples is only known once the whole file has been decoded
+	var lows, highs []int8
+	// The decoder always writes 16-bit little-endian stereo
+	pcm := make([]byte, 4*waveformChunkSamples)
+	for {
+		n, err := io.ReadFull(decoder, pcm)
+		if n >= 4 {
+			low, high := int8(math.MaxInt8), int8(math.MinInt8)
+			for i := 0; i+4 <= n; i += 4 {
+				left := int(int16(binary.LittleEndian.Uint16(pcm[i:])))
+				right := int(int16(binary.LittleEndian.Uint16(pcm[i+2:])))
+				sample := int8((left + right) / 2 / 256)
+				low, high = min(low, sample), max(high, sample)
+			}
+			lows, highs = append(lows, low), append(highs, high)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(lows) == 0 {
+		return nil, fmt.Errorf("%w: no Layer III audio decoded", ErrMediaInvalid)
+	}
+
+	perPoint := (len(lows) + WaveformResolution - 1) / WaveformResolution
+	waveform := &Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      decoder.SampleRate(),
+		SamplesPerPixel: perPoint * waveformChunkSamples,
+		Bits:            8,
+	}
+	for i := 0; i < len(lows); i += perPoint {
+		end := min(i+perPoint, len(lows))
+		waveform.Data = append(waveform.Data, slices.Min(lows[i:end]), slices.Max(highs[i:end]))
+	}
+	waveform.Length = len(waveform.Data) / 2
+	return waveform, nil
+}
+
+// WaveformService stores one waveform per episode as
+// waveforms/<episode id>.json in the content directory
+type WaveformService struct {
+	dir   string
+	media *MediaProber
+}
+
+// NewWaveformService creates a waveform service using the default content
+// directory and the public directory next to it
+func NewWaveformService() *WaveformService {
+	dir := DefaultContentDir()
+	return NewWaveformServiceFromDir(dir, NewMediaProber(filepath.Join(dir, "..", "public")))
+}
+
+// NewWaveformServiceFromDir creates a waveform service for the content
+// directory dir, reading audio through media
+func NewWaveformServiceFromDir(dir string, media *MediaProber) *WaveformService {
+	return &WaveformService{dir: filepath.Join(dir, "waveforms"), media: media}
+}
+
+// path returns the file of the waveform of episodeID
+func (s *WaveformService) path(episodeID string) (string, error) {
+	if !episodeIDPattern.MatchString(episodeID) {
+		return "", ErrWaveformNotFound
+	}
+	return filepath.Join(s.dir, episodeID+".json"), nil
+}
+
+// Get returns the stored waveform of episodeID
+func (s *WaveformService) Get(episodeID string) (*Waveform, error) {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrWaveformNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read waveform: %w", err)
+	}
+
+	var waveform Waveform
+	if err := json.Unmarshal(data, &waveform); err != nil {
+		return nil, fmt.Errorf("failed to parse waveform JSON: %w", err)
+	}
+	return &waveform, nil
+}
+
+// Delete removes the waveform of episodeID
+func (s *WaveformService) Delete(episodeID string) error {
+	path, err := s.path(episodeID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return ErrWaveformNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to delete waveform: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the current audio file of e and whether its waveform is
+// missing or was generated from a different file. Audio that is not served
+// locally is never pending.
+func (s *WaveformService) Pending(e *Episode) (WaveformSource, bool) {
+	file, stat, err := s.media.Open(e.AudioURL)
+	if errors.Is(err, ErrMediaNotLocal) {
+		return WaveformSource{}, false
+	}
+	source := WaveformSource{AudioURL: e.AudioURL, Generator: waveformGenerator}
+	if err != nil {
+		// Missing files are retried until they appear
+		return source, true
+	}
+	file.Close()
+	source.Length, source.Modified = stat.Size(), stat.ModTime().UTC()
+
+	stored, err := s.Get(e.ID)
+	return source, err != nil || stored.Source == nil || !stored.Source.Equal(source)
+}
+
+// Generate computes and stores the waveform of e from its audio file
+func (s *WaveformService) Generate(e *Episode) (*Waveform, error) {
+	path, err := s.path(e.ID)
+	if err != nil {
+		return nil, err
+	}
+	file, stat, err := s.media.Open(e.AudioURL)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	waveform, err := GenerateWaveform(file, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	waveform.Source = &WaveformSource{AudioURL: e.AudioURL, Length: stat.Size(), Modified: stat.ModTime().UTC(), Generator: waveformGenerator}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create waveforms directory: %w", err)
+	}
+	if err := writeJSONFile(path, waveform); err != nil {
+		return nil, fmt.Errorf("failed to save waveform: %w", err)
+	}
+	return waveform, nil
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testWaveformMP3 builds a mono MP3 whose frames hold a steady tone at the
+// level of the given global gain; a gain of 0 marks a silent frame. Each
+// granule carries a single spectral line, the lowest, coded as the pair
+// (1, 0) with Huffman table 1.
+func testWaveformMP3(gains []int) []byte {
+	data := testMP3(0, nil)
+	for _, gain := range gains {
+		frame := make([]byte, 417)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0xc0})
+		if gain > 0 {
+			// Side information of MPEG-1 mono: 18 bits, then 59 bits per
+			// granule: part2_3_length, big_values, global_gain, no scale
+			// factors, long blocks and table_select
+			for granule := 0; granule < 2; granule++ {
+				offset := 32 + 18 + granule*59
+				setTestBits(frame, offset, 12, 3)
+				setTestBits(frame, offset+12, 9, 1)
+				setTestBits(frame, offset+21, 8, gain)
+				setTestBits(frame, offset+34, 5, 1)
+				// The main data follows: the code 01 and a positive sign
+				setTestBits(frame, (4+17)*8+granule*3, 3, 0b010)
+			}
+		}
+		data = append(data, frame...)
+	}
+	return data
+}
+
+// setTestBits writes value as n big-endian bits at bit offset
+func setTestBits(data []byte, offset, n, value int) {
+	for i := 0; i < n; i++ {
+		bit := offset + i
+		if value>>(n-1-i)&1 == 1 {
+			data[bit/8] |= 0x80 >> (bit % 8)
+		}
+	}
+}
+
+func TestGenerateWaveform(t *testing.T) {
+	data := testWaveformMP3([]int{210, 210, 210, 0, 0, 0, 202, 202, 202})
+	waveform, err := GenerateWaveform(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("GenerateWaveform returned error: %v", err)
+	}
+	if waveform.Version != 2 || waveform.Channels != 1 || waveform.Bits != 8 || waveform.SampleRate != 44100 || waveform.SamplesPerPixel != 576 {
+		t.Errorf("Unexpected waveform header: %+v", waveform)
+	}
+	// The lowest spectral line decodes to a level of -90 once the filter
+	// bank has settled, with ringing where the level changes. Silence is
+	// exact and 8 gain steps are a quarter of the level.
+	expected := []int8{
+		-24, 18, -92, -25, -90, -90, -90, -90, -90, -90, -90, -90,
+		-109, -65, -65, 1, 0, 0, 0, 0, 0, 0, 0, 0,
+		-6, 4, -23, -6, -22, -22, -22, -22, -22, -22, -22, -22,
+	}
+	if waveform.Length != 18 || !slices.Equal(waveform.Data, expected) {
+		t.Errorf("Expected %v, got %d points %v", expected, waveform.Length, waveform.Data)
+	}
+}
+
+func TestGenerateWaveformLong(t *testing.T) {
+	gains := make([]int, 3000)
+	for i := range gains {
+		gains[i] = 150 + i%60
+	}
+	data := testWaveformMP3(gains)
+	waveform, err := GenerateWaveform(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("GenerateWaveform returned error: %v", err)
+	}
+	// 6000 granules fit 4000 points two at a time
+	if waveform.Length != 3000 || len(waveform.Data) != 6000 || waveform.SamplesPerPixel != 1152 {
+		t.Errorf("Expected 3000 points of 1152 samples, got %d of %d", waveform.Length, waveform.SamplesPerPixel)
+	}
+}
+
+func TestGenerateWaveformErrors(t *testing.T) {
+	layer2 := testMP3(10, nil)
+	for i := 30; i < len(layer2); i += 417 {
+		layer2[i+1] = 0xfd
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{"m4a", testMP4(44100, 44100, "soun"), ErrMediaUnsupported},
+		{"layer ii", layer2, ErrMediaUnsupported},
+	}
+
+	for _, tt := range tests {
+		if _, err := GenerateWaveform(bytes.NewReader(tt.data), int64(len(tt.data))); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestWaveformResample(t *testing.T) {
+	waveform := &Waveform{
+		SamplesPerPixel: 576,
+		Length:          5,
+		Data:            []int8{-10, 10, -50, 50, -20, 20, -5, 5, -90, 90},
+		Source:          &WaveformSource{AudioURL: "/a.mp3"},
+	}
+
+	resampled := waveform.Resample(2)
+	expected := []int8{-50, 50, -90, 90}
+	if resampled.Length != 2 || !slices.Equal(resampled.Data, expected) {
+		t.Errorf("Expected %v, got %v", expected, resampled.Data)
+	}
+	if resampled.SamplesPerPixel != 1440 || resampled.Source != nil {
+		t.Errorf("Unexpected resampled waveform: %+v", resampled)
+	}
+
+	if same := waveform.Resample(10); same.Length != 5 || same.Source != nil {
+		t.Errorf("Expected the full waveform without source, got %+v", same)
+	}
+}
+
+func TestWaveformService(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "audio"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(root, "audio", "ep.mp3")
+	if err := os.WriteFile(path, testWaveformMP3([]int{200, 210}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewWaveformServiceFromDir(t.TempDir(), NewMediaProber(root))
+	episode := &Episode{ID: "ep001", AudioURL: "/audio/ep.mp3"}
+
+	if _, pending := service.Pending(&Episode{ID: "remote", AudioURL: "https://cdn.example.com/ep.mp3"}); pending {
+		t.Error("Expected remote audio never to be pending")
+	}
+	if _, pending := service.Pending(episode); !pending {
+		t.Error("Expected a missing waveform to be pending")
+	}
+	if _, err := service.Generate(episode); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if _, pending := service.Pending(episode); pending {
+		t.Error("Expected a generated waveform not to be pending")
+	}
+
+	stored, err := service.Get("ep001")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if stored.Length != 4 || stored.Source == nil || stored.Source.AudioURL != "/audio/ep.mp3" {
+		t.Errorf("Unexpected stored waveform: %+v", stored)
+	}
+
+	// Waveforms of an earlier generator are generated again
+	stored.Source.Generator = 0
+	if err := writeJSONFile(filepath.Join(service.dir, "ep001.json"), stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, pending := service.Pending(episode); !pending {
+		t.Error("Expected a waveform of an earlier generator to be pending")
+	}
+	if _, err := service.Generate(episode); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, pending := service.Pending(episode); !pending {
+		t.Error("Expected a changed audio file to be pending")
+	}
+
+	if err := service.Delete("ep001"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := service.Get("ep001"); !errors.Is(err, ErrWaveformNotFound) {
+		t.Errorf("Expected ErrWaveformNotFound, got %v", err)
+	}
+	if _, err := service.Get("../ep001"); !errors.Is(err, ErrWaveformNotFound) {
+		t.Errorf("Expected ErrWaveformNotFound for an invalid ID, got %v", err)
+	}
+}
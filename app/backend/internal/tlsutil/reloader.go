@@ -0,0 +1,197 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate loaded from disk and reloads it when the
+// certificate or key file changes, so renewed certificates are picked up
+// without a restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mutex    sync.RWMutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	onReload func(error)
+	// failedCertMod and failedKeyMod are the modification times of files
+	// that failed to load, so they are not retried until they change again
+	failedCertMod time.Time
+	failedKeyMod  time.Time
+	// statErr is the last failure to stat the files, reported only when it
+	// first occurs
+	statErr string
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewCertReloader loads the key pair and returns a reloader for it
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		stop:     make(chan struct{}),
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// OnReload registers a callback invoked after every reload attempt triggered
+// by a file change, with a nil error on success
+func (r *CertReloader) OnReload(fn func(error)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.onReload = fn
+}
+
+// GetCertificate returns the current certificate; use it as tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.cert, nil
+}
+
+// Watch polls the certificate files every interval until Stop is called
+func (r *CertReloader) Watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			changed, err := r.reload()
+			if !changed && err == nil {
+				continue
+			}
+
+			r.mutex.RLock()
+			onReload := r.onReload
+			r.mutex.RUnlock()
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+// Stop ends Watch
+func (r *CertReloader) Stop() {
+	r.once.Do(func() { close(r.stop) })
+}
+
+// reload loads the key pair if either file changed since the last attempt.
+// A failed stat or load keeps the previous certificate and is reported once.
+func (r *CertReloader) reload() (bool, error) {
+	certMod, keyMod, err := r.stat()
+	r.mutex.Lock()
+	repeated := err != nil && err.Error() == r.statErr
+	r.statErr = ""
+	if err != nil {
+		r.statErr = err.Error()
+	}
+	r.mutex.Unlock()
+	if repeated {
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	r.mutex.RLock()
+	unchanged := r.cert != nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod)
+	failed := certMod.Equal(r.failedCertMod) && keyMod.Equal(r.failedKeyMod)
+	r.mutex.RUnlock()
+	if unchanged || failed {
+		return false, nil
+	}
+
+	cert, err := r.load()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err != nil {
+		r.failedCertMod, r.failedKeyMod = certMod, keyMod
+		return true, err
+	}
+	r.cert = cert
+	r.certMod, r.keyMod = certMod, keyMod
+	r.failedCertMod, r.failedKeyMod = time.Time{}, time.Time{}
+	return true, nil
+}
+
+// stat returns the modification times of the certificate and key files
+func (r *CertReloader) stat() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat key: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// load reads and parses the key pair
+func (r *CertReloader) load() (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key pair: %w", err)
+	}
+	if cert.Leaf == nil && len(cert.Certificate) > 0 {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+	}
+	return &cert, nil
+}
+
+// ServerConfig returns a TLS configuration that serves the reloaded certificate
+func (r *CertReloader) ServerConfig(minVersion uint16) *tls.Config {
+	return &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// LoadClientCAs reads a PEM bundle of certificate authorities for client authentication
+func LoadClientCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// ParseVersion converts "1.2" or "1.3" to a tls version constant
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q", version)
+	}
+}
@@ -0,0 +1,186 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSigned writes a self-signed certificate for commonName and its key
+func writeSelfSigned(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func currentCommonName(t *testing.T, r *CertReloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.NotNil(t, cert.Leaf)
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Minute)
+
+	writeSelfSigned(t, certFile, keyFile, "first", start)
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "first", currentCommonName(t, r))
+
+	changed, err := r.reload()
+	require.NoError(t, err)
+	assert.False(t, changed, "unchanged files must not be reloaded")
+
+	writeSelfSigned(t, certFile, keyFile, "second", start.Add(time.Second))
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "second", currentCommonName(t, r))
+
+	// A broken key pair keeps serving the last good certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	_, err = r.reload()
+	assert.Error(t, err)
+	assert.Equal(t, "second", currentCommonName(t, r))
+
+	// and is not retried until the files change again
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.False(t, changed, "a failed key pair must not be reloaded until it changes")
+
+	writeSelfSigned(t, certFile, keyFile, "third", start.Add(2*time.Second))
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "third", currentCommonName(t, r))
+}
+
+func TestCertReloaderMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Minute)
+
+	writeSelfSigned(t, certFile, keyFile, "first", start)
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	// A file that cannot be stat'ed is reported once
+	require.NoError(t, os.Rename(certFile, certFile+".old"))
+	changed, err := r.reload()
+	assert.True(t, changed)
+	assert.Error(t, err)
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.False(t, changed, "a repeated stat failure must not be reported again")
+	assert.Equal(t, "first", currentCommonName(t, r))
+
+	// and again once the failure changes
+	require.NoError(t, os.Rename(certFile+".old", certFile))
+	require.NoError(t, os.Rename(keyFile, keyFile+".old"))
+	_, err = r.reload()
+	assert.Error(t, err)
+
+	require.NoError(t, os.Rename(keyFile+".old", keyFile))
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.False(t, changed, "restored files that did not change must not be reloaded")
+
+	writeSelfSigned(t, certFile, keyFile, "second", start.Add(time.Second))
+	changed, err = r.reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "second", currentCommonName(t, r))
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Minute)
+
+	writeSelfSigned(t, certFile, keyFile, "first", start)
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	reloaded := make(chan error, 1)
+	r.OnReload(func(err error) { reloaded <- err })
+	go r.Watch(10 * time.Millisecond)
+	defer r.Stop()
+
+	writeSelfSigned(t, certFile, keyFile, "renewed", start.Add(time.Second))
+
+	select {
+	case err := <-reloaded:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("certificate change was not detected")
+	}
+	assert.Equal(t, "renewed", currentCommonName(t, r))
+}
+
+func TestNewCertReloaderMissingFiles(t *testing.T) {
+	_, err := NewCertReloader(filepath.Join(t.TempDir(), "cert.pem"), "key.pem")
+	assert.Error(t, err)
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected uint16
+		wantErr  bool
+	}{
+		{"", tls.VersionTLS12, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			version, err := ParseVersion(tt.version)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}